@@ -0,0 +1,60 @@
+package catalog_test
+
+import (
+	"github.com/ryogrid/SamehadaDB/lib/catalog"
+	"github.com/ryogrid/SamehadaDB/lib/common"
+	"github.com/ryogrid/SamehadaDB/lib/samehada"
+	"github.com/ryogrid/SamehadaDB/lib/storage/index"
+	"github.com/ryogrid/SamehadaDB/lib/storage/index/index_constants"
+	"github.com/ryogrid/SamehadaDB/lib/storage/table/column"
+	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
+	"github.com/ryogrid/SamehadaDB/lib/storage/tuple"
+	testingpkg "github.com/ryogrid/SamehadaDB/lib/testing/testing_assert"
+	"github.com/ryogrid/SamehadaDB/lib/types"
+	"testing"
+)
+
+// pages of dropped table are deallocated at commit
+func TestDropTableDeallocatesPages(t *testing.T) {
+	samehada_instance := samehada.NewSamehadaInstance(t.Name(), common.BufferPoolMaxFrameNumForTest)
+	bpm := samehada_instance.GetBufferPoolManager()
+	txnMgr := samehada_instance.GetTransactionManager()
+
+	txn := txnMgr.Begin(nil)
+	catalog_ := catalog.BootstrapCatalog(bpm, samehada_instance.GetLogManager(), samehada_instance.GetLockManager(), txn)
+
+	columnA := column.NewColumn("a", types.Integer, false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), nil)
+	columnB := column.NewColumn("b", types.Varchar, true, index_constants.INDEX_KIND_HASH, types.PageID(-1), nil)
+	tm := catalog_.CreateTable("test_1", schema.NewSchema([]*column.Column{columnA, columnB}), txn)
+	for ii := 0; ii < 500; ii++ {
+		row := []types.Value{types.NewInteger(int32(ii)), types.NewVarchar("value of column b")}
+		tuple_ := tuple.NewTupleFromSchema(row, tm.Schema())
+		rid, _ := tm.Table().InsertTuple(tuple_, txn, tm.OID(), false)
+		tm.GetIndex(1).InsertEntry(tuple_, *rid, txn)
+	}
+	txnMgr.Commit(catalog_, txn)
+
+	pageIds := tm.Table().GetPageIds()
+	testingpkg.SimpleAssert(t, len(pageIds) > 1)
+	pageIds = append(pageIds, tm.GetIndex(1).(*index.LinearProbeHashTableIndex).GetPageIds()...)
+	isDeallocatedAll := func() bool {
+		for _, pageId := range pageIds {
+			pg := bpm.FetchPage(pageId)
+			isDeallocated := pg.IsDeallocated()
+			bpm.UnpinPage(pageId, false)
+			if !isDeallocated {
+				return false
+			}
+		}
+		return true
+	}
+
+	txn = txnMgr.Begin(nil)
+	testingpkg.SimpleAssert(t, catalog_.DropTable("columns_catalog", txn) == catalog.SystemCatalogModifyErr)
+	testingpkg.SimpleAssert(t, catalog_.DropTable("test_1", txn) == nil)
+	testingpkg.SimpleAssert(t, catalog_.GetTableByName("test_1") == nil)
+	// deallocation is done after commit
+	testingpkg.SimpleAssert(t, !isDeallocatedAll())
+	txnMgr.Commit(catalog_, txn)
+	testingpkg.SimpleAssert(t, isDeallocatedAll())
+}
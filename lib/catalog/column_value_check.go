@@ -0,0 +1,53 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/ryogrid/SamehadaDB/lib/storage/index/index_constants"
+	"github.com/ryogrid/SamehadaDB/lib/storage/table/column"
+	"github.com/ryogrid/SamehadaDB/lib/types"
+)
+
+// returned when a value of INSERT or UPDATE can't be stored to the column
+var TypeMismatchErr = errors.New("type of value doesn't match with the column")
+var ValueTooLongErr = errors.New("value is too long for the column")
+
+// CoerceValueForColumn returns the value converted to type of the column (ex: Integer to Float).
+// error is returned when the value can't be stored to the column or can't be a key of index on the column.
+// colName is used for error message only
+func CoerceValueForColumn(col *column.Column, val *types.Value, colName string) (*types.Value, error) {
+	convedVal, ok := val.CoerceTo(col.GetType())
+	if !ok {
+		return nil, fmt.Errorf("%w: %s value is passed for %s column %s", TypeMismatchErr, val.ValueType().Name(), col.GetType().Name(), colName)
+	}
+	if convedVal.IsNull() {
+		return convedVal, nil
+	}
+	if err := checkValueSize(col, convedVal, colName); err != nil {
+		return nil, err
+	}
+	return convedVal, nil
+}
+
+// checks the value can be stored to the column and can be a key of index on the column
+func checkValueSize(col *column.Column, val *types.Value, colName string) error {
+	isTooLong := false
+	switch col.GetType() {
+	case types.Varchar:
+		// declared length of VARCHAR is count of characters but serialized value is limited by bytes
+		str := val.ToVarchar()
+		isTooLong = len(str) > types.MaxVariableLengthSize || uint32(utf8.RuneCountInString(str)) > col.VariableLength()
+	case types.Blob:
+		isTooLong = uint32(len(val.ToBlob())) > col.VariableLength()
+	}
+	if isTooLong {
+		return fmt.Errorf("%w: value of %s is too long. max length is %d", ValueTooLongErr, colName, col.VariableLength())
+	}
+	if col.HasIndex() && (col.IndexKind() == index_constants.INDEX_KIND_SKIP_LIST || col.IndexKind() == index_constants.INDEX_KIND_UNIQ_SKIP_LIST) &&
+		val.Size() > index_constants.MaxSkipListKeySize {
+		return fmt.Errorf("%w: value of %s is too large for index key", ValueTooLongErr, colName)
+	}
+	return nil
+}
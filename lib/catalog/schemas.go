@@ -27,6 +27,8 @@ func ColumnsCatalogSchema() *schema.Schema {
 	hasIndexColumn := column.NewColumn("has_index", types.Integer, false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), nil)
 	indexKind := column.NewColumn("index_kind", types.Integer, false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), nil)
 	indexHeaderPageId := column.NewColumn("index_header_page_id", types.Integer, false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), nil)
+	indexName := column.NewColumn("index_name", types.Varchar, false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), nil)
+	notNull := column.NewColumn("not_null", types.Integer, false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), nil)
 
 	return schema.NewSchema([]*column.Column{
 		tableOIDColumn,
@@ -37,5 +39,24 @@ func ColumnsCatalogSchema() *schema.Schema {
 		offsetColumn,
 		hasIndexColumn,
 		indexKind,
-		indexHeaderPageId})
+		indexHeaderPageId,
+		indexName,
+		notNull})
+}
+
+// schema of catalog for indexes which are not held by Column object (composite indexes).
+// key_columns is names of key columns joined with ","
+func IndexesCatalogSchema() *schema.Schema {
+	tableOIDColumn := column.NewColumn("table_oid", types.Integer, false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), nil)
+	nameColumn := column.NewColumn("name", types.Varchar, false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), nil)
+	indexKind := column.NewColumn("index_kind", types.Integer, false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), nil)
+	keyColumns := column.NewColumn("key_columns", types.Varchar, false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), nil)
+	return schema.NewSchema([]*column.Column{tableOIDColumn, nameColumn, indexKind, keyColumns})
+}
+
+// schema of catalog for CHECK constraints. expr is SQL text of the constraint expression
+func ChecksCatalogSchema() *schema.Schema {
+	tableOIDColumn := column.NewColumn("table_oid", types.Integer, false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), nil)
+	exprColumn := column.NewColumn("expr", types.Varchar, false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), nil)
+	return schema.NewSchema([]*column.Column{tableOIDColumn, exprColumn})
 }
@@ -32,6 +32,8 @@ func NewDistinctCounter(colType types.TypeID) *distinctCounter {
 		return &distinctCounter{samehada_util.GetPonterOfValue(types.NewVarchar("")).SetInfMin(), samehada_util.GetPonterOfValue(types.NewVarchar("")).SetInfMax(), 0, 0, colType, make(map[interface{}]bool, 0)}
 	case types.Boolean:
 		return &distinctCounter{samehada_util.GetPonterOfValue(types.NewBoolean(false)).SetInfMin(), samehada_util.GetPonterOfValue(types.NewBoolean(true)).SetInfMax(), 0, 0, colType, make(map[interface{}]bool, 0)}
+	case types.BigInt, types.Double, types.Decimal, types.Timestamp, types.Blob:
+		return &distinctCounter{infMinValueOf(colType), infMaxValueOf(colType), 0, 0, colType, make(map[interface{}]bool, 0)}
 	default:
 		panic("unkown type")
 	}
@@ -79,11 +81,21 @@ func NewColumnStats(colType types.TypeID) *columnStats {
 		return &columnStats{samehada_util.GetPonterOfValue(types.NewVarchar("")).SetInfMin(), samehada_util.GetPonterOfValue(types.NewVarchar("")).SetInfMax(), 0, 0, colType, common.NewRWLatch()}
 	case types.Boolean:
 		return &columnStats{samehada_util.GetPonterOfValue(types.NewBoolean(true)).SetInfMin(), samehada_util.GetPonterOfValue(types.NewBoolean(false)).SetInfMax(), 0, 0, colType, common.NewRWLatch()}
+	case types.BigInt, types.Double, types.Decimal, types.Timestamp, types.Blob:
+		return &columnStats{infMinValueOf(colType), infMaxValueOf(colType), 0, 0, colType, common.NewRWLatch()}
 	default:
 		panic("unkown type")
 	}
 }
 
+func infMinValueOf(colType types.TypeID) *types.Value {
+	return samehada_util.GetPonterOfValue(types.NewZeroValueOf(colType)).SetInfMin()
+}
+
+func infMaxValueOf(colType types.TypeID) *types.Value {
+	return samehada_util.GetPonterOfValue(types.NewZeroValueOf(colType)).SetInfMax()
+}
+
 func (cs *columnStats) Count() int64 {
 	cs.latch.RLock()
 	defer cs.latch.RUnlock()
@@ -97,6 +109,8 @@ func (cs *columnStats) Count() int64 {
 		return cs.count
 	case types.Boolean:
 		return cs.count
+	case types.BigInt, types.Double, types.Decimal, types.Timestamp, types.Blob:
+		return cs.count
 	default:
 		panic("unkown or not supported type")
 	}
@@ -115,6 +129,8 @@ func (cs *columnStats) Distinct() int64 {
 		return cs.distinct
 	case types.Boolean:
 		return cs.distinct
+	case types.BigInt, types.Double, types.Decimal, types.Timestamp, types.Blob:
+		return cs.distinct
 	default:
 		panic("unkown or not supported type")
 	}
@@ -150,7 +166,14 @@ func (cs *columnStats) EstimateCount(from *types.Value, to *types.Value) float64
 		} else { // Float
 			return float64(tmpVal.ToFloat()+1) * float64(cs.count) / guardNotZeroReturn(float64(cs.distinct))
 		}
-	} else if cs.colType == types.Varchar {
+	} else if cs.colType == types.BigInt || cs.colType == types.Double || cs.colType == types.Decimal || cs.colType == types.Timestamp {
+		if to.CompareLessThanOrEqual(*from) {
+			to.Swap(from)
+		}
+		from = retValAccordingToCompareResult(from.CompareLessThan(*cs.min), cs.min, from)
+		to = retValAccordingToCompareResult(to.CompareLessThan(*cs.max), to, cs.max)
+		return (float64Of(to) - float64Of(from) + 1) * float64(cs.count) / guardNotZeroReturn(float64(cs.distinct))
+	} else if cs.colType == types.Varchar || cs.colType == types.Blob {
 		if to.CompareLessThanOrEqual(*from) {
 			to.Swap(from)
 		}
@@ -165,6 +188,22 @@ func (cs *columnStats) EstimateCount(from *types.Value, to *types.Value) float64
 	}
 }
 
+// returns value of BigInt, Double, Decimal or Timestamp as float64 for estimation
+func float64Of(val *types.Value) float64 {
+	switch val.ValueType() {
+	case types.BigInt:
+		return float64(val.ToBigInt())
+	case types.Double:
+		return val.ToDouble()
+	case types.Decimal:
+		return val.ToDecimal().Float64()
+	case types.Timestamp:
+		return float64(val.ToTimestamp().UnixMicro())
+	default:
+		panic("not supported type")
+	}
+}
+
 func (cs *columnStats) Multiply(multiplier float64) *columnStats {
 	cs.count = int64(math.Floor(float64(cs.count) * multiplier))
 	cs.distinct = int64(math.Floor(float64(cs.distinct) * multiplier))
@@ -215,6 +254,8 @@ func (ts *TableStatistics) Update(target *TableMetadata, txn *access.Transaction
 			distCounters[ii].Output(cs)
 		case types.Boolean:
 			distCounters[ii].Output(cs)
+		case types.BigInt, types.Double, types.Decimal, types.Timestamp, types.Blob:
+			distCounters[ii].Output(cs)
 		default:
 			panic("unkown or not supported type")
 		}
@@ -282,8 +323,10 @@ func (ts *TableStatistics) ReductionFactor(sc *schema.Schema, predicate expressi
 				return guardNotZeroReturn(ts.ReductionFactor(sc, boLogi.GetChildAt(0)) * ts.ReductionFactor(sc, boLogi.GetChildAt(1)))
 			}
 			if boLogi.GetLogicalOpType() == expression.OR {
-				// TODO: what should be returned?
-				return guardNotZeroReturn(ts.ReductionFactor(sc, boLogi.GetChildAt(0)) * ts.ReductionFactor(sc, boLogi.GetChildAt(1)))
+				// selectivity (reciprocal of reduction factor) of OR is s1 + s2 - s1 * s2
+				selectivityL := 1 / ts.ReductionFactor(sc, boLogi.GetChildAt(0))
+				selectivityR := 1 / ts.ReductionFactor(sc, boLogi.GetChildAt(1))
+				return guardNotZeroReturn(1 / (selectivityL + selectivityR - selectivityL*selectivityR))
 			}
 		}
 	}
@@ -4,6 +4,10 @@
 package catalog
 
 import (
+	"errors"
+	"golang.org/x/exp/slices"
+	"math"
+	"sort"
 	"strings"
 	"sync"
 
@@ -14,6 +18,7 @@ import (
 	"github.com/ryogrid/SamehadaDB/lib/recovery"
 	"github.com/ryogrid/SamehadaDB/lib/storage/access"
 	"github.com/ryogrid/SamehadaDB/lib/storage/buffer"
+	"github.com/ryogrid/SamehadaDB/lib/storage/page"
 	"github.com/ryogrid/SamehadaDB/lib/storage/table/column"
 	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
 	"github.com/ryogrid/SamehadaDB/lib/storage/tuple"
@@ -28,8 +33,37 @@ const TableCatalogPageId = 0
 // The second page is reserved for the table catalog
 const ColumnsCatalogPageId = 1
 
+// IndexesCatalogPageId indicates the page where the indexes catalog can be found
+// The third page is reserved for the indexes catalog. it is not registered as a table
+const IndexesCatalogPageId = 2
+
+// ChecksCatalogPageId indicates the page where the CHECK constraints catalog can be found
+// The fourth page is reserved for the CHECK constraints catalog. it is not registered as a table
+const ChecksCatalogPageId = 3
+
 const ColumnsCatalogOID = 0
 
+var TableNotFoundErr = errors.New("table not found")
+var ColumnNotFoundErr = errors.New("column not found")
+var IndexAlreadyExistsErr = errors.New("index already exists")
+var ColumnAlreadyIndexedErr = errors.New("column already has an index")
+var IndexNotFoundErr = errors.New("index not found")
+var DuplicateKeyErr = errors.New("duplicate key value violates unique index")
+var ColumnAlreadyExistsErr = errors.New("column already exists")
+var LastColumnDropErr = errors.New("all columns of a table can't be dropped")
+var SystemCatalogModifyErr = errors.New("system catalog can't be modified")
+var IndexColumnDuplicatedErr = errors.New("column is specified more than once for an index")
+var NotNullViolationErr = errors.New("null value violates not-null constraint")
+var CheckViolationErr = errors.New("new row violates check constraint")
+
+// returns true when err is returned for a tuple which violates constraints of a table
+func IsConstraintViolationErr(err error) bool {
+	return err == NotNullViolationErr || err == DuplicateKeyErr || err == CheckViolationErr
+}
+
+// returned when txn is aborted due to lock conflict on DDL
+var CatalogUpdateAbortedErr = errors.New("catalog update is aborted")
+
 // Catalog is a non-persistent catalog that is designed for the executor to use.
 // It handles table creation and table lookup
 type Catalog struct {
@@ -39,6 +73,8 @@ type Catalog struct {
 	// incrementation must be atomic
 	nextTableId     uint32
 	tableHeap       *access.TableHeap
+	indexesHeap     *access.TableHeap
+	checksHeap      *access.TableHeap
 	Log_manager     *recovery.LogManager
 	Lock_manager    *access.LockManager
 	tableIdsMutex   *sync.Mutex
@@ -56,8 +92,12 @@ func Int32toBool(val int32) bool {
 // BootstrapCatalog bootstrap the systems' catalogs on the first database initialization
 func BootstrapCatalog(bpm *buffer.BufferPoolManager, log_manager *recovery.LogManager, lock_manager *access.LockManager, txn *access.Transaction) *Catalog {
 	tableCatalogHeap := access.NewTableHeap(bpm, log_manager, lock_manager, txn)
-	tableCatalog := &Catalog{bpm, make(map[uint32]*TableMetadata), make(map[string]*TableMetadata), 0, tableCatalogHeap, log_manager, lock_manager, new(sync.Mutex), new(sync.Mutex)}
+	tableCatalog := &Catalog{bpm, make(map[uint32]*TableMetadata), make(map[string]*TableMetadata), 0, tableCatalogHeap, nil, nil, log_manager, lock_manager, new(sync.Mutex), new(sync.Mutex)}
 	tableCatalog.CreateTable("columns_catalog", ColumnsCatalogSchema(), txn)
+	// first page of this heap is IndexesCatalogPageId because columns catalog has no index
+	tableCatalog.indexesHeap = access.NewTableHeap(bpm, log_manager, lock_manager, txn)
+	// first page of this heap is ChecksCatalogPageId
+	tableCatalog.checksHeap = access.NewTableHeap(bpm, log_manager, lock_manager, txn)
 	return tableCatalog
 }
 
@@ -67,6 +107,7 @@ func RecoveryCatalogFromCatalogPage(bpm *buffer.BufferPoolManager, log_manager *
 
 	tableIds := make(map[uint32]*TableMetadata)
 	tableNames := make(map[string]*TableMetadata)
+	nextTableId := uint32(1)
 
 	for tuple_outer := tableCatalogHeapIt.Current(); !tableCatalogHeapIt.End(); tuple_outer = tableCatalogHeapIt.Next() {
 		oid := tuple_outer.GetValue(TableCatalogSchema(), TableCatalogSchema().GetColIndex("oid")).ToInteger()
@@ -88,6 +129,8 @@ func RecoveryCatalogFromCatalogPage(bpm *buffer.BufferPoolManager, log_manager *
 			hasIndex := Int32toBool(tuple_inner.GetValue(ColumnsCatalogSchema(), ColumnsCatalogSchema().GetColIndex("has_index")).ToInteger())
 			indexKind := tuple_inner.GetValue(ColumnsCatalogSchema(), ColumnsCatalogSchema().GetColIndex("index_kind")).ToInteger()
 			indexHeaderPageId := tuple_inner.GetValue(ColumnsCatalogSchema(), ColumnsCatalogSchema().GetColIndex("index_header_page_id")).ToInteger()
+			indexName := tuple_inner.GetValue(ColumnsCatalogSchema(), ColumnsCatalogSchema().GetColIndex("index_name")).ToVarchar()
+			notNull := Int32toBool(tuple_inner.GetValue(ColumnsCatalogSchema(), ColumnsCatalogSchema().GetColIndex("not_null")).ToInteger())
 
 			column_ := column.NewColumn(columnName, types.TypeID(columnType), false, index_constants.INDEX_KIND_INVALID, types.PageID(indexHeaderPageId), nil)
 			column_.SetFixedLength(uint32(fixedLength))
@@ -96,9 +139,13 @@ func RecoveryCatalogFromCatalogPage(bpm *buffer.BufferPoolManager, log_manager *
 			column_.SetHasIndex(hasIndex)
 			column_.SetIndexKind(index_constants.IndexKind(indexKind))
 			column_.SetIndexHeaderPageId(types.PageID(indexHeaderPageId))
+			column_.SetIndexName(indexName)
+			column_.SetIsNotNull(notNull)
 
 			columns = append(columns, column_)
 		}
+		// entries are not placed in order of columns when freed slots of columns catalog are reused
+		sort.Slice(columns, func(i, j int) bool { return columns[i].GetOffset() < columns[j].GetOffset() })
 
 		tableMetadata := NewTableMetadata(
 			schema.NewSchema(columns),
@@ -111,9 +158,42 @@ func RecoveryCatalogFromCatalogPage(bpm *buffer.BufferPoolManager, log_manager *
 
 		tableIds[uint32(oid)] = tableMetadata
 		tableNames[name] = tableMetadata
+		if uint32(oid) >= nextTableId {
+			nextTableId = uint32(oid) + 1
+		}
+	}
+
+	indexesHeap := access.InitTableHeap(bpm, IndexesCatalogPageId, log_manager, lock_manager)
+	indexesCatalogHeapIt := indexesHeap.Iterator(txn)
+	for tuple_ := indexesCatalogHeapIt.Current(); !indexesCatalogHeapIt.End(); tuple_ = indexesCatalogHeapIt.Next() {
+		tableOid := tuple_.GetValue(IndexesCatalogSchema(), IndexesCatalogSchema().GetColIndex("table_oid")).ToInteger()
+		indexName := tuple_.GetValue(IndexesCatalogSchema(), IndexesCatalogSchema().GetColIndex("name")).ToVarchar()
+		indexKind := tuple_.GetValue(IndexesCatalogSchema(), IndexesCatalogSchema().GetColIndex("index_kind")).ToInteger()
+		keyColumns := tuple_.GetValue(IndexesCatalogSchema(), IndexesCatalogSchema().GetColIndex("key_columns")).ToVarchar()
+		tableMetadata, ok := tableIds[uint32(tableOid)]
+		if !ok {
+			continue
+		}
+		// data of the index is reconstructed at launch
+		index_, err := tableMetadata.newCompositeIndex(indexName, strings.Split(keyColumns, ","), index_constants.IndexKind(indexKind) == index_constants.INDEX_KIND_UNIQ_SKIP_LIST, log_manager)
+		if err != nil {
+			panic("definition of composite index is broken!")
+		}
+		tableMetadata.compositeIndexes = append(tableMetadata.compositeIndexes, index_)
+	}
+
+	checksHeap := access.InitTableHeap(bpm, ChecksCatalogPageId, log_manager, lock_manager)
+	checksCatalogHeapIt := checksHeap.Iterator(txn)
+	for tuple_ := checksCatalogHeapIt.Current(); !checksCatalogHeapIt.End(); tuple_ = checksCatalogHeapIt.Next() {
+		tableOid := tuple_.GetValue(ChecksCatalogSchema(), ChecksCatalogSchema().GetColIndex("table_oid")).ToInteger()
+		exprStr := tuple_.GetValue(ChecksCatalogSchema(), ChecksCatalogSchema().GetColIndex("expr")).ToVarchar()
+		if tableMetadata, ok := tableIds[uint32(tableOid)]; ok {
+			// expression is set after the text is parsed at launch
+			tableMetadata.checks = append(tableMetadata.checks, NewCheckConstraint(exprStr, nil))
+		}
 	}
 
-	return &Catalog{bpm, tableIds, tableNames, 1, access.InitTableHeap(bpm, 0, log_manager, lock_manager), log_manager, lock_manager, new(sync.Mutex), new(sync.Mutex)}
+	return &Catalog{bpm, tableIds, tableNames, nextTableId, access.InitTableHeap(bpm, 0, log_manager, lock_manager), indexesHeap, checksHeap, log_manager, lock_manager, new(sync.Mutex), new(sync.Mutex)}
 }
 
 func (c *Catalog) GetTableByName(table string) *TableMetadata {
@@ -161,6 +241,12 @@ func attachTableNameToColumnsName(schema_ *schema.Schema, tblName string) *schem
 // CreateTable creates a new table and return its metadata
 // ATTENTION: this function modifies column name filed of Column objects on *schema_* argument if needed
 func (c *Catalog) CreateTable(name string, schema_ *schema.Schema, txn *access.Transaction) *TableMetadata {
+	return c.CreateTableWithChecks(name, schema_, nil, txn)
+}
+
+// same as CreateTable but the table has CHECK constraints.
+// expressions of checks should be created for schema_
+func (c *Catalog) CreateTableWithChecks(name string, schema_ *schema.Schema, checks []*CheckConstraint, txn *access.Transaction) *TableMetadata {
 	// note: alphabets on table name is stored in lowercase
 	name_ := strings.ToLower(name)
 
@@ -173,6 +259,7 @@ func (c *Catalog) CreateTable(name string, schema_ *schema.Schema, txn *access.T
 	attachTableNameToColumnsName(schema_, name_)
 
 	tableMetadata := NewTableMetadata(schema_, name_, tableHeap, oid, c.Log_manager, true)
+	tableMetadata.checks = append(tableMetadata.checks, checks...)
 
 	c.tableIdsMutex.Lock()
 	c.tableIds[oid] = tableMetadata
@@ -193,6 +280,22 @@ func boolToInt32(val bool) int32 {
 	}
 }
 
+func newColumnsCatalogTuple(oid uint32, column_ *column.Column) *tuple.Tuple {
+	row := make([]types.Value, 0)
+	row = append(row, types.NewInteger(int32(oid)))
+	row = append(row, types.NewInteger(int32(column_.GetType())))
+	row = append(row, types.NewVarchar(column_.GetColumnName()))
+	row = append(row, types.NewInteger(int32(column_.FixedLength())))
+	row = append(row, types.NewInteger(int32(column_.VariableLength())))
+	row = append(row, types.NewInteger(int32(column_.GetOffset())))
+	row = append(row, types.NewInteger(boolToInt32(column_.HasIndex())))
+	row = append(row, types.NewInteger(int32(column_.IndexKind())))
+	row = append(row, types.NewInteger(int32(column_.IndexHeaderPageId())))
+	row = append(row, types.NewVarchar(column_.IndexName()))
+	row = append(row, types.NewInteger(boolToInt32(column_.IsNotNull())))
+	return tuple.NewTupleFromSchema(row, ColumnsCatalogSchema())
+}
+
 func (c *Catalog) insertTable(tableMetadata *TableMetadata, txn *access.Transaction) {
 	row := make([]types.Value, 0)
 
@@ -202,38 +305,427 @@ func (c *Catalog) insertTable(tableMetadata *TableMetadata, txn *access.Transact
 	first_tuple := tuple.NewTupleFromSchema(row, TableCatalogSchema())
 
 	// insert entry to TableCatalogPage (PageId = 0)
-	c.tableHeap.InsertTuple(first_tuple, txn, tableMetadata.OID(), false)
+	// note: oid of columns catalog is passed because catalog tables have no index
+	//       and rollback of index data is not needed
+	c.tableHeap.InsertTuple(first_tuple, txn, ColumnsCatalogOID, false)
 	for _, column_ := range tableMetadata.schema.GetColumns() {
-		row := make([]types.Value, 0)
-		row = append(row, types.NewInteger(int32(tableMetadata.oid)))
-		row = append(row, types.NewInteger(int32(column_.GetType())))
-		row = append(row, types.NewVarchar(column_.GetColumnName()))
-		row = append(row, types.NewInteger(int32(column_.FixedLength())))
-		row = append(row, types.NewInteger(int32(column_.VariableLength())))
-		row = append(row, types.NewInteger(int32(column_.GetOffset())))
-		row = append(row, types.NewInteger(boolToInt32(column_.HasIndex())))
-		row = append(row, types.NewInteger(int32(column_.IndexKind())))
-		row = append(row, types.NewInteger(int32(column_.IndexHeaderPageId())))
-		new_tuple := tuple.NewTupleFromSchema(row, ColumnsCatalogSchema())
+		new_tuple := newColumnsCatalogTuple(tableMetadata.oid, column_)
 
 		// insert entry to ColumnsCatalogPage (PageId = 1)
 		c.tableIds[ColumnsCatalogOID].Table().InsertTuple(new_tuple, txn, ColumnsCatalogOID, false)
 	}
+	for _, index_ := range tableMetadata.compositeIndexes {
+		c.insertIndexEntry(tableMetadata, index_, txn)
+	}
+	for _, check := range tableMetadata.checks {
+		row = []types.Value{types.NewInteger(int32(tableMetadata.oid)), types.NewVarchar(check.exprStr)}
+		c.checksHeap.InsertTuple(tuple.NewTupleFromSchema(row, ChecksCatalogSchema()), txn, ColumnsCatalogOID, false)
+	}
+	if len(tableMetadata.checks) > 0 {
+		c.bpm.FlushPage(ChecksCatalogPageId)
+	}
 	// flush a page having table definitions
 	c.bpm.FlushPage(TableCatalogPageId)
 	// flush a page having columns definitions on table
 	c.bpm.FlushPage(ColumnsCatalogPageId)
 }
 
+// inserts entry of composite index to indexes catalog
+func (c *Catalog) insertIndexEntry(tableMetadata *TableMetadata, index_ *index.CompositeSkipListIndex, txn *access.Transaction) error {
+	row := make([]types.Value, 0)
+	row = append(row, types.NewInteger(int32(tableMetadata.oid)))
+	row = append(row, types.NewVarchar(*index_.GetName()))
+	if index_.IsUnique() {
+		row = append(row, types.NewInteger(int32(index_constants.INDEX_KIND_UNIQ_SKIP_LIST)))
+	} else {
+		row = append(row, types.NewInteger(int32(index_constants.INDEX_KIND_SKIP_LIST)))
+	}
+	row = append(row, types.NewVarchar(strings.Join(tableMetadata.getKeyColNames(index_), ",")))
+	// catalog tables have no index. so, oid of columns catalog is passed as insertTable
+	if _, err := c.indexesHeap.InsertTuple(tuple.NewTupleFromSchema(row, IndexesCatalogSchema()), txn, ColumnsCatalogOID, false); err != nil {
+		txn.SetState(access.ABORTED)
+		return CatalogUpdateAbortedErr
+	}
+	c.bpm.FlushPage(IndexesCatalogPageId)
+	return nil
+}
+
+// returns metadata of a table which is created by user
+func (c *Catalog) getUserTable(tableName string) (*TableMetadata, error) {
+	tm := c.GetTableByName(tableName)
+	if tm == nil {
+		return nil, TableNotFoundErr
+	}
+	if tm.OID() == ColumnsCatalogOID {
+		return nil, SystemCatalogModifyErr
+	}
+	return tm, nil
+}
+
+// DropTable removes a table from the catalog. pages used for the table heap and indexes
+// are deallocated for reuse when txn is committed.
+// ATTENTION: other transactions should be blocked while this function is executed
+//
+//	in-memory state is changed immediately as CreateTable. so, txn should be committed
+//	when nil is returned. when error is returned, caller should abort txn
+func (c *Catalog) DropTable(tableName string, txn *access.Transaction) error {
+	tm, err := c.getUserTable(tableName)
+	if err != nil {
+		return err
+	}
+	if err = tm.checkNoUncommittedChanges(txn); err != nil {
+		return err
+	}
+	if err = c.deleteTableEntries(tm.OID(), txn); err != nil {
+		return err
+	}
+	tm.Table().DeallocatePagesAtCommit(tm.getPageIds(), txn)
+
+	c.tableIdsMutex.Lock()
+	delete(c.tableIds, tm.OID())
+	c.tableIdsMutex.Unlock()
+	c.tableNamesMutex.Lock()
+	delete(c.tableNames, tm.name)
+	c.tableNamesMutex.Unlock()
+	return nil
+}
+
+// TruncateTable removes all tuples of a table. the table heap and indexes are replaced with
+// empty ones and old pages are deallocated for reuse when txn is committed.
+// ATTENTION: as DropTable, other transactions should be blocked while this function is executed
+func (c *Catalog) TruncateTable(tableName string, txn *access.Transaction) error {
+	tm, err := c.getUserTable(tableName)
+	if err != nil {
+		return err
+	}
+	if err = tm.checkNoUncommittedChanges(txn); err != nil {
+		return err
+	}
+	return c.rewriteTable(tm, tm.Schema().GetColumns(), nil, txn)
+}
+
+// AlterTable drops columns which have dropColNames and adds addColumns to the end of a table.
+// added columns are filled with NULL and all tuples are rewritten to a new table heap.
+// as the columns defined at CREATE TABLE, addColumns should have index setting
+// ATTENTION: as DropTable, other transactions should be blocked while this function is executed
+func (c *Catalog) AlterTable(tableName string, addColumns []*column.Column, dropColNames []string, txn *access.Transaction) error {
+	tm, err := c.getUserTable(tableName)
+	if err != nil {
+		return err
+	}
+
+	isDropped := make([]bool, tm.GetColumnNum())
+	for _, colName := range dropColNames {
+		colIdx := tm.Schema().GetColIndex(colName)
+		if colIdx == math.MaxUint32 {
+			return ColumnNotFoundErr
+		}
+		isDropped[colIdx] = true
+	}
+	columns := make([]*column.Column, 0)
+	// index of the column on current schema. math.MaxUint32 means added column
+	srcColIdxs := make([]uint32, 0)
+	for colIdx, column_ := range tm.Schema().GetColumns() {
+		if !isDropped[colIdx] {
+			columns = append(columns, column_)
+			srcColIdxs = append(srcColIdxs, uint32(colIdx))
+		}
+	}
+	for _, column_ := range addColumns {
+		colName := tm.name + "." + column_.GetColumnName()
+		for _, existing := range columns {
+			if strings.EqualFold(existing.GetColumnName(), colName) {
+				return ColumnAlreadyExistsErr
+			}
+		}
+		column_.SetColumnName(colName)
+		columns = append(columns, column_)
+		srcColIdxs = append(srcColIdxs, math.MaxUint32)
+	}
+	if len(columns) == 0 {
+		return LastColumnDropErr
+	}
+
+	return c.rewriteTable(tm, columns, srcColIdxs, txn)
+}
+
+// replaces table heap, indexes and schema of tm with new ones which have columns.
+// when srcColIdxs is not nil, all tuples are copied to the new table heap. each element of it is
+// index of the column on current schema or math.MaxUint32 (the column is filled with NULL).
+// pages of old table heap and indexes are deallocated when txn is committed
+func (c *Catalog) rewriteTable(tm *TableMetadata, columns []*column.Column, srcColIdxs []uint32, txn *access.Transaction) error {
+	// Attention: this method call copies passed Column objects
+	newSchema := schema.NewSchema(columns)
+	for _, column_ := range newSchema.GetColumns() {
+		if column_.HasIndex() {
+			// new pages are allocated for the index
+			column_.SetIndexHeaderPageId(types.PageID(-1))
+		}
+	}
+	newTm := NewTableMetadata(newSchema, tm.name, access.NewTableHeap(c.bpm, c.Log_manager, c.Lock_manager, txn), tm.OID(), c.Log_manager, true)
+	for _, index_ := range tm.compositeIndexes {
+		// composite index which has dropped column is dropped
+		if newIndex, err := newTm.newCompositeIndex(*index_.GetName(), tm.getKeyColNames(index_), index_.IsUnique(), c.Log_manager); err == nil {
+			newTm.compositeIndexes = append(newTm.compositeIndexes, newIndex)
+		}
+	}
+
+	if srcColIdxs != nil {
+		it := tm.Table().Iterator(txn)
+		for tuple_ := it.Current(); !it.End(); tuple_ = it.Next() {
+			row := make([]types.Value, 0)
+			for idx, srcColIdx := range srcColIdxs {
+				if srcColIdx == math.MaxUint32 {
+					if newSchema.GetColumn(uint32(idx)).IsNotNull() {
+						// added column is filled with NULL
+						return NotNullViolationErr
+					}
+					row = append(row, types.NewNullOfType(newSchema.GetColumn(uint32(idx)).GetType()))
+				} else {
+					row = append(row, tuple_.GetValue(tm.Schema(), srcColIdx))
+				}
+			}
+			newTuple := tuple.NewTupleFromSchema(row, newSchema)
+			// write record is not needed because the new table heap is not used when txn is aborted
+			rid, err := newTm.Table().InsertTuple(newTuple, txn, tm.OID(), true)
+			if err != nil {
+				txn.SetState(access.ABORTED)
+				return CatalogUpdateAbortedErr
+			}
+			for _, index_ := range newTm.Indexes() {
+				if index_ != nil {
+					index_.InsertEntry(newTuple, *rid, txn)
+				}
+			}
+			for _, index_ := range newTm.compositeIndexes {
+				index_.InsertEntry(newTuple, *rid, txn)
+			}
+		}
+		if txn.GetState() == access.ABORTED {
+			// lock conflict occured at the scan
+			return CatalogUpdateAbortedErr
+		}
+	}
+
+	if err := c.deleteTableEntries(tm.OID(), txn); err != nil {
+		return err
+	}
+	for _, check := range tm.checks {
+		// as composite index, CHECK constraint which has dropped column is dropped
+		if remapColumnsOfExpr(check.expr, tm.Schema(), newSchema) {
+			newTm.checks = append(newTm.checks, check)
+		}
+	}
+	c.insertTable(newTm, txn)
+	tm.Table().DeallocatePagesAtCommit(tm.getPageIds(), txn)
+
+	// tm is updated for keeping references to it valid
+	tm.schema = newTm.schema
+	tm.table = newTm.table
+	tm.indexes = newTm.indexes
+	tm.compositeIndexes = newTm.compositeIndexes
+	tm.checks = newTm.checks
+	tm.statiscs = newTm.statiscs
+	return nil
+}
+
+// marks entries of the table which has oid on all catalogs as deleted
+func (c *Catalog) deleteTableEntries(oid uint32, txn *access.Transaction) error {
+	if err := deleteEntriesOfOID(c.tableHeap, TableCatalogSchema(), "oid", oid, "", txn); err != nil {
+		return err
+	}
+	if err := deleteEntriesOfOID(c.GetTableByOID(ColumnsCatalogOID).Table(), ColumnsCatalogSchema(), "table_oid", oid, "", txn); err != nil {
+		return err
+	}
+	if err := deleteEntriesOfOID(c.indexesHeap, IndexesCatalogSchema(), "table_oid", oid, "", txn); err != nil {
+		return err
+	}
+	return deleteEntriesOfOID(c.checksHeap, ChecksCatalogSchema(), "table_oid", oid, "", txn)
+}
+
+// when name is not empty, only entries whose "name" column equals to it are marked
+func deleteEntriesOfOID(catalogHeap *access.TableHeap, schema_ *schema.Schema, oidColName string, oid uint32, name string, txn *access.Transaction) error {
+	rids := make([]page.RID, 0)
+	it := catalogHeap.Iterator(txn)
+	for tuple_ := it.Current(); !it.End(); tuple_ = it.Next() {
+		if name != "" && tuple_.GetValue(schema_, schema_.GetColIndex("name")).ToVarchar() != name {
+			continue
+		}
+		if uint32(tuple_.GetValue(schema_, schema_.GetColIndex(oidColName)).ToInteger()) == oid {
+			rids = append(rids, *tuple_.GetRID())
+		}
+	}
+	if txn.GetState() == access.ABORTED {
+		return CatalogUpdateAbortedErr
+	}
+	for idx := range rids {
+		// catalog tables have no index. so, oid of columns catalog is passed as insertTable
+		// note: address of element is passed because it is kept in write record
+		if !catalogHeap.MarkDelete(&rids[idx], ColumnsCatalogOID, txn, false) {
+			return CatalogUpdateAbortedErr
+		}
+	}
+	return nil
+}
+
+// CreateIndex creates an index on a column of existing table and fills it with all tuples of the table.
+// an index is held per column, so the column must not have an index yet.
+// ATTENTION: other transactions should be blocked while this function is executed
+//
+//	because index entries are not added by concurrent modifications during the scan.
+//	when error is returned, in-memory state is restored but caller should abort txn
+func (c *Catalog) CreateIndex(indexName string, tableName string, colName string, indexKind index_constants.IndexKind, txn *access.Transaction) error {
+	// note: alphabets on index name is stored in lowercase
+	indexName_ := strings.ToLower(indexName)
+
+	tm := c.GetTableByName(tableName)
+	if tm == nil {
+		return TableNotFoundErr
+	}
+	colIdx := tm.Schema().GetColIndex(colName)
+	if colIdx == math.MaxUint32 {
+		return ColumnNotFoundErr
+	}
+	if tm.getColIdxOfIndex(indexName_) != math.MaxUint32 || tm.GetCompositeIndex(indexName_) != nil {
+		return IndexAlreadyExistsErr
+	}
+	column_ := tm.Schema().GetColumn(colIdx)
+	if column_.HasIndex() {
+		return ColumnAlreadyIndexedErr
+	}
+
+	column_.SetHasIndex(true)
+	column_.SetIndexKind(indexKind)
+	column_.SetIndexHeaderPageId(types.PageID(-1))
+	column_.SetIndexName(indexName_)
+	tm.indexes[colIdx] = newIndexOfColumn(tm.Schema(), colIdx, tm.name, tm.table, c.Log_manager, true)
+
+	err := tm.fillIndex(tm.indexes[colIdx], indexKind == index_constants.INDEX_KIND_UNIQ_SKIP_LIST, txn)
+	if err == nil {
+		err = c.updateColumnEntry(tm, colIdx, txn)
+	}
+	if err != nil {
+		// pages allocated for the index are not reused
+		tm.indexes[colIdx] = nil
+		clearIndexOfColumn(column_)
+		return err
+	}
+	return nil
+}
+
+// CreateCompositeIndex creates an index whose key consists of columns which have colNames in the order.
+// the index is SkipList based and range scan on prefix of the columns is available.
+// when isUnique is true, the index works as PRIMARY KEY or UNIQUE constraint on the columns.
+// ATTENTION: as CreateIndex, other transactions should be blocked while this function is executed
+func (c *Catalog) CreateCompositeIndex(indexName string, tableName string, colNames []string, isUnique bool, txn *access.Transaction) error {
+	// note: alphabets on index name is stored in lowercase
+	indexName_ := strings.ToLower(indexName)
+
+	tm := c.GetTableByName(tableName)
+	if tm == nil {
+		return TableNotFoundErr
+	}
+	if tm.getColIdxOfIndex(indexName_) != math.MaxUint32 || tm.GetCompositeIndex(indexName_) != nil {
+		return IndexAlreadyExistsErr
+	}
+	index_, err := tm.newCompositeIndex(indexName_, colNames, isUnique, c.Log_manager)
+	if err != nil {
+		return err
+	}
+	keyAttrs := index_.GetKeyAttrs()
+	for ii := 0; ii < len(keyAttrs); ii++ {
+		if slices.Contains(keyAttrs[:ii], keyAttrs[ii]) {
+			return IndexColumnDuplicatedErr
+		}
+	}
+
+	if err = tm.fillIndex(index_, isUnique, txn); err != nil {
+		// pages allocated for the index are not reused
+		return err
+	}
+	if err = c.insertIndexEntry(tm, index_, txn); err != nil {
+		return err
+	}
+	tm.compositeIndexes = append(tm.compositeIndexes, index_)
+	return nil
+}
+
+// DropIndex removes an index which is created with CreateIndex, CreateCompositeIndex or at table creation.
+// pages of composite index are deallocated for reuse when txn is committed.
+// ATTENTION: as CreateIndex, other transactions should be blocked while this function is executed
+func (c *Catalog) DropIndex(indexName string, tableName string, txn *access.Transaction) error {
+	tm := c.GetTableByName(tableName)
+	if tm == nil {
+		return TableNotFoundErr
+	}
+	if index_ := tm.GetCompositeIndex(strings.ToLower(indexName)); index_ != nil {
+		if err := deleteEntriesOfOID(c.indexesHeap, IndexesCatalogSchema(), "table_oid", tm.OID(), *index_.GetName(), txn); err != nil {
+			return err
+		}
+		tm.Table().DeallocatePagesAtCommit(index_.GetPageIds(), txn)
+		tm.compositeIndexes = slices.DeleteFunc(tm.compositeIndexes, func(idx *index.CompositeSkipListIndex) bool { return idx == index_ })
+		return nil
+	}
+	colIdx := tm.getColIdxOfIndex(strings.ToLower(indexName))
+	if colIdx == math.MaxUint32 {
+		return IndexNotFoundErr
+	}
+
+	column_ := tm.Schema().GetColumn(colIdx)
+	backup := *column_
+	clearIndexOfColumn(column_)
+	if err := c.updateColumnEntry(tm, colIdx, txn); err != nil {
+		*column_ = backup
+		return err
+	}
+	// pages allocated for the index are not reused
+	tm.indexes[colIdx] = nil
+	return nil
+}
+
+func clearIndexOfColumn(column_ *column.Column) {
+	column_.SetHasIndex(false)
+	column_.SetIndexKind(index_constants.INDEX_KIND_INVALID)
+	column_.SetIndexHeaderPageId(types.PageID(-1))
+	column_.SetIndexName("")
+}
+
+// overwrites entry of the column at colIdx on columns catalog with current definition
+func (c *Catalog) updateColumnEntry(tm *TableMetadata, colIdx uint32, txn *access.Transaction) error {
+	column_ := tm.Schema().GetColumn(colIdx)
+	columnsCatalog := c.GetTableByOID(ColumnsCatalogOID)
+	it := columnsCatalog.Table().Iterator(txn)
+	for tuple_ := it.Current(); !it.End(); tuple_ = it.Next() {
+		tableOid := tuple_.GetValue(ColumnsCatalogSchema(), ColumnsCatalogSchema().GetColIndex("table_oid")).ToInteger()
+		name := tuple_.GetValue(ColumnsCatalogSchema(), ColumnsCatalogSchema().GetColIndex("name")).ToVarchar()
+		if uint32(tableOid) != tm.OID() || name != column_.GetColumnName() {
+			continue
+		}
+		isUpdated, _, _, _, _ := columnsCatalog.Table().UpdateTuple(newColumnsCatalogTuple(tm.OID(), column_), nil, nil, ColumnsCatalogOID, *tuple_.GetRID(), txn, false)
+		if !isUpdated || txn.GetState() == access.ABORTED {
+			return CatalogUpdateAbortedErr
+		}
+		return nil
+	}
+	if txn.GetState() == access.ABORTED {
+		return CatalogUpdateAbortedErr
+	}
+	return ColumnNotFoundErr
+}
+
 // for Redo/Undo
 //
-// returned list's length is same with column num of table.
+// returned list's head elements are indexes of each column and composite indexes follow them.
 // value of elements corresponding to columns which doesn't have index is nil.
 func (c *Catalog) GetRollbackNeededIndexes(indexMap map[uint32][]index.Index, oid uint32) []index.Index {
 	if indexes, found := indexMap[oid]; found {
 		return indexes
 	} else {
-		indexes_ := c.GetTableByOID(oid).Indexes()
+		tm := c.GetTableByOID(oid)
+		indexes_ := append([]index.Index{}, tm.Indexes()...)
+		for _, index_ := range tm.CompositeIndexes() {
+			indexes_ = append(indexes_, index_)
+		}
 		indexMap[oid] = indexes_
 		return indexes_
 	}
@@ -0,0 +1,174 @@
+package catalog
+
+import (
+	"github.com/ryogrid/SamehadaDB/lib/execution/expression"
+	"github.com/ryogrid/SamehadaDB/lib/storage/access"
+	"github.com/ryogrid/SamehadaDB/lib/storage/index"
+	"github.com/ryogrid/SamehadaDB/lib/storage/index/index_constants"
+	"github.com/ryogrid/SamehadaDB/lib/storage/page"
+	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
+	"github.com/ryogrid/SamehadaDB/lib/storage/tuple"
+	"math"
+)
+
+// CheckConstraint is a CHECK constraint of a table.
+// exprStr is SQL text of the expression and it is stored to catalog. catalog can't parse it,
+// so expr of the constraints loaded from catalog is nil until SetExpr is called at launch
+type CheckConstraint struct {
+	exprStr string
+	expr    expression.Expression
+}
+
+func NewCheckConstraint(exprStr string, expr expression.Expression) *CheckConstraint {
+	return &CheckConstraint{exprStr, expr}
+}
+
+func (cc *CheckConstraint) ExprStr() string {
+	return cc.exprStr
+}
+
+func (cc *CheckConstraint) Expr() expression.Expression {
+	return cc.expr
+}
+
+func (cc *CheckConstraint) SetExpr(expr expression.Expression) {
+	cc.expr = expr
+}
+
+type checkResult int
+
+const (
+	checkFalse checkResult = iota
+	checkTrue
+	checkUnknown
+)
+
+// evaluates exp with three-valued logic of SQL. comparison with NULL value is unknown
+// except the one created from IS NULL and IS NOT NULL (compared with NULL literal)
+func evaluateCheckExpr(exp expression.Expression, tuple_ *tuple.Tuple, schema_ *schema.Schema) checkResult {
+	switch casted := exp.(type) {
+	case *expression.LogicalOp:
+		left := evaluateCheckExpr(casted.GetChildAt(0), tuple_, schema_)
+		if casted.GetLogicalOpType() == expression.NOT {
+			switch left {
+			case checkTrue:
+				return checkFalse
+			case checkFalse:
+				return checkTrue
+			default:
+				return checkUnknown
+			}
+		}
+		right := evaluateCheckExpr(casted.GetChildAt(1), tuple_, schema_)
+		// false on AND and true on OR decide the result regardless of the other side
+		decisive, other := checkFalse, checkTrue
+		if casted.GetLogicalOpType() == expression.OR {
+			decisive, other = checkTrue, checkFalse
+		}
+		if left == decisive || right == decisive {
+			return decisive
+		}
+		if left == checkUnknown || right == checkUnknown {
+			return checkUnknown
+		}
+		return other
+	case *expression.Comparison:
+		if constVal, ok := casted.GetChildAt(1).(*expression.ConstantValue); !ok || !constVal.GetValue().IsNull() {
+			lhs := casted.GetChildAt(0).Evaluate(tuple_, schema_)
+			rhs := casted.GetChildAt(1).Evaluate(tuple_, schema_)
+			if lhs.IsNull() || rhs.IsNull() {
+				return checkUnknown
+			}
+		}
+	}
+	if exp.Evaluate(tuple_, schema_).ToBoolean() {
+		return checkTrue
+	}
+	return checkFalse
+}
+
+// remaps column indexes referenced by exp from ones on oldSchema to ones on newSchema.
+// returns false when some of the columns don't exist on newSchema. exp is not modified in the case
+func remapColumnsOfExpr(exp expression.Expression, oldSchema *schema.Schema, newSchema *schema.Schema) bool {
+	columnValues := make([]*expression.ColumnValue, 0)
+	newColIdxs := make([]uint32, 0)
+	var collect func(expression.Expression) bool
+	collect = func(node expression.Expression) bool {
+		switch casted := node.(type) {
+		case *expression.ColumnValue:
+			newColIdx := newSchema.GetColIndex(oldSchema.GetColumn(casted.GetColIndex()).GetColumnName())
+			if newColIdx == math.MaxUint32 {
+				return false
+			}
+			columnValues = append(columnValues, casted)
+			newColIdxs = append(newColIdxs, newColIdx)
+		case *expression.Comparison, *expression.LogicalOp:
+			for ii := uint32(0); ii < 2; ii++ {
+				if child := node.GetChildAt(ii); child != nil && !collect(child) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	if !collect(exp) {
+		return false
+	}
+	for idx, columnValue := range columnValues {
+		columnValue.SetColIndex(newColIdxs[idx])
+	}
+	return true
+}
+
+// returns true when other tuple which has same key with tuple_ exists on index_.
+// entry of the tuple at rid is ignored. key which contains NULL is never duplicated
+func isDuplicatedKey(index_ index.Index, tuple_ *tuple.Tuple, rid *page.RID, txn *access.Transaction) bool {
+	for _, colIdx := range index_.GetKeyAttrs() {
+		if tuple_.GetValue(index_.GetTupleSchema(), colIdx).IsNull() {
+			return false
+		}
+	}
+	for _, foundRID := range index_.ScanKey(tuple_, txn) {
+		if rid == nil || foundRID != *rid {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTuple validates tuple_ which is inserted or is updated with NOT NULL, PRIMARY KEY, UNIQUE
+// and CHECK constraints of the table. rid is location of the tuple before update and nil is passed at insertion.
+// uniqueness is checked with index entries. so, tuple_ should be checked before it is inserted to indexes.
+// note: same key inserted by other txn after the check is not detected
+func (t *TableMetadata) ValidateTuple(tuple_ *tuple.Tuple, rid *page.RID, txn *access.Transaction) error {
+	for colIdx, column_ := range t.schema.GetColumns() {
+		if column_.IsNotNull() && tuple_.GetValue(t.schema, uint32(colIdx)).IsNull() {
+			return NotNullViolationErr
+		}
+	}
+	for colIdx, index_ := range t.indexes {
+		if index_ == nil || t.schema.GetColumn(uint32(colIdx)).IndexKind() != index_constants.INDEX_KIND_UNIQ_SKIP_LIST {
+			continue
+		}
+		if isDuplicatedKey(index_, tuple_, rid, txn) {
+			return DuplicateKeyErr
+		}
+	}
+	for _, index_ := range t.compositeIndexes {
+		if index_.IsUnique() && isDuplicatedKey(index_, tuple_, rid, txn) {
+			return DuplicateKeyErr
+		}
+	}
+	for _, check := range t.checks {
+		// unknown (NULL) result satisfies the constraint
+		if evaluateCheckExpr(check.expr, tuple_, t.schema) == checkFalse {
+			return CheckViolationErr
+		}
+	}
+	return nil
+}
+
+// returns CHECK constraints of the table
+func (t *TableMetadata) CheckConstraints() []*CheckConstraint {
+	return t.checks
+}
@@ -9,7 +9,11 @@ import (
 	"github.com/ryogrid/SamehadaDB/lib/storage/access"
 	"github.com/ryogrid/SamehadaDB/lib/storage/index"
 	"github.com/ryogrid/SamehadaDB/lib/storage/index/index_constants"
+	"github.com/ryogrid/SamehadaDB/lib/storage/table/column"
 	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
+	"github.com/ryogrid/SamehadaDB/lib/types"
+	"math"
+	"strings"
 )
 
 type TableMetadata struct {
@@ -19,6 +23,11 @@ type TableMetadata struct {
 	// index data class obj of each column
 	// if column has no index, respond element is nil
 	indexes []index.Index
+	// indexes whose key consists of multiple columns. they are not held by Column objects
+	compositeIndexes []*index.CompositeSkipListIndex
+	// CHECK constraints. NOT NULL constraint is held by Column objects and
+	// PRIMARY KEY and UNIQUE constraints are represented as unique indexes
+	checks []*CheckConstraint
 	// locking is needed when accessing statiscs.colStats[x]
 	statiscs *TableStatistics
 	oid      uint32
@@ -35,60 +44,88 @@ func NewTableMetadata(schema *schema.Schema, name string, table *access.TableHea
 	indexes := make([]index.Index, 0)
 	for idx, column_ := range schema.GetColumns() {
 		if column_.HasIndex() {
-			switch column_.IndexKind() {
-			case index_constants.INDEX_KIND_HASH:
-				// index bucket size is common.BucketSizeOfHashIndex (auto size extending is needed...)
-				//       note: one bucket is used pages for storing index key/value pairs for a column.
-				//             one page can store 512 key/value pair
-				im := index.NewIndexMetadata(column_.GetColumnName()+"_index", name, schema, []uint32{uint32(idx)})
-				hIdx := index.NewLinearProbeHashTableIndex(im, table.GetBufferPoolManager(), uint32(idx), common.BucketSizeOfHashIndex, column_.IndexHeaderPageId())
-
-				indexes = append(indexes, hIdx)
-				// at first allocation of pages for index, column's indexHeaderPageID is -1 at above code (column_.IndexHeaderPageId() == -1)
-				// because first allocation occurs when table creation is processed (not launched DB instace from existing db file which has difinition of this table)
-				// so, for first allocation case, allocated page GetPageId of header page need to be set to column info here
-				column_.SetIndexHeaderPageId(hIdx.GetHeaderPageId())
-			case index_constants.INDEX_KIND_UNIQ_SKIP_LIST:
-				// currently, SkipList Index always use new pages even if relaunch
-				im := index.NewIndexMetadata(column_.GetColumnName()+"_index", name, schema, []uint32{uint32(idx)})
-				// TODO: (SDB) need to add index headae ID argument like HashIndex (NewTableMetadata)
-				slIdx := index.NewUniqSkipListIndex(im, table.GetBufferPoolManager(), uint32(idx))
-
-				indexes = append(indexes, slIdx)
-				//column_.SetIndexHeaderPageId(slIdx.GetHeaderPageId())
-			case index_constants.INDEX_KIND_SKIP_LIST:
-				// currently, SkipList Index always use new pages even if relaunch
-				im := index.NewIndexMetadata(column_.GetColumnName()+"_index", name, schema, []uint32{uint32(idx)})
-				// TODO: (SDB) need to add index headae ID argument like HashIndex (NewTableMetadata)
-				slIdx := index.NewSkipListIndex(im, table.GetBufferPoolManager(), uint32(idx), log_manager)
-
-				indexes = append(indexes, slIdx)
-				//column_.SetIndexHeaderPageId(slIdx.GetHeaderPageId())
-			case index_constants.INDEX_KIND_BTREE:
-				im := index.NewIndexMetadata(column_.GetColumnName()+"_index", name, schema, []uint32{uint32(idx)})
-				var pageZeroId *int32 = nil
-				if column_.IndexHeaderPageId() != -1 && isGracefulShutdown {
-					pageZeroId = new(int32)
-					*pageZeroId = int32(column_.IndexHeaderPageId())
-				}
-
-				btrIdx := index.NewBTreeIndex(im, table.GetBufferPoolManager(), uint32(idx), log_manager, pageZeroId)
-
-				indexes = append(indexes, btrIdx)
-				column_.SetIndexHeaderPageId(btrIdx.GetHeaderPageId())
-			default:
-				panic("illegal index kind!")
-			}
+			indexes = append(indexes, newIndexOfColumn(schema, uint32(idx), name, table, log_manager, isGracefulShutdown))
 		} else {
 			indexes = append(indexes, nil)
 		}
 	}
 
 	ret.indexes = indexes
+	ret.compositeIndexes = make([]*index.CompositeSkipListIndex, 0)
+	ret.checks = make([]*CheckConstraint, 0)
 
 	return ret
 }
 
+// creates composite index on columns which have colNames. the index always uses new pages
+// as SkipListIndex and its data is reconstructed at launch.
+// returns ColumnNotFoundErr when the table doesn't have some of the columns
+func (t *TableMetadata) newCompositeIndex(indexName string, colNames []string, isUnique bool, log_manager *recovery.LogManager) (*index.CompositeSkipListIndex, error) {
+	keyAttrs := make([]uint32, 0)
+	for _, colName := range colNames {
+		colIdx := t.schema.GetColIndex(colName)
+		if colIdx == math.MaxUint32 {
+			return nil, ColumnNotFoundErr
+		}
+		keyAttrs = append(keyAttrs, colIdx)
+	}
+	im := index.NewIndexMetadata(indexName, t.name, t.schema, keyAttrs)
+	return index.NewCompositeSkipListIndex(im, t.table.GetBufferPoolManager(), log_manager, isUnique), nil
+}
+
+// default index name of a column. e.g. "col1_index" for "table1.col1"
+func defaultIndexName(column_ *column.Column) string {
+	colName := column_.GetColumnName()
+	if strings.Contains(colName, ".") {
+		colName = strings.Split(colName, ".")[1]
+	}
+	return colName + "_index"
+}
+
+// creates index data class obj for the column at colIdx of schema_.
+// index kind and header page id are got from the column, and header page id of
+// newly allocated index is set to the column
+func newIndexOfColumn(schema_ *schema.Schema, colIdx uint32, tblName string, table *access.TableHeap, log_manager *recovery.LogManager, isGracefulShutdown bool) index.Index {
+	column_ := schema_.GetColumn(colIdx)
+	if column_.IndexName() == "" {
+		column_.SetIndexName(defaultIndexName(column_))
+	}
+	im := index.NewIndexMetadata(column_.IndexName(), tblName, schema_, []uint32{colIdx})
+
+	switch column_.IndexKind() {
+	case index_constants.INDEX_KIND_HASH:
+		// index bucket size is common.BucketSizeOfHashIndex (auto size extending is needed...)
+		//       note: one bucket is used pages for storing index key/value pairs for a column.
+		//             one page can store 512 key/value pair
+		hIdx := index.NewLinearProbeHashTableIndex(im, table.GetBufferPoolManager(), colIdx, common.BucketSizeOfHashIndex, column_.IndexHeaderPageId())
+		// at first allocation of pages for index, column's indexHeaderPageID is -1 at above code (column_.IndexHeaderPageId() == -1)
+		// because first allocation occurs when table creation is processed (not launched DB instace from existing db file which has difinition of this table)
+		// so, for first allocation case, allocated page GetPageId of header page need to be set to column info here
+		column_.SetIndexHeaderPageId(hIdx.GetHeaderPageId())
+		return hIdx
+	case index_constants.INDEX_KIND_UNIQ_SKIP_LIST:
+		// currently, SkipList Index always use new pages even if relaunch
+		// TODO: (SDB) need to add index headae ID argument like HashIndex (NewTableMetadata)
+		return index.NewUniqSkipListIndex(im, table.GetBufferPoolManager(), colIdx)
+	case index_constants.INDEX_KIND_SKIP_LIST:
+		// currently, SkipList Index always use new pages even if relaunch
+		// TODO: (SDB) need to add index headae ID argument like HashIndex (NewTableMetadata)
+		return index.NewSkipListIndex(im, table.GetBufferPoolManager(), colIdx, log_manager)
+	case index_constants.INDEX_KIND_BTREE:
+		var pageZeroId *int32 = nil
+		if column_.IndexHeaderPageId() != -1 && isGracefulShutdown {
+			pageZeroId = new(int32)
+			*pageZeroId = int32(column_.IndexHeaderPageId())
+		}
+
+		btrIdx := index.NewBTreeIndex(im, table.GetBufferPoolManager(), colIdx, log_manager, pageZeroId)
+		column_.SetIndexHeaderPageId(btrIdx.GetHeaderPageId())
+		return btrIdx
+	default:
+		panic("illegal index kind!")
+	}
+}
+
 func (t *TableMetadata) Schema() *schema.Schema {
 	return t.schema
 }
@@ -110,6 +147,30 @@ func (t *TableMetadata) GetIndex(colIndex int) index.Index {
 	}
 }
 
+// returns indexes whose key consists of multiple columns
+func (t *TableMetadata) CompositeIndexes() []*index.CompositeSkipListIndex {
+	return t.compositeIndexes
+}
+
+// returns nil when composite index which has indexName does not exist
+func (t *TableMetadata) GetCompositeIndex(indexName string) *index.CompositeSkipListIndex {
+	for _, index_ := range t.compositeIndexes {
+		if *index_.GetName() == indexName {
+			return index_
+		}
+	}
+	return nil
+}
+
+// names of key columns of composite index
+func (t *TableMetadata) getKeyColNames(index_ index.Index) []string {
+	ret := make([]string, 0)
+	for _, colIdx := range index_.GetKeyAttrs() {
+		ret = append(ret, t.schema.GetColumn(colIdx).GetColumnName())
+	}
+	return ret
+}
+
 func (t *TableMetadata) GetColumnNum() uint32 {
 	return t.schema.GetColumnCount()
 }
@@ -129,3 +190,61 @@ func (t *TableMetadata) GetStatistics() *TableStatistics {
 func (t *TableMetadata) GetTableName() *string {
 	return &t.name
 }
+
+// returns math.MaxUint32 when index which has indexName does not exist or the index is composite index
+func (t *TableMetadata) getColIdxOfIndex(indexName string) uint32 {
+	for idx, column_ := range t.schema.GetColumns() {
+		if column_.HasIndex() && column_.IndexName() == indexName {
+			return uint32(idx)
+		}
+	}
+	return math.MaxUint32
+}
+
+// inserts entries of all tuples to index_ like reconstruction of index data at launch
+func (t *TableMetadata) fillIndex(index_ index.Index, isUnique bool, txn *access.Transaction) error {
+	it := t.table.Iterator(txn)
+	for tuple_ := it.Current(); !it.End(); tuple_ = it.Next() {
+		if isUnique && isDuplicatedKey(index_, tuple_, nil, txn) {
+			return DuplicateKeyErr
+		}
+		index_.InsertEntry(tuple_, *tuple_.GetRID(), txn)
+	}
+	if txn.GetState() == access.ABORTED {
+		// lock conflict occured at the scan
+		return CatalogUpdateAbortedErr
+	}
+	return nil
+}
+
+// scans all tuples for checking that other txns don't have uncommitted changes on the table.
+// the changes are detected as lock conflict because tuples changed by a txn are exclusive locked until its end
+func (t *TableMetadata) checkNoUncommittedChanges(txn *access.Transaction) error {
+	it := t.table.Iterator(txn)
+	for it.Current(); !it.End(); it.Next() {
+	}
+	if txn.GetState() == access.ABORTED {
+		return CatalogUpdateAbortedErr
+	}
+	return nil
+}
+
+// returns ids of pages used for the table heap and indexes.
+// pages of BTree index are not contained because they are managed by BLTree container
+func (t *TableMetadata) getPageIds() []types.PageID {
+	ret := t.table.GetPageIds()
+	for _, index_ := range t.indexes {
+		switch idx := index_.(type) {
+		case *index.LinearProbeHashTableIndex:
+			ret = append(ret, idx.GetPageIds()...)
+		case *index.SkipListIndex:
+			ret = append(ret, idx.GetPageIds()...)
+		case *index.UniqSkipListIndex:
+			ret = append(ret, idx.GetPageIds()...)
+		}
+	}
+	for _, index_ := range t.compositeIndexes {
+		ret = append(ret, index_.GetPageIds()...)
+	}
+	return ret
+}
@@ -12,8 +12,9 @@ var LogTimeout time.Duration
 
 const EnableDebug bool = false //true
 
-// use on memory virtual storage or not
-const EnableOnMemStorage = true
+// use on memory virtual storage or not when storage is not specified with options of SamehadaDB.
+// it can be changed at runtime but it affects SamehadaDB objects created after the change only
+var EnableOnMemStorage = true
 
 // when this is true, virtual storage use is suppressed
 // for test case which can't work with virtual storage
@@ -38,8 +39,10 @@ const (
 	BufferPoolMaxFrameNumForTest = 32
 	// number for calculate log buffer size (number of page size)
 	LogBufferSizeBase = 128
-	// size of a log buffer in byte
+	// size of a log buffer in byte (default)
 	LogBufferSize = (LogBufferSizeBase + 1) * PageSize
+	// log buffer must be able to store largest log record (NEW_OVERFLOW_PAGE, UPDATE of large tuple)
+	MinLogBufferSize = 2 * PageSize
 	// size of hash bucket
 	BucketSizeOfHashIndex = 10
 	// probability used for determin node level on SkipList
@@ -2,14 +2,18 @@ package concurrency
 
 import (
 	"fmt"
+	"github.com/ryogrid/SamehadaDB/lib/common"
 	"github.com/ryogrid/SamehadaDB/lib/recovery"
 	"github.com/ryogrid/SamehadaDB/lib/storage/access"
 	"github.com/ryogrid/SamehadaDB/lib/storage/buffer"
+	"github.com/ryogrid/SamehadaDB/lib/types"
 	"time"
 )
 
 /**
- * CheckpointManager creates consistent checkpoints by blocking all other transactions temporarily.
+ * CheckpointManager creates fuzzy checkpoints (ARIES style). transactions are not blocked.
+ * dirty page table and active transaction table are written to END_CHECKPOINT log record
+ * and recovery starts redo from recLSN of the oldest dirty page in the table.
  */
 type CheckpointManager struct {
 	transaction_manager *access.TransactionManager
@@ -17,19 +21,32 @@ type CheckpointManager struct {
 	buffer_pool_manager *buffer.BufferPoolManager
 	// checkpointing thread works when this flag is true
 	isCheckpointActive bool
+	interval           time.Duration
+	// pages in dirty page table of last checkpoint. they are written at EndCheckpoint
+	dirtyPageIds []types.PageID
+	// redo LSN of last checkpoint
+	lastRedoLSN types.LSN
 }
 
+// interval of checkpointing when it is not specified with SetCheckpointInterval
+const DefaultCheckpointInterval = time.Second * 30
+
 func NewCheckpointManager(
 	transaction_manager *access.TransactionManager,
 	log_manager *recovery.LogManager,
 	buffer_pool_manager *buffer.BufferPoolManager) *CheckpointManager {
-	return &CheckpointManager{transaction_manager, log_manager, buffer_pool_manager, true}
+	return &CheckpointManager{transaction_manager, log_manager, buffer_pool_manager, true, DefaultCheckpointInterval, nil, common.InvalidLSN}
+}
+
+// should be called before StartCheckpointTh
+func (checkpoint_manager *CheckpointManager) SetCheckpointInterval(interval time.Duration) {
+	checkpoint_manager.interval = interval
 }
 
 func (checkpoint_manager *CheckpointManager) StartCheckpointTh() {
 	go func() {
 		for checkpoint_manager.IsCheckpointActive() {
-			time.Sleep(time.Second * 30)
+			time.Sleep(checkpoint_manager.interval)
 			if !checkpoint_manager.IsCheckpointActive() {
 				break
 			}
@@ -41,21 +58,45 @@ func (checkpoint_manager *CheckpointManager) StartCheckpointTh() {
 	}()
 }
 
+// BeginCheckpoint writes BEGIN_CHECKPOINT and END_CHECKPOINT log records which have
+// dirty page table and active transaction table. transactions are not blocked
 func (checkpoint_manager *CheckpointManager) BeginCheckpoint() {
-	// Block all the transactions and ensure that both the WAL and all dirty buffer pool pages are persisted to disk,
-	// creating a consistent checkpoint. Do NOT allow transactions to resume at the end of this method, resume them
-	// in CheckpointManager::EndCheckpoint() instead. This is for grading purposes.
-	checkpoint_manager.transaction_manager.BlockAllTransactions()
-	isSuccess := checkpoint_manager.buffer_pool_manager.FlushAllDirtyPages()
-	if !isSuccess {
-		fmt.Println("flush all dirty pages failed! at checkpointing (NOT BUG)")
+	beginLSN := checkpoint_manager.log_manager.AppendLogRecord(recovery.NewLogRecordBeginCheckpoint())
+
+	// tables are collected after BEGIN_CHECKPOINT. so, modifications which are logged before
+	// BEGIN_CHECKPOINT and not written to disk are covered with the dirty page table
+	dpt := checkpoint_manager.buffer_pool_manager.GetDirtyPageTable()
+	att := checkpoint_manager.transaction_manager.GetActiveTxnTable()
+
+	dirtyPages := make([]recovery.DirtyPageEntry, 0, len(dpt))
+	dirtyPageIds := make([]types.PageID, 0, len(dpt))
+	for pageId, recLSN := range dpt {
+		dirtyPages = append(dirtyPages, recovery.DirtyPageEntry{PageId: pageId, RecLSN: recLSN})
+		dirtyPageIds = append(dirtyPageIds, pageId)
 	}
-	checkpoint_manager.log_manager.Flush()
+	logRecord := recovery.NewLogRecordEndCheckpoint(beginLSN, dirtyPages, att)
+	checkpoint_manager.log_manager.AppendLogRecord(logRecord)
+	checkpoint_manager.log_manager.FlushAndSync()
+
+	checkpoint_manager.dirtyPageIds = dirtyPageIds
+	checkpoint_manager.lastRedoLSN = logRecord.RedoLSN()
 }
 
+// EndCheckpoint writes dirty pages which are recorded at BeginCheckpoint in background of transactions.
+// it makes redo LSN of next checkpoint newer
 func (checkpoint_manager *CheckpointManager) EndCheckpoint() {
-	// Allow transactions to resume, completing the checkpoint.
-	checkpoint_manager.transaction_manager.ResumeTransactions()
+	for _, pageId := range checkpoint_manager.dirtyPageIds {
+		if !checkpoint_manager.buffer_pool_manager.FlushDirtyPage(pageId) {
+			fmt.Printf("flush of dirty page failed at checkpointing. pageId:%d\n", pageId)
+		}
+	}
+	checkpoint_manager.dirtyPageIds = nil
+}
+
+// GetLastRedoLSN returns LSN which redo starts from when the system crashes now
+// (common.InvalidLSN means no checkpoint has been taken)
+func (checkpoint_manager *CheckpointManager) GetLastRedoLSN() types.LSN {
+	return checkpoint_manager.lastRedoLSN
 }
 
 func (checkpoint_manager *CheckpointManager) StopCheckpointTh() {
@@ -20,6 +20,13 @@ func HashValue(val *types.Value) uint32 {
 	case types.Varchar:
 		raw := val.Serialize()
 		return GenHashMurMur(raw)
+	case types.BigInt, types.Double, types.Timestamp, types.Blob:
+		raw := val.Serialize()
+		return GenHashMurMur(raw)
+	case types.Decimal:
+		// equal values which have different scale (ex: 1.5 and 1.50) must have same hash
+		raw := types.NewDecimal(val.ToDecimal().Normalize()).Serialize()
+		return GenHashMurMur(raw)
 	default:
 		fmt.Println(val.ValueType())
 		panic("not supported type!")
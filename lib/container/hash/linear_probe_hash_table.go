@@ -187,3 +187,18 @@ func (ht *LinearProbeHashTable) hash(key []byte) uint64 {
 func (ht *LinearProbeHashTable) GetHeaderPageId() types.PageID {
 	return ht.headerPageId
 }
+
+// returns ids of header page and all block pages
+func (ht *LinearProbeHashTable) GetPageIds() []types.PageID {
+	ht.table_latch.RLock()
+	defer ht.table_latch.RUnlock()
+	hPageData := ht.bpm.FetchPage(ht.headerPageId).Data()
+	headerPage := (*page.HashTableHeaderPage)(unsafe.Pointer(hPageData))
+
+	ret := []types.PageID{ht.headerPageId}
+	for ii := uint64(0); ii < headerPage.NumBlocks(); ii++ {
+		ret = append(ret, headerPage.GetBlockPageId(ii))
+	}
+	ht.bpm.UnpinPage(ht.headerPageId, false)
+	return ret
+}
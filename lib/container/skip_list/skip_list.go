@@ -341,3 +341,18 @@ func (sl *SkipList) GetNodeLevel() int32 {
 func (sl *SkipList) GetHeaderPageId() types.PageID {
 	return sl.headerPage.GetPageId()
 }
+
+// returns ids of header page and all nodes including start node and sentinel node.
+// ATTENTION: concurrent modification is not considered
+func (sl *SkipList) GetPageIds() []types.PageID {
+	ret := []types.PageID{sl.headerPage.GetPageId()}
+	pageId := sl.headerPage.GetListStartPageId()
+	for pageId != sl.SentinelNodeID {
+		ret = append(ret, pageId)
+		node := skip_list_page.FetchAndCastToBlockPage(sl.bpm, pageId)
+		nextPageId := node.GetForwardEntry(0)
+		sl.bpm.UnpinPage(pageId, false)
+		pageId = nextPageId
+	}
+	return append(ret, sl.SentinelNodeID)
+}
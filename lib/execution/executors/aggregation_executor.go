@@ -3,7 +3,6 @@ package executors
 import (
 	"fmt"
 	"github.com/ryogrid/SamehadaDB/lib/catalog"
-	"math"
 
 	"github.com/ryogrid/SamehadaDB/lib/container/hash"
 	"github.com/ryogrid/SamehadaDB/lib/execution/expression"
@@ -101,46 +100,78 @@ func HashValuesOnAggregateKey(key *plans.AggregateKey) uint32 {
 /** @return the initial aggregrate value for this aggregation executor */
 func (ht *SimpleAggregationHashTable) GenerateInitialAggregateValue() *plans.AggregateValue {
 	var values []*types.Value
-	for _, agg_type := range ht.agg_types_ {
+	for ii, agg_type := range ht.agg_types_ {
 		switch agg_type {
-		case plans.COUNT_AGGREGATE:
+		case plans.COUNT_AGGREGATE, plans.COUNT_DISTINCT_AGGREGATE:
 			// count starts at zero.
 			new_elem := types.NewInteger(0)
 			values = append(values, &new_elem)
-		case plans.SUM_AGGREGATE:
-			// Sum starts at zero.
-			new_elem := types.NewInteger(0)
+		case plans.AVG_AGGREGATE:
+			// avg is calculated from sum and count. sum is kept as type of result.
+			new_elem := types.NewNullOfType(plans.AvgTypeOf(ht.agg_exprs_[ii].GetReturnType()))
 			values = append(values, &new_elem)
-		case plans.MIN_AGGREGATE:
-			// min starts at INT_MAX.
-			new_elem := types.NewInteger(math.MaxInt32)
-			values = append(values, &new_elem)
-		case plans.MAX_AGGREGATE:
-			// max starts at INT_MIN.
-			new_elem := types.NewInteger(math.MinInt32)
+		default:
+			// sum, min and max start at NULL. they are NULL when no value is aggregated.
+			new_elem := types.NewNullOfType(ht.agg_exprs_[ii].GetReturnType())
 			values = append(values, &new_elem)
 		}
 	}
-	return &plans.AggregateValue{Aggregates_: values}
+	distincts := make([]map[string]bool, len(ht.agg_types_))
+	for ii := range distincts {
+		distincts[ii] = make(map[string]bool)
+	}
+	return &plans.AggregateValue{Aggregates_: values, Counts_: make([]int32, len(ht.agg_types_)), Distincts_: distincts}
 }
 
-/** Combines the input into the aggregation result. */
+/** Combines the input into the aggregation result. NULL input is ignored. */
 func (aht *SimpleAggregationHashTable) CombineAggregateValues(result *plans.AggregateValue, input *plans.AggregateValue) {
 	for i := 0; i < len(aht.agg_exprs_); i++ {
+		if input.Aggregates_[i].IsNull() {
+			continue
+		}
 		switch aht.agg_types_[i] {
 		case plans.COUNT_AGGREGATE:
 			// count increases by one.
 			add_val := types.NewInteger(1)
 			result.Aggregates_[i] = result.Aggregates_[i].Add(&add_val)
+		case plans.COUNT_DISTINCT_AGGREGATE:
+			// count increases by one only for value which is not counted yet.
+			serialized := string(input.Aggregates_[i].Serialize())
+			if !result.Distincts_[i][serialized] {
+				result.Distincts_[i][serialized] = true
+				add_val := types.NewInteger(1)
+				result.Aggregates_[i] = result.Aggregates_[i].Add(&add_val)
+			}
+		case plans.AVG_AGGREGATE:
+			// sum is kept as type of result and it is divided by count at finalization.
+			add_val, _ := input.Aggregates_[i].CastAs(plans.AvgTypeOf(aht.agg_exprs_[i].GetReturnType()))
+			if result.Aggregates_[i].IsNull() {
+				result.Aggregates_[i] = add_val
+			} else {
+				result.Aggregates_[i] = result.Aggregates_[i].Add(add_val)
+			}
+			result.Counts_[i]++
 		case plans.SUM_AGGREGATE:
 			// Sum increases by addition.
-			result.Aggregates_[i] = result.Aggregates_[i].Add(input.Aggregates_[i])
+			if result.Aggregates_[i].IsNull() {
+				result.Aggregates_[i] = input.Aggregates_[i].GetDeepCopy()
+			} else {
+				result.Aggregates_[i] = result.Aggregates_[i].Add(input.Aggregates_[i])
+			}
 		case plans.MIN_AGGREGATE:
 			// min is just the min.
-			result.Aggregates_[i] = result.Aggregates_[i].Min(input.Aggregates_[i])
+			if result.Aggregates_[i].IsNull() {
+				result.Aggregates_[i] = input.Aggregates_[i].GetDeepCopy()
+			} else {
+				result.Aggregates_[i] = result.Aggregates_[i].Min(input.Aggregates_[i])
+			}
 		case plans.MAX_AGGREGATE:
 			// max is just the max.
-			result.Aggregates_[i] = result.Aggregates_[i].Max(input.Aggregates_[i])
+			if result.Aggregates_[i].IsNull() {
+				result.Aggregates_[i] = input.Aggregates_[i].GetDeepCopy()
+			} else {
+				result.Aggregates_[i] = result.Aggregates_[i].Max(input.Aggregates_[i])
+			}
 		}
 	}
 }
@@ -151,18 +182,36 @@ func (aht *SimpleAggregationHashTable) CombineAggregateValues(result *plans.Aggr
  * @param agg_val the value to be inserted
  */
 func (aht *SimpleAggregationHashTable) InsertCombine(agg_key *plans.AggregateKey, agg_val *plans.AggregateValue) {
+	hashval_of_aggkey := aht.insertInitialIfNeeded(agg_key)
+	cur_val := aht.ht_val[hashval_of_aggkey]
+	aht.CombineAggregateValues(cur_val, agg_val)
+}
+
+// inserts initial aggregate value for agg_key if the key is not inserted yet. returns hash value of agg_key
+func (aht *SimpleAggregationHashTable) insertInitialIfNeeded(agg_key *plans.AggregateKey) uint32 {
 	hashval_of_aggkey := HashValuesOnAggregateKey(agg_key)
 	//fmt.Printf("%v ", hashval_of_aggkey)
 	if _, ok := aht.ht_val[hashval_of_aggkey]; !ok {
 		aht.ht_val[hashval_of_aggkey] = aht.GenerateInitialAggregateValue()
 	}
-	cur_val := aht.ht_val[hashval_of_aggkey]
-	aht.CombineAggregateValues(cur_val, agg_val)
 
 	// additional data store for realize iterator
 	if _, ok := aht.ht_key[hashval_of_aggkey]; !ok {
 		aht.ht_key[hashval_of_aggkey] = agg_key
 	}
+	return hashval_of_aggkey
+}
+
+// calculates results of aggregations which can't be calculated incrementally (AVG)
+func (aht *SimpleAggregationHashTable) Finalize() {
+	for _, val := range aht.ht_val {
+		for ii, agg_type := range aht.agg_types_ {
+			if agg_type == plans.AVG_AGGREGATE && val.Counts_[ii] > 0 {
+				count, _ := types.NewInteger(val.Counts_[ii]).CastAs(val.Aggregates_[ii].ValueType())
+				val.Aggregates_[ii] = val.Aggregates_[ii].Div(count)
+			}
+		}
+	}
 }
 
 /* return iterator to the start of the hash table */
@@ -190,6 +239,8 @@ type AggregationExecutor struct {
 	/** Simple aggregation hash table iterator. */
 	aht_iterator_ *AggregateHTIterator
 	exprs_        []expression.Expression
+	// error which child returned on Init. it is returned with Next
+	err_ error
 }
 
 /**
@@ -201,7 +252,7 @@ type AggregationExecutor struct {
 func NewAggregationExecutor(exec_ctx *ExecutorContext, plan *plans.AggregationPlanNode,
 	child Executor) *AggregationExecutor {
 	aht := NewSimpleAggregationHashTable(plan.GetAggregates(), plan.GetAggregateTypes())
-	return &AggregationExecutor{exec_ctx, plan, []Executor{child}, aht, nil, []expression.Expression{}}
+	return &AggregationExecutor{exec_ctx, plan, []Executor{child}, aht, nil, []expression.Expression{}, nil}
 }
 
 func (e *AggregationExecutor) GetOutputSchema() *schema.Schema { return e.plan_.OutputSchema() }
@@ -220,6 +271,7 @@ func (e *AggregationExecutor) Init() {
 		if err != nil || done {
 			if err != nil {
 				fmt.Println(err)
+				e.err_ = err
 			}
 			break
 		}
@@ -230,11 +282,19 @@ func (e *AggregationExecutor) Init() {
 		}
 	}
 	fmt.Printf("insert_call_cnt %d\n", insert_call_cnt)
+	if insert_call_cnt == 0 && len(e.plan_.GetGroupBys()) == 0 {
+		// aggregation without GROUP BY returns a row even if there is no input (ex: COUNT(*) returns 0)
+		e.aht_.insertInitialIfNeeded(&plans.AggregateKey{Group_bys_: []*types.Value{}})
+	}
+	e.aht_.Finalize()
 	e.aht_iterator_ = e.aht_.Begin()
 }
 
 func (e *AggregationExecutor) Next() (*tuple.Tuple, Done, error) {
-	for !e.aht_iterator_.IsNextEnd() && e.plan_.GetHaving() != nil && !e.plan_.GetHaving().EvaluateAggregate(e.aht_iterator_.Key().Group_bys_, e.aht_iterator_.Val().Aggregates_).ToBoolean() {
+	if e.err_ != nil {
+		return nil, false, e.err_
+	}
+	for !e.aht_iterator_.IsEnd() && e.plan_.GetHaving() != nil && !e.plan_.GetHaving().EvaluateAggregate(e.aht_iterator_.Key().Group_bys_, e.aht_iterator_.Val().Aggregates_).ToBoolean() {
 		e.aht_iterator_.Next()
 	}
 	if e.aht_iterator_.IsEnd() {
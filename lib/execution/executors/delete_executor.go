@@ -71,7 +71,14 @@ func (e *DeleteExecutor) Next() (*tuple.Tuple, Done, error) {
 				index_.DeleteEntry(t, *rid, e.txn)
 			}
 		}
+		for _, index_ := range tableMetadata.CompositeIndexes() {
+			index_.DeleteEntry(t, *rid, e.txn)
+		}
 
+		if e.plan.OutputSchema() != nil {
+			// RETURNING clause
+			return projectToSchema(t, e.child.GetOutputSchema(), e.plan.OutputSchema()), false, nil
+		}
 		return t, false, nil
 	}
 
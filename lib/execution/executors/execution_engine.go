@@ -19,6 +19,12 @@ type ExecutionEngine struct {
 }
 
 func (e *ExecutionEngine) Execute(plan plans.Plan, context *ExecutorContext) []*tuple.Tuple {
+	tuples, _ := e.ExecuteRetErr(plan, context)
+	return tuples
+}
+
+// same as Execute but error returned by executor is also returned. txn is aborted when it is not nil
+func (e *ExecutionEngine) ExecuteRetErr(plan plans.Plan, context *ExecutorContext) ([]*tuple.Tuple, error) {
 	executor := e.CreateExecutor(plan, context)
 	executor.Init()
 
@@ -27,7 +33,7 @@ func (e *ExecutionEngine) Execute(plan plans.Plan, context *ExecutorContext) []*
 		tuple, done, err := executor.Next()
 		if err != nil {
 			context.txn.SetState(access.ABORTED)
-			return nil
+			return nil, err
 		}
 		if done {
 			break
@@ -38,19 +44,30 @@ func (e *ExecutionEngine) Execute(plan plans.Plan, context *ExecutorContext) []*
 		}
 	}
 
-	return tuples
+	return tuples, nil
 }
 
 func (e *ExecutionEngine) CreateExecutor(plan plans.Plan, context *ExecutorContext) Executor {
 	switch p := plan.(type) {
 	case *plans.InsertPlanNode:
-		return NewInsertExecutor(context, p)
+		if p.IsRawInsert() {
+			return NewInsertExecutor(context, p, nil)
+		}
+		return NewInsertExecutor(context, p, e.CreateExecutor(plan.GetChildAt(0), context))
 	case *plans.SeqScanPlanNode:
 		return NewSeqScanExecutor(context, p)
 	case *plans.PointScanWithIndexPlanNode:
 		return NewPointScanWithIndexExecutor(context, p)
 	case *plans.RangeScanWithIndexPlanNode:
 		return NewRangeScanWithIndexExecutor(context, p)
+	case *plans.RangeScanWithCompositeIndexPlanNode:
+		return NewRangeScanWithCompositeIndexExecutor(context, p)
+	case *plans.IndexUnionPlanNode:
+		children := make([]Executor, 0)
+		for _, child := range plan.GetChildren() {
+			children = append(children, e.CreateExecutor(child, context))
+		}
+		return NewIndexUnionExecutor(context, p, children)
 	case *plans.LimitPlanNode:
 		return NewLimitExecutor(context, p, e.CreateExecutor(plan.GetChildAt(0), context))
 	case *plans.DeletePlanNode:
@@ -2511,7 +2511,7 @@ func TestSimpleSeqScanAndOrderBy(t *testing.T) {
 
 	orderby_plan := plans.NewOrderbyPlanNode(
 		nil, scan_plan, []int{0, 1},
-		[]plans.OrderbyType{plans.ASC, plans.ASC})
+		[]plans.OrderbyType{plans.ASC, plans.ASC}, []bool{false, false})
 
 	results := executionEngine.Execute(orderby_plan, exec_ctx)
 
@@ -2534,7 +2534,7 @@ func TestSimpleSeqScanAndOrderBy(t *testing.T) {
 	// test other order
 	orderby_plan = plans.NewOrderbyPlanNode(
 		nil, scan_plan, []int{0, 1},
-		[]plans.OrderbyType{plans.DESC, plans.DESC})
+		[]plans.OrderbyType{plans.DESC, plans.DESC}, []bool{true, true})
 
 	results = executionEngine.Execute(orderby_plan, exec_ctx)
 
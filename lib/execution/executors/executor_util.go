@@ -1 +1,32 @@
 package executors
+
+import (
+	"errors"
+	"github.com/ryogrid/SamehadaDB/lib/execution/expression"
+	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
+	"github.com/ryogrid/SamehadaDB/lib/storage/tuple"
+	"github.com/ryogrid/SamehadaDB/lib/types"
+)
+
+// joinPredicate of join plan is evaluated on joined tuple. nil predicate means always true
+func satisfiesJoinPredicate(joinPredicate expression.Expression, joinedTuple *tuple.Tuple, joinedSchema *schema.Schema) bool {
+	return joinPredicate == nil || joinPredicate.Evaluate(joinedTuple, joinedSchema).ToBoolean()
+}
+
+// returned by join executor when record of left child matches more than one record on LEFT_SINGLE_JOIN
+var ScalarSubqueryReturnedMultipleRowsErr = errors.New("more than one row returned by a subquery used as an expression!")
+
+// used for padding of outer join
+func nullValueOfColumn(sc *schema.Schema, colIdx uint32) types.Value {
+	return types.NewNullOfType(sc.GetColumn(colIdx).GetType())
+}
+
+// returns tuple which has values of columns of outSchema. the values are got from tuple_ whose schema is schema_.
+// used for RETURNING clause of INSERT, UPDATE and DELETE
+func projectToSchema(tuple_ *tuple.Tuple, schema_ *schema.Schema, outSchema *schema.Schema) *tuple.Tuple {
+	values := make([]types.Value, 0)
+	for _, col := range outSchema.GetColumns() {
+		values = append(values, tuple_.GetValue(schema_, schema_.GetColIndex(col.GetColumnName())))
+	}
+	return tuple.NewTupleFromSchema(values, outSchema)
+}
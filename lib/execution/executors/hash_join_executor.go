@@ -13,7 +13,7 @@ import (
 )
 
 /**
-* HashJoinExecutor executes hash join operations (inner join).
+* HashJoinExecutor executes hash join operations (inner join, outer joins, semi join and anti join).
  */
 type HashJoinExecutor struct {
 	context *ExecutorContext
@@ -32,6 +32,18 @@ type HashJoinExecutor struct {
 	output_exprs_    []expression.Expression
 	tmp_page_ids_    []types.PageID
 	right_tuple_     tuple.Tuple
+	// whether right_tuple_ is fetched and not yet padded (RIGHT and FULL OUTER JOIN)
+	has_right_tuple_ bool
+	// whether right_tuple_ matched with any left tuple
+	right_matched_ bool
+	// all left tuples and matched ones (LEFT and FULL OUTER JOIN, ANTI JOIN and LEFT SINGLE JOIN).
+	// matched ones are also recorded on SEMI JOIN
+	left_tmp_tuples_ []materialization.TmpTuple
+	left_matched_    map[materialization.TmpTuple]bool
+	right_done_      bool
+	left_pad_idx_    int
+	// error which occured on Init. it is returned with Next
+	err_ error
 }
 
 /**
@@ -51,6 +63,7 @@ func NewHashJoinExecutor(exec_ctx *ExecutorContext, plan *plans.HashJoinPlanNode
 	// about 200k entry can be stored
 	ret.jht_num_buckets_ = 100
 	ret.jht_ = NewSimpleHashJoinHashTable()
+	ret.left_matched_ = make(map[materialization.TmpTuple]bool)
 	return ret
 }
 
@@ -86,7 +99,11 @@ func (e *HashJoinExecutor) Init() {
 	var tmp_page *materialization.TmpTuplePage = nil
 	var tmp_page_id types.PageID = common.InvalidPageID
 	var tmp_tuple materialization.TmpTuple
-	for left_tuple, done, _ := e.left_.Next(); !done; left_tuple, done, _ = e.left_.Next() {
+	for left_tuple, done, err := e.left_.Next(); !done; left_tuple, done, err = e.left_.Next() {
+		if err != nil {
+			e.err_ = err
+			return
+		}
 		if left_tuple == nil {
 			return
 		}
@@ -107,6 +124,10 @@ func (e *HashJoinExecutor) Init() {
 			// reinsert the tuple
 			tmp_page.Insert(left_tuple, &tmp_tuple)
 		}
+		// left tuple which has NULL key matches nothing. but it is emitted with padding on LEFT OUTER JOIN
+		if e.plan_.GetJoinType().PreservesLeft() {
+			e.left_tmp_tuples_ = append(e.left_tmp_tuples_, tmp_tuple)
+		}
 		valueAsKey := e.left_expr_.Evaluate(left_tuple, e.left_.GetOutputSchema())
 		if !valueAsKey.IsNull() {
 			e.jht_.Insert(hash.HashValue(&valueAsKey), &tmp_tuple)
@@ -118,25 +139,31 @@ func (e *HashJoinExecutor) Init() {
 //
 //	current impl is avoiding the method because it does not exist when this code was wrote
 func (e *HashJoinExecutor) Next() (*tuple.Tuple, Done, error) {
-	inner_next_cnt := 0
-	for {
+	if e.err_ != nil {
+		return nil, false, e.err_
+	}
+	joinType := e.plan_.GetJoinType()
+	for !e.right_done_ {
 		for int(e.index_) == len(e.tmp_tuples_) {
 			// we have traversed all possible join combination of the current right tuple
+			if e.has_right_tuple_ && !e.right_matched_ && joinType.PreservesRight() {
+				e.has_right_tuple_ = false
+				return e.MakeOutputTuple(nil, &e.right_tuple_), false, nil
+			}
 			// move to the next right tuple
 			e.tmp_tuples_ = []materialization.TmpTuple{}
 			e.index_ = 0
-			var done Done = false
-			var tmp_tuple *tuple.Tuple
-			if tmp_tuple, done, _ = e.right_.Next(); done {
-				// hash join finished, delete all the tmp page we created
-				for _, tmp_page_id := range e.tmp_page_ids_ {
-					e.context.GetBufferPoolManager().DeallocatePage(tmp_page_id, true)
-				}
-				// tmp_tuple should be nil
-				return tmp_tuple, true, nil
+			tmp_tuple, done, err := e.right_.Next()
+			if err != nil {
+				return nil, false, err
+			}
+			if done {
+				e.right_done_ = true
+				break
 			}
-			inner_next_cnt++
 			e.right_tuple_ = *tmp_tuple
+			e.has_right_tuple_ = true
+			e.right_matched_ = false
 			value := e.right_expr_.Evaluate(&e.right_tuple_, e.right_.GetOutputSchema())
 			if value.IsNull() {
 				continue
@@ -144,35 +171,79 @@ func (e *HashJoinExecutor) Next() (*tuple.Tuple, Done, error) {
 			e.tmp_tuples_ = e.jht_.GetValue(hash.HashValue(&value))
 		}
 		// traverse corresponding left tuples stored in the tmp pages until we find a tuple which satisfies the predicate with current right tuple
-		left_tmp_tuple := e.tmp_tuples_[e.index_]
-		var left_tuple tuple.Tuple
-		materialization.FetchTupleFromTmpTuplePage(e.context.bpm, &left_tuple, &left_tmp_tuple)
-		for !e.IsValidCombination(&left_tuple, &e.right_tuple_) {
+		for int(e.index_) < len(e.tmp_tuples_) {
+			left_tmp_tuple := e.tmp_tuples_[e.index_]
 			e.index_++
-			if int(e.index_) == len(e.tmp_tuples_) {
-				break
-			}
-			left_tmp_tuple = e.tmp_tuples_[e.index_]
+			var left_tuple tuple.Tuple
 			materialization.FetchTupleFromTmpTuplePage(e.context.bpm, &left_tuple, &left_tmp_tuple)
-		}
-		if int(e.index_) < len(e.tmp_tuples_) {
-			// valid combination found
+			if !e.IsValidCombination(&left_tuple, &e.right_tuple_) {
+				continue
+			}
 			ret_tuple := e.MakeOutputTuple(&left_tuple, &e.right_tuple_)
-			e.index_++
+			if !satisfiesJoinPredicate(e.plan_.GetJoinPredicate(), ret_tuple, e.GetOutputSchema()) {
+				continue
+			}
+			// valid combination found
+			switch joinType {
+			case plans.SEMI_JOIN:
+				// left tuple is emitted only once even if it matches multiple right tuples
+				if e.left_matched_[left_tmp_tuple] {
+					continue
+				}
+				e.left_matched_[left_tmp_tuple] = true
+				return e.MakeOutputTuple(&left_tuple, nil), false, nil
+			case plans.ANTI_JOIN:
+				// left tuples which matched nothing are emitted after all right tuples are traversed
+				e.left_matched_[left_tmp_tuple] = true
+				continue
+			case plans.LEFT_SINGLE_JOIN:
+				if e.left_matched_[left_tmp_tuple] {
+					return nil, false, ScalarSubqueryReturnedMultipleRowsErr
+				}
+			}
+			e.right_matched_ = true
+			if joinType.PreservesLeft() {
+				e.left_matched_[left_tmp_tuple] = true
+			}
 			return ret_tuple, false, nil
 		}
 		// no valid combination, turn to the next right tuple by for loop
 	}
+
+	// emit left tuples which did not match with any right tuple (LEFT and FULL OUTER JOIN, ANTI JOIN and LEFT SINGLE JOIN)
+	for e.left_pad_idx_ < len(e.left_tmp_tuples_) {
+		left_tmp_tuple := e.left_tmp_tuples_[e.left_pad_idx_]
+		e.left_pad_idx_++
+		if e.left_matched_[left_tmp_tuple] {
+			continue
+		}
+		var left_tuple tuple.Tuple
+		materialization.FetchTupleFromTmpTuplePage(e.context.bpm, &left_tuple, &left_tmp_tuple)
+		return e.MakeOutputTuple(&left_tuple, nil), false, nil
+	}
+
+	// hash join finished, delete all the tmp page we created
+	for _, tmp_page_id := range e.tmp_page_ids_ {
+		e.context.GetBufferPoolManager().DeallocatePage(tmp_page_id, true)
+	}
+	e.tmp_page_ids_ = nil
+	return nil, true, nil
 }
 
 func (e *HashJoinExecutor) IsValidCombination(left_tuple *tuple.Tuple, right_tuple *tuple.Tuple) bool {
 	return e.plan_.OnPredicate().EvaluateJoin(left_tuple, e.left_.GetOutputSchema(), right_tuple, e.right_.GetOutputSchema()).ToBoolean()
 }
 
+// left_tuple or right_tuple is nil when the tuple is padded with NULL on outer join
 func (e *HashJoinExecutor) MakeOutputTuple(left_tuple *tuple.Tuple, right_tuple *tuple.Tuple) *tuple.Tuple {
 	output_column_cnt := int(e.GetOutputSchema().GetColumnCount())
 	values := make([]types.Value, output_column_cnt)
 	for i := 0; i < output_column_cnt; i++ {
+		isLeft := e.GetOutputSchema().GetColumn(uint32(i)).IsLeft()
+		if (isLeft && left_tuple == nil) || (!isLeft && right_tuple == nil) {
+			values[i] = nullValueOfColumn(e.GetOutputSchema(), uint32(i))
+			continue
+		}
 		values[i] =
 			e.output_exprs_[i].EvaluateJoin(left_tuple, e.left_.GetOutputSchema(), right_tuple, e.right_.GetOutputSchema())
 	}
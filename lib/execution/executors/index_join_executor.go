@@ -8,6 +8,7 @@ import (
 	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
 	"github.com/ryogrid/SamehadaDB/lib/storage/tuple"
 	"github.com/ryogrid/SamehadaDB/lib/types"
+	"math"
 )
 
 func makePointScanPlanNodeForJoin(c *catalog.Catalog, getKeyVal *types.Value, scanTblSchema *schema.Schema, keyColIdx uint32, scanTblOID uint32) (createdPlan plans.Plan) {
@@ -26,6 +27,8 @@ type IndexJoinExecutor struct {
 	retTuples     []*tuple.Tuple
 	curIdx        int32
 	output_exprs_ []expression.Expression
+	// error which occured on Init. it is returned with Next
+	err error
 }
 
 func NewIndexJoinExecutor(exec_ctx *ExecutorContext, plan *plans.IndexJoinPlanNode, left Executor) *IndexJoinExecutor {
@@ -61,6 +64,10 @@ func (e *IndexJoinExecutor) Init() {
 		} else {
 			colname := column_.GetColumnName()
 			colIndex := rightTblSchema.GetColIndex(colname)
+			if colIndex == math.MaxUint32 {
+				// renamed column (ex: table in subquery). columns of right out schema are same as the table
+				colIndex = e.plan_.GetRightOutSchema().GetColIndex(colname)
+			}
 			colVal = expression.NewColumnValue(1, colIndex, types.Invalid)
 		}
 
@@ -72,7 +79,11 @@ func (e *IndexJoinExecutor) Init() {
 
 	// use value of Value::ToIFValue() as key
 	rightTuplesCache := make(map[interface{}]*[]*tuple.Tuple, 0)
-	for left_tuple, done, _ := e.left_.Next(); !done; left_tuple, done, _ = e.left_.Next() {
+	for left_tuple, done, err := e.left_.Next(); !done; left_tuple, done, err = e.left_.Next() {
+		if err != nil {
+			e.err = err
+			return
+		}
 		if left_tuple == nil {
 			return
 		}
@@ -81,8 +92,10 @@ func (e *IndexJoinExecutor) Init() {
 		// find matching tuples from right table using point scan
 
 		var foundTuples []*tuple.Tuple
-		cachedTuples, ok := rightTuplesCache[leftValueAsKey.ToIFValue()]
-		if ok {
+		if leftValueAsKey.IsNull() {
+			// NULL key matches nothing
+			foundTuples = []*tuple.Tuple{}
+		} else if cachedTuples, ok := rightTuplesCache[leftValueAsKey.ToIFValue()]; ok {
 			// already same key has been lookup
 			foundTuples = *cachedTuples
 		} else {
@@ -94,26 +107,47 @@ func (e *IndexJoinExecutor) Init() {
 			if foundTuplesTmp == nil {
 				return
 			}
-			if len(foundTuplesTmp) == 0 {
-				continue
-			}
 			// cache point scaned tuples
 			rightTuplesCache[leftValueAsKey.ToIFValue()] = &foundTuplesTmp
 			foundTuples = foundTuplesTmp
 		}
 
 		// make joined tuples and store them
+		matched := false
 		for _, right_tuple := range foundTuples {
 			// TODO: SDB [OPT] should be removed after debugging (on IndexJoinExecutor::Init)
 			if !e.IsValidCombination(left_tuple, right_tuple, rightTblSchema) {
 				panic("Invalid combination!")
 			}
-			e.retTuples = append(e.retTuples, e.MakeOutputTuple(left_tuple, right_tuple, rightTblSchema))
+			joinedTuple := e.MakeOutputTuple(left_tuple, right_tuple, rightTblSchema)
+			if !satisfiesJoinPredicate(e.plan_.GetJoinPredicate(), joinedTuple, e.GetOutputSchema()) {
+				continue
+			}
+			if matched && e.plan_.GetJoinType() == plans.LEFT_SINGLE_JOIN {
+				e.context.txn.SetState(access.ABORTED)
+				e.err = ScalarSubqueryReturnedMultipleRowsErr
+				return
+			}
+			matched = true
+			if e.plan_.GetJoinType().IsSemiOrAnti() {
+				// one matching record is enough
+				break
+			}
+			e.retTuples = append(e.retTuples, joinedTuple)
+		}
+		if matched && e.plan_.GetJoinType() == plans.SEMI_JOIN {
+			e.retTuples = append(e.retTuples, e.MakeOutputTuple(left_tuple, nil, rightTblSchema))
+		}
+		if !matched && e.plan_.GetJoinType().PreservesLeft() {
+			e.retTuples = append(e.retTuples, e.MakeOutputTuple(left_tuple, nil, rightTblSchema))
 		}
 	}
 }
 
 func (e *IndexJoinExecutor) Next() (*tuple.Tuple, Done, error) {
+	if e.err != nil {
+		return nil, false, e.err
+	}
 	if e.curIdx >= int32(len(e.retTuples)) {
 		return nil, true, nil
 	}
@@ -126,10 +160,15 @@ func (e *IndexJoinExecutor) IsValidCombination(left_tuple *tuple.Tuple, right_tu
 	return e.plan_.OnPredicate().EvaluateJoin(left_tuple, e.left_.GetOutputSchema(), right_tuple, right_org_schema).ToBoolean()
 }
 
+// right_tuple is nil when the tuple is padded with NULL on LEFT OUTER JOIN
 func (e *IndexJoinExecutor) MakeOutputTuple(left_tuple *tuple.Tuple, right_tuple *tuple.Tuple, right_org_schema *schema.Schema) *tuple.Tuple {
 	output_column_cnt := int(e.GetOutputSchema().GetColumnCount())
 	values := make([]types.Value, output_column_cnt)
 	for i := 0; i < output_column_cnt; i++ {
+		if right_tuple == nil && !e.GetOutputSchema().GetColumn(uint32(i)).IsLeft() {
+			values[i] = nullValueOfColumn(e.GetOutputSchema(), uint32(i))
+			continue
+		}
 		values[i] =
 			e.output_exprs_[i].EvaluateJoin(left_tuple, e.left_.GetOutputSchema(), right_tuple, right_org_schema)
 	}
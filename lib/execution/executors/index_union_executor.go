@@ -0,0 +1,70 @@
+package executors
+
+import (
+	"errors"
+	"github.com/ryogrid/SamehadaDB/lib/catalog"
+	"github.com/ryogrid/SamehadaDB/lib/execution/plans"
+	"github.com/ryogrid/SamehadaDB/lib/storage/access"
+	"github.com/ryogrid/SamehadaDB/lib/storage/page"
+	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
+	"github.com/ryogrid/SamehadaDB/lib/storage/tuple"
+)
+
+/**
+ * IndexUnionExecutor emits records of children (index scans on same table) in order.
+ * records which have already been emitted are skipped with checking RID
+ */
+type IndexUnionExecutor struct {
+	context       *ExecutorContext
+	plan          *plans.IndexUnionPlanNode
+	children      []Executor
+	tableMetadata *catalog.TableMetadata
+	curChildIdx   int
+	emittedRIDs   map[page.RID]bool
+}
+
+func NewIndexUnionExecutor(context *ExecutorContext, plan *plans.IndexUnionPlanNode, children []Executor) Executor {
+	tableMetadata := context.GetCatalog().GetTableByOID(plan.GetTableOID())
+	return &IndexUnionExecutor{context, plan, children, tableMetadata, 0, nil}
+}
+
+func (e *IndexUnionExecutor) Init() {
+	for _, child := range e.children {
+		child.Init()
+	}
+	e.curChildIdx = 0
+	e.emittedRIDs = make(map[page.RID]bool)
+}
+
+func (e *IndexUnionExecutor) Next() (*tuple.Tuple, Done, error) {
+	for e.curChildIdx < len(e.children) {
+		t, done, err := e.children[e.curChildIdx].Next()
+		if err != nil {
+			return nil, true, err
+		}
+		if done {
+			e.curChildIdx++
+			continue
+		}
+		if t == nil || t.GetRID() == nil {
+			err := errors.New("child of IndexUnionExecutor returned invalid tuple unexpectedly.")
+			e.context.GetTransaction().SetState(access.ABORTED)
+			return nil, true, err
+		}
+		if e.emittedRIDs[*t.GetRID()] {
+			continue
+		}
+		e.emittedRIDs[*t.GetRID()] = true
+		return t, false, nil
+	}
+
+	return nil, true, nil
+}
+
+func (e *IndexUnionExecutor) GetOutputSchema() *schema.Schema {
+	return e.plan.OutputSchema()
+}
+
+func (e *IndexUnionExecutor) GetTableMetaData() *catalog.TableMetadata {
+	return e.tableMetadata
+}
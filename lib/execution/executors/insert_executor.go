@@ -6,8 +6,10 @@ package executors
 import (
 	"github.com/ryogrid/SamehadaDB/lib/catalog"
 	"github.com/ryogrid/SamehadaDB/lib/execution/plans"
+	"github.com/ryogrid/SamehadaDB/lib/storage/access"
 	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
 	"github.com/ryogrid/SamehadaDB/lib/storage/tuple"
+	"github.com/ryogrid/SamehadaDB/lib/types"
 )
 
 /**
@@ -17,46 +19,116 @@ import (
 type InsertExecutor struct {
 	context       *ExecutorContext
 	plan          *plans.InsertPlanNode
+	child         Executor // nil when raw insert
 	tableMetadata *catalog.TableMetadata
+	rows          [][]types.Value
+	cursor        int
+	// error which occurred at reading output of the child
+	err error
 }
 
-func NewInsertExecutor(context *ExecutorContext, plan *plans.InsertPlanNode) Executor {
+func NewInsertExecutor(context *ExecutorContext, plan *plans.InsertPlanNode, child Executor) Executor {
 	tableMetadata := context.GetCatalog().GetTableByOID(plan.GetTableOID())
 
-	return &InsertExecutor{context, plan, tableMetadata}
+	return &InsertExecutor{context, plan, child, tableMetadata, nil, 0, nil}
 }
 
 func (e *InsertExecutor) Init() {
+	e.rows = e.plan.GetRawValues()
+	e.cursor = 0
+	e.err = nil
+	if e.plan.IsRawInsert() {
+		return
+	}
 
+	// all output of the child is read before insertion.
+	// otherwise, inserted tuples are read by the child when it scans the target table
+	e.child.Init()
+	e.rows = make([][]types.Value, 0)
+	for {
+		t, done, err := e.child.Next()
+		if err != nil {
+			e.err = err
+			return
+		}
+		if done {
+			break
+		}
+		row, err := e.rowOfChildOutput(t)
+		if err != nil {
+			e.err = err
+			return
+		}
+		e.rows = append(e.rows, row)
+	}
 }
 
-// Next inserts the tuples into the tables
-// Note that Insert does not return any tuple
-// We return an error if the insert failed for any reason, and return nil if all inserts succeeded.
-func (e *InsertExecutor) Next() (*tuple.Tuple, Done, error) {
-	// let's assume it is raw insert
-
-	for _, values := range e.plan.GetRawValues() {
-		tuple_ := tuple.NewTupleFromSchema(values, e.tableMetadata.Schema())
-		tableHeap := e.tableMetadata.Table()
-		rid, err := tableHeap.InsertTuple(tuple_, e.context.txn, e.tableMetadata.OID(), false)
+// values of output of the child are placed in order of columns of the table and omitted columns are filled with NULL
+func (e *InsertExecutor) rowOfChildOutput(t *tuple.Tuple) ([]types.Value, error) {
+	schema_ := e.tableMetadata.Schema()
+	childSchema := e.child.GetOutputSchema()
+	row := make([]types.Value, schema_.GetColumnCount())
+	for ii := range row {
+		row[ii] = nullValueOfColumn(schema_, uint32(ii))
+	}
+	for ii, colIdx := range e.plan.GetTargetColIdxs() {
+		val := t.GetValue(childSchema, uint32(ii))
+		if val.IsNull() {
+			continue
+		}
+		col := schema_.GetColumn(uint32(colIdx))
+		convedVal, err := catalog.CoerceValueForColumn(col, &val, col.GetColumnName())
 		if err != nil {
-			return nil, true, err
+			e.context.txn.SetState(access.ABORTED)
+			return nil, err
 		}
+		row[colIdx] = *convedVal
+	}
+	return row, nil
+}
+
+// Next inserts a row into the table and returns the inserted tuple.
+// when RETURNING clause is specified, the tuple is projected to the output schema
+// We return an error if the insert failed for any reason
+func (e *InsertExecutor) Next() (*tuple.Tuple, Done, error) {
+	if e.err != nil {
+		return nil, true, e.err
+	}
+	if e.cursor >= len(e.rows) {
+		return nil, true, nil
+	}
+	values := e.rows[e.cursor]
+	e.cursor++
 
-		colNum := e.tableMetadata.GetColumnNum()
-		for ii := 0; ii < int(colNum); ii++ {
-			ret := e.tableMetadata.GetIndex(ii)
-			if ret == nil {
-				continue
-			} else {
-				index_ := ret
-				index_.InsertEntry(tuple_, *rid, e.context.txn)
-			}
+	tuple_ := tuple.NewTupleFromSchema(values, e.tableMetadata.Schema())
+	if err := e.tableMetadata.ValidateTuple(tuple_, nil, e.context.txn); err != nil {
+		e.context.txn.SetState(access.ABORTED)
+		return nil, true, err
+	}
+	tableHeap := e.tableMetadata.Table()
+	rid, err := tableHeap.InsertTuple(tuple_, e.context.txn, e.tableMetadata.OID(), false)
+	if err != nil {
+		return nil, true, err
+	}
+
+	colNum := e.tableMetadata.GetColumnNum()
+	for ii := 0; ii < int(colNum); ii++ {
+		ret := e.tableMetadata.GetIndex(ii)
+		if ret == nil {
+			continue
+		} else {
+			index_ := ret
+			index_.InsertEntry(tuple_, *rid, e.context.txn)
 		}
 	}
+	for _, index_ := range e.tableMetadata.CompositeIndexes() {
+		index_.InsertEntry(tuple_, *rid, e.context.txn)
+	}
 
-	return nil, true, nil
+	if e.plan.OutputSchema() != nil {
+		return projectToSchema(tuple_, e.tableMetadata.Schema(), e.plan.OutputSchema()), false, nil
+	}
+	return tuple_, false, nil
 }
 
 func (e *InsertExecutor) GetOutputSchema() *schema.Schema {
@@ -16,6 +16,8 @@ type NestedLoopJoinExecutor struct {
 	right     Executor
 	retTuples []*tuple.Tuple
 	curIdx    int32
+	// error which occured on Init. it is returned with Next
+	err error
 }
 
 func NewNestedLoopJoinExecutor(exec_ctx *ExecutorContext, plan *plans.NestedLoopJoinPlanNode, left Executor,
@@ -39,24 +41,61 @@ func (e *NestedLoopJoinExecutor) Init() {
 	for rightTuple, doneRight, errRight := e.right.Next(); !doneRight; rightTuple, doneRight, errRight = e.right.Next() {
 		if errRight != nil {
 			e.context.txn.SetState(access.ABORTED)
+			e.err = errRight
 			return
 		}
 		rightTuples = append(rightTuples, rightTuple)
 	}
 
+	joinType := e.plan.GetJoinType()
+	rightMatched := make([]bool, len(rightTuples))
 	for leftTuple, doneLeft, errLeft := e.left.Next(); !doneLeft; leftTuple, doneLeft, errLeft = e.left.Next() {
 		if errLeft != nil {
 			e.context.txn.SetState(access.ABORTED)
+			e.err = errLeft
 			return
 		}
-		for _, rightTuple := range rightTuples {
-			e.retTuples = append(e.retTuples, e.MakeOutputTuple(leftTuple, rightTuple))
+		leftMatched := false
+		for ii, rightTuple := range rightTuples {
+			joinedTuple := e.MakeOutputTuple(leftTuple, rightTuple)
+			if !satisfiesJoinPredicate(e.plan.GetJoinPredicate(), joinedTuple, e.GetOutputSchema()) {
+				continue
+			}
+			if leftMatched && joinType == plans.LEFT_SINGLE_JOIN {
+				e.context.txn.SetState(access.ABORTED)
+				e.err = ScalarSubqueryReturnedMultipleRowsErr
+				return
+			}
+			leftMatched = true
+			rightMatched[ii] = true
+			if joinType.IsSemiOrAnti() {
+				// one matching record is enough
+				break
+			}
+			e.retTuples = append(e.retTuples, joinedTuple)
+		}
+		if leftMatched && joinType == plans.SEMI_JOIN {
+			e.retTuples = append(e.retTuples, e.MakeOutputTuple(leftTuple, nil))
+		}
+		if !leftMatched && joinType.PreservesLeft() {
+			e.retTuples = append(e.retTuples, e.MakeOutputTuple(leftTuple, nil))
+		}
+	}
+
+	if joinType.PreservesRight() {
+		for ii, rightTuple := range rightTuples {
+			if !rightMatched[ii] {
+				e.retTuples = append(e.retTuples, e.MakeOutputTuple(nil, rightTuple))
+			}
 		}
 	}
 }
 
 // TODO: (SDB) need to refactor NestedLoopJoinExecutor::Next method to use GetExpr method of Column class
 func (e *NestedLoopJoinExecutor) Next() (*tuple.Tuple, Done, error) {
+	if e.err != nil {
+		return nil, false, e.err
+	}
 	if e.curIdx >= int32(len(e.retTuples)) {
 		return nil, true, nil
 	}
@@ -65,15 +104,24 @@ func (e *NestedLoopJoinExecutor) Next() (*tuple.Tuple, Done, error) {
 	return ret, false, nil
 }
 
+// left_tuple or right_tuple is nil when the tuple is padded with NULL on outer join
 func (e *NestedLoopJoinExecutor) MakeOutputTuple(left_tuple *tuple.Tuple, right_tuple *tuple.Tuple) *tuple.Tuple {
 	outputColumnCnt := int(e.GetOutputSchema().GetColumnCount())
 	leftColumnCnt := int(e.left.GetOutputSchema().GetColumnCount())
 	values := make([]types.Value, outputColumnCnt)
 	for ii := 0; ii < outputColumnCnt; ii++ {
 		if ii < leftColumnCnt {
-			values[ii] = left_tuple.GetValue(e.left.GetOutputSchema(), uint32(ii))
+			if left_tuple == nil {
+				values[ii] = nullValueOfColumn(e.GetOutputSchema(), uint32(ii))
+			} else {
+				values[ii] = left_tuple.GetValue(e.left.GetOutputSchema(), uint32(ii))
+			}
 		} else {
-			values[ii] = right_tuple.GetValue(e.right.GetOutputSchema(), uint32(ii-leftColumnCnt))
+			if right_tuple == nil {
+				values[ii] = nullValueOfColumn(e.GetOutputSchema(), uint32(ii))
+			} else {
+				values[ii] = right_tuple.GetValue(e.right.GetOutputSchema(), uint32(ii-leftColumnCnt))
+			}
 		}
 	}
 	return tuple.NewTupleFromSchema(values, e.GetOutputSchema())
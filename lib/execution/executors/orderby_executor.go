@@ -21,6 +21,8 @@ type OrderbyExecutor struct {
 	child_       []Executor
 	sort_tuples_ []*tuple.Tuple
 	cur_idx_     int // target tuple index on Next method
+	// error which child returned on Init. it is returned with Next
+	err_ error
 }
 
 /**
@@ -31,7 +33,7 @@ type OrderbyExecutor struct {
  */
 func NewOrderbyExecutor(exec_ctx *ExecutorContext, plan *plans.OrderbyPlanNode,
 	child Executor) *OrderbyExecutor {
-	return &OrderbyExecutor{exec_ctx, plan, []Executor{child}, make([]*tuple.Tuple, 0), 0}
+	return &OrderbyExecutor{exec_ctx, plan, []Executor{child}, make([]*tuple.Tuple, 0), 0, nil}
 }
 
 func (e *OrderbyExecutor) GetOutputSchema() *schema.Schema { return e.plan_.OutputSchema() }
@@ -49,6 +51,7 @@ func (e *OrderbyExecutor) Init() {
 		if err != nil || done {
 			if err != nil {
 				fmt.Println(err)
+				e.err_ = err
 			}
 			break
 		}
@@ -64,15 +67,22 @@ func (e *OrderbyExecutor) Init() {
 			tmp_val := types.NewInteger(inserted_tuple_cnt)
 			tmp_row = append(tmp_row, &tmp_val)
 			sort_values = append(sort_values, tmp_row)
+			inserted_tuple_cnt++
 		}
-		inserted_tuple_cnt++
 	}
 	// decide tuple order by sort of values on tuples
-	sort.Slice(sort_values, func(i, j int) bool {
+	sort.SliceStable(sort_values, func(i, j int) bool {
 
 		cols_num := len(e.plan_.GetColIdxs())
 		for idx := 0; idx < cols_num; idx++ {
 			order_type := e.plan_.GetOrderbyTypes()[idx]
+			if sort_values[i][idx].IsNull() || sort_values[j][idx].IsNull() {
+				if sort_values[i][idx].IsNull() && sort_values[j][idx].IsNull() {
+					continue
+				}
+				// position of NULL doesn't depend on ASC or DESC
+				return sort_values[i][idx].IsNull() == e.isNullsFirst(idx)
+			}
 			if order_type == plans.ASC {
 				if sort_values[i][idx].CompareEquals(*sort_values[j][idx]) {
 					continue
@@ -105,7 +115,18 @@ func (e *OrderbyExecutor) Init() {
 	e.sort_tuples_ = tmp_tuples
 }
 
+func (e *OrderbyExecutor) isNullsFirst(idx int) bool {
+	if e.plan_.GetNullsFirsts() == nil {
+		// NULL is regarded as larger than any value
+		return e.plan_.GetOrderbyTypes()[idx] == plans.DESC
+	}
+	return e.plan_.GetNullsFirsts()[idx]
+}
+
 func (e *OrderbyExecutor) Next() (*tuple.Tuple, Done, error) {
+	if e.err_ != nil {
+		return nil, false, e.err_
+	}
 	if e.cur_idx_ < len(e.sort_tuples_) {
 		ret := e.sort_tuples_[e.cur_idx_]
 		e.cur_idx_++
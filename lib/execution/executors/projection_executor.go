@@ -51,8 +51,16 @@ func (e *ProjectionExecutor) projects(tuple_ *tuple.Tuple) *tuple.Tuple {
 	projectSchema := e.plan.OutputSchema()
 
 	values := []types.Value{}
+	exprs := e.plan.GetExprs()
 	for i := uint32(0); i < projectSchema.GetColumnCount(); i++ {
-		colIndex := srcOutSchema.GetColIndex(projectSchema.GetColumns()[i].GetColumnName())
+		if exprs != nil && exprs[i] != nil {
+			values = append(values, exprs[i].Evaluate(tuple_, srcOutSchema))
+			continue
+		}
+		colIndex := i
+		if !e.plan.IsRenaming() {
+			colIndex = srcOutSchema.GetColIndex(projectSchema.GetColumns()[i].GetColumnName())
+		}
 		values = append(values, tuple_.GetValue(srcOutSchema, colIndex))
 	}
 
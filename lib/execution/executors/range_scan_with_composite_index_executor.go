@@ -0,0 +1,87 @@
+package executors
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ryogrid/SamehadaDB/lib/catalog"
+	"github.com/ryogrid/SamehadaDB/lib/execution/plans"
+	"github.com/ryogrid/SamehadaDB/lib/samehada/samehada_util"
+	"github.com/ryogrid/SamehadaDB/lib/storage/access"
+	"github.com/ryogrid/SamehadaDB/lib/storage/index"
+	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
+	"github.com/ryogrid/SamehadaDB/lib/storage/tuple"
+	"github.com/ryogrid/SamehadaDB/lib/types"
+)
+
+/**
+ * RangeScanWithCompositeIndexExecutor executes scan with composite index.
+ * predicates which are not covered by the key range are checked by parent SelectionPlanNode
+ */
+type RangeScanWithCompositeIndexExecutor struct {
+	context       *ExecutorContext
+	plan          *plans.RangeScanWithCompositeIndexPlanNode
+	tableMetadata *catalog.TableMetadata
+	txn           *access.Transaction
+	index_        *index.CompositeSkipListIndex
+	ridItr        index.IndexRangeScanIterator
+}
+
+func NewRangeScanWithCompositeIndexExecutor(context *ExecutorContext, plan *plans.RangeScanWithCompositeIndexPlanNode) Executor {
+	tableMetadata := context.GetCatalog().GetTableByOID(plan.GetTableOID())
+
+	return &RangeScanWithCompositeIndexExecutor{context, plan, tableMetadata, context.GetTransaction(), nil, nil}
+}
+
+// infinite value which is set on Range of optimizer means that the range is not bounded
+func boundOrNil(val *types.Value) *types.Value {
+	if val == nil || val.IsInfMin() || val.IsInfMax() {
+		return nil
+	}
+	return val
+}
+
+func (e *RangeScanWithCompositeIndexExecutor) Init() {
+	e.index_ = e.tableMetadata.GetCompositeIndex(e.plan.GetIndexName())
+	e.ridItr = e.index_.GetPrefixRangeScanIterator(e.plan.GetPrefixVals(), boundOrNil(e.plan.GetStartRange()), boundOrNil(e.plan.GetEndRange()), e.txn)
+}
+
+func (e *RangeScanWithCompositeIndexExecutor) Next() (*tuple.Tuple, Done, error) {
+	for done, _, key, rid := e.ridItr.Next(); !done; done, _, key, rid = e.ridItr.Next() {
+		tuple_, err := e.tableMetadata.Table().GetTuple(rid, e.txn)
+		if tuple_ == nil && (err == nil || err == access.ErrGeneral) {
+			err := errors.New("e.ridItr.Next returned nil")
+			e.txn.SetState(access.ABORTED)
+			return nil, true, err
+		}
+
+		if err == access.ErrSelfDeletedCase {
+			fmt.Println("RangeScanWithCompositeIndexExecutor:Next ErrSelfDeletedCase!")
+			continue
+		}
+
+		if e.txn.GetState() == access.ABORTED {
+			return nil, true, access.ErrGeneral
+		}
+
+		// check value update after getting iterator which contains snapshot of RIDs and Keys which were stored in Index
+		curKey := samehada_util.EncodeValuesAndRIDToDicOrderComparableVarchar(e.index_.GetKeyValues(tuple_), rid)
+		if !curKey.CompareEquals(*key) {
+			// column value corresponding index key is updated
+			e.txn.SetState(access.ABORTED)
+			return nil, true, errors.New("detect value update after iterator created. changes transaction state to aborted.")
+		}
+
+		tuple_.SetRID(rid)
+		return tuple_, false, nil
+	}
+
+	return nil, true, nil
+}
+
+func (e *RangeScanWithCompositeIndexExecutor) GetOutputSchema() *schema.Schema {
+	return e.plan.OutputSchema()
+}
+
+func (e *RangeScanWithCompositeIndexExecutor) GetTableMetaData() *catalog.TableMetadata {
+	return e.tableMetadata
+}
@@ -104,20 +104,16 @@ func (e *RangeScanWithIndexExecutor) Next() (*tuple.Tuple, Done, error) {
 		// check predicate
 		if e.selects(tuple_, e.plan.GetPredicate()) {
 			tuple_.SetRID(rid)
-			break
+			// tuple_ is projected to OutputSchema
+			ret := e.projects(tuple_)
+			ret.SetRID(tuple_.GetRID())
+			return ret, false, nil
 		}
 	}
 
 	// roop above passed because done is true
-	if tuple_ == nil {
-		return nil, true, nil
-	}
-
-	// tuple_ is projected to OutputSchema
-	ret := e.projects(tuple_)
-	ret.SetRID(tuple_.GetRID())
-
-	return ret, false, nil
+	// (last tuple_ may be skipped one. so it must not be returned)
+	return nil, true, nil
 }
 
 // select evaluates an expression on the tuple
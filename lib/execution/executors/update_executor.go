@@ -12,6 +12,7 @@ import (
 	"github.com/ryogrid/SamehadaDB/lib/storage/access"
 	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
 	"github.com/ryogrid/SamehadaDB/lib/storage/tuple"
+	"github.com/ryogrid/SamehadaDB/lib/types"
 )
 
 /**
@@ -55,6 +56,11 @@ func (e *UpdateExecutor) Next() (*tuple.Tuple, Done, error) {
 		rid := t.GetRID()
 		values := e.plan.GetRawValues()
 		new_tuple := tuple.NewTupleFromSchema(values, e.child.GetTableMetaData().Schema())
+		updatedTuple := e.getUpdatedTuple(t)
+		if err_ := e.child.GetTableMetaData().ValidateTuple(updatedTuple, rid, e.txn); err_ != nil {
+			e.txn.SetState(access.ABORTED)
+			return nil, true, err_
+		}
 
 		var is_updated = false
 		var new_rid *page.RID = nil
@@ -100,13 +106,45 @@ func (e *UpdateExecutor) Next() (*tuple.Tuple, Done, error) {
 				}
 			}
 		}
+		for _, index_ := range e.child.GetTableMetaData().CompositeIndexes() {
+			isKeyUpdated := updateIdxs == nil
+			for _, keyColIdx := range index_.GetKeyAttrs() {
+				if samehada_util.IsContainList[int](updateIdxs, int(keyColIdx)) {
+					isKeyUpdated = true
+				}
+			}
+			if new_rid != nil {
+				index_.UpdateEntry(t, *rid, updateTuple, *new_rid, e.txn)
+			} else if isKeyUpdated {
+				index_.UpdateEntry(t, *rid, updateTuple, *rid, e.txn)
+			}
+		}
 
+		if e.plan.OutputSchema() != nil {
+			// RETURNING clause
+			return projectToSchema(updatedTuple, e.child.GetTableMetaData().Schema(), e.plan.OutputSchema()), false, updateErr
+		}
 		return new_tuple, false, updateErr
 	}
 
 	return nil, true, nil
 }
 
+// returns tuple which has values after update. values of columns which are not updated are got from t
+func (e *UpdateExecutor) getUpdatedTuple(t *tuple.Tuple) *tuple.Tuple {
+	schema_ := e.child.GetTableMetaData().Schema()
+	updateIdxs := e.plan.GetUpdateColIdxs()
+	values := make([]types.Value, 0)
+	for ii, value := range e.plan.GetRawValues() {
+		if updateIdxs == nil || samehada_util.IsContainList[int](updateIdxs, ii) {
+			values = append(values, value)
+		} else {
+			values = append(values, t.GetValue(schema_, uint32(ii)))
+		}
+	}
+	return tuple.NewTupleFromSchema(values, schema_)
+}
+
 func (e *UpdateExecutor) GetOutputSchema() *schema.Schema {
 	return e.plan.OutputSchema()
 }
@@ -8,3 +8,7 @@ type AbstractExpression struct {
 	/** The return type of this expression. */
 	ret_type types.TypeID
 }
+
+func (a *AbstractExpression) GetReturnType() types.TypeID {
+	return a.ret_type
+}
@@ -0,0 +1,118 @@
+package expression
+
+import (
+	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
+	"github.com/ryogrid/SamehadaDB/lib/storage/tuple"
+	"github.com/ryogrid/SamehadaDB/lib/types"
+)
+
+type ArithmeticOpType int
+
+/** ArithmeticOpType represents the type of calculation that we want to perform. */
+const (
+	ADD ArithmeticOpType = iota // A + B
+	SUB                         // A - B
+	MUL                         // A * B
+	DIV                         // A / B
+	MOD                         // A % B
+)
+
+/**
+ * ArithmeticOp represents two expressions being calculated.
+ * result is the higher of numeric types of them on Integer < BigInt < Decimal < Float < Double,
+ * and Integer when both are not numeric (division of Integer and BigInt is truncated).
+ * result is NULL when either of them is NULL or divisor is zero
+ */
+type ArithmeticOp struct {
+	*AbstractExpression
+	arithmeticOpType ArithmeticOpType
+}
+
+func NewArithmeticOp(left Expression, right Expression, arithmeticOpType ArithmeticOpType) Expression {
+	retType := types.Integer
+	for _, operandType := range []types.TypeID{left.GetReturnType(), right.GetReturnType()} {
+		if operandType.IsNumeric() {
+			retType = types.CommonTypeOf(retType, operandType)
+		}
+	}
+	return &ArithmeticOp{&AbstractExpression{[2]Expression{left, right}, retType}, arithmeticOpType}
+}
+
+func (c *ArithmeticOp) Evaluate(tuple_ *tuple.Tuple, schema_ *schema.Schema) types.Value {
+	lhs := c.children[0].Evaluate(tuple_, schema_)
+	rhs := c.children[1].Evaluate(tuple_, schema_)
+	return c.performArithmeticOp(lhs, rhs)
+}
+
+func (c *ArithmeticOp) performArithmeticOp(lhs types.Value, rhs types.Value) types.Value {
+	// operands are converted to type of result (ex: '1' + 2 => 1 + 2)
+	left, okL := lhs.CastAs(c.ret_type)
+	right, okR := rhs.CastAs(c.ret_type)
+	if !okL || !okR || left.IsNull() || right.IsNull() {
+		return types.NewNullOfType(c.ret_type)
+	}
+	switch c.arithmeticOpType {
+	case ADD:
+		return *left.Add(right)
+	case SUB:
+		return *left.Sub(right)
+	case MUL:
+		return *left.Mul(right)
+	case DIV:
+		if isZero(right) {
+			return types.NewNullOfType(c.ret_type)
+		}
+		return *left.Div(right)
+	case MOD:
+		if isZero(right) {
+			return types.NewNullOfType(c.ret_type)
+		}
+		return *left.Mod(right)
+	default:
+		panic("illegal arithmeticOpType is passed!")
+	}
+}
+
+func isZero(val *types.Value) bool {
+	switch val.ValueType() {
+	case types.Integer:
+		return val.ToInteger() == 0
+	case types.Float:
+		return val.ToFloat() == 0
+	case types.BigInt:
+		return val.ToBigInt() == 0
+	case types.Double:
+		return val.ToDouble() == 0
+	case types.Decimal:
+		return val.ToDecimal().IsZero()
+	default:
+		return false
+	}
+}
+
+func (c *ArithmeticOp) GetArithmeticOpType() ArithmeticOpType {
+	return c.arithmeticOpType
+}
+
+func (c *ArithmeticOp) EvaluateJoin(left_tuple *tuple.Tuple, left_schema *schema.Schema, right_tuple *tuple.Tuple, right_schema *schema.Schema) types.Value {
+	lhs := c.GetChildAt(0).EvaluateJoin(left_tuple, left_schema, right_tuple, right_schema)
+	rhs := c.GetChildAt(1).EvaluateJoin(left_tuple, left_schema, right_tuple, right_schema)
+	return c.performArithmeticOp(lhs, rhs)
+}
+
+func (c *ArithmeticOp) EvaluateAggregate(group_bys []*types.Value, aggregates []*types.Value) types.Value {
+	lhs := c.GetChildAt(0).EvaluateAggregate(group_bys, aggregates)
+	rhs := c.GetChildAt(1).EvaluateAggregate(group_bys, aggregates)
+	return c.performArithmeticOp(lhs, rhs)
+}
+
+func (c *ArithmeticOp) GetChildAt(child_idx uint32) Expression {
+	if int(child_idx) >= len(c.children) {
+		return nil
+	}
+	return c.children[child_idx]
+}
+
+func (c *ArithmeticOp) GetType() ExpressionType {
+	return EXPRESSION_TYPE_ARITHMETIC_OP
+}
@@ -19,6 +19,8 @@ const (
 	GreaterThanOrEqual // A >= B
 	LessThan           // A < B
 	LessThanOrEqual    // A <= B
+	Like               // A LIKE B
+	NotLike            // A NOT LIKE B
 )
 
 /**
@@ -44,6 +46,18 @@ func (c *Comparison) Evaluate(tuple_ *tuple.Tuple, schema_ *schema.Schema) types
 }
 
 func (c *Comparison) performComparison(lhs types.Value, rhs types.Value) bool {
+	if c.comparisonType == Like || c.comparisonType == NotLike {
+		// NULL matches no pattern
+		if lhs.IsNull() || rhs.IsNull() {
+			return false
+		}
+		return matchLikePattern(lhs.ToString(), rhs.ToString()) == (c.comparisonType == Like)
+	}
+	lhs, rhs, ok := promoteTypes(lhs, rhs)
+	if !ok {
+		// ex: Timestamp column is compared with string which is not timestamp
+		return false
+	}
 	switch c.comparisonType {
 	case Equal:
 		return lhs.CompareEquals(rhs)
@@ -63,6 +77,63 @@ func (c *Comparison) performComparison(lhs types.Value, rhs types.Value) bool {
 
 }
 
+// when types of lhs and rhs are different, both are converted to common type of them
+// (ex: Integer and Float => Float, Timestamp and Varchar => Timestamp).
+// false is returned when the conversion failed
+func promoteTypes(lhs types.Value, rhs types.Value) (types.Value, types.Value, bool) {
+	if lhs.IsNull() || rhs.IsNull() || lhs.ValueType() == rhs.ValueType() {
+		return lhs, rhs, true
+	}
+	if !types.IsComparable(lhs.ValueType(), rhs.ValueType()) {
+		return lhs, rhs, true
+	}
+	commonType := types.CommonTypeOf(lhs.ValueType(), rhs.ValueType())
+	convedL, okL := lhs.CastAs(commonType)
+	convedR, okR := rhs.CastAs(commonType)
+	if !okL || !okR {
+		return lhs, rhs, false
+	}
+	return *convedL, *convedR, true
+}
+
+// matches str with pattern of LIKE. "%" matches any sequence of characters, "_" matches any
+// one character and "\" escapes next character
+func matchLikePattern(str string, pattern string) bool {
+	s := []rune(str)
+	p := []rune(pattern)
+	// position of pattern and str just after last "%" for backtracking
+	starP, starS := -1, -1
+	si, pi := 0, 0
+	for si < len(s) {
+		if pi < len(p) {
+			switch {
+			case p[pi] == '%':
+				starP, starS = pi, si
+				pi++
+				continue
+			case p[pi] == '\\' && pi+1 < len(p):
+				if p[pi+1] == s[si] {
+					si, pi = si+1, pi+2
+					continue
+				}
+			case p[pi] == '_' || p[pi] == s[si]:
+				si, pi = si+1, pi+1
+				continue
+			}
+		}
+		if starP == -1 {
+			return false
+		}
+		// "%" consumes one more character
+		starS++
+		si, pi = starS, starP+1
+	}
+	for pi < len(p) && p[pi] == '%' {
+		pi++
+	}
+	return pi == len(p)
+}
+
 func (c *Comparison) GetLeftSideColIdx() uint32 {
 	return c.children[0].(*ColumnValue).colIndex
 }
@@ -18,6 +18,8 @@ const (
 	EXPRESSION_TYPE_COLUMN_VALUE
 	EXPRESSION_TYPE_CONSTANT_VALUE
 	EXPRESSION_TYPE_LOGICAL_OP
+	EXPRESSION_TYPE_ARITHMETIC_OP
+	EXPRESSION_TYPE_FUNCTION
 )
 
 /**
@@ -30,4 +32,5 @@ type Expression interface {
 	EvaluateJoin(*tuple.Tuple, *schema.Schema, *tuple.Tuple, *schema.Schema) types.Value
 	EvaluateAggregate([]*types.Value, []*types.Value) types.Value
 	GetType() ExpressionType
+	GetReturnType() types.TypeID
 }
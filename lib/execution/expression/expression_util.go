@@ -37,6 +37,10 @@ func GetExpTreeStr(node interface{}) string {
 			retStr += "< "
 		case LessThanOrEqual: // A <= B
 			retStr += "<= "
+		case Like:
+			retStr += "LIKE "
+		case NotLike:
+			retStr += "NOT LIKE "
 		default:
 			panic("illegal comparisonType!")
 		}
@@ -54,6 +58,48 @@ func GetExpTreeStr(node interface{}) string {
 			panic("illegal logicalOpType!")
 		}
 		return retStr
+	case *ArithmeticOp:
+		retStr += childTraverse(typedNode)
+		switch typedNode.arithmeticOpType {
+		case ADD:
+			retStr += "+ "
+		case SUB:
+			retStr += "- "
+		case MUL:
+			retStr += "* "
+		case DIV:
+			retStr += "/ "
+		case MOD:
+			retStr += "% "
+		default:
+			panic("illegal arithmeticOpType!")
+		}
+		return retStr
+	case *Function:
+		retStr += childTraverse(typedNode)
+		switch typedNode.funcType {
+		case LOWER:
+			retStr += "LOWER"
+		case UPPER:
+			retStr += "UPPER"
+		case LENGTH:
+			retStr += "LENGTH"
+		case SUBSTR:
+			retStr += "SUBSTR"
+		case ABS:
+			retStr += "ABS"
+		case ROUND:
+			retStr += "ROUND"
+		case COALESCE:
+			retStr += "COALESCE"
+		case CASE:
+			retStr += "CASE"
+		case CAST:
+			retStr += "CAST"
+		default:
+			panic("illegal funcType!")
+		}
+		return retStr + "/" + strconv.Itoa(len(typedNode.args)) + " "
 	case *AggregateValueExpression:
 		panic("AggregateValueExpression is not implemented yet!")
 	case *ConstantValue:
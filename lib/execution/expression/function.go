@@ -0,0 +1,294 @@
+package expression
+
+import (
+	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
+	"github.com/ryogrid/SamehadaDB/lib/storage/tuple"
+	"github.com/ryogrid/SamehadaDB/lib/types"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+type FuncType int
+
+/** FuncType represents the scalar function which we want to call. */
+const (
+	LOWER    FuncType = iota // LOWER(str)
+	UPPER                    // UPPER(str)
+	LENGTH                   // LENGTH(str): number of characters
+	SUBSTR                   // SUBSTR(str, pos[, len]): pos is 1-based and negative pos counts from the end
+	ABS                      // ABS(num)
+	ROUND                    // ROUND(num[, digits])
+	COALESCE                 // COALESCE(val1, val2, ...)
+	CASE                     // CASE WHEN cond1 THEN val1 ... [ELSE valN] END: args are cond1, val1, ..., valN
+	CAST                     // CAST(val AS type): return type is the target type
+)
+
+/**
+ * Function represents a scalar function call which has variable number of arguments.
+ * result is NULL when an argument is NULL (except for COALESCE and CASE) or it can't be converted
+ * to the return type (ex: CAST('abc' AS SIGNED))
+ */
+type Function struct {
+	*AbstractExpression
+	funcType FuncType
+	args     []Expression
+}
+
+func NewFunction(funcType FuncType, args []Expression) Expression {
+	var retType types.TypeID
+	switch funcType {
+	case LOWER, UPPER, SUBSTR:
+		retType = types.Varchar
+	case LENGTH:
+		retType = types.Integer
+	case ABS, ROUND:
+		retType = types.Integer
+		if args[0].GetReturnType().IsNumeric() {
+			retType = args[0].GetReturnType()
+		}
+	case COALESCE:
+		retType = commonTypeOf(args)
+	case CASE:
+		results := make([]Expression, 0)
+		for idx := 1; idx < len(args); idx += 2 {
+			results = append(results, args[idx])
+		}
+		if len(args)%2 == 1 {
+			// ELSE clause
+			results = append(results, args[len(args)-1])
+		}
+		retType = commonTypeOf(results)
+	default:
+		panic("illegal funcType is passed!")
+	}
+	return &Function{&AbstractExpression{[2]Expression{}, retType}, funcType, args}
+}
+
+func NewCastFunction(arg Expression, retType types.TypeID) Expression {
+	return &Function{&AbstractExpression{[2]Expression{}, retType}, CAST, []Expression{arg}}
+}
+
+// returns type which values of exps can be converted to without losing information mostly.
+// numeric types are unified with types.CommonTypeOf and NULL literal is ignored
+func commonTypeOf(exps []Expression) types.TypeID {
+	ret := types.Invalid
+	for _, exp := range exps {
+		if constVal, ok := exp.(*ConstantValue); ok && constVal.GetValue().IsNull() {
+			continue
+		}
+		expType := exp.GetReturnType()
+		switch {
+		case ret == types.Invalid || ret == expType:
+			ret = expType
+		case ret.IsNumeric() && expType.IsNumeric():
+			ret = types.CommonTypeOf(ret, expType)
+		case ret.IsNumeric() && expType == types.Boolean:
+		case ret == types.Boolean && expType.IsNumeric():
+			ret = expType
+		default:
+			return types.Varchar
+		}
+	}
+	if ret == types.Invalid {
+		return types.Integer
+	}
+	return ret
+}
+
+func (c *Function) Evaluate(tuple_ *tuple.Tuple, schema_ *schema.Schema) types.Value {
+	argVals := make([]types.Value, 0, len(c.args))
+	for _, arg := range c.args {
+		argVals = append(argVals, arg.Evaluate(tuple_, schema_))
+	}
+	return c.performFunction(argVals)
+}
+
+// returns val which is converted to return type. NULL is returned when it can't be converted
+func (c *Function) castToRetType(val types.Value) types.Value {
+	ret, ok := val.CastAs(c.ret_type)
+	if !ok {
+		return types.NewNullOfType(c.ret_type)
+	}
+	return *ret
+}
+
+func (c *Function) performFunction(argVals []types.Value) types.Value {
+	switch c.funcType {
+	case COALESCE:
+		for _, val := range argVals {
+			if !val.IsNull() {
+				return c.castToRetType(val)
+			}
+		}
+		return types.NewNullOfType(c.ret_type)
+	case CASE:
+		for idx := 0; idx+1 < len(argVals); idx += 2 {
+			if !argVals[idx].IsNull() && argVals[idx].ToBoolean() {
+				return c.castToRetType(argVals[idx+1])
+			}
+		}
+		if len(argVals)%2 == 1 {
+			return c.castToRetType(argVals[len(argVals)-1])
+		}
+		return types.NewNullOfType(c.ret_type)
+	case CAST:
+		return c.castToRetType(argVals[0])
+	}
+
+	for _, val := range argVals {
+		if val.IsNull() {
+			return types.NewNullOfType(c.ret_type)
+		}
+	}
+	switch c.funcType {
+	case LOWER, UPPER, LENGTH:
+		str, ok := argVals[0].CastAs(types.Varchar)
+		if !ok {
+			return types.NewNullOfType(c.ret_type)
+		}
+		switch c.funcType {
+		case LOWER:
+			return types.NewVarchar(strings.ToLower(str.ToVarchar()))
+		case UPPER:
+			return types.NewVarchar(strings.ToUpper(str.ToVarchar()))
+		default:
+			return types.NewInteger(int32(utf8.RuneCountInString(str.ToVarchar())))
+		}
+	case SUBSTR:
+		return performSubstr(argVals)
+	case ABS:
+		num := c.castToRetType(argVals[0])
+		if num.IsNull() {
+			return num
+		}
+		switch c.ret_type {
+		case types.Float:
+			return types.NewFloat(float32(math.Abs(float64(num.ToFloat()))))
+		case types.Double:
+			return types.NewDouble(math.Abs(num.ToDouble()))
+		case types.BigInt:
+			if num.ToBigInt() < 0 {
+				return types.NewBigInt(-num.ToBigInt())
+			}
+		case types.Decimal:
+			if num.ToDecimal().Cmp(types.NewDecimalFromInt64(0)) < 0 {
+				return types.NewDecimal(num.ToDecimal().Neg())
+			}
+		default:
+			if num.ToInteger() < 0 {
+				return types.NewInteger(-num.ToInteger())
+			}
+		}
+		return num
+	case ROUND:
+		return c.performRound(argVals)
+	default:
+		panic("illegal funcType is passed!")
+	}
+}
+
+func performSubstr(argVals []types.Value) types.Value {
+	str, okS := argVals[0].CastAs(types.Varchar)
+	pos, okP := argVals[1].CastAs(types.Integer)
+	if !okS || !okP {
+		return types.NewNullOfType(types.Varchar)
+	}
+	runes := []rune(str.ToVarchar())
+	start := int(pos.ToInteger())
+	if start < 0 {
+		start = len(runes) + start + 1
+	}
+	if start < 1 || start > len(runes) {
+		return types.NewVarchar("")
+	}
+	start--
+	end := len(runes)
+	if len(argVals) > 2 {
+		length, okL := argVals[2].CastAs(types.Integer)
+		if !okL {
+			return types.NewNullOfType(types.Varchar)
+		}
+		if length.ToInteger() < 1 {
+			return types.NewVarchar("")
+		}
+		if start+int(length.ToInteger()) < end {
+			end = start + int(length.ToInteger())
+		}
+	}
+	return types.NewVarchar(string(runes[start:end]))
+}
+
+// rounds half away from zero. negative digits rounds integral part (ex: ROUND(125, -1) => 130)
+func (c *Function) performRound(argVals []types.Value) types.Value {
+	num := c.castToRetType(argVals[0])
+	if num.IsNull() {
+		return num
+	}
+	digits := int32(0)
+	if len(argVals) > 1 {
+		digitsVal, ok := argVals[1].CastAs(types.Integer)
+		if !ok {
+			return types.NewNullOfType(c.ret_type)
+		}
+		digits = digitsVal.ToInteger()
+	}
+	scale := math.Pow10(int(digits))
+	switch c.ret_type {
+	case types.Float:
+		return types.NewFloat(float32(math.Round(float64(num.ToFloat())*scale) / scale))
+	case types.Double:
+		return types.NewDouble(math.Round(num.ToDouble()*scale) / scale)
+	case types.Decimal:
+		rounded, ok := num.ToDecimal().Round(int(digits))
+		if !ok {
+			return types.NewNullOfType(types.Decimal)
+		}
+		return types.NewDecimal(rounded)
+	case types.BigInt:
+		rounded, ok := types.NewDecimalFromInt64(num.ToBigInt()).Round(int(digits))
+		if !ok {
+			return types.NewNullOfType(types.BigInt)
+		}
+		return types.NewBigInt(rounded.Truncate())
+	}
+	if digits >= 0 {
+		return num
+	}
+	rounded := math.Round(float64(num.ToInteger())*scale) / scale
+	if rounded > math.MaxInt32 || rounded < math.MinInt32 {
+		return types.NewNullOfType(types.Integer)
+	}
+	return types.NewInteger(int32(rounded))
+}
+
+func (c *Function) GetFuncType() FuncType {
+	return c.funcType
+}
+
+func (c *Function) EvaluateJoin(left_tuple *tuple.Tuple, left_schema *schema.Schema, right_tuple *tuple.Tuple, right_schema *schema.Schema) types.Value {
+	argVals := make([]types.Value, 0, len(c.args))
+	for _, arg := range c.args {
+		argVals = append(argVals, arg.EvaluateJoin(left_tuple, left_schema, right_tuple, right_schema))
+	}
+	return c.performFunction(argVals)
+}
+
+func (c *Function) EvaluateAggregate(group_bys []*types.Value, aggregates []*types.Value) types.Value {
+	argVals := make([]types.Value, 0, len(c.args))
+	for _, arg := range c.args {
+		argVals = append(argVals, arg.EvaluateAggregate(group_bys, aggregates))
+	}
+	return c.performFunction(argVals)
+}
+
+func (c *Function) GetChildAt(child_idx uint32) Expression {
+	if int(child_idx) >= len(c.args) {
+		return nil
+	}
+	return c.args[child_idx]
+}
+
+func (c *Function) GetType() ExpressionType {
+	return EXPRESSION_TYPE_FUNCTION
+}
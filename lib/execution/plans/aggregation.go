@@ -17,11 +17,26 @@ const (
 	SUM_AGGREGATE
 	MIN_AGGREGATE
 	MAX_AGGREGATE
+	AVG_AGGREGATE
+	COUNT_DISTINCT_AGGREGATE
 )
 
+// AvgTypeOf returns type of result of AVG whose argument is argType.
+// Float is used for Integer and Float, Double for BigInt and Double, and Decimal for Decimal
+func AvgTypeOf(argType types.TypeID) types.TypeID {
+	switch argType {
+	case types.BigInt, types.Double:
+		return types.Double
+	case types.Decimal:
+		return types.Decimal
+	default:
+		return types.Float
+	}
+}
+
 /**
  * AggregationPlanNode represents the various SQL aggregation functions.
- * For example, COUNT(), SUM(), MIN() and MAX().
+ * For example, COUNT(), SUM(), MIN(), MAX(), AVG() and COUNT(DISTINCT).
  * To simplfiy this project, AggregationPlanNode must always have exactly one child.
  */
 type AggregationPlanNode struct {
@@ -99,8 +114,11 @@ func (p *AggregationPlanNode) EmitRowCount(c *catalog.Catalog) uint64 {
 }
 
 func (p *AggregationPlanNode) GetDebugStr() string {
-	// TODO: (SDB) [OPT] not implemented yet (AggregationPlanNode::GetDebugStr)
-	panic("not implemented yet")
+	outColNames := "["
+	for _, col := range p.outputSchema.GetColumns() {
+		outColNames += col.GetColumnName() + ", "
+	}
+	return "AggregationPlanNode " + outColNames + "]"
 }
 
 func (p *AggregationPlanNode) GetStatistics() *catalog.TableStatistics {
@@ -113,4 +131,8 @@ type AggregateKey struct {
 
 type AggregateValue struct {
 	Aggregates_ []*types.Value
+	// count of aggregated values which are not NULL (used for AVG)
+	Counts_ []int32
+	// already counted values for COUNT(DISTINCT). key is serialized value
+	Distincts_ []map[string]bool
 }
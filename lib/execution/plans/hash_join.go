@@ -22,6 +22,7 @@ type HashJoinPlanNode struct {
 	/** The right child's hash keys. */
 	right_hash_keys []expression.Expression
 	stats_          *catalog.TableStatistics
+	JoinAttr
 }
 
 func GenHashJoinStats(leftPlan Plan, rightPlan Plan) *catalog.TableStatistics {
@@ -33,7 +34,7 @@ func GenHashJoinStats(leftPlan Plan, rightPlan Plan) *catalog.TableStatistics {
 func NewHashJoinPlanNode(output_schema *schema.Schema, children []Plan,
 	onPredicate expression.Expression, left_hash_keys []expression.Expression,
 	right_hash_keys []expression.Expression) *HashJoinPlanNode {
-	return &HashJoinPlanNode{&AbstractPlanNode{output_schema, children}, onPredicate, left_hash_keys, right_hash_keys, GenHashJoinStats(children[0], children[1])}
+	return &HashJoinPlanNode{&AbstractPlanNode{output_schema, children}, onPredicate, left_hash_keys, right_hash_keys, GenHashJoinStats(children[0], children[1]), JoinAttr{}}
 }
 
 func NewHashJoinPlanNodeWithChilds(left_child Plan, left_hash_keys []expression.Expression, right_child Plan, right_hash_keys []expression.Expression) *HashJoinPlanNode {
@@ -47,7 +48,7 @@ func NewHashJoinPlanNodeWithChilds(left_child Plan, left_hash_keys []expression.
 	onPredicate := constructOnExpressionFromKeysInfo(left_hash_keys, right_hash_keys)
 	output_schema := makeMergedOutputSchema(left_child.OutputSchema(), right_child.OutputSchema())
 
-	return &HashJoinPlanNode{&AbstractPlanNode{output_schema, []Plan{left_child, right_child}}, onPredicate, left_hash_keys, right_hash_keys, GenHashJoinStats(left_child, right_child)}
+	return &HashJoinPlanNode{&AbstractPlanNode{output_schema, []Plan{left_child, right_child}}, onPredicate, left_hash_keys, right_hash_keys, GenHashJoinStats(left_child, right_child), JoinAttr{}}
 }
 func (p *HashJoinPlanNode) GetType() PlanType { return HashJoin }
 
@@ -97,7 +98,7 @@ func (p *HashJoinPlanNode) GetDebugStr() string {
 	leftColName := p.GetChildAt(0).OutputSchema().GetColumn(leftColIdx).GetColumnName()
 	rightColIdx := p.onPredicate.GetChildAt(1).(*expression.ColumnValue).GetColIndex()
 	rightColName := p.GetChildAt(1).OutputSchema().GetColumn(rightColIdx).GetColumnName()
-	return "HashJoinPlanNode [" + leftColName + " = " + rightColName + "]"
+	return "HashJoinPlanNode [" + leftColName + " = " + rightColName + "]" + p.JoinAttr.getDebugStr()
 }
 
 func (p *HashJoinPlanNode) GetStatistics() *catalog.TableStatistics {
@@ -105,5 +106,10 @@ func (p *HashJoinPlanNode) GetStatistics() *catalog.TableStatistics {
 }
 
 func (p *HashJoinPlanNode) EmitRowCount(c *catalog.Catalog) uint64 {
-	return uint64(math.Min(float64(p.GetLeftPlan().EmitRowCount(c)), float64(p.GetRightPlan().EmitRowCount(c))))
+	emitRowCount := uint64(math.Min(float64(p.GetLeftPlan().EmitRowCount(c)), float64(p.GetRightPlan().EmitRowCount(c))))
+	return p.adjustEmitRowCount(c, emitRowCount, p.GetLeftPlan(), p.GetRightPlan())
+}
+
+func (p *HashJoinPlanNode) SetJoinAttr(joinType JoinType, joinPredicate expression.Expression) {
+	p.JoinAttr.setJoinAttr(joinType, joinPredicate, p.OutputSchema())
 }
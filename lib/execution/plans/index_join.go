@@ -14,6 +14,8 @@ type IndexJoinPlanNode struct {
 	rigthTableOID  uint32
 	rightOutSchema *schema.Schema
 	stats_         *catalog.TableStatistics
+	// RIGHT_OUTER_JOIN and FULL_OUTER_JOIN are not supported because right side is not scanned
+	JoinAttr
 }
 
 func GenIndexJoinStats(c *catalog.Catalog, leftPlan Plan, rightTableOID uint32) *catalog.TableStatistics {
@@ -33,7 +35,7 @@ func NewIndexJoinPlanNode(c *catalog.Catalog, leftChild Plan, leftKeys []express
 
 	outputSchema := makeMergedOutputSchema(leftChild.OutputSchema(), rightOutSchema)
 	onPredicate := constructOnExpressionFromKeysInfo(leftKeys, rightKeys)
-	return &IndexJoinPlanNode{&AbstractPlanNode{outputSchema, []Plan{leftChild}}, onPredicate, rightTblOID, rightOutSchema, GenIndexJoinStats(c, leftChild, rightTblOID)}
+	return &IndexJoinPlanNode{&AbstractPlanNode{outputSchema, []Plan{leftChild}}, onPredicate, rightTblOID, rightOutSchema, GenIndexJoinStats(c, leftChild, rightTblOID), JoinAttr{}}
 }
 
 func (p *IndexJoinPlanNode) GetLeftPlan() Plan {
@@ -58,6 +60,11 @@ func (p *IndexJoinPlanNode) GetRightTableOID() uint32 {
 	return p.rigthTableOID
 }
 
+// columns are same as the right table but they may be renamed (ex: table in subquery)
+func (p *IndexJoinPlanNode) GetRightOutSchema() *schema.Schema {
+	return p.rightOutSchema
+}
+
 func (p *IndexJoinPlanNode) getRightTableRows(c *catalog.Catalog) uint64 {
 	tm := c.GetTableByOID(p.rigthTableOID)
 	return tm.GetStatistics().Rows()
@@ -72,7 +79,7 @@ func (p *IndexJoinPlanNode) GetDebugStr() string {
 	leftColName := p.GetChildAt(0).OutputSchema().GetColumn(leftColIdx).GetColumnName()
 	rightColIdx := p.onPredicate.GetChildAt(1).(*expression.ColumnValue).GetColIndex()
 	rightColName := p.rightOutSchema.GetColumn(rightColIdx).GetColumnName()
-	return "IndexJoinPlanNode [" + leftColName + " = " + rightColName + "]"
+	return "IndexJoinPlanNode [" + leftColName + " = " + rightColName + "]" + p.JoinAttr.getDebugStr()
 }
 
 func (p *IndexJoinPlanNode) GetStatistics() *catalog.TableStatistics {
@@ -80,5 +87,13 @@ func (p *IndexJoinPlanNode) GetStatistics() *catalog.TableStatistics {
 }
 
 func (p *IndexJoinPlanNode) EmitRowCount(c *catalog.Catalog) uint64 {
-	return uint64(math.Min(float64(p.GetLeftPlan().EmitRowCount(c)), float64(p.getRightTableRows(c))))
+	emitRowCount := uint64(math.Min(float64(p.GetLeftPlan().EmitRowCount(c)), float64(p.getRightTableRows(c))))
+	return p.adjustEmitRowCount(c, emitRowCount, p.GetLeftPlan(), nil)
+}
+
+func (p *IndexJoinPlanNode) SetJoinAttr(joinType JoinType, joinPredicate expression.Expression) {
+	if joinType.PreservesRight() {
+		panic("IndexJoinPlanNode does not support " + joinType.String() + " JOIN")
+	}
+	p.JoinAttr.setJoinAttr(joinType, joinPredicate, p.OutputSchema())
 }
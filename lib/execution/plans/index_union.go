@@ -0,0 +1,65 @@
+package plans
+
+import (
+	"github.com/ryogrid/SamehadaDB/lib/catalog"
+	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
+	"math"
+)
+
+/**
+ * IndexUnionPlanNode unions records of index scans on same table.
+ * it is used for predicate which consists of OR of conditions on indexed columns (ex: a = 1 OR b < 10).
+ * records which are emitted from several children are emitted only once
+ */
+type IndexUnionPlanNode struct {
+	*AbstractPlanNode
+	tableOID uint32
+	stats_   *catalog.TableStatistics
+}
+
+func NewIndexUnionPlanNode(c *catalog.Catalog, schema *schema.Schema, tableOID uint32, children []Plan) Plan {
+	tm := c.GetTableByOID(tableOID)
+	ret := &IndexUnionPlanNode{&AbstractPlanNode{schema, children}, tableOID, tm.GetStatistics().GetDeepCopy()}
+	rows := ret.stats_.Rows()
+	if rows > 0 {
+		ret.stats_.Multiply(math.Min(float64(ret.EmitRowCount(c)), float64(rows)) / float64(rows))
+	}
+	return ret
+}
+
+func (p *IndexUnionPlanNode) GetType() PlanType {
+	return IndexUnion
+}
+
+func (p *IndexUnionPlanNode) GetTableOID() uint32 {
+	return p.tableOID
+}
+
+func (p *IndexUnionPlanNode) AccessRowCount(c *catalog.Catalog) uint64 {
+	ret := uint64(0)
+	for _, child := range p.children {
+		ret += child.AccessRowCount(c)
+	}
+	return ret
+}
+
+// duplicated records are not considered. so returned value is upper bound
+func (p *IndexUnionPlanNode) EmitRowCount(c *catalog.Catalog) uint64 {
+	ret := uint64(0)
+	for _, child := range p.children {
+		ret += child.EmitRowCount(c)
+	}
+	return ret
+}
+
+func (p *IndexUnionPlanNode) GetDebugStr() string {
+	outColNames := "["
+	for _, col := range p.OutputSchema().GetColumns() {
+		outColNames += col.GetColumnName() + ", "
+	}
+	return "IndexUnionPlanNode " + outColNames + "]"
+}
+
+func (p *IndexUnionPlanNode) GetStatistics() *catalog.TableStatistics {
+	return p.stats_
+}
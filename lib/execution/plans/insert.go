@@ -16,12 +16,20 @@ import (
 type InsertPlanNode struct {
 	*AbstractPlanNode
 	rawValues [][]types.Value
-	tableOID  uint32
+	// indexes of columns of the table which values of output of the child are inserted to
+	targetColIdxs []int
+	tableOID      uint32
 }
 
 // NewInsertPlanNode creates a new insert plan node for inserting raw values
 func NewInsertPlanNode(rawValues [][]types.Value, oid uint32) Plan {
-	return &InsertPlanNode{&AbstractPlanNode{nil, nil}, rawValues, oid}
+	return &InsertPlanNode{&AbstractPlanNode{nil, nil}, rawValues, nil, oid}
+}
+
+// NewInsertPlanNodeWithChild creates a new insert plan node for inserting output of child (INSERT ... SELECT).
+// i-th column of output schema of child is inserted to the column whose index is targetColIdxs[i]
+func NewInsertPlanNodeWithChild(child Plan, targetColIdxs []int, oid uint32) Plan {
+	return &InsertPlanNode{&AbstractPlanNode{nil, []Plan{child}}, nil, targetColIdxs, oid}
 }
 
 // GetTableOID returns the identifier of the table that should be inserted into
@@ -29,20 +37,35 @@ func (p *InsertPlanNode) GetTableOID() uint32 {
 	return p.tableOID
 }
 
-// GetRawValues returns the raw values to be inserted
+// GetRawValues returns the raw values to be inserted. it is nil when values come from the child
 func (p *InsertPlanNode) GetRawValues() [][]types.Value {
 	return p.rawValues
 }
 
+func (p *InsertPlanNode) GetTargetColIdxs() []int {
+	return p.targetColIdxs
+}
+
+// IsRawInsert returns false when values to be inserted come from the child
+func (p *InsertPlanNode) IsRawInsert() bool {
+	return len(p.children) == 0
+}
+
 func (p *InsertPlanNode) GetType() PlanType {
 	return Insert
 }
 
 func (p *InsertPlanNode) AccessRowCount(c *catalog.Catalog) uint64 {
+	if !p.IsRawInsert() {
+		return p.children[0].AccessRowCount(c)
+	}
 	return uint64(len(p.rawValues))
 }
 
 func (p *InsertPlanNode) EmitRowCount(c *catalog.Catalog) uint64 {
+	if !p.IsRawInsert() {
+		return p.children[0].EmitRowCount(c)
+	}
 	return uint64(len(p.rawValues))
 }
 
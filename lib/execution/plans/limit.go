@@ -4,7 +4,9 @@
 package plans
 
 import (
+	"fmt"
 	"github.com/ryogrid/SamehadaDB/lib/catalog"
+	"math"
 )
 
 type LimitPlanNode struct {
@@ -14,8 +16,9 @@ type LimitPlanNode struct {
 	stats_ *catalog.TableStatistics
 }
 
+// output schema is same with child
 func NewLimitPlanNode(child Plan, limit uint32, offset uint32) Plan {
-	return &LimitPlanNode{&AbstractPlanNode{nil, []Plan{child}}, limit, offset, child.GetStatistics().GetDeepCopy()}
+	return &LimitPlanNode{&AbstractPlanNode{child.OutputSchema(), []Plan{child}}, limit, offset, child.GetStatistics().GetDeepCopy()}
 }
 
 func (p *LimitPlanNode) GetLimit() uint32 {
@@ -39,12 +42,11 @@ func (p *LimitPlanNode) AccessRowCount(c *catalog.Catalog) uint64 {
 }
 
 func (p *LimitPlanNode) EmitRowCount(c *catalog.Catalog) uint64 {
-	return uint64(p.limit)
+	return uint64(math.Min(float64(p.limit), float64(p.children[0].EmitRowCount(c))))
 }
 
 func (p *LimitPlanNode) GetDebugStr() string {
-	// TODO: (SDB) [OPT] not implemented yet (LimitPlanNode::GetDebugStr)
-	panic("not implemented yet")
+	return fmt.Sprintf("LimitPlanNode limit=%d offset=%d", p.limit, p.offset)
 }
 
 func (p *LimitPlanNode) GetStatistics() *catalog.TableStatistics {
@@ -3,12 +3,14 @@ package plans
 import (
 	"github.com/ryogrid/SamehadaDB/lib/catalog"
 	"github.com/ryogrid/SamehadaDB/lib/common"
+	"github.com/ryogrid/SamehadaDB/lib/execution/expression"
 	"math"
 )
 
 type NestedLoopJoinPlanNode struct {
 	*AbstractPlanNode
 	stats_ *catalog.TableStatistics
+	JoinAttr
 }
 
 func GenNestedLoopJoinStats(leftPlan Plan, rightPlan Plan) *catalog.TableStatistics {
@@ -20,11 +22,12 @@ func GenNestedLoopJoinStats(leftPlan Plan, rightPlan Plan) *catalog.TableStatist
 	return leftStats
 }
 
-// used only for Cross Join
+// Cross Join. join condition can be set with SetJoinAttr
 func NewNestedLoopJoinPlanNode(children []Plan) *NestedLoopJoinPlanNode {
 	return &NestedLoopJoinPlanNode{
 		&AbstractPlanNode{makeMergedOutputSchema(children[0].OutputSchema(), children[1].OutputSchema()), children},
-		GenNestedLoopJoinStats(children[0], children[1])}
+		GenNestedLoopJoinStats(children[0], children[1]),
+		JoinAttr{}}
 }
 
 func (p *NestedLoopJoinPlanNode) GetType() PlanType { return NestedLoopJoin }
@@ -50,7 +53,7 @@ func (p *NestedLoopJoinPlanNode) AccessRowCount(c *catalog.Catalog) uint64 {
 }
 
 func (p *NestedLoopJoinPlanNode) EmitRowCount(c *catalog.Catalog) uint64 {
-	return p.GetLeftPlan().EmitRowCount(c) * p.GetRightPlan().EmitRowCount(c)
+	return p.adjustEmitRowCount(c, p.GetLeftPlan().EmitRowCount(c)*p.GetRightPlan().EmitRowCount(c), p.GetLeftPlan(), p.GetRightPlan())
 }
 
 func (p *NestedLoopJoinPlanNode) GetStatistics() *catalog.TableStatistics {
@@ -58,5 +61,9 @@ func (p *NestedLoopJoinPlanNode) GetStatistics() *catalog.TableStatistics {
 }
 
 func (p *NestedLoopJoinPlanNode) GetDebugStr() string {
-	return "NestedLoopJoinPlanNode"
+	return "NestedLoopJoinPlanNode" + p.JoinAttr.getDebugStr()
+}
+
+func (p *NestedLoopJoinPlanNode) SetJoinAttr(joinType JoinType, joinPredicate expression.Expression) {
+	p.JoinAttr.setJoinAttr(joinType, joinPredicate, p.OutputSchema())
 }
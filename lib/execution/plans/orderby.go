@@ -1,6 +1,7 @@
 package plans
 
 import (
+	"fmt"
 	"github.com/ryogrid/SamehadaDB/lib/catalog"
 	"github.com/ryogrid/SamehadaDB/lib/common"
 	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
@@ -22,6 +23,7 @@ type OrderbyPlanNode struct {
 	*AbstractPlanNode
 	col_idxs_      []int
 	orderby_types_ []OrderbyType
+	nulls_firsts_  []bool
 	stats_         *catalog.TableStatistics
 }
 
@@ -31,10 +33,11 @@ type OrderbyPlanNode struct {
  * @param child the child plan to sort data over
  * @param col_idxs the specified columns idx at ORDER BY clause
  * @param order_types the order types of sorting with specifed columns
+ * @param nulls_firsts whether NULL is placed before other values for each specified column
  */
 func NewOrderbyPlanNode(child_schema *schema.Schema, child Plan, col_idxs []int,
-	order_types []OrderbyType) *OrderbyPlanNode {
-	return &OrderbyPlanNode{&AbstractPlanNode{child_schema, []Plan{child}}, col_idxs, order_types, child.GetStatistics().GetDeepCopy()}
+	order_types []OrderbyType, nulls_firsts []bool) *OrderbyPlanNode {
+	return &OrderbyPlanNode{&AbstractPlanNode{child_schema, []Plan{child}}, col_idxs, order_types, nulls_firsts, child.GetStatistics().GetDeepCopy()}
 }
 
 func (p *OrderbyPlanNode) GetType() PlanType { return Orderby }
@@ -64,6 +67,9 @@ func (p *OrderbyPlanNode) GetColIdxs() []int { return p.col_idxs_ }
 /** @return the Order type ASC or DESC */
 func (p *OrderbyPlanNode) GetOrderbyTypes() []OrderbyType { return p.orderby_types_ }
 
+/** @return whether NULL is placed first for each column */
+func (p *OrderbyPlanNode) GetNullsFirsts() []bool { return p.nulls_firsts_ }
+
 func (p *OrderbyPlanNode) GetTableOID() uint32 {
 	return p.children[0].GetTableOID()
 }
@@ -77,8 +83,7 @@ func (p *OrderbyPlanNode) EmitRowCount(c *catalog.Catalog) uint64 {
 }
 
 func (p *OrderbyPlanNode) GetDebugStr() string {
-	// TODO: (SDB) [OPT] not implemented yet (OrderbyPlanNode::GetDebugStr)
-	panic("not implemented yet")
+	return fmt.Sprintf("OrderbyPlanNode %v %v", p.col_idxs_, p.orderby_types_)
 }
 
 func (p *OrderbyPlanNode) GetStatistics() *catalog.TableStatistics {
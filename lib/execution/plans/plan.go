@@ -25,6 +25,8 @@ const (
 	Orderby
 	Projection
 	Selection
+	CompositeIndexRangeScan
+	IndexUnion
 )
 
 type Plan interface {
@@ -67,3 +69,9 @@ func (p *AbstractPlanNode) GetChildren() []Plan {
 func (p *AbstractPlanNode) OutputSchema() *schema.Schema {
 	return p.outputSchema
 }
+
+// SetOutputSchema is used for INSERT, UPDATE and DELETE which have RETURNING clause.
+// output schema of these plans is nil when the clause is not specified
+func (p *AbstractPlanNode) SetOutputSchema(outSchema *schema.Schema) {
+	p.outputSchema = outSchema
+}
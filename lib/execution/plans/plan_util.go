@@ -2,12 +2,136 @@ package plans
 
 import (
 	"fmt"
+	"github.com/ryogrid/SamehadaDB/lib/catalog"
 	"github.com/ryogrid/SamehadaDB/lib/execution/expression"
 	"github.com/ryogrid/SamehadaDB/lib/storage/table/column"
 	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
 	"github.com/ryogrid/SamehadaDB/lib/types"
+	"math"
 )
 
+type JoinType int
+
+const (
+	INNER_JOIN JoinType = iota
+	// all records of left child are emitted. right side is padded with NULL when no record matches
+	LEFT_OUTER_JOIN
+	// all records of right child are emitted. left side is padded with NULL when no record matches
+	RIGHT_OUTER_JOIN
+	// LEFT_OUTER_JOIN and RIGHT_OUTER_JOIN
+	FULL_OUTER_JOIN
+	// records of left child which have matching record are emitted once. right side is padded with NULL
+	// (EXISTS and IN subquery)
+	SEMI_JOIN
+	// records of left child which have no matching record are emitted. right side is padded with NULL
+	// (NOT EXISTS and NOT IN subquery)
+	ANTI_JOIN
+	// same as LEFT_OUTER_JOIN but record of left child must match at most one record (scalar subquery)
+	LEFT_SINGLE_JOIN
+)
+
+func (jt JoinType) PreservesLeft() bool {
+	return jt == LEFT_OUTER_JOIN || jt == FULL_OUTER_JOIN || jt == ANTI_JOIN || jt == LEFT_SINGLE_JOIN
+}
+
+func (jt JoinType) PreservesRight() bool {
+	return jt == RIGHT_OUTER_JOIN || jt == FULL_OUTER_JOIN
+}
+
+// columns of right child are not output on semi join and anti join
+func (jt JoinType) IsSemiOrAnti() bool {
+	return jt == SEMI_JOIN || jt == ANTI_JOIN
+}
+
+// returns false when left and right child can't be swapped
+func (jt JoinType) IsReversible() bool {
+	return jt != SEMI_JOIN && jt != ANTI_JOIN && jt != LEFT_SINGLE_JOIN
+}
+
+// returns join type which has same meaning when left and right child are swapped
+func (jt JoinType) Reverse() JoinType {
+	switch jt {
+	case LEFT_OUTER_JOIN:
+		return RIGHT_OUTER_JOIN
+	case RIGHT_OUTER_JOIN:
+		return LEFT_OUTER_JOIN
+	default:
+		return jt
+	}
+}
+
+func (jt JoinType) String() string {
+	switch jt {
+	case LEFT_OUTER_JOIN:
+		return "LEFT OUTER"
+	case RIGHT_OUTER_JOIN:
+		return "RIGHT OUTER"
+	case FULL_OUTER_JOIN:
+		return "FULL OUTER"
+	case SEMI_JOIN:
+		return "SEMI"
+	case ANTI_JOIN:
+		return "ANTI"
+	case LEFT_SINGLE_JOIN:
+		return "LEFT SINGLE"
+	default:
+		return "INNER"
+	}
+}
+
+// JoinAttr is embedded to join plan nodes.
+// joinPredicate is evaluated on joined record (output schema of the join plan) in addition to join keys.
+// when the join is outer join, record which has no matching record with the predicate is emitted with NULLs
+type JoinAttr struct {
+	joinType      JoinType
+	joinPredicate expression.Expression
+}
+
+func (a *JoinAttr) GetJoinType() JoinType {
+	return a.joinType
+}
+
+func (a *JoinAttr) GetJoinPredicate() expression.Expression {
+	return a.joinPredicate
+}
+
+// joinPredicate can be nil. columns of outSchema which can be padded with NULL are marked as nullable
+func (a *JoinAttr) setJoinAttr(joinType JoinType, joinPredicate expression.Expression, outSchema *schema.Schema) {
+	a.joinType = joinType
+	a.joinPredicate = joinPredicate
+	for _, col := range outSchema.GetColumns() {
+		if (col.IsLeft() && joinType.PreservesRight()) || (!col.IsLeft() && (joinType.PreservesLeft() || joinType.IsSemiOrAnti())) {
+			col.SetIsNotNull(false)
+		}
+	}
+}
+
+func (a *JoinAttr) getDebugStr() string {
+	ret := ""
+	if a.joinType != INNER_JOIN {
+		ret += " " + a.joinType.String()
+	}
+	if a.joinPredicate != nil {
+		ret += " pred:[ " + expression.GetExpTreeStr(a.joinPredicate) + "]"
+	}
+	return ret
+}
+
+// estimated row count of outer join is not less than row count of preserved side
+// and row count of semi join and anti join is not more than row count of left side
+func (a *JoinAttr) adjustEmitRowCount(c *catalog.Catalog, emitRowCount uint64, left Plan, right Plan) uint64 {
+	if a.joinType.IsSemiOrAnti() && left != nil {
+		return left.EmitRowCount(c)
+	}
+	if a.joinType.PreservesLeft() && left != nil {
+		emitRowCount = uint64(math.Max(float64(emitRowCount), float64(left.EmitRowCount(c))))
+	}
+	if a.joinType.PreservesRight() && right != nil {
+		emitRowCount = uint64(math.Max(float64(emitRowCount), float64(right.EmitRowCount(c))))
+	}
+	return emitRowCount
+}
+
 func makeMergedOutputSchema(left_schema *schema.Schema, right_schema *schema.Schema) *schema.Schema {
 	var ret *schema.Schema
 	columns := make([]*column.Column, 0)
@@ -2,17 +2,52 @@ package plans
 
 import (
 	"github.com/ryogrid/SamehadaDB/lib/catalog"
+	"github.com/ryogrid/SamehadaDB/lib/execution/expression"
+	"github.com/ryogrid/SamehadaDB/lib/storage/index/index_constants"
+	"github.com/ryogrid/SamehadaDB/lib/storage/table/column"
 	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
+	"github.com/ryogrid/SamehadaDB/lib/types"
 	"math"
 )
 
 type ProjectionPlanNode struct {
 	*AbstractPlanNode
 	stats_ *catalog.TableStatistics
+	// columns are mapped by position, not by name
+	isRenaming bool
+	// expressions which calculate each column. column is copied from child when it is nil
+	exprs []expression.Expression
 }
 
 func NewProjectionPlanNode(child Plan, projectColumns *schema.Schema) Plan {
-	return &ProjectionPlanNode{&AbstractPlanNode{projectColumns, []Plan{child}}, child.GetStatistics().GetDeepCopy()}
+	return &ProjectionPlanNode{&AbstractPlanNode{projectColumns, []Plan{child}}, child.GetStatistics().GetDeepCopy(), false, nil}
+}
+
+// NewCalcProjectionPlanNode returns projection whose columns are calculated with exprs
+// (ex: SELECT a.x + 1 FROM a). column which corresponds to nil element of exprs is copied from child
+func NewCalcProjectionPlanNode(child Plan, projectColumns *schema.Schema, exprs []expression.Expression) Plan {
+	return &ProjectionPlanNode{&AbstractPlanNode{projectColumns, []Plan{child}}, child.GetStatistics().GetDeepCopy(), false, exprs}
+}
+
+// NewRenamingProjectionPlanNode returns projection which outputs all columns of child with colNames
+// (ex: columns of derived table are renamed to "<alias>.<column name>")
+func NewRenamingProjectionPlanNode(child Plan, colNames []string) Plan {
+	columns := make([]*column.Column, 0, len(colNames))
+	for idx, colName := range colNames {
+		col := child.OutputSchema().GetColumn(uint32(idx))
+		outCol := column.NewColumn(colName, col.GetType(), false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), nil)
+		outCol.SetIsNotNull(col.IsNotNull())
+		columns = append(columns, outCol)
+	}
+	return &ProjectionPlanNode{&AbstractPlanNode{schema.NewSchema(columns), []Plan{child}}, child.GetStatistics().GetDeepCopy(), true, nil}
+}
+
+func (p *ProjectionPlanNode) IsRenaming() bool {
+	return p.isRenaming
+}
+
+func (p *ProjectionPlanNode) GetExprs() []expression.Expression {
+	return p.exprs
 }
 
 func (p *ProjectionPlanNode) GetType() PlanType {
@@ -36,6 +71,12 @@ func (p *ProjectionPlanNode) GetDebugStr() string {
 	for _, col := range p.outputSchema.GetColumns() {
 		projColNames += col.GetColumnName() + ", "
 	}
+	if p.isRenaming {
+		return "ProjectionPlanNode (rename) " + projColNames + "]"
+	}
+	if p.exprs != nil {
+		return "ProjectionPlanNode (calc) " + projColNames + "]"
+	}
 	return "ProjectionPlanNode " + projColNames + "]"
 }
 
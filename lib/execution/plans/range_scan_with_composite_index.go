@@ -0,0 +1,104 @@
+package plans
+
+import (
+	"github.com/ryogrid/SamehadaDB/lib/catalog"
+	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
+	"github.com/ryogrid/SamehadaDB/lib/types"
+	"math"
+)
+
+/**
+ * RangeScanWithCompositeIndexPlanNode use composite index to get rows whose values of first key columns
+ * are equal to prefixVals and value of next key column is in [startRange, endRange].
+ * startRange and endRange may be nil or infinite value when the range is not bounded
+ */
+type RangeScanWithCompositeIndexPlanNode struct {
+	*AbstractPlanNode
+	tableOID   uint32
+	indexName  string
+	prefixVals []*types.Value
+	startRange *types.Value
+	endRange   *types.Value
+	stats_     *catalog.TableStatistics
+}
+
+func NewRangeScanWithCompositeIndexPlanNode(c *catalog.Catalog, schema *schema.Schema, tableOID uint32, indexName string, prefixVals []*types.Value, startRange *types.Value, endRange *types.Value) Plan {
+	tm := c.GetTableByOID(tableOID)
+	ret := &RangeScanWithCompositeIndexPlanNode{&AbstractPlanNode{schema, nil}, tableOID, indexName, prefixVals, startRange, endRange, tm.GetStatistics().GetDeepCopy()}
+	rows := ret.stats_.Rows()
+	if rows > 0 {
+		ret.stats_.Multiply(ret.estimateCount(c) / float64(rows))
+	}
+	return ret
+}
+
+// estimated row count is calculated with assuming that values of key columns are independent
+func (p *RangeScanWithCompositeIndexPlanNode) estimateCount(c *catalog.Catalog) float64 {
+	tm := c.GetTableByOID(p.tableOID)
+	stats := tm.GetStatistics()
+	rows := float64(stats.Rows())
+	if rows == 0 {
+		return 0
+	}
+	keyAttrs := tm.GetCompositeIndex(p.indexName).GetKeyAttrs()
+	ret := rows
+	for ii, val := range p.prefixVals {
+		ret *= math.Min(stats.EstimateCount(int32(keyAttrs[ii]), val.GetDeepCopy(), val.GetDeepCopy()), rows) / rows
+	}
+	if p.startRange != nil && p.endRange != nil && len(p.prefixVals) < len(keyAttrs) {
+		ret *= math.Min(stats.EstimateCount(int32(keyAttrs[len(p.prefixVals)]), p.startRange.GetDeepCopy(), p.endRange.GetDeepCopy()), rows) / rows
+	}
+	return ret
+}
+
+func (p *RangeScanWithCompositeIndexPlanNode) GetTableOID() uint32 {
+	return p.tableOID
+}
+
+func (p *RangeScanWithCompositeIndexPlanNode) GetIndexName() string {
+	return p.indexName
+}
+
+func (p *RangeScanWithCompositeIndexPlanNode) GetPrefixVals() []*types.Value {
+	return p.prefixVals
+}
+
+func (p *RangeScanWithCompositeIndexPlanNode) GetStartRange() *types.Value {
+	return p.startRange
+}
+
+func (p *RangeScanWithCompositeIndexPlanNode) GetEndRange() *types.Value {
+	return p.endRange
+}
+
+func (p *RangeScanWithCompositeIndexPlanNode) GetType() PlanType {
+	return CompositeIndexRangeScan
+}
+
+func (p *RangeScanWithCompositeIndexPlanNode) AccessRowCount(c *catalog.Catalog) uint64 {
+	return p.EmitRowCount(c)
+}
+
+func (p *RangeScanWithCompositeIndexPlanNode) EmitRowCount(c *catalog.Catalog) uint64 {
+	return uint64(math.Ceil(p.estimateCount(c)))
+}
+
+func (p *RangeScanWithCompositeIndexPlanNode) GetDebugStr() string {
+	outColNames := "["
+	for _, col := range p.OutputSchema().GetColumns() {
+		outColNames += col.GetColumnName() + ", "
+	}
+	prefixStr := "["
+	for _, val := range p.prefixVals {
+		prefixStr += val.ToString() + ", "
+	}
+	ret := "RangeScanWithCompositeIndexPlanNode " + outColNames + "] " + "index:" + p.indexName + " prefix:" + prefixStr + "]"
+	if p.startRange != nil && p.endRange != nil {
+		ret += " start:" + p.startRange.ToString() + " end:" + p.endRange.ToString()
+	}
+	return ret
+}
+
+func (p *RangeScanWithCompositeIndexPlanNode) GetStatistics() *catalog.TableStatistics {
+	return p.stats_
+}
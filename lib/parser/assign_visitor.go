@@ -2,6 +2,7 @@ package parser
 
 import (
 	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/opcode"
 	driver "github.com/pingcap/tidb/types/parser_driver"
 	"github.com/ryogrid/SamehadaDB/lib/types"
 )
@@ -20,6 +21,12 @@ func (v *AssignVisitor) Enter(in ast.Node) (ast.Node, bool) {
 	case *driver.ValueExpr:
 		v.Value_ = ValueExprToValue(node)
 		return in, true
+	case *ast.UnaryOperationExpr:
+		// negative number
+		if negated := negatedValueOf(operandOf(nil, node.V)); node.Op == opcode.Minus && negated != nil {
+			v.Value_ = negated
+			return in, true
+		}
 	default:
 	}
 
@@ -2,10 +2,15 @@ package parser
 
 import (
 	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/charset"
+	"github.com/pingcap/parser/format"
+	"github.com/pingcap/parser/mysql"
 	"github.com/pingcap/parser/opcode"
 	driver "github.com/pingcap/tidb/types/parser_driver"
 	"github.com/ryogrid/SamehadaDB/lib/execution/expression"
+	"github.com/ryogrid/SamehadaDB/lib/samehada/samehada_util"
 	"github.com/ryogrid/SamehadaDB/lib/types"
+	"strings"
 )
 
 type BinaryOpVisitor struct {
@@ -16,6 +21,10 @@ type BinaryOpVisitor struct {
 func (v *BinaryOpVisitor) Enter(in ast.Node) (ast.Node, bool) {
 	switch node := in.(type) {
 	case *ast.BinaryOperationExpr:
+		if arithType, ok := arithmeticOpTypeOf(node.Op); ok {
+			v.setCalc(arithmeticCalcOf(arithType, operandOf(v.QueryInfo_, node.L), operandOf(v.QueryInfo_, node.R)))
+			return in, true
+		}
 		l_visitor := &BinaryOpVisitor{v.QueryInfo_, new(BinaryOpExpression)}
 		node.L.Accept(l_visitor)
 		r_visitor := &BinaryOpVisitor{v.QueryInfo_, new(BinaryOpExpression)}
@@ -38,7 +47,7 @@ func (v *BinaryOpVisitor) Enter(in ast.Node) (ast.Node, bool) {
 				r_visitor.BinaryOpExpression_.LogicalOperationType_ == -1 {
 				v.BinaryOpExpression_.Right_ = r_visitor.BinaryOpExpression_.Left_
 			} else {
-				v.BinaryOpExpression_.Right_ = l_visitor.BinaryOpExpression_
+				v.BinaryOpExpression_.Right_ = r_visitor.BinaryOpExpression_
 			}
 		} else {
 			v.BinaryOpExpression_.Left_ = l_visitor.BinaryOpExpression_
@@ -58,14 +67,104 @@ func (v *BinaryOpVisitor) Enter(in ast.Node) (ast.Node, bool) {
 
 		null_val := types.NewNull()
 		v.BinaryOpExpression_.Left_ = cdv.ChildDatas_[0]
+		if colExpr, ok := node.Expr.(*ast.ColumnNameExpr); ok {
+			// keep table name of the column (ex: a.c IS NULL)
+			colname := colExpr.Name.String()
+			v.BinaryOpExpression_.Left_ = &colname
+		} else if operand := operandOf(v.QueryInfo_, node.Expr); isCalcOperand(operand) {
+			// ex: COALESCE(a, b) IS NULL
+			v.BinaryOpExpression_.Left_ = operand
+		}
 		v.BinaryOpExpression_.Right_ = &null_val
 		return in, true
+	case *ast.PatternLikeExpr:
+		v.BinaryOpExpression_.LogicalOperationType_ = -1
+		v.BinaryOpExpression_.ComparisonOperationType_ = expression.Like
+		if node.Not {
+			v.BinaryOpExpression_.ComparisonOperationType_ = expression.NotLike
+		}
+		v.BinaryOpExpression_.Left_ = operandOf(v.QueryInfo_, node.Expr)
+		v.BinaryOpExpression_.Right_ = operandOf(v.QueryInfo_, node.Pattern)
+		return in, true
+	case *ast.BetweenExpr:
+		// a BETWEEN 1 AND 10 is same as a >= 1 AND a <= 10. a NOT BETWEEN 1 AND 10 is same as a < 1 OR a > 10
+		target := operandOf(v.QueryInfo_, node.Expr)
+		lower := &BinaryOpExpression{-1, expression.GreaterThanOrEqual, target, operandOf(v.QueryInfo_, node.Left)}
+		upper := &BinaryOpExpression{-1, expression.LessThanOrEqual, copyOperand(target), operandOf(v.QueryInfo_, node.Right)}
+		if node.Not {
+			lower.ComparisonOperationType_ = expression.LessThan
+			upper.ComparisonOperationType_ = expression.GreaterThan
+			*v.BinaryOpExpression_ = BinaryOpExpression{expression.OR, -1, lower, upper}
+		} else {
+			*v.BinaryOpExpression_ = BinaryOpExpression{expression.AND, -1, lower, upper}
+		}
+		return in, true
+	case *ast.UnaryOperationExpr:
+		switch node.Op {
+		case opcode.Minus:
+			operand := operandOf(v.QueryInfo_, node.V)
+			if negated := negatedValueOf(operand); negated != nil {
+				v.setValue(negated)
+			} else {
+				// -a is same as 0 - a
+				zero := types.NewInteger(0)
+				v.setCalc(arithmeticCalcOf(expression.SUB, &zero, operand))
+			}
+			return in, true
+		case opcode.Plus:
+			// +a is same as a
+			return in, false
+		}
+	case *ast.FuncCallExpr:
+		v.setCalc(funcCalcOf(v.QueryInfo_, node))
+		return in, true
+	case *ast.FuncCastExpr:
+		v.setCalc(castCalcOf(v.QueryInfo_, node))
+		return in, true
+	case *ast.CaseExpr:
+		v.setCalc(caseCalcOf(v.QueryInfo_, node))
+		return in, true
 	case *ast.ColumnNameExpr:
 		v.BinaryOpExpression_.LogicalOperationType_ = -1
 		v.BinaryOpExpression_.ComparisonOperationType_ = -1
 		left_val := node.Name.String()
 		v.BinaryOpExpression_.Left_ = &left_val
 		return in, true
+	case *ast.AggregateFuncExpr:
+		// on HAVING clause
+		v.BinaryOpExpression_.LogicalOperationType_ = -1
+		v.BinaryOpExpression_.ComparisonOperationType_ = -1
+		v.BinaryOpExpression_.Left_ = aggSelectFieldOf(node)
+		return in, true
+	case *ast.SubqueryExpr:
+		// scalar subquery which is an operand of comparison
+		v.BinaryOpExpression_.LogicalOperationType_ = -1
+		v.BinaryOpExpression_.ComparisonOperationType_ = -1
+		v.BinaryOpExpression_.Left_ = subqueryOf(node, SCALAR_SUBQUERY)
+		return in, true
+	case *ast.ExistsSubqueryExpr:
+		v.BinaryOpExpression_.LogicalOperationType_ = -1
+		v.BinaryOpExpression_.ComparisonOperationType_ = -1
+		sq := subqueryOf(node.Sel.(*ast.SubqueryExpr), EXISTS_SUBQUERY)
+		sq.Not_ = node.Not
+		v.BinaryOpExpression_.Left_ = sq
+		return in, true
+	case *ast.PatternInExpr:
+		if node.Sel != nil {
+			v.BinaryOpExpression_.LogicalOperationType_ = -1
+			v.BinaryOpExpression_.ComparisonOperationType_ = -1
+			sq := subqueryOf(node.Sel.(*ast.SubqueryExpr), IN_SUBQUERY)
+			sq.Not_ = node.Not
+			if colExpr, ok := node.Expr.(*ast.ColumnNameExpr); ok {
+				colname := colExpr.Name.String()
+				sq.InColName_ = &colname
+			}
+			v.BinaryOpExpression_.Left_ = sq
+			return in, true
+		}
+		// a IN (1, 2) is same as a = 1 OR a = 2. a NOT IN (1, 2) is same as a != 1 AND a != 2
+		*v.BinaryOpExpression_ = *inListExpOf(v.QueryInfo_, node)
+		return in, true
 	case *driver.ValueExpr:
 		v.BinaryOpExpression_.LogicalOperationType_ = -1
 		v.BinaryOpExpression_.ComparisonOperationType_ = -1
@@ -77,6 +176,265 @@ func (v *BinaryOpVisitor) Enter(in ast.Node) (ast.Node, bool) {
 	return in, false
 }
 
+func inListExpOf(qi *QueryInfo, node *ast.PatternInExpr) *BinaryOpExpression {
+	compType := expression.Equal
+	logicType := expression.OR
+	if node.Not {
+		compType = expression.NotEqual
+		logicType = expression.AND
+	}
+	var ret *BinaryOpExpression = nil
+	for _, item := range node.List {
+		l_visitor := &BinaryOpVisitor{qi, new(BinaryOpExpression)}
+		node.Expr.Accept(l_visitor)
+		r_visitor := &BinaryOpVisitor{qi, new(BinaryOpExpression)}
+		item.Accept(r_visitor)
+		comp := &BinaryOpExpression{-1, compType, l_visitor.BinaryOpExpression_.Left_, r_visitor.BinaryOpExpression_.Left_}
+		if ret == nil {
+			ret = comp
+		} else {
+			ret = &BinaryOpExpression{logicType, -1, ret, comp}
+		}
+	}
+	return ret
+}
+
+// sets leaf of value (column name or constant) to v
+func (v *BinaryOpVisitor) setValue(val interface{}) {
+	v.BinaryOpExpression_.LogicalOperationType_ = -1
+	v.BinaryOpExpression_.ComparisonOperationType_ = -1
+	v.BinaryOpExpression_.Left_ = val
+}
+
+// calc is nil when the expression is not supported. optimizer returns error for it
+func (v *BinaryOpVisitor) setCalc(calc *CalcExpression) {
+	v.setValue(calc)
+}
+
+// returns operand of comparison or calculation which node represents. it is column name (*string), constant (*types.Value),
+// *CalcExpression, *SelectFieldExpression (aggregate function), *SubqueryExpression or *BinaryOpExpression (predicate).
+// nil of *CalcExpression is returned when node is not supported
+func operandOf(qi *QueryInfo, node ast.Node) interface{} {
+	visitor := &BinaryOpVisitor{qi, &BinaryOpExpression{-1, -1, nil, nil}}
+	node.Accept(visitor)
+	if visitor.BinaryOpExpression_.ComparisonOperationType_ == -1 &&
+		visitor.BinaryOpExpression_.LogicalOperationType_ == -1 {
+		if visitor.BinaryOpExpression_.Left_ == nil {
+			return (*CalcExpression)(nil)
+		}
+		return visitor.BinaryOpExpression_.Left_
+	}
+	return visitor.BinaryOpExpression_
+}
+
+// returns negated value when operand is numeric literal, otherwise nil (ex: -2 is parsed as unary minus and 2)
+func negatedValueOf(operand interface{}) *types.Value {
+	val, ok := operand.(*types.Value)
+	if !ok || val.IsNull() {
+		return nil
+	}
+	switch val.ValueType() {
+	case types.Integer:
+		return samehada_util.GetPonterOfValue(types.NewInteger(-val.ToInteger()))
+	case types.Float:
+		return samehada_util.GetPonterOfValue(types.NewFloat(-val.ToFloat()))
+	case types.BigInt:
+		return samehada_util.GetPonterOfValue(types.NewBigInt(-val.ToBigInt()))
+	case types.Double:
+		return samehada_util.GetPonterOfValue(types.NewDouble(-val.ToDouble()))
+	case types.Decimal:
+		return samehada_util.GetPonterOfValue(types.NewDecimal(val.ToDecimal().Neg()))
+	default:
+		return nil
+	}
+}
+
+// returns true when operand can be an operand or argument of CalcExpression
+func isCalcOperand(operand interface{}) bool {
+	switch casted := operand.(type) {
+	case *string, *types.Value:
+		return true
+	case *CalcExpression:
+		return casted != nil
+	case *SubqueryExpression:
+		return casted.SubqueryType_ == SCALAR_SUBQUERY
+	default:
+		return false
+	}
+}
+
+func copyOperand(operand interface{}) interface{} {
+	switch casted := operand.(type) {
+	case *string:
+		tmpStr := *casted
+		return &tmpStr
+	case *types.Value:
+		return casted.GetDeepCopy()
+	case *CalcExpression:
+		return casted.GetDeepCopy()
+	default:
+		// subquery is shared. it is named and planned once
+		return operand
+	}
+}
+
+func arithmeticOpTypeOf(opcode_ opcode.Op) (expression.ArithmeticOpType, bool) {
+	switch opcode_ {
+	case opcode.Plus:
+		return expression.ADD, true
+	case opcode.Minus:
+		return expression.SUB, true
+	case opcode.Mul:
+		return expression.MUL, true
+	case opcode.Div, opcode.IntDiv:
+		return expression.DIV, true
+	case opcode.Mod:
+		return expression.MOD, true
+	default:
+		return -1, false
+	}
+}
+
+func arithmeticCalcOf(arithType expression.ArithmeticOpType, left interface{}, right interface{}) *CalcExpression {
+	if !isCalcOperand(left) || !isCalcOperand(right) {
+		return nil
+	}
+	return &CalcExpression{arithType, -1, []interface{}{left, right}, types.Invalid}
+}
+
+// returns nil when the function is not supported or number of arguments is wrong
+func funcCalcOf(qi *QueryInfo, node *ast.FuncCallExpr) *CalcExpression {
+	var funcType expression.FuncType
+	minArgNum, maxArgNum := 1, 1
+	switch node.FnName.L {
+	case "lower", "lcase":
+		funcType = expression.LOWER
+	case "upper", "ucase":
+		funcType = expression.UPPER
+	case "length", "char_length", "character_length":
+		funcType = expression.LENGTH
+	case "substr", "substring":
+		funcType, minArgNum, maxArgNum = expression.SUBSTR, 2, 3
+	case "abs":
+		funcType = expression.ABS
+	case "round":
+		funcType, maxArgNum = expression.ROUND, 2
+	case "coalesce":
+		funcType, maxArgNum = expression.COALESCE, len(node.Args)
+	case "ifnull":
+		funcType, minArgNum, maxArgNum = expression.COALESCE, 2, 2
+	default:
+		return nil
+	}
+	if len(node.Args) < minArgNum || len(node.Args) > maxArgNum {
+		return nil
+	}
+	args := make([]interface{}, 0, len(node.Args))
+	for _, argNode := range node.Args {
+		arg := operandOf(qi, argNode)
+		if !isCalcOperand(arg) {
+			return nil
+		}
+		args = append(args, arg)
+	}
+	return &CalcExpression{-1, funcType, args, types.Invalid}
+}
+
+// CAST(a AS SIGNED), CAST(a AS DOUBLE), CAST(a AS CHAR), etc...
+func castCalcOf(qi *QueryInfo, node *ast.FuncCastExpr) *CalcExpression {
+	var castType types.TypeID
+	switch node.Tp.Tp {
+	case mysql.TypeTiny, mysql.TypeShort, mysql.TypeLong:
+		castType = types.Integer
+	case mysql.TypeLonglong:
+		// SIGNED and UNSIGNED
+		castType = types.BigInt
+	case mysql.TypeFloat:
+		castType = types.Float
+	case mysql.TypeDouble:
+		castType = types.Double
+	case mysql.TypeNewDecimal:
+		castType = types.Decimal
+	case mysql.TypeDate, mysql.TypeDatetime:
+		castType = types.Timestamp
+	case mysql.TypeVarchar, mysql.TypeVarString, mysql.TypeString:
+		if node.Tp.Charset == charset.CharsetBin {
+			// BINARY
+			castType = types.Blob
+		} else {
+			castType = types.Varchar
+		}
+	default:
+		return nil
+	}
+	arg := operandOf(qi, node.Expr)
+	if !isCalcOperand(arg) {
+		return nil
+	}
+	return &CalcExpression{-1, expression.CAST, []interface{}{arg}, castType}
+}
+
+// CASE a WHEN 1 THEN ... is converted to CASE WHEN a = 1 THEN ...
+func caseCalcOf(qi *QueryInfo, node *ast.CaseExpr) *CalcExpression {
+	var value interface{} = nil
+	if node.Value != nil {
+		value = operandOf(qi, node.Value)
+		if !isCalcOperand(value) {
+			return nil
+		}
+	}
+	args := make([]interface{}, 0, len(node.WhenClauses)*2+1)
+	for _, when := range node.WhenClauses {
+		var cond interface{}
+		if value != nil {
+			whenVal := operandOf(qi, when.Expr)
+			if !isCalcOperand(whenVal) {
+				return nil
+			}
+			cond = &BinaryOpExpression{-1, expression.Equal, copyOperand(value), whenVal}
+		} else {
+			cond = operandOf(qi, when.Expr)
+		}
+		if _, ok := cond.(*BinaryOpExpression); !ok {
+			return nil
+		}
+		result := operandOf(qi, when.Result)
+		if !isCalcOperand(result) {
+			return nil
+		}
+		args = append(args, cond, result)
+	}
+	if node.ElseClause != nil {
+		result := operandOf(qi, node.ElseClause)
+		if !isCalcOperand(result) {
+			return nil
+		}
+		args = append(args, result)
+	}
+	return &CalcExpression{-1, expression.CASE, args, types.Invalid}
+}
+
+// (((a))) => a
+func unwrapParentheses(node ast.ExprNode) ast.ExprNode {
+	for {
+		paren, ok := node.(*ast.ParenthesesExpr)
+		if !ok {
+			return node
+		}
+		node = paren.Expr
+	}
+}
+
+// returns name of output column of calculated value on SELECT clause (ex: "a+1", "upper(name)")
+func calcColNameOf(node ast.ExprNode) string {
+	var sb strings.Builder
+	flags := format.RestoreStringSingleQuotes | format.RestoreKeyWordLowercase | format.RestoreNameLowercase
+	if err := node.Restore(format.NewRestoreCtx(flags, &sb)); err != nil {
+		return ""
+	}
+	return sb.String()
+}
+
 func (v *BinaryOpVisitor) Leave(in ast.Node) (ast.Node, bool) {
 	return in, true
 }
@@ -2,28 +2,101 @@ package parser
 
 import (
 	"github.com/pingcap/parser/ast"
+	"github.com/ryogrid/SamehadaDB/lib/execution/plans"
 )
 
+// JoinVisitor collects tables and join conditions of FROM clause.
+// the tree of joins is traversed manually because tables on each side of outer join are needed
 type JoinVisitor struct {
 	QueryInfo_ *QueryInfo
 }
 
 func (v *JoinVisitor) Enter(in ast.Node) (ast.Node, bool) {
-	switch node := in.(type) {
+	if node, ok := in.(*ast.Join); ok {
+		v.visitJoin(node, false)
+	}
+	return in, true
+}
+
+func (v *JoinVisitor) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+// returns names of tables which are included in node.
+// isInOuterJoin is true when node is in one side of outer join
+func (v *JoinVisitor) visitResultSet(node ast.ResultSetNode, isInOuterJoin bool) []*string {
+	switch n := node.(type) {
+	case *ast.Join:
+		return v.visitJoin(n, isInOuterJoin)
+	case *ast.TableSource:
+		if sel, ok := n.Source.(*ast.SelectStmt); ok {
+			// derived table. it is referred with the alias like a table
+			alias := n.AsName.String()
+			sq := subqueryOf(&ast.SubqueryExpr{Query: sel}, DERIVED_TABLE)
+			sq.Name_ = &alias
+			v.QueryInfo_.DerivedTables_ = append(v.QueryInfo_.DerivedTables_, sq)
+			return []*string{&alias}
+		}
+		return v.visitResultSet(n.Source, isInOuterJoin)
 	case *ast.TableName:
-		tblname := node.Name.String()
+		tblname := n.Name.String()
 		v.QueryInfo_.JoinTables_ = append(v.QueryInfo_.JoinTables_, &tblname)
-		return in, true
-	case *ast.BinaryOperationExpr:
+		return []*string{&tblname}
+	default:
+		// UNION, etc... are not supported
+		return []*string{}
+	}
+}
+
+func (v *JoinVisitor) visitJoin(node *ast.Join, isInOuterJoin bool) []*string {
+	joinType := joinTypeOf(node)
+	isInOuterJoin_ := isInOuterJoin || joinType != plans.INNER_JOIN
+	leftTables := v.visitResultSet(node.Left, isInOuterJoin_)
+	if node.Right == nil {
+		return leftTables
+	}
+	rightTables := v.visitResultSet(node.Right, isInOuterJoin_)
+
+	var onExp *BinaryOpExpression = nil
+	if node.On != nil {
 		bv := &BinaryOpVisitor{v.QueryInfo_, new(BinaryOpExpression)}
-		node.Accept(bv)
-		v.QueryInfo_.OnExpressions_ = bv.BinaryOpExpression_
-		return in, true
+		node.On.Expr.Accept(bv)
+		onExp = bv.BinaryOpExpression_
+	}
+
+	if joinType == plans.INNER_JOIN && !isInOuterJoin {
+		// conditions of inner joins are merged because they can be evaluated in any order
+		if onExp != nil {
+			if isEmptyBinaryOpExp(v.QueryInfo_.OnExpressions_) {
+				v.QueryInfo_.OnExpressions_ = onExp
+			} else {
+				v.QueryInfo_.OnExpressions_ = v.QueryInfo_.OnExpressions_.AppendBinaryOpExpWithAnd(onExp)
+			}
+		}
+	} else if joinType != plans.INNER_JOIN || onExp != nil {
+		if onExp == nil {
+			onExp = new(BinaryOpExpression)
+		}
+		v.QueryInfo_.Joins_ = append(v.QueryInfo_.Joins_, &JoinExpression{joinType, leftTables, rightTables, onExp})
+	}
+
+	return append(append([]*string{}, leftTables...), rightTables...)
+}
+
+func joinTypeOf(node *ast.Join) plans.JoinType {
+	switch {
+	case node.StraightJoin:
+		// FULL OUTER JOIN is rewritten to STRAIGHT_JOIN (see rewriteFullJoin)
+		return plans.FULL_OUTER_JOIN
+	case node.Tp == ast.LeftJoin:
+		return plans.LEFT_OUTER_JOIN
+	case node.Tp == ast.RightJoin:
+		return plans.RIGHT_OUTER_JOIN
 	default:
+		return plans.INNER_JOIN
 	}
-	return in, false
 }
 
-func (v *JoinVisitor) Leave(in ast.Node) (ast.Node, bool) {
-	return in, true
+func isEmptyBinaryOpExp(exp *BinaryOpExpression) bool {
+	return exp == nil || (exp.Left_ == nil && exp.Right_ == nil)
 }
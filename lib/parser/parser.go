@@ -1,11 +1,14 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"github.com/pingcap/parser"
 	"github.com/pingcap/parser/ast"
 	_ "github.com/pingcap/tidb/types/parser_driver"
 	"github.com/ryogrid/SamehadaDB/lib/types"
+	"regexp"
+	"strings"
 )
 
 type QueryInfo struct {
@@ -13,18 +16,119 @@ type QueryInfo struct {
 	SelectFields_        []*SelectFieldExpression // SELECT
 	SetExpressions_      []*SetExpression         // UPDATE
 	NewTable_            *string                  // CREATE TABLE
-	ColDefExpressions_   []*ColDefExpression      // CREATE TABLE
-	IndexDefExpressions_ []*IndexDefExpression    // CREATE TABLE
+	ColDefExpressions_   []*ColDefExpression      // CREATE TABLE, ALTER TABLE (ADD COLUMN)
+	IndexDefExpressions_ []*IndexDefExpression    // CREATE TABLE, CREATE INDEX, DROP INDEX
+	CheckExprStrs_       []*string                // CREATE TABLE (table constraints)
+	DropColumns_         []*string                // ALTER TABLE (DROP COLUMN)
 	TargetCols_          []*string                // INSERT
-	Values_              []*types.Value           // INSERT
+	Values_              []*types.Value           // INSERT (values of all rows are stored in order)
+	ValueRowLens_        []int                    // INSERT (count of values of each row on VALUES clause)
+	SelectQuery_         *QueryInfo               // INSERT (INSERT ... SELECT)
+	ReturningFields_     []*SelectFieldExpression // INSERT, UPDATE, DELETE (RETURNING clause. nil when it is not specified)
 	OnExpressions_       *BinaryOpExpression      // SELECT (with JOIN)
-	JoinTables_          []*string                // SELECT
+	Joins_               []*JoinExpression        // SELECT (with LEFT/RIGHT/FULL OUTER JOIN)
+	JoinTables_          []*string                // SELECT, CREATE INDEX, DROP INDEX, DROP TABLE, TRUNCATE, ALTER TABLE
+	DerivedTables_       []*SubqueryExpression    // SELECT (subquery on FROM clause)
 	WhereExpression_     *BinaryOpExpression      // SELECT, UPDATE, DELETE
 	LimitNum_            int32                    // SELECT
 	OffsetNum_           int32                    // SELECT
 	OrderByExpressions_  []*OrderByExpression     // SELECT
+	GroupByCols_         []*string                // SELECT
+	HavingExpression_    *BinaryOpExpression      // SELECT
 }
 
+// GetDeepCopy returns a copy which can be modified without affecting qi.
+// constant values and predicates are copied. names of tables and columns are shared
+// because they are not modified after parsing.
+func (qi *QueryInfo) GetDeepCopy() *QueryInfo {
+	ret := *qi
+	ret.SelectFields_ = make([]*SelectFieldExpression, len(qi.SelectFields_))
+	for idx, sfield := range qi.SelectFields_ {
+		ret.SelectFields_[idx] = sfield.GetDeepCopy()
+	}
+	ret.SetExpressions_ = make([]*SetExpression, len(qi.SetExpressions_))
+	for idx, setExp := range qi.SetExpressions_ {
+		ret.SetExpressions_[idx] = &SetExpression{setExp.ColName_, setExp.UpdateValue_.GetDeepCopy()}
+	}
+	ret.ColDefExpressions_ = append([]*ColDefExpression{}, qi.ColDefExpressions_...)
+	ret.IndexDefExpressions_ = append([]*IndexDefExpression{}, qi.IndexDefExpressions_...)
+	ret.CheckExprStrs_ = append([]*string{}, qi.CheckExprStrs_...)
+	ret.DropColumns_ = append([]*string{}, qi.DropColumns_...)
+	ret.TargetCols_ = append([]*string{}, qi.TargetCols_...)
+	ret.Values_ = make([]*types.Value, len(qi.Values_))
+	for idx, val := range qi.Values_ {
+		ret.Values_[idx] = val.GetDeepCopy()
+	}
+	ret.ValueRowLens_ = append([]int{}, qi.ValueRowLens_...)
+	if qi.SelectQuery_ != nil {
+		ret.SelectQuery_ = qi.SelectQuery_.GetDeepCopy()
+	}
+	if qi.ReturningFields_ != nil {
+		ret.ReturningFields_ = make([]*SelectFieldExpression, len(qi.ReturningFields_))
+		for idx, sfield := range qi.ReturningFields_ {
+			ret.ReturningFields_[idx] = sfield.GetDeepCopy()
+		}
+	}
+	if qi.OnExpressions_ != nil {
+		ret.OnExpressions_ = qi.OnExpressions_.GetDeepCopy()
+	}
+	ret.Joins_ = make([]*JoinExpression, len(qi.Joins_))
+	for idx, join := range qi.Joins_ {
+		ret.Joins_[idx] = join.GetDeepCopy()
+	}
+	ret.JoinTables_ = append([]*string{}, qi.JoinTables_...)
+	ret.DerivedTables_ = make([]*SubqueryExpression, len(qi.DerivedTables_))
+	for idx, derived := range qi.DerivedTables_ {
+		ret.DerivedTables_[idx] = derived.GetDeepCopy()
+	}
+	if qi.WhereExpression_ != nil {
+		ret.WhereExpression_ = qi.WhereExpression_.GetDeepCopy()
+	}
+	ret.OrderByExpressions_ = append([]*OrderByExpression{}, qi.OrderByExpressions_...)
+	ret.GroupByCols_ = append([]*string{}, qi.GroupByCols_...)
+	if qi.HavingExpression_ != nil {
+		ret.HavingExpression_ = qi.HavingExpression_.GetDeepCopy()
+	}
+	return &ret
+}
+
+// Subqueries returns subqueries which are directly nested in qi (on WHERE, SELECT and FROM clause).
+// subqueries in them are not included
+func (qi *QueryInfo) Subqueries() []*SubqueryExpression {
+	ret := make([]*SubqueryExpression, 0)
+	var traverse func(exp interface{})
+	traverse = func(exp interface{}) {
+		switch casted := exp.(type) {
+		case *BinaryOpExpression:
+			if casted != nil {
+				traverse(casted.Left_)
+				traverse(casted.Right_)
+			}
+		case *SubqueryExpression:
+			ret = append(ret, casted)
+		case *CalcExpression:
+			if casted != nil {
+				for _, arg := range casted.Args_ {
+					traverse(arg)
+				}
+			}
+		}
+	}
+	traverse(qi.WhereExpression_)
+	for _, sfield := range qi.SelectFields_ {
+		if sfield != nil && sfield.Subquery_ != nil {
+			ret = append(ret, sfield.Subquery_)
+		}
+		if sfield != nil {
+			traverse(sfield.Calc_)
+		}
+	}
+	return append(ret, qi.DerivedTables_...)
+}
+
+// returned when SQL string has no statement (only comments, etc...)
+var EmptyQueryErr = errors.New("query is empty")
+
 func extractInfoFromAST(rootNode *ast.StmtNode) *QueryInfo {
 	v := NewRootSQLVisitor()
 	(*rootNode).Accept(v)
@@ -38,18 +142,154 @@ func parse(sqlStr *string) (*ast.StmtNode, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(stmtNodes) == 0 {
+		return nil, EmptyQueryErr
+	}
 
 	return &stmtNodes[0], nil
 }
 
+var createIndexStmtRegexp = regexp.MustCompile(`(?is)^\s*CREATE\s+(UNIQUE\s+)?INDEX\s`)
+var usingSkipListRegexp = regexp.MustCompile(`(?i)\s+USING\s+(UNIQUE\s+)?SKIPLIST\b`)
+var createKeywordRegexp = regexp.MustCompile(`(?i)^\s*CREATE\s+`)
+
+// USING {SKIPLIST|UNIQUE SKIPLIST} of CREATE INDEX can't be parsed with TiDB parser.
+// so, the clause is removed (skip list is default) and UNIQUE is moved to front of INDEX
+func rewriteUsingSkipList(sqlStr string) string {
+	if !createIndexStmtRegexp.MatchString(sqlStr) {
+		return sqlStr
+	}
+	isUnique := false
+	ret := usingSkipListRegexp.ReplaceAllStringFunc(sqlStr, func(matched string) string {
+		isUnique = usingSkipListRegexp.FindStringSubmatch(matched)[1] != ""
+		return ""
+	})
+	if isUnique && createIndexStmtRegexp.FindStringSubmatch(ret)[1] == "" {
+		ret = createKeywordRegexp.ReplaceAllString(ret, "${0}UNIQUE ")
+	}
+	return ret
+}
+
+var orderByKeywordRegexp = regexp.MustCompile(`(?i)\bORDER\s+BY\b`)
+var nullsOrderRegexp = regexp.MustCompile(`(?i)\s+NULLS\s+(FIRST|LAST)\b`)
+
+// NULLS {FIRST|LAST} of ORDER BY clause can't be parsed with TiDB parser.
+// so, the clause is removed and returned map has whether NULL is placed first
+// for each index of item on ORDER BY clause
+func rewriteNullsOrder(sqlStr string) (string, map[int]bool) {
+	locs := orderByKeywordRegexp.FindAllStringIndex(sqlStr, -1)
+	if len(locs) == 0 {
+		return sqlStr, nil
+	}
+	orderByEnd := locs[len(locs)-1][1]
+	ret := sqlStr[:orderByEnd]
+	rest := sqlStr[orderByEnd:]
+	nullsFirsts := make(map[int]bool)
+	itemIdx := 0
+	prev := 0
+	for _, loc := range nullsOrderRegexp.FindAllStringSubmatchIndex(rest, -1) {
+		// items are separated by comma
+		itemIdx += strings.Count(rest[prev:loc[0]], ",")
+		nullsFirsts[itemIdx] = strings.ToUpper(rest[loc[2]:loc[3]]) == "FIRST"
+		ret += rest[prev:loc[0]]
+		prev = loc[1]
+	}
+	return ret + rest[prev:], nullsFirsts
+}
+
+var fullJoinRegexp = regexp.MustCompile(`(?i)\bFULL\s+(OUTER\s+)?JOIN\b`)
+
+// FULL [OUTER] JOIN can't be parsed with TiDB parser (FULL is regarded as alias of table).
+// so, it is replaced with STRAIGHT_JOIN which can have ON clause and is not used in SamehadaDB.
+// JoinVisitor regards STRAIGHT_JOIN as FULL OUTER JOIN
+func rewriteFullJoin(sqlStr string) string {
+	return fullJoinRegexp.ReplaceAllString(sqlStr, "STRAIGHT_JOIN")
+}
+
+var dmlStmtRegexp = regexp.MustCompile(`(?is)^\s*(INSERT|UPDATE|DELETE)\s`)
+var returningKeywordRegexp = regexp.MustCompile(`(?i)\bRETURNING\b`)
+
+// returned when other than columns of the table is specified on RETURNING clause
+var UnsupportedReturningErr = errors.New("only columns of the table can be specified on RETURNING clause")
+
+// RETURNING clause of INSERT, UPDATE and DELETE can't be parsed with TiDB parser.
+// so, the clause is removed and returned with list of items on it (nil when the clause is not specified)
+func rewriteReturning(sqlStr string) (string, *string) {
+	if !dmlStmtRegexp.MatchString(sqlStr) {
+		return sqlStr, nil
+	}
+	// the clause is placed at the end of the statement
+	locs := returningKeywordRegexp.FindAllStringIndex(sqlStr, -1)
+	for ii := len(locs) - 1; ii >= 0; ii-- {
+		if isInQuotes(sqlStr, locs[ii][0]) {
+			continue
+		}
+		items := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(sqlStr[locs[ii][1]:]), ";"))
+		return sqlStr[:locs[ii][0]], &items
+	}
+	return sqlStr, nil
+}
+
+// returns true when pos of sqlStr is in string literal or quoted identifier
+func isInQuotes(sqlStr string, pos int) bool {
+	var quote byte = 0
+	for idx := 0; idx < pos; idx++ {
+		c := sqlStr[idx]
+		if quote != 0 {
+			if c == '\\' && quote == '\'' {
+				// escaped character
+				idx++
+			} else if c == quote {
+				quote = 0
+			}
+		} else if c == '\'' || c == '"' || c == '`' {
+			quote = c
+		}
+	}
+	return quote != 0
+}
+
+// items of RETURNING clause are parsed as SELECT clause
+func returningFieldsOf(items string) ([]*SelectFieldExpression, error) {
+	selectStr := "SELECT " + items
+	astNode, err := parse(&selectStr)
+	if err != nil {
+		return nil, err
+	}
+	fields := extractInfoFromAST(astNode).SelectFields_
+	for _, sfield := range fields {
+		if sfield == nil || sfield.IsAgg_ || sfield.Subquery_ != nil || sfield.Calc_ != nil || sfield.ColName_ == nil {
+			return nil, UnsupportedReturningErr
+		}
+	}
+	return fields, nil
+}
+
 func ProcessSQLStr(sqlStr *string) (*QueryInfo, error) {
-	astNode, err := parse(sqlStr)
+	sqlStr_, returningItems := rewriteReturning(*sqlStr)
+	sqlStr_ = rewriteUsingSkipList(sqlStr_)
+	sqlStr_ = rewriteFullJoin(sqlStr_)
+	sqlStr_, nullsFirsts := rewriteNullsOrder(sqlStr_)
+	astNode, err := parse(&sqlStr_)
 	if err != nil {
 		fmt.Printf("parse error: %v\n", err.Error())
 		return nil, err
 	}
 
-	return extractInfoFromAST(astNode), nil
+	qi := extractInfoFromAST(astNode)
+	if returningItems != nil {
+		qi.ReturningFields_, err = returningFieldsOf(*returningItems)
+		if err != nil {
+			fmt.Printf("parse error: %v\n", err.Error())
+			return nil, err
+		}
+	}
+	for idx, nullsFirst := range nullsFirsts {
+		if idx < len(qi.OrderByExpressions_) {
+			qi.OrderByExpressions_[idx].NullsFirst_ = nullsFirst
+		}
+	}
+	return qi, nil
 }
 
 // for utity func on develop phase
@@ -21,6 +21,8 @@ const (
 	IsNull
 	ColumnName
 	Constant
+	// EXISTS or IN predicate of subquery. Left_ is *SubqueryExpression
+	Subquery
 )
 
 type BinaryOpExpression struct {
@@ -48,6 +50,8 @@ func (expr *BinaryOpExpression) GetType() BinaryOpExpType {
 		}
 	} else if expr.LogicalOperationType_ != -1 {
 		return Logical
+	} else if _, ok := expr.Left_.(*SubqueryExpression); ok {
+		return Subquery
 	} else {
 		panic("BinaryOpExpression tree is broken")
 	}
@@ -64,6 +68,19 @@ func (expr *BinaryOpExpression) TouchedColumns() mapset.Set[string] {
 		if samehada_util.IsColumnName(expr.Right_) {
 			ret.Add(strings.ToLower(*expr.Right_.(*string)))
 		}
+		// aggregate function on HAVING clause
+		if sfield, ok := expr.Left_.(*SelectFieldExpression); ok && sfield != nil {
+			ret = ret.Union(sfield.TouchedColumns())
+		}
+		if sfield, ok := expr.Right_.(*SelectFieldExpression); ok && sfield != nil {
+			ret = ret.Union(sfield.TouchedColumns())
+		}
+		if calc, ok := expr.Left_.(*CalcExpression); ok {
+			ret = ret.Union(calc.TouchedColumns())
+		}
+		if calc, ok := expr.Right_.(*CalcExpression); ok {
+			ret = ret.Union(calc.TouchedColumns())
+		}
 	case Logical:
 		ret = ret.Union(expr.Left_.(*BinaryOpExpression).TouchedColumns())
 		ret = ret.Union(expr.Right_.(*BinaryOpExpression).TouchedColumns())
@@ -71,6 +88,14 @@ func (expr *BinaryOpExpression) TouchedColumns() mapset.Set[string] {
 		if samehada_util.IsColumnName(expr.Left_) {
 			ret.Add(strings.ToLower(*expr.Left_.(*string)))
 		}
+		if calc, ok := expr.Left_.(*CalcExpression); ok {
+			ret = ret.Union(calc.TouchedColumns())
+		}
+	case Subquery:
+		// columns in the subquery are not included
+		if sq := expr.Left_.(*SubqueryExpression); sq.InColName_ != nil {
+			ret.Add(strings.ToLower(*sq.InColName_))
+		}
 	default:
 		panic("BinaryOpExpression tree is broken")
 	}
@@ -92,6 +117,12 @@ func (expr *BinaryOpExpression) GetDeepCopy() *BinaryOpExpression {
 			ret.Left_ = expr.Left_.(*types.Value).GetDeepCopy()
 		case *BinaryOpExpression:
 			ret.Left_ = expr.Left_.(*BinaryOpExpression).GetDeepCopy()
+		case *SelectFieldExpression:
+			ret.Left_ = expr.Left_.(*SelectFieldExpression).GetDeepCopy()
+		case *SubqueryExpression:
+			ret.Left_ = expr.Left_.(*SubqueryExpression).GetDeepCopy()
+		case *CalcExpression:
+			ret.Left_ = expr.Left_.(*CalcExpression).GetDeepCopy()
 		default:
 			panic("BinaryOpExpression tree is broken")
 		}
@@ -107,6 +138,12 @@ func (expr *BinaryOpExpression) GetDeepCopy() *BinaryOpExpression {
 			ret.Right_ = expr.Right_.(*types.Value).GetDeepCopy()
 		case *BinaryOpExpression:
 			ret.Right_ = expr.Right_.(*BinaryOpExpression).GetDeepCopy()
+		case *SelectFieldExpression:
+			ret.Right_ = expr.Right_.(*SelectFieldExpression).GetDeepCopy()
+		case *SubqueryExpression:
+			ret.Right_ = expr.Right_.(*SubqueryExpression).GetDeepCopy()
+		case *CalcExpression:
+			ret.Right_ = expr.Right_.(*CalcExpression).GetDeepCopy()
 		default:
 			panic("BinaryOpExpression tree is broken")
 		}
@@ -118,6 +155,119 @@ func (expr *BinaryOpExpression) AppendBinaryOpExpWithAnd(expr2 *BinaryOpExpressi
 	return &BinaryOpExpression{expression.AND, -1, expr, expr2}
 }
 
+// JoinExpression is a LEFT, RIGHT or FULL OUTER JOIN in FROM clause, or an INNER JOIN which is nested
+// in one side of them. ON clause of the inner join can't be merged to WHERE clause because it should be
+// evaluated before the outer join. LeftTables_ and RightTables_ are all tables on each side of the join
+type JoinExpression struct {
+	JoinType_     plans.JoinType
+	LeftTables_   []*string
+	RightTables_  []*string
+	OnExpression_ *BinaryOpExpression
+}
+
+func (expr *JoinExpression) GetDeepCopy() *JoinExpression {
+	ret := *expr
+	ret.LeftTables_ = append([]*string{}, expr.LeftTables_...)
+	ret.RightTables_ = append([]*string{}, expr.RightTables_...)
+	ret.OnExpression_ = expr.OnExpression_.GetDeepCopy()
+	return &ret
+}
+
+type SubqueryType int
+
+const (
+	// (SELECT ...) which is used as a value. it is placed on SELECT clause or compared on WHERE clause
+	SCALAR_SUBQUERY SubqueryType = iota
+	// [NOT] EXISTS (SELECT ...)
+	EXISTS_SUBQUERY
+	// column [NOT] IN (SELECT ...)
+	IN_SUBQUERY
+	// FROM (SELECT ...) AS alias
+	DERIVED_TABLE
+)
+
+// SubqueryExpression is a SELECT statement nested in other query. EXISTS_SUBQUERY and IN_SUBQUERY are
+// predicates on WHERE clause, SCALAR_SUBQUERY is an operand of comparison on WHERE clause or on SELECT clause
+// and DERIVED_TABLE is on QueryInfo.DerivedTables_
+type SubqueryExpression struct {
+	SubqueryType_ SubqueryType
+	// NOT EXISTS and NOT IN
+	Not_ bool
+	// column on left side of IN
+	InColName_ *string
+	Query_     *QueryInfo
+	// alias of derived table. name of other subquery is set with RewriteQueryInfo of optimizer
+	// and columns of the subquery are referred as "<name>.<column name>" on planning
+	Name_ *string
+}
+
+// query of subquery is deep copied. names are shared because they are not modified after rewriting
+func (sq *SubqueryExpression) GetDeepCopy() *SubqueryExpression {
+	ret := *sq
+	ret.Query_ = sq.Query_.GetDeepCopy()
+	return &ret
+}
+
+// CalcExpression is an arithmetic operation or a scalar function call (ex: a + 1, UPPER(name), CASE ...).
+// it is placed as an operand of comparison or on SELECT clause. nil means not supported expression
+type CalcExpression struct {
+	// -1 when this is function call
+	ArithmeticOpType_ expression.ArithmeticOpType
+	// -1 when this is arithmetic operation
+	FuncType_ expression.FuncType
+	// operands or arguments. each item is column name (*string), constant (*types.Value), *CalcExpression,
+	// scalar subquery (*SubqueryExpression) or condition of CASE (*BinaryOpExpression).
+	// when both of ArithmeticOpType_ and FuncType_ are -1, Args_ has one item and it is the value (ex: SELECT 1)
+	Args_ []interface{}
+	// target type of CAST
+	CastType_ types.TypeID
+}
+
+func (calc *CalcExpression) TouchedColumns() mapset.Set[string] {
+	// note: alphabets on column and table name is stored in lowercase
+	ret := mapset.NewSet[string]()
+	if calc == nil {
+		return ret
+	}
+	for _, arg := range calc.Args_ {
+		switch casted := arg.(type) {
+		case *string:
+			ret.Add(strings.ToLower(*casted))
+		case *CalcExpression:
+			ret = ret.Union(casted.TouchedColumns())
+		case *BinaryOpExpression:
+			ret = ret.Union(casted.TouchedColumns())
+		}
+	}
+	return ret
+}
+
+func (calc *CalcExpression) GetDeepCopy() *CalcExpression {
+	if calc == nil {
+		return nil
+	}
+	ret := *calc
+	ret.Args_ = make([]interface{}, len(calc.Args_))
+	for idx, arg := range calc.Args_ {
+		switch casted := arg.(type) {
+		case *string:
+			tmpStr := *casted
+			ret.Args_[idx] = &tmpStr
+		case *types.Value:
+			ret.Args_[idx] = casted.GetDeepCopy()
+		case *CalcExpression:
+			ret.Args_[idx] = casted.GetDeepCopy()
+		case *BinaryOpExpression:
+			ret.Args_[idx] = casted.GetDeepCopy()
+		case *SubqueryExpression:
+			ret.Args_[idx] = casted.GetDeepCopy()
+		default:
+			panic("CalcExpression is broken")
+		}
+	}
+	return &ret
+}
+
 type SetExpression struct {
 	ColName_     *string
 	UpdateValue_ *types.Value
@@ -126,11 +276,23 @@ type SetExpression struct {
 type ColDefExpression struct {
 	ColName_ *string
 	ColType_ *types.TypeID
+	// PRIMARY KEY column has both of NOT NULL and UNIQUE constraints
+	IsNotNull_ bool
+	IsUnique_  bool
+	// TEXT, BLOB and JSON column. large values are expected to be stored
+	IsLargeObject_ bool
+	// declared length of VARCHAR(n), CHAR(n), VARBINARY(n) and BINARY(n). 0 when it is not declared
+	Length_ uint32
+	// SQL texts of CHECK constraints declared at the column definition
+	CheckExprStrs_ []*string
 }
 
 type IndexDefExpression struct {
 	IndexName_ *string
 	Colnames_  []*string
+	// CREATE INDEX and CREATE TABLE. INDEX_KIND_INVALID when specified kind is not supported.
+	// at CREATE TABLE, INDEX_KIND_UNIQ_SKIP_LIST means PRIMARY KEY or UNIQUE constraint
+	IndexKind_ index_constants.IndexKind
 }
 
 type SelectFieldExpression struct {
@@ -138,13 +300,26 @@ type SelectFieldExpression struct {
 	AggType_   plans.AggregationType
 	TableName_ *string // if specified
 	ColName_   *string
+	// AS clause. it is used for column name of derived table
+	Alias_ *string
+	// scalar subquery. TableName_ and ColName_ are set on rewriting (see SubqueryExpression)
+	Subquery_ *SubqueryExpression
+	// calculated value (ex: a + 1). ColName_ is nil and Alias_ is text of the expression when AS clause
+	// is not specified. both of Calc_ and ColName_ are nil when the expression is not supported
+	Calc_ *CalcExpression
 }
 
 func (sf *SelectFieldExpression) TouchedColumns() mapset.Set[string] {
 	// note: alphabets on table and column name is stored in lowercase
 
-	// TODO: (SDB) need to support aggregation function
 	ret := mapset.NewSet[string]()
+	if sf.Calc_ != nil {
+		return sf.Calc_.TouchedColumns()
+	}
+	if sf.ColName_ == nil || *sf.ColName_ == "*" {
+		// COUNT(*) touches no column
+		return ret
+	}
 	colName := *sf.ColName_
 	if sf.TableName_ != nil {
 		colName = *sf.TableName_ + "." + *sf.ColName_
@@ -153,9 +328,25 @@ func (sf *SelectFieldExpression) TouchedColumns() mapset.Set[string] {
 	return ret
 }
 
+// table name and column name are shared with sf because they are not modified after parsing
+func (sf *SelectFieldExpression) GetDeepCopy() *SelectFieldExpression {
+	if sf == nil {
+		return nil
+	}
+	ret := *sf
+	if sf.Subquery_ != nil {
+		ret.Subquery_ = sf.Subquery_.GetDeepCopy()
+	}
+	ret.Calc_ = sf.Calc_.GetDeepCopy()
+	return &ret
+}
+
 type OrderByExpression struct {
-	IsDesc_  bool
-	ColName_ *string
+	IsDesc_     bool
+	NullsFirst_ bool
+	ColName_    *string
+	// aggregate function (ex: ORDER BY COUNT(*)). ColName_ is nil in this case
+	AggField_ *SelectFieldExpression
 }
 
 // attiontion: this func can be used only for predicate of SelectionPlanNode
@@ -165,11 +356,35 @@ func ConvBinaryOpExpReafToExpIFOne(sc *schema.Schema, convSrc interface{}) expre
 		return expression.NewColumnValue(0, sc.GetColIndex(*convSrc.(*string)), sc.GetColumn(sc.GetColIndex(*convSrc.(*string))).GetType())
 	case *types.Value:
 		return expression.NewConstantValue(*convSrc.(*types.Value), convSrc.(*types.Value).ValueType())
+	case *CalcExpression:
+		return ConvCalcExpToExpIFOne(sc, convSrc.(*CalcExpression))
 	default:
 		panic("BinaryOpExpression tree is broken")
 	}
 }
 
+// attiontion: subqueries in convSrc should be replaced with columns which have their results before
+func ConvCalcExpToExpIFOne(sc *schema.Schema, convSrc *CalcExpression) expression.Expression {
+	args := make([]expression.Expression, 0, len(convSrc.Args_))
+	for _, arg := range convSrc.Args_ {
+		if cond, ok := arg.(*BinaryOpExpression); ok {
+			args = append(args, ConvParsedBinaryOpExprToExpIFOne(sc, cond))
+		} else {
+			args = append(args, ConvBinaryOpExpReafToExpIFOne(sc, arg))
+		}
+	}
+	switch {
+	case convSrc.ArithmeticOpType_ != -1:
+		return expression.NewArithmeticOp(args[0], args[1], convSrc.ArithmeticOpType_)
+	case convSrc.FuncType_ == expression.CAST:
+		return expression.NewCastFunction(args[0], convSrc.CastType_)
+	case convSrc.FuncType_ != -1:
+		return expression.NewFunction(convSrc.FuncType_, args)
+	default:
+		return args[0]
+	}
+}
+
 // attiontion: this func can be used only for predicate of SelectionPlanNode
 func ConvParsedBinaryOpExprToExpIFOne(sc *schema.Schema, convSrc *BinaryOpExpression) expression.Expression {
 	switch convSrc.GetType() {
@@ -183,9 +398,8 @@ func ConvParsedBinaryOpExprToExpIFOne(sc *schema.Schema, convSrc *BinaryOpExpres
 
 		return expression.NewComparison(leftExp, rightExp, convSrc.ComparisonOperationType_, types.Boolean)
 	case IsNull: // node of is null operation
-		tmpColIdx := sc.GetColIndex(*convSrc.Left_.(*string))
 		return expression.NewComparison(
-			expression.NewColumnValue(0, tmpColIdx, sc.GetColumn(tmpColIdx).GetType()),
+			ConvBinaryOpExpReafToExpIFOne(sc, convSrc.Left_),
 			expression.NewConstantValue(*convSrc.Right_.(*types.Value).GetDeepCopy(), convSrc.Right_.(*types.Value).ValueType()),
 			convSrc.ComparisonOperationType_,
 			types.Boolean)
@@ -212,7 +426,9 @@ func ConvParsedSelectionExprToSchema(c *catalog.Catalog, convSrc []*SelectFieldE
 			indexHeaderPageID = sc.GetColumn(colIdx).IndexHeaderPageId()
 		}
 
-		outColDefs = append(outColDefs, column.NewColumn(*tableName+"."+*colName, colType, hasIndex, indexKind, indexHeaderPageID, nil))
+		outCol := column.NewColumn(*tableName+"."+*colName, colType, hasIndex, indexKind, indexHeaderPageID, nil)
+		outCol.SetIsNotNull(sc.GetColumn(colIdx).IsNotNull())
+		outColDefs = append(outColDefs, outCol)
 	}
 	return schema.NewSchema(outColDefs)
 }
@@ -1,8 +1,12 @@
 package parser
 
 import (
+	"errors"
 	"github.com/ryogrid/SamehadaDB/lib/execution/expression"
 	"github.com/ryogrid/SamehadaDB/lib/execution/plans"
+	"github.com/ryogrid/SamehadaDB/lib/storage/index/index_constants"
+	"github.com/ryogrid/SamehadaDB/lib/storage/table/column"
+	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
 	testingpkg "github.com/ryogrid/SamehadaDB/lib/testing/testing_assert"
 	"github.com/ryogrid/SamehadaDB/lib/types"
 	"testing"
@@ -205,6 +209,41 @@ func TestAggFuncSelectQuery(t *testing.T) {
 	testingpkg.SimpleAssert(t, queryInfo.WhereExpression_.Right_.(*types.Value).ToInteger() == 10)
 }
 
+func TestGroupByHavingSelectQuery(t *testing.T) {
+	sqlStr := "SELECT t.b, avg(c), count(distinct d) FROM t WHERE a = 10 GROUP BY t.b HAVING count(*) > 2 AND t.b < 100;"
+	queryInfo, _ := ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, *queryInfo.QueryType_ == SELECT)
+
+	testingpkg.SimpleAssert(t, queryInfo.SelectFields_[0].IsAgg_ == false)
+	testingpkg.SimpleAssert(t, *queryInfo.SelectFields_[0].TableName_ == "t")
+	testingpkg.SimpleAssert(t, queryInfo.SelectFields_[1].IsAgg_ == true)
+	testingpkg.SimpleAssert(t, queryInfo.SelectFields_[1].AggType_ == plans.AVG_AGGREGATE)
+	testingpkg.SimpleAssert(t, *queryInfo.SelectFields_[1].ColName_ == "c")
+	testingpkg.SimpleAssert(t, queryInfo.SelectFields_[2].IsAgg_ == true)
+	testingpkg.SimpleAssert(t, queryInfo.SelectFields_[2].AggType_ == plans.COUNT_DISTINCT_AGGREGATE)
+	testingpkg.SimpleAssert(t, *queryInfo.SelectFields_[2].ColName_ == "d")
+
+	testingpkg.SimpleAssert(t, len(queryInfo.GroupByCols_) == 1)
+	testingpkg.SimpleAssert(t, *queryInfo.GroupByCols_[0] == "t.b")
+
+	having := queryInfo.HavingExpression_
+	testingpkg.SimpleAssert(t, having.LogicalOperationType_ == expression.AND)
+	left := having.Left_.(*BinaryOpExpression)
+	testingpkg.SimpleAssert(t, left.ComparisonOperationType_ == expression.GreaterThan)
+	testingpkg.SimpleAssert(t, left.Left_.(*SelectFieldExpression).AggType_ == plans.COUNT_AGGREGATE)
+	testingpkg.SimpleAssert(t, *left.Left_.(*SelectFieldExpression).ColName_ == "*")
+	testingpkg.SimpleAssert(t, left.Right_.(*types.Value).ToInteger() == 2)
+	right := having.Right_.(*BinaryOpExpression)
+	testingpkg.SimpleAssert(t, right.ComparisonOperationType_ == expression.LessThan)
+	testingpkg.SimpleAssert(t, *right.Left_.(*string) == "t.b")
+	testingpkg.SimpleAssert(t, having.TouchedColumns().Contains("t.b"))
+
+	sqlStr = "SELECT a FROM t WHERE a = 10;"
+	queryInfo, _ = ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, len(queryInfo.GroupByCols_) == 0)
+	testingpkg.SimpleAssert(t, queryInfo.HavingExpression_.Left_ == nil)
+}
+
 func TestLimitOffsetSelectQuery(t *testing.T) {
 	sqlStr := "SELECT a, b FROM t WHERE a = 10 LIMIT 100 OFFSET 200;"
 	queryInfo, _ := ProcessSQLStr(&sqlStr)
@@ -227,6 +266,25 @@ func TestLimitOffsetSelectQuery(t *testing.T) {
 	testingpkg.SimpleAssert(t, queryInfo.OffsetNum_ == -1)
 }
 
+func TestOrderBySelectQuery(t *testing.T) {
+	sqlStr := "SELECT a, b FROM t WHERE a = 10 ORDER BY a DESC, b NULLS FIRST, c, d DESC NULLS LAST LIMIT 10;"
+	queryInfo, _ := ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, *queryInfo.QueryType_ == SELECT)
+	obes := queryInfo.OrderByExpressions_
+	testingpkg.SimpleAssert(t, len(obes) == 4)
+	testingpkg.SimpleAssert(t, *obes[0].ColName_ == "a" && obes[0].IsDesc_ && obes[0].NullsFirst_)
+	testingpkg.SimpleAssert(t, *obes[1].ColName_ == "b" && !obes[1].IsDesc_ && obes[1].NullsFirst_)
+	testingpkg.SimpleAssert(t, *obes[2].ColName_ == "c" && !obes[2].IsDesc_ && !obes[2].NullsFirst_)
+	testingpkg.SimpleAssert(t, *obes[3].ColName_ == "d" && obes[3].IsDesc_ && !obes[3].NullsFirst_)
+	testingpkg.SimpleAssert(t, queryInfo.LimitNum_ == 10)
+
+	sqlStr = "SELECT b, count(*) FROM t GROUP BY b ORDER BY count(*) DESC;"
+	queryInfo, _ = ProcessSQLStr(&sqlStr)
+	obes = queryInfo.OrderByExpressions_
+	testingpkg.SimpleAssert(t, len(obes) == 1 && obes[0].ColName_ == nil && obes[0].IsDesc_)
+	testingpkg.SimpleAssert(t, obes[0].AggField_.AggType_ == plans.COUNT_AGGREGATE)
+}
+
 func TestIsNullIsNotNullSelectQuery(t *testing.T) {
 	// (a IS NULL) AND (b > 10)
 	sqlStr := "SELECT a, b FROM t WHERE a IS NULL AND b > 10;"
@@ -346,6 +404,112 @@ func TestSimpleJoinSelectQuery(t *testing.T) {
 	testingpkg.SimpleAssert(t, queryInfo.WhereExpression_.Right_.(*types.Value).ToInteger() == 10)
 }
 
+func TestOuterJoinSelectQuery(t *testing.T) {
+	sqlStr := "SELECT * FROM staff JOIN friend ON staff.c = friend.c LEFT OUTER JOIN pet ON friend.d = pet.d AND pet.e > 1 RIGHT JOIN toy ON pet.f = toy.f;"
+	queryInfo, _ := ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, *queryInfo.QueryType_ == SELECT)
+
+	testingpkg.SimpleAssert(t, len(queryInfo.JoinTables_) == 4)
+	testingpkg.SimpleAssert(t, *queryInfo.JoinTables_[0] == "staff")
+	testingpkg.SimpleAssert(t, *queryInfo.JoinTables_[1] == "friend")
+	testingpkg.SimpleAssert(t, *queryInfo.JoinTables_[2] == "pet")
+	testingpkg.SimpleAssert(t, *queryInfo.JoinTables_[3] == "toy")
+
+	// inner join in one side of outer join is also listed
+	testingpkg.SimpleAssert(t, isEmptyBinaryOpExp(queryInfo.OnExpressions_))
+	testingpkg.SimpleAssert(t, len(queryInfo.Joins_) == 3)
+	innerJoin := queryInfo.Joins_[0]
+	testingpkg.SimpleAssert(t, innerJoin.JoinType_ == plans.INNER_JOIN)
+	testingpkg.SimpleAssert(t, innerJoin.OnExpression_.ComparisonOperationType_ == expression.Equal)
+	testingpkg.SimpleAssert(t, *innerJoin.OnExpression_.Left_.(*string) == "staff.c")
+	testingpkg.SimpleAssert(t, *innerJoin.OnExpression_.Right_.(*string) == "friend.c")
+
+	leftJoin := queryInfo.Joins_[1]
+	testingpkg.SimpleAssert(t, leftJoin.JoinType_ == plans.LEFT_OUTER_JOIN)
+	testingpkg.SimpleAssert(t, len(leftJoin.LeftTables_) == 2)
+	testingpkg.SimpleAssert(t, *leftJoin.LeftTables_[0] == "staff")
+	testingpkg.SimpleAssert(t, *leftJoin.LeftTables_[1] == "friend")
+	testingpkg.SimpleAssert(t, len(leftJoin.RightTables_) == 1)
+	testingpkg.SimpleAssert(t, *leftJoin.RightTables_[0] == "pet")
+	testingpkg.SimpleAssert(t, leftJoin.OnExpression_.LogicalOperationType_ == expression.AND)
+	testingpkg.SimpleAssert(t, *leftJoin.OnExpression_.Left_.(*BinaryOpExpression).Left_.(*string) == "friend.d")
+	testingpkg.SimpleAssert(t, leftJoin.OnExpression_.Right_.(*BinaryOpExpression).ComparisonOperationType_ == expression.GreaterThan)
+
+	rightJoin := queryInfo.Joins_[2]
+	testingpkg.SimpleAssert(t, rightJoin.JoinType_ == plans.RIGHT_OUTER_JOIN)
+	testingpkg.SimpleAssert(t, len(rightJoin.LeftTables_) == 3)
+	testingpkg.SimpleAssert(t, *rightJoin.RightTables_[0] == "toy")
+	testingpkg.SimpleAssert(t, *rightJoin.OnExpression_.Left_.(*string) == "pet.f")
+	testingpkg.SimpleAssert(t, *rightJoin.OnExpression_.Right_.(*string) == "toy.f")
+
+	sqlStr = "SELECT * FROM staff FULL OUTER JOIN friend ON staff.c = friend.c WHERE staff.c IS NULL;"
+	queryInfo, _ = ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, len(queryInfo.JoinTables_) == 2)
+	testingpkg.SimpleAssert(t, isEmptyBinaryOpExp(queryInfo.OnExpressions_))
+	testingpkg.SimpleAssert(t, len(queryInfo.Joins_) == 1)
+	testingpkg.SimpleAssert(t, queryInfo.Joins_[0].JoinType_ == plans.FULL_OUTER_JOIN)
+	testingpkg.SimpleAssert(t, *queryInfo.Joins_[0].LeftTables_[0] == "staff")
+	testingpkg.SimpleAssert(t, *queryInfo.Joins_[0].RightTables_[0] == "friend")
+	testingpkg.SimpleAssert(t, *queryInfo.WhereExpression_.Left_.(*string) == "staff.c")
+
+	sqlStr = "SELECT * FROM staff FULL JOIN friend ON staff.c = friend.c;"
+	queryInfo, _ = ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, len(queryInfo.Joins_) == 1)
+	testingpkg.SimpleAssert(t, queryInfo.Joins_[0].JoinType_ == plans.FULL_OUTER_JOIN)
+
+	// ON clause of inner join in one side of outer join is not merged
+	sqlStr = "SELECT * FROM staff LEFT JOIN (friend JOIN pet ON friend.d = pet.d) ON staff.c = friend.c;"
+	queryInfo, _ = ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, len(queryInfo.JoinTables_) == 3)
+	testingpkg.SimpleAssert(t, isEmptyBinaryOpExp(queryInfo.OnExpressions_))
+	testingpkg.SimpleAssert(t, len(queryInfo.Joins_) == 2)
+	testingpkg.SimpleAssert(t, queryInfo.Joins_[0].JoinType_ == plans.INNER_JOIN)
+	testingpkg.SimpleAssert(t, *queryInfo.Joins_[0].OnExpression_.Left_.(*string) == "friend.d")
+	testingpkg.SimpleAssert(t, queryInfo.Joins_[1].JoinType_ == plans.LEFT_OUTER_JOIN)
+	testingpkg.SimpleAssert(t, len(queryInfo.Joins_[1].RightTables_) == 2)
+}
+
+func TestSubquerySelectQuery(t *testing.T) {
+	sqlStr := "SELECT a.id FROM a WHERE a.id IN (SELECT b.a_id FROM b WHERE b.v > 10) AND NOT EXISTS (SELECT * FROM c WHERE c.a_id = a.id);"
+	queryInfo, _ := ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, queryInfo.WhereExpression_.LogicalOperationType_ == expression.AND)
+	inExp := queryInfo.WhereExpression_.Left_.(*BinaryOpExpression)
+	testingpkg.SimpleAssert(t, inExp.GetType() == Subquery)
+	inSubquery := inExp.Left_.(*SubqueryExpression)
+	testingpkg.SimpleAssert(t, inSubquery.SubqueryType_ == IN_SUBQUERY && !inSubquery.Not_)
+	testingpkg.SimpleAssert(t, *inSubquery.InColName_ == "a.id")
+	testingpkg.SimpleAssert(t, *inSubquery.Query_.JoinTables_[0] == "b")
+	testingpkg.SimpleAssert(t, *inSubquery.Query_.SelectFields_[0].ColName_ == "a_id")
+	existsSubquery := queryInfo.WhereExpression_.Right_.(*BinaryOpExpression).Left_.(*SubqueryExpression)
+	testingpkg.SimpleAssert(t, existsSubquery.SubqueryType_ == EXISTS_SUBQUERY && existsSubquery.Not_)
+	testingpkg.SimpleAssert(t, len(queryInfo.Subqueries()) == 2)
+
+	// scalar subqueries
+	sqlStr = "SELECT a.id, (SELECT MAX(b.v) FROM b WHERE b.a_id = a.id) FROM a WHERE a.v > (SELECT AVG(b.v) FROM b);"
+	queryInfo, _ = ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, len(queryInfo.SelectFields_) == 2)
+	testingpkg.SimpleAssert(t, queryInfo.SelectFields_[1].Subquery_.SubqueryType_ == SCALAR_SUBQUERY)
+	testingpkg.SimpleAssert(t, queryInfo.SelectFields_[1].Subquery_.Query_.SelectFields_[0].IsAgg_)
+	testingpkg.SimpleAssert(t, queryInfo.WhereExpression_.ComparisonOperationType_ == expression.GreaterThan)
+	testingpkg.SimpleAssert(t, queryInfo.WhereExpression_.Right_.(*SubqueryExpression).SubqueryType_ == SCALAR_SUBQUERY)
+
+	// derived table and alias of column
+	sqlStr = "SELECT t.a_id, t.total FROM (SELECT a_id, SUM(v) AS total FROM b GROUP BY a_id) AS t WHERE t.total > 100;"
+	queryInfo, _ = ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, len(queryInfo.JoinTables_) == 0)
+	testingpkg.SimpleAssert(t, len(queryInfo.DerivedTables_) == 1)
+	derived := queryInfo.DerivedTables_[0]
+	testingpkg.SimpleAssert(t, derived.SubqueryType_ == DERIVED_TABLE && *derived.Name_ == "t")
+	testingpkg.SimpleAssert(t, *derived.Query_.SelectFields_[1].Alias_ == "total")
+	testingpkg.SimpleAssert(t, *derived.Query_.GroupByCols_[0] == "a_id")
+
+	// IN list is rewritten to OR of equalities
+	sqlStr = "SELECT a.id FROM a WHERE a.id IN (1, 2);"
+	queryInfo, _ = ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, queryInfo.WhereExpression_.LogicalOperationType_ == expression.OR)
+	testingpkg.SimpleAssert(t, queryInfo.WhereExpression_.Left_.(*BinaryOpExpression).ComparisonOperationType_ == expression.Equal)
+}
+
 func TestSimpleCreateTableQuery(t *testing.T) {
 	sqlStr := "CREATE TABLE name_age_list(name VARCHAR(256), age INT);"
 	queryInfo, _ := ProcessSQLStr(&sqlStr)
@@ -378,6 +542,117 @@ func TestCreateTableWithIndexDefQuery(t *testing.T) {
 	testingpkg.SimpleAssert(t, *queryInfo.IndexDefExpressions_[1].Colnames_[1] == "age")
 }
 
+func TestCreateAndDropIndexQuery(t *testing.T) {
+	sqlStr := "CREATE INDEX age_idx ON name_age_list(age) USING HASH;"
+	queryInfo, _ := ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, *queryInfo.QueryType_ == CREATE_INDEX)
+	testingpkg.SimpleAssert(t, *queryInfo.JoinTables_[0] == "name_age_list")
+	testingpkg.SimpleAssert(t, *queryInfo.IndexDefExpressions_[0].IndexName_ == "age_idx")
+	testingpkg.SimpleAssert(t, *queryInfo.IndexDefExpressions_[0].Colnames_[0] == "age")
+	testingpkg.SimpleAssert(t, queryInfo.IndexDefExpressions_[0].IndexKind_ == index_constants.INDEX_KIND_HASH)
+
+	kindMap := map[string]index_constants.IndexKind{
+		"CREATE INDEX age_idx ON name_age_list(age);":                           index_constants.INDEX_KIND_SKIP_LIST,
+		"CREATE INDEX age_idx ON name_age_list(age) USING SKIPLIST;":            index_constants.INDEX_KIND_SKIP_LIST,
+		"create index age_idx on name_age_list(age) using unique skiplist;":     index_constants.INDEX_KIND_UNIQ_SKIP_LIST,
+		"CREATE UNIQUE INDEX age_idx ON name_age_list(age);":                    index_constants.INDEX_KIND_UNIQ_SKIP_LIST,
+		"CREATE INDEX age_idx ON name_age_list(age) USING BTREE;":               index_constants.INDEX_KIND_BTREE,
+		"CREATE INDEX age_idx USING HASH ON name_age_list(age);":                index_constants.INDEX_KIND_HASH,
+		"CREATE UNIQUE INDEX age_idx ON name_age_list(age) USING HASH;":         index_constants.INDEX_KIND_INVALID,
+		"CREATE INDEX age_idx ON name_age_list(age) USING RTREE;":               index_constants.INDEX_KIND_INVALID,
+		"CREATE INDEX name_age_idx ON name_age_list(name, age) USING SKIPLIST;": index_constants.INDEX_KIND_SKIP_LIST,
+	}
+	for sqlStr_, kind := range kindMap {
+		queryInfo, err := ProcessSQLStr(&sqlStr_)
+		testingpkg.SimpleAssert(t, err == nil)
+		testingpkg.SimpleAssert(t, queryInfo.IndexDefExpressions_[0].IndexKind_ == kind)
+	}
+
+	sqlStr = "DROP INDEX age_idx ON name_age_list;"
+	queryInfo, _ = ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, *queryInfo.QueryType_ == DROP_INDEX)
+	testingpkg.SimpleAssert(t, *queryInfo.JoinTables_[0] == "name_age_list")
+	testingpkg.SimpleAssert(t, *queryInfo.IndexDefExpressions_[0].IndexName_ == "age_idx")
+}
+
+func TestDropTruncateAndAlterTableQuery(t *testing.T) {
+	sqlStr := "DROP TABLE name_age_list;"
+	queryInfo, _ := ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, *queryInfo.QueryType_ == DROP_TABLE)
+	testingpkg.SimpleAssert(t, *queryInfo.JoinTables_[0] == "name_age_list")
+
+	sqlStr = "TRUNCATE TABLE name_age_list;"
+	queryInfo, _ = ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, *queryInfo.QueryType_ == TRUNCATE)
+	testingpkg.SimpleAssert(t, *queryInfo.JoinTables_[0] == "name_age_list")
+
+	sqlStr = "ALTER TABLE name_age_list ADD COLUMN height FLOAT, DROP COLUMN age, ADD COLUMN address VARCHAR(256);"
+	queryInfo, _ = ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, *queryInfo.QueryType_ == ALTER_TABLE)
+	testingpkg.SimpleAssert(t, *queryInfo.JoinTables_[0] == "name_age_list")
+	testingpkg.SimpleAssert(t, len(queryInfo.ColDefExpressions_) == 2)
+	testingpkg.SimpleAssert(t, *queryInfo.ColDefExpressions_[0].ColName_ == "height")
+	testingpkg.SimpleAssert(t, *queryInfo.ColDefExpressions_[0].ColType_ == types.Float)
+	testingpkg.SimpleAssert(t, *queryInfo.ColDefExpressions_[1].ColName_ == "address")
+	testingpkg.SimpleAssert(t, *queryInfo.ColDefExpressions_[1].ColType_ == types.Varchar)
+	testingpkg.SimpleAssert(t, len(queryInfo.DropColumns_) == 1)
+	testingpkg.SimpleAssert(t, *queryInfo.DropColumns_[0] == "age")
+
+	// not supported alteration
+	sqlStr = "ALTER TABLE name_age_list RENAME TO name_list;"
+	queryInfo, _ = ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, *queryInfo.QueryType_ == ALTER_TABLE)
+	testingpkg.SimpleAssert(t, len(queryInfo.ColDefExpressions_) == 0)
+	testingpkg.SimpleAssert(t, len(queryInfo.DropColumns_) == 0)
+}
+
+func TestCreateTableWithConstraints(t *testing.T) {
+	sqlStr := "CREATE TABLE account(id INT PRIMARY KEY, mail VARCHAR(256) UNIQUE, name VARCHAR(256) NOT NULL, age INT CHECK (age >= 0), note VARCHAR(256));"
+	queryInfo, _ := ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, *queryInfo.QueryType_ == CREATE_TABLE)
+	cdefs := queryInfo.ColDefExpressions_
+	testingpkg.SimpleAssert(t, cdefs[0].IsNotNull_ && cdefs[0].IsUnique_)
+	testingpkg.SimpleAssert(t, !cdefs[1].IsNotNull_ && cdefs[1].IsUnique_)
+	testingpkg.SimpleAssert(t, cdefs[2].IsNotNull_ && !cdefs[2].IsUnique_)
+	testingpkg.SimpleAssert(t, len(cdefs[3].CheckExprStrs_) == 1 && *cdefs[3].CheckExprStrs_[0] == "`age`>=0")
+	testingpkg.SimpleAssert(t, !cdefs[4].IsNotNull_ && !cdefs[4].IsUnique_ && len(cdefs[4].CheckExprStrs_) == 0)
+
+	// declared length
+	sqlStr = "CREATE TABLE doc(code VARCHAR(5), kind CHAR(2), body TEXT, id INT);"
+	queryInfo, _ = ProcessSQLStr(&sqlStr)
+	cdefs = queryInfo.ColDefExpressions_
+	testingpkg.SimpleAssert(t, cdefs[0].Length_ == 5 && cdefs[1].Length_ == 2)
+	testingpkg.SimpleAssert(t, cdefs[2].Length_ == 0 && cdefs[2].IsLargeObject_)
+	testingpkg.SimpleAssert(t, cdefs[3].Length_ == 0 && !cdefs[3].IsLargeObject_)
+
+	sqlStr = "CREATE TABLE order_list(customer VARCHAR(256), item_id INT, num INT, PRIMARY KEY (customer, item_id), CHECK (num > 0 AND num <= 100));"
+	queryInfo, _ = ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, *queryInfo.QueryType_ == CREATE_TABLE)
+	testingpkg.SimpleAssert(t, queryInfo.ColDefExpressions_[0].IsNotNull_ && queryInfo.ColDefExpressions_[1].IsNotNull_)
+	testingpkg.SimpleAssert(t, !queryInfo.ColDefExpressions_[2].IsNotNull_)
+	testingpkg.SimpleAssert(t, len(queryInfo.IndexDefExpressions_) == 1)
+	testingpkg.SimpleAssert(t, queryInfo.IndexDefExpressions_[0].IndexKind_ == index_constants.INDEX_KIND_UNIQ_SKIP_LIST)
+	testingpkg.SimpleAssert(t, len(queryInfo.IndexDefExpressions_[0].Colnames_) == 2)
+	testingpkg.SimpleAssert(t, len(queryInfo.CheckExprStrs_) == 1)
+
+	// SQL text of CHECK constraint is converted to expression
+	cols := []*column.Column{
+		column.NewColumn("customer", types.Varchar, false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), nil),
+		column.NewColumn("num", types.Integer, false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), nil),
+	}
+	sc := schema.NewSchema(cols)
+	_, err := ParseCheckExpr(sc, *queryInfo.CheckExprStrs_[0])
+	testingpkg.SimpleAssert(t, err == nil)
+	_, err = ParseCheckExpr(sc, "customer IS NOT NULL OR num = 1")
+	testingpkg.SimpleAssert(t, err == nil)
+	_, err = ParseCheckExpr(sc, "num = 'a'")
+	testingpkg.SimpleAssert(t, err != nil)
+	_, err = ParseCheckExpr(sc, "price > 0")
+	testingpkg.SimpleAssert(t, err != nil)
+	_, err = ParseCheckExpr(sc, "num + 1 > 0")
+	testingpkg.SimpleAssert(t, errors.Is(err, UnsupportedCheckExprErr))
+}
+
 func TestInsertQuery(t *testing.T) {
 	sqlStr := "INSERT INTO syain(name) VALUES ('鈴木');"
 	queryInfo, _ := ProcessSQLStr(&sqlStr)
@@ -402,6 +677,65 @@ func TestInsertQuery(t *testing.T) {
 	testingpkg.SimpleAssert(t, queryInfo.Values_[2].ToVarchar() == "suzuki")
 }
 
+func TestInsertQueryWithMultiRowsAndSelect(t *testing.T) {
+	sqlStr := "INSERT INTO syain(id, name) VALUES (1, 'a'), (2, 'b'), (3);"
+	queryInfo, _ := ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, *queryInfo.QueryType_ == INSERT)
+	testingpkg.SimpleAssert(t, len(queryInfo.Values_) == 5)
+	testingpkg.SimpleAssert(t, len(queryInfo.ValueRowLens_) == 3)
+	testingpkg.SimpleAssert(t, queryInfo.ValueRowLens_[1] == 2)
+	testingpkg.SimpleAssert(t, queryInfo.ValueRowLens_[2] == 1)
+	testingpkg.SimpleAssert(t, queryInfo.Values_[3].ToVarchar() == "b")
+	testingpkg.SimpleAssert(t, queryInfo.SelectQuery_ == nil)
+
+	sqlStr = "INSERT INTO archive(id, name) SELECT id, name FROM syain WHERE id > 1;"
+	queryInfo, _ = ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, *queryInfo.QueryType_ == INSERT)
+	testingpkg.SimpleAssert(t, len(queryInfo.JoinTables_) == 1)
+	testingpkg.SimpleAssert(t, *queryInfo.JoinTables_[0] == "archive")
+	testingpkg.SimpleAssert(t, len(queryInfo.TargetCols_) == 2)
+	testingpkg.SimpleAssert(t, *queryInfo.TargetCols_[1] == "name")
+	testingpkg.SimpleAssert(t, len(queryInfo.Values_) == 0)
+	selectQi := queryInfo.SelectQuery_
+	testingpkg.SimpleAssert(t, *selectQi.QueryType_ == SELECT)
+	testingpkg.SimpleAssert(t, *selectQi.JoinTables_[0] == "syain")
+	testingpkg.SimpleAssert(t, len(selectQi.SelectFields_) == 2)
+	testingpkg.SimpleAssert(t, selectQi.WhereExpression_.ComparisonOperationType_ == expression.GreaterThan)
+
+	sqlStr = "INSERT INTO archive SELECT * FROM syain;"
+	queryInfo, _ = ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, len(queryInfo.TargetCols_) == 0)
+	testingpkg.SimpleAssert(t, *queryInfo.SelectQuery_.SelectFields_[0].ColName_ == "*")
+}
+
+func TestReturningClause(t *testing.T) {
+	sqlStr := "INSERT INTO syain(id, name) VALUES (1, 'returning') RETURNING id, syain.name;"
+	queryInfo, _ := ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, *queryInfo.QueryType_ == INSERT)
+	testingpkg.SimpleAssert(t, queryInfo.Values_[1].ToVarchar() == "returning")
+	testingpkg.SimpleAssert(t, len(queryInfo.ReturningFields_) == 2)
+	testingpkg.SimpleAssert(t, *queryInfo.ReturningFields_[0].ColName_ == "id")
+	testingpkg.SimpleAssert(t, *queryInfo.ReturningFields_[1].TableName_ == "syain")
+	testingpkg.SimpleAssert(t, *queryInfo.ReturningFields_[1].ColName_ == "name")
+
+	sqlStr = "UPDATE syain SET name = 'x' WHERE id = 1 RETURNING *"
+	queryInfo, _ = ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, *queryInfo.QueryType_ == UPDATE)
+	testingpkg.SimpleAssert(t, len(queryInfo.SetExpressions_) == 1)
+	testingpkg.SimpleAssert(t, len(queryInfo.ReturningFields_) == 1)
+	testingpkg.SimpleAssert(t, *queryInfo.ReturningFields_[0].ColName_ == "*")
+
+	sqlStr = "DELETE FROM syain WHERE name = 'a RETURNING b';"
+	queryInfo, _ = ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, *queryInfo.QueryType_ == DELETE)
+	testingpkg.SimpleAssert(t, queryInfo.ReturningFields_ == nil)
+	testingpkg.SimpleAssert(t, queryInfo.WhereExpression_.Right_.(*types.Value).ToVarchar() == "a RETURNING b")
+
+	sqlStr = "DELETE FROM syain RETURNING COUNT(*);"
+	_, err := ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, errors.Is(err, UnsupportedReturningErr))
+}
+
 func TestInsertQueryIncludesSpaceOnString(t *testing.T) {
 	sqlStr := "INSERT INTO syain(name) VALUES ('鈴 木');"
 	queryInfo, _ := ProcessSQLStr(&sqlStr)
@@ -455,3 +789,63 @@ func TestSimpleUpdateQuery(t *testing.T) {
 	testingpkg.SimpleAssert(t, *queryInfo.WhereExpression_.Left_.(*string) == "gender")
 	testingpkg.SimpleAssert(t, queryInfo.WhereExpression_.Right_.(*types.Value).ToVarchar() == "M")
 }
+
+func TestTxnControlQuery(t *testing.T) {
+	sqlStr := "BEGIN;"
+	queryInfo, _ := ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, *queryInfo.QueryType_ == BEGIN)
+
+	sqlStr = "START TRANSACTION;"
+	queryInfo, _ = ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, *queryInfo.QueryType_ == BEGIN)
+
+	sqlStr = "COMMIT;"
+	queryInfo, _ = ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, *queryInfo.QueryType_ == COMMIT)
+
+	sqlStr = "ROLLBACK;"
+	queryInfo, _ = ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, *queryInfo.QueryType_ == ROLLBACK)
+}
+
+func TestEmptyQuery(t *testing.T) {
+	sqlStr := "-- comment only"
+	queryInfo, err := ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, queryInfo == nil)
+	testingpkg.SimpleAssert(t, err == EmptyQueryErr)
+}
+
+func TestExpressionQuery(t *testing.T) {
+	sqlStr := "SELECT a.x + 1 AS y, UPPER(a.name), a.z FROM a WHERE a.x > a.w * 2 AND a.name LIKE 'ab%' AND a.v > -2;"
+	queryInfo, _ := ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, len(queryInfo.SelectFields_) == 3)
+	calc := queryInfo.SelectFields_[0].Calc_
+	testingpkg.SimpleAssert(t, calc != nil && calc.ArithmeticOpType_ == expression.ADD)
+	testingpkg.SimpleAssert(t, *calc.Args_[0].(*string) == "a.x" && calc.Args_[1].(*types.Value).ToInteger() == 1)
+	testingpkg.SimpleAssert(t, *queryInfo.SelectFields_[0].Alias_ == "y")
+	testingpkg.SimpleAssert(t, queryInfo.SelectFields_[1].Calc_.FuncType_ == expression.UPPER)
+	testingpkg.SimpleAssert(t, *queryInfo.SelectFields_[1].Alias_ == "upper(a.name)")
+	testingpkg.SimpleAssert(t, queryInfo.SelectFields_[2].Calc_ == nil && *queryInfo.SelectFields_[2].ColName_ == "z")
+
+	conds := queryInfo.WhereExpression_.Left_.(*BinaryOpExpression)
+	colCompare := conds.Left_.(*BinaryOpExpression)
+	testingpkg.SimpleAssert(t, *colCompare.Left_.(*string) == "a.x")
+	testingpkg.SimpleAssert(t, colCompare.Right_.(*CalcExpression).ArithmeticOpType_ == expression.MUL)
+	like := conds.Right_.(*BinaryOpExpression)
+	testingpkg.SimpleAssert(t, like.ComparisonOperationType_ == expression.Like)
+	testingpkg.SimpleAssert(t, like.Right_.(*types.Value).ToVarchar() == "ab%")
+	negative := queryInfo.WhereExpression_.Right_.(*BinaryOpExpression)
+	testingpkg.SimpleAssert(t, negative.Right_.(*types.Value).ToInteger() == -2)
+
+	// BETWEEN is rewritten to two comparisons
+	sqlStr = "SELECT a.x FROM a WHERE a.x BETWEEN 1 AND 10;"
+	queryInfo, _ = ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, queryInfo.WhereExpression_.LogicalOperationType_ == expression.AND)
+	testingpkg.SimpleAssert(t, queryInfo.WhereExpression_.Left_.(*BinaryOpExpression).ComparisonOperationType_ == expression.GreaterThanOrEqual)
+	testingpkg.SimpleAssert(t, queryInfo.WhereExpression_.Right_.(*BinaryOpExpression).ComparisonOperationType_ == expression.LessThanOrEqual)
+
+	// unsupported function is kept as nil CalcExpression
+	sqlStr = "SELECT CONCAT(a.name, 'x') FROM a;"
+	queryInfo, _ = ProcessSQLStr(&sqlStr)
+	testingpkg.SimpleAssert(t, queryInfo.SelectFields_[0].Calc_ == nil && queryInfo.SelectFields_[0].ColName_ == nil)
+}
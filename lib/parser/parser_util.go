@@ -1,11 +1,16 @@
 package parser
 
 import (
+	"errors"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/format"
+	"github.com/pingcap/parser/opcode"
 	ptypes "github.com/pingcap/tidb/types"
 	driver "github.com/pingcap/tidb/types/parser_driver"
 	"github.com/ryogrid/SamehadaDB/lib/execution/expression"
 	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
 	"github.com/ryogrid/SamehadaDB/lib/types"
+	"math"
 	"strconv"
 	"strings"
 )
@@ -18,21 +23,58 @@ const (
 	INSERT
 	DELETE
 	UPDATE
+	BEGIN
+	COMMIT
+	ROLLBACK
+	CREATE_INDEX
+	DROP_INDEX
+	DROP_TABLE
+	TRUNCATE
+	ALTER_TABLE
 )
 
 func ValueExprToValue(expr *driver.ValueExpr) *types.Value {
 	switch expr.Datum.Kind() {
-	case ptypes.KindInt64, ptypes.KindUint64:
-		val_str := expr.String()
-		istr := strings.Split(val_str, " ")[1]
-		ival, _ := strconv.Atoi(istr)
+	case ptypes.KindNull:
+		ret := types.NewNull()
+		return &ret
+	case ptypes.KindInt64:
+		ival := expr.Datum.GetInt64()
+		if ival < math.MinInt32 || ival > math.MaxInt32 {
+			ret := types.NewBigInt(ival)
+			return &ret
+		}
 		ret := types.NewInteger(int32(ival))
 		return &ret
+	case ptypes.KindUint64:
+		uval := expr.Datum.GetUint64()
+		if uval <= math.MaxInt32 {
+			// LIMIT and OFFSET counts are parsed as unsigned
+			ret := types.NewInteger(int32(uval))
+			return &ret
+		} else if uval > math.MaxInt64 {
+			ret := types.NewDouble(float64(uval))
+			return &ret
+		}
+		ret := types.NewBigInt(int64(uval))
+		return &ret
 	case ptypes.KindMysqlDecimal:
-		val_str := expr.String()
-		fstr := strings.Split(val_str, " ")[1]
-		fval, _ := strconv.ParseFloat(fstr, 32)
-		ret := types.NewFloat(float32(fval))
+		// ex: 12.34
+		fstr := expr.Datum.GetMysqlDecimal().String()
+		if dval, ok := types.NewDecimalFromString(fstr); ok {
+			ret := types.NewDecimal(dval)
+			return &ret
+		}
+		fval, _ := strconv.ParseFloat(fstr, 64)
+		ret := types.NewDouble(fval)
+		return &ret
+	case ptypes.KindFloat64:
+		// ex: 1.5e3
+		ret := types.NewDouble(expr.Datum.GetFloat64())
+		return &ret
+	case ptypes.KindBinaryLiteral, ptypes.KindMysqlBit:
+		// ex: X'0AFF'
+		ret := types.NewBlob(expr.Datum.GetBytes())
 		return &ret
 	default: // varchar
 		val_str := expr.String()
@@ -48,3 +90,106 @@ func GetPredicateExprFromStr(schema_ *schema.Schema, pred *string) expression.Ex
 	qi, _ := ProcessSQLStr(&sqlStr)
 	return ConvParsedBinaryOpExprToExpIFOne(schema_, qi.WhereExpression_)
 }
+
+// returns SQL text of node. returned text is parsed again with ParseCheckExpr
+func exprNodeToStr(node ast.ExprNode) string {
+	var sb strings.Builder
+	if err := node.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)); err != nil {
+		// ParseCheckExpr returns error for the broken text
+		return ""
+	}
+	return sb.String()
+}
+
+var UnsupportedCheckExprErr = errors.New("CHECK constraint supports only comparison of columns and literals, IS NULL, AND and OR")
+
+// validates that expression of CHECK constraint can be converted with BinaryOpVisitor
+type checkExprVisitor struct {
+	isSupported bool
+}
+
+func (v *checkExprVisitor) Enter(in ast.Node) (ast.Node, bool) {
+	switch node := in.(type) {
+	case *ast.BinaryOperationExpr:
+		switch node.Op {
+		case opcode.EQ, opcode.GT, opcode.GE, opcode.LT, opcode.LE, opcode.NE, opcode.LogicAnd, opcode.LogicOr:
+		default:
+			v.isSupported = false
+		}
+	case *ast.IsNullExpr, *ast.ColumnNameExpr, *ast.ColumnName, *ast.ParenthesesExpr, *driver.ValueExpr:
+	default:
+		v.isSupported = false
+	}
+	return in, !v.isSupported
+}
+
+func (v *checkExprVisitor) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+// checks that columns used in exp exist on sc and types of compared column and literal are comparable
+func validateCheckExpr(sc *schema.Schema, exp *BinaryOpExpression) error {
+	typeOf := func(operand interface{}) (types.TypeID, error) {
+		switch casted := operand.(type) {
+		case *string:
+			colIdx := sc.GetColIndex(*casted)
+			if colIdx == math.MaxUint32 {
+				return types.Invalid, errors.New("column " + *casted + " on CHECK constraint does not exist")
+			}
+			return sc.GetColumn(colIdx).GetType(), nil
+		case *types.Value:
+			return casted.ValueType(), nil
+		default:
+			return types.Invalid, UnsupportedCheckExprErr
+		}
+	}
+
+	if exp.LogicalOperationType_ == -1 && exp.ComparisonOperationType_ == -1 {
+		// column or literal only
+		return UnsupportedCheckExprErr
+	}
+	switch exp.GetType() {
+	case Logical:
+		if err := validateCheckExpr(sc, exp.Left_.(*BinaryOpExpression)); err != nil {
+			return err
+		}
+		return validateCheckExpr(sc, exp.Right_.(*BinaryOpExpression))
+	case IsNull:
+		_, err := typeOf(exp.Left_)
+		return err
+	default: // Compare
+		leftType, err := typeOf(exp.Left_)
+		if err != nil {
+			return err
+		}
+		rightType, err := typeOf(exp.Right_)
+		if err != nil {
+			return err
+		}
+		if !types.IsComparable(leftType, rightType) {
+			return errors.New("types of compared values on CHECK constraint are different")
+		}
+		return nil
+	}
+}
+
+// ParseCheckExpr converts SQL text of CHECK constraint to expression which is evaluated with tuples of sc
+func ParseCheckExpr(sc *schema.Schema, exprStr string) (expression.Expression, error) {
+	sqlStr := "SELECT * FROM dummy WHERE " + exprStr + ";"
+	stmt, err := parse(&sqlStr)
+	if err != nil {
+		return nil, err
+	}
+	where := (*stmt).(*ast.SelectStmt).Where
+	cev := &checkExprVisitor{true}
+	where.Accept(cev)
+	if !cev.isSupported {
+		return nil, UnsupportedCheckExprErr
+	}
+	bov := &BinaryOpVisitor{nil, new(BinaryOpExpression)}
+	where.Accept(bov)
+	if err = validateCheckExpr(sc, bov.BinaryOpExpression_); err != nil {
+		return nil, err
+	}
+	return ConvParsedBinaryOpExprToExpIFOne(sc, bov.BinaryOpExpression_), nil
+}
@@ -2,9 +2,14 @@ package parser
 
 import (
 	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/charset"
+	"github.com/pingcap/parser/model"
 	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/opcode"
 	driver "github.com/pingcap/tidb/types/parser_driver"
+	"github.com/ryogrid/SamehadaDB/lib/storage/index/index_constants"
 	"github.com/ryogrid/SamehadaDB/lib/types"
+	"strings"
 )
 
 type RootSQLVisitor struct {
@@ -19,14 +24,21 @@ func NewRootSQLVisitor() *RootSQLVisitor {
 	qinfo.SetExpressions_ = make([]*SetExpression, 0)
 	qinfo.ColDefExpressions_ = make([]*ColDefExpression, 0)
 	qinfo.IndexDefExpressions_ = make([]*IndexDefExpression, 0)
+	qinfo.CheckExprStrs_ = make([]*string, 0)
+	qinfo.DropColumns_ = make([]*string, 0)
 	qinfo.TargetCols_ = make([]*string, 0)
 	qinfo.Values_ = make([]*types.Value, 0)
+	qinfo.ValueRowLens_ = make([]int, 0)
 	qinfo.OnExpressions_ = new(BinaryOpExpression)
+	qinfo.Joins_ = make([]*JoinExpression, 0)
 	qinfo.JoinTables_ = make([]*string, 0)
+	qinfo.DerivedTables_ = make([]*SubqueryExpression, 0)
 	qinfo.WhereExpression_ = new(BinaryOpExpression)
 	qinfo.LimitNum_ = -1
 	qinfo.OffsetNum_ = -1
 	qinfo.OrderByExpressions_ = make([]*OrderByExpression, 0)
+	qinfo.GroupByCols_ = make([]*string, 0)
+	qinfo.HavingExpression_ = new(BinaryOpExpression)
 	ret.QueryInfo_ = qinfo
 
 	return ret
@@ -40,14 +52,97 @@ func (v *RootSQLVisitor) Enter(in ast.Node) (ast.Node, bool) {
 		*v.QueryInfo_.QueryType_ = CREATE_TABLE
 	case *ast.InsertStmt:
 		*v.QueryInfo_.QueryType_ = INSERT
+		if node.Select != nil {
+			// INSERT ... SELECT. the query is extracted with another RootSQLVisitor
+			node.Table.Accept(v)
+			for _, col := range node.Columns {
+				col.Accept(v)
+			}
+			sv := NewRootSQLVisitor()
+			node.Select.Accept(sv)
+			v.QueryInfo_.SelectQuery_ = sv.QueryInfo_
+			return in, true
+		}
+		for _, row := range node.Lists {
+			v.QueryInfo_.ValueRowLens_ = append(v.QueryInfo_.ValueRowLens_, len(row))
+		}
 	case *ast.DeleteStmt:
 		*v.QueryInfo_.QueryType_ = DELETE
 	case *ast.UpdateStmt:
 		*v.QueryInfo_.QueryType_ = UPDATE
+	case *ast.CreateIndexStmt:
+		*v.QueryInfo_.QueryType_ = CREATE_INDEX
+		tblname := node.Table.Name.String()
+		v.QueryInfo_.JoinTables_ = append(v.QueryInfo_.JoinTables_, &tblname)
+		idf := new(IndexDefExpression)
+		idxname := node.IndexName
+		idf.IndexName_ = &idxname
+		for _, spec := range node.IndexPartSpecifications {
+			if spec.Column != nil {
+				colname := spec.Column.Name.String()
+				idf.Colnames_ = append(idf.Colnames_, &colname)
+			}
+		}
+		idf.IndexKind_ = indexKindOfCreateIndexStmt(node)
+		v.QueryInfo_.IndexDefExpressions_ = append(v.QueryInfo_.IndexDefExpressions_, idf)
+		return in, true
+	case *ast.DropIndexStmt:
+		*v.QueryInfo_.QueryType_ = DROP_INDEX
+		tblname := node.Table.Name.String()
+		v.QueryInfo_.JoinTables_ = append(v.QueryInfo_.JoinTables_, &tblname)
+		idxname := node.IndexName
+		v.QueryInfo_.IndexDefExpressions_ = append(v.QueryInfo_.IndexDefExpressions_, &IndexDefExpression{&idxname, nil, index_constants.INDEX_KIND_INVALID})
+		return in, true
+	case *ast.DropTableStmt:
+		*v.QueryInfo_.QueryType_ = DROP_TABLE
+		for _, table := range node.Tables {
+			tblname := table.Name.String()
+			v.QueryInfo_.JoinTables_ = append(v.QueryInfo_.JoinTables_, &tblname)
+		}
+		return in, true
+	case *ast.TruncateTableStmt:
+		*v.QueryInfo_.QueryType_ = TRUNCATE
+		tblname := node.Table.Name.String()
+		v.QueryInfo_.JoinTables_ = append(v.QueryInfo_.JoinTables_, &tblname)
+		return in, true
+	case *ast.AlterTableStmt:
+		*v.QueryInfo_.QueryType_ = ALTER_TABLE
+		tblname := node.Table.Name.String()
+		v.QueryInfo_.JoinTables_ = append(v.QueryInfo_.JoinTables_, &tblname)
+		for _, spec := range node.Specs {
+			switch spec.Tp {
+			case ast.AlterTableAddColumns:
+				for _, colDef := range spec.NewColumns {
+					v.QueryInfo_.ColDefExpressions_ = append(v.QueryInfo_.ColDefExpressions_, colDefExpressionOf(colDef))
+				}
+			case ast.AlterTableDropColumn:
+				colname := spec.OldColumnName.Name.String()
+				v.QueryInfo_.DropColumns_ = append(v.QueryInfo_.DropColumns_, &colname)
+			default:
+				// not supported alteration. planner returns error for no column to be added or dropped
+				v.QueryInfo_.ColDefExpressions_ = make([]*ColDefExpression, 0)
+				v.QueryInfo_.DropColumns_ = make([]*string, 0)
+				return in, true
+			}
+		}
+		return in, true
+	case *ast.BeginStmt:
+		*v.QueryInfo_.QueryType_ = BEGIN
+		return in, true
+	case *ast.CommitStmt:
+		*v.QueryInfo_.QueryType_ = COMMIT
+		return in, true
+	case *ast.RollbackStmt:
+		*v.QueryInfo_.QueryType_ = ROLLBACK
+		return in, true
 	case *ast.FieldList:
 	case *ast.SelectField:
 		sv := &SelectFieldsVisitor{v.QueryInfo_}
 		node.Accept(sv)
+		if node.AsName.L != "" && len(v.QueryInfo_.SelectFields_) > 0 {
+			alias := node.AsName.String()
+			v.QueryInfo_.SelectFields_[len(v.QueryInfo_.SelectFields_)-1].Alias_ = &alias
+		}
 		return in, true
 	case *ast.TableRefsClause:
 	case *ast.Assignment:
@@ -75,27 +170,19 @@ func (v *RootSQLVisitor) Enter(in ast.Node) (ast.Node, bool) {
 		}
 	case *ast.ColumnDef:
 		if *v.QueryInfo_.QueryType_ == CREATE_TABLE {
-			cdef := new(ColDefExpression)
-			cname := node.Name.String()
-			cdef.ColName_ = &cname
-			col_type := node.Tp.Tp
-			switch col_type {
-			case mysql.TypeTiny, mysql.TypeLong:
-				ctype := types.Integer
-				cdef.ColType_ = &ctype
-			case mysql.TypeFloat, mysql.TypeLonglong:
-				ctype := types.Float
-				cdef.ColType_ = &ctype
-			default:
-				ctype := types.Varchar
-				cdef.ColType_ = &ctype
-			}
-			v.QueryInfo_.ColDefExpressions_ = append(v.QueryInfo_.ColDefExpressions_, cdef)
+			v.QueryInfo_.ColDefExpressions_ = append(v.QueryInfo_.ColDefExpressions_, colDefExpressionOf(node))
 			return in, true
 		}
 	case *ast.Constraint:
 		// Index definition at CREATE TABLE
 		if *v.QueryInfo_.QueryType_ == CREATE_TABLE {
+			if node.Tp == ast.ConstraintCheck {
+				if node.Enforced {
+					exprStr := exprNodeToStr(node.Expr)
+					v.QueryInfo_.CheckExprStrs_ = append(v.QueryInfo_.CheckExprStrs_, &exprStr)
+				}
+				return in, true
+			}
 			// get all specified column
 			cdv := &ChildDataVisitor{make([]interface{}, 0)}
 			node.Accept(cdv)
@@ -104,6 +191,17 @@ func (v *RootSQLVisitor) Enter(in ast.Node) (ast.Node, bool) {
 			for _, colname := range cdv.ChildDatas_ {
 				idf.Colnames_ = append(idf.Colnames_, colname.(*string))
 			}
+			idf.IndexKind_ = indexKindOfConstraint(node)
+			if node.Tp == ast.ConstraintPrimaryKey {
+				// column definitions are visited before constraints
+				for _, cdef := range v.QueryInfo_.ColDefExpressions_ {
+					for _, colname := range idf.Colnames_ {
+						if strings.EqualFold(*cdef.ColName_, *colname) {
+							cdef.IsNotNull_ = true
+						}
+					}
+				}
+			}
 			v.QueryInfo_.IndexDefExpressions_ = append(v.QueryInfo_.IndexDefExpressions_, idf)
 			return in, true
 		}
@@ -124,11 +222,25 @@ func (v *RootSQLVisitor) Enter(in ast.Node) (ast.Node, bool) {
 		v.QueryInfo_.WhereExpression_.LogicalOperationType_ = logicType
 		v.QueryInfo_.WhereExpression_.ComparisonOperationType_ = compType
 
+		return in, true
+	case *ast.IsNullExpr, *ast.ParenthesesExpr, *ast.ExistsSubqueryExpr, *ast.PatternInExpr, *ast.PatternLikeExpr, *ast.BetweenExpr:
+		// for WHERE clause whose top level is not binary operation (ex: WHERE a IS NULL)
+		new_visitor := &BinaryOpVisitor{v.QueryInfo_, new(BinaryOpExpression)}
+		in.Accept(new_visitor)
+		v.QueryInfo_.WhereExpression_ = new_visitor.BinaryOpExpression_
 		return in, true
 	case *driver.ValueExpr:
 		// when INSERT
 		v.QueryInfo_.Values_ = append(v.QueryInfo_.Values_, ValueExprToValue(node))
 		return in, true
+	case *ast.UnaryOperationExpr:
+		// when INSERT with negative number
+		negated := negatedValueOf(operandOf(v.QueryInfo_, node.V))
+		if node.Op != opcode.Minus || negated == nil {
+			panic("unknown node for visitor")
+		}
+		v.QueryInfo_.Values_ = append(v.QueryInfo_.Values_, negated)
+		return in, true
 	case *ast.Limit:
 		cdv := &ChildDataVisitor{make([]interface{}, 0)}
 		node.Accept(cdv)
@@ -139,14 +251,39 @@ func (v *RootSQLVisitor) Enter(in ast.Node) (ast.Node, bool) {
 			v.QueryInfo_.OffsetNum_ = cdv.ChildDatas_[1].(*types.Value).ToInteger()
 		}
 
+		return in, true
+	case *ast.GroupByClause:
+		for _, item := range node.Items {
+			// only column can be specified. planner returns error for other expressions
+			colname := exprNodeToStr(item.Expr)
+			if colExpr, ok := item.Expr.(*ast.ColumnNameExpr); ok {
+				colname = colExpr.Name.String()
+			}
+			v.QueryInfo_.GroupByCols_ = append(v.QueryInfo_.GroupByCols_, &colname)
+		}
+		return in, true
+	case *ast.HavingClause:
+		new_visitor := &BinaryOpVisitor{v.QueryInfo_, new(BinaryOpExpression)}
+		node.Expr.Accept(new_visitor)
+		v.QueryInfo_.HavingExpression_ = new_visitor.BinaryOpExpression_
 		return in, true
 	case *ast.OrderByClause:
 	case *ast.ByItem:
-		cdv := &ChildDataVisitor{make([]interface{}, 0)}
-		node.Accept(cdv)
 		obe := new(OrderByExpression)
 		obe.IsDesc_ = node.Desc
-		obe.ColName_ = cdv.ChildDatas_[0].(*string)
+		// NULL is regarded as larger than any value when NULLS FIRST or LAST is not specified
+		obe.NullsFirst_ = node.Desc
+		if aggExpr, ok := node.Expr.(*ast.AggregateFuncExpr); ok {
+			obe.AggField_ = aggSelectFieldOf(aggExpr)
+		} else if _, ok := unwrapParentheses(node.Expr).(*ast.ColumnNameExpr); !ok {
+			// calculated value on SELECT clause (ex: ORDER BY a + 1)
+			colname := calcColNameOf(unwrapParentheses(node.Expr))
+			obe.ColName_ = &colname
+		} else {
+			cdv := &ChildDataVisitor{make([]interface{}, 0)}
+			node.Accept(cdv)
+			obe.ColName_ = cdv.ChildDatas_[0].(*string)
+		}
 		v.QueryInfo_.OrderByExpressions_ = append(v.QueryInfo_.OrderByExpressions_, obe)
 		return in, true
 	default:
@@ -155,6 +292,104 @@ func (v *RootSQLVisitor) Enter(in ast.Node) (ast.Node, bool) {
 	return in, false
 }
 
+// returns subquery whose query is extracted with another RootSQLVisitor
+func subqueryOf(node *ast.SubqueryExpr, subqueryType SubqueryType) *SubqueryExpression {
+	sv := NewRootSQLVisitor()
+	node.Query.Accept(sv)
+	return &SubqueryExpression{subqueryType, false, nil, sv.QueryInfo_, nil}
+}
+
+func colDefExpressionOf(node *ast.ColumnDef) *ColDefExpression {
+	cdef := new(ColDefExpression)
+	cname := node.Name.String()
+	cdef.ColName_ = &cname
+	col_type := node.Tp.Tp
+	var ctype types.TypeID
+	switch col_type {
+	case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong:
+		ctype = types.Integer
+	case mysql.TypeLonglong:
+		ctype = types.BigInt
+	case mysql.TypeFloat:
+		ctype = types.Float
+	case mysql.TypeDouble:
+		ctype = types.Double
+	case mysql.TypeNewDecimal:
+		ctype = types.Decimal
+	case mysql.TypeDate, mysql.TypeDatetime, mysql.TypeTimestamp:
+		// DATE is stored as Timestamp of 00:00:00
+		ctype = types.Timestamp
+	default:
+		if node.Tp.Charset == charset.CharsetBin {
+			// BLOB, BINARY and VARBINARY
+			ctype = types.Blob
+		} else {
+			ctype = types.Varchar
+		}
+	}
+	cdef.ColType_ = &ctype
+	switch col_type {
+	case mysql.TypeTinyBlob, mysql.TypeBlob, mysql.TypeMediumBlob, mysql.TypeLongBlob, mysql.TypeJSON:
+		// TEXT is also parsed as one of the blob types
+		cdef.IsLargeObject_ = true
+	default:
+		if ctype.IsVariableLength() && node.Tp.Flen > 0 {
+			cdef.Length_ = uint32(node.Tp.Flen)
+		}
+	}
+	for _, option := range node.Options {
+		switch option.Tp {
+		case ast.ColumnOptionNotNull:
+			cdef.IsNotNull_ = true
+		case ast.ColumnOptionPrimaryKey:
+			cdef.IsNotNull_ = true
+			cdef.IsUnique_ = true
+		case ast.ColumnOptionUniqKey:
+			cdef.IsUnique_ = true
+		case ast.ColumnOptionCheck:
+			if option.Enforced {
+				exprStr := exprNodeToStr(option.Expr)
+				cdef.CheckExprStrs_ = append(cdef.CheckExprStrs_, &exprStr)
+			}
+		}
+	}
+	return cdef
+}
+
+// PRIMARY KEY and UNIQUE constraints are backed by unique skip list index.
+// INDEX_KIND_INVALID is returned for not supported constraint (FOREIGN KEY, etc...)
+func indexKindOfConstraint(node *ast.Constraint) index_constants.IndexKind {
+	switch node.Tp {
+	case ast.ConstraintPrimaryKey, ast.ConstraintUniq, ast.ConstraintUniqKey, ast.ConstraintUniqIndex:
+		return index_constants.INDEX_KIND_UNIQ_SKIP_LIST
+	case ast.ConstraintKey, ast.ConstraintIndex:
+		return index_constants.INDEX_KIND_SKIP_LIST
+	default:
+		return index_constants.INDEX_KIND_INVALID
+	}
+}
+
+// USING SKIPLIST is rewritten to no USING clause at ProcessSQLStr, so skip list is default
+func indexKindOfCreateIndexStmt(node *ast.CreateIndexStmt) index_constants.IndexKind {
+	tp := model.IndexTypeInvalid
+	if node.IndexOption != nil {
+		tp = node.IndexOption.Tp
+	}
+	isUnique := node.KeyType == ast.IndexKeyTypeUnique
+	switch {
+	case tp == model.IndexTypeInvalid && isUnique:
+		return index_constants.INDEX_KIND_UNIQ_SKIP_LIST
+	case tp == model.IndexTypeInvalid:
+		return index_constants.INDEX_KIND_SKIP_LIST
+	case tp == model.IndexTypeHash && !isUnique:
+		return index_constants.INDEX_KIND_HASH
+	case tp == model.IndexTypeBtree && !isUnique:
+		return index_constants.INDEX_KIND_BTREE
+	default:
+		return index_constants.INDEX_KIND_INVALID
+	}
+}
+
 func (v *RootSQLVisitor) Leave(in ast.Node) (ast.Node, bool) {
 	return in, true
 }
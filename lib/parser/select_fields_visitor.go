@@ -3,6 +3,7 @@ package parser
 import (
 	"github.com/pingcap/parser/ast"
 	"github.com/ryogrid/SamehadaDB/lib/execution/plans"
+	"github.com/ryogrid/SamehadaDB/lib/types"
 	"strings"
 )
 
@@ -34,24 +35,33 @@ func (v *SelectFieldsVisitor) Enter(in ast.Node) (ast.Node, bool) {
 			v.QueryInfo_.SelectFields_ = append(v.QueryInfo_.SelectFields_, sfield)
 			return in, true
 		}
-	case *ast.AggregateFuncExpr:
-		av := new(AggFuncVisitor)
-		node.Accept(av)
-		var sfield *SelectFieldExpression = nil
-		aggTypeStr := node.F
-		switch aggTypeStr {
-		case "count":
-			sfield = &SelectFieldExpression{true, plans.COUNT_AGGREGATE, av.TableName_, av.ColumnName_}
-		case "max":
-			sfield = &SelectFieldExpression{true, plans.MAX_AGGREGATE, av.TableName_, av.ColumnName_}
-		case "min":
-			sfield = &SelectFieldExpression{true, plans.MIN_AGGREGATE, av.TableName_, av.ColumnName_}
-		case "sum":
-			sfield = &SelectFieldExpression{true, plans.SUM_AGGREGATE, av.TableName_, av.ColumnName_}
+		expr := unwrapParentheses(node.Expr)
+		switch expr.(type) {
+		case *ast.ColumnNameExpr, *ast.SubqueryExpr, *ast.AggregateFuncExpr:
+		default:
+			// calculated value (ex: a + 1, UPPER(name))
+			sfield := new(SelectFieldExpression)
+			operand := operandOf(v.QueryInfo_, expr)
+			if isCalcOperand(operand) {
+				if calc, ok := operand.(*CalcExpression); ok {
+					sfield.Calc_ = calc
+				} else {
+					sfield.Calc_ = &CalcExpression{-1, -1, []interface{}{operand}, types.Invalid}
+				}
+			}
+			alias := calcColNameOf(expr)
+			sfield.Alias_ = &alias
+			v.QueryInfo_.SelectFields_ = append(v.QueryInfo_.SelectFields_, sfield)
+			return in, true
 		}
-
+	case *ast.SubqueryExpr:
+		sfield := new(SelectFieldExpression)
+		sfield.Subquery_ = subqueryOf(node, SCALAR_SUBQUERY)
 		v.QueryInfo_.SelectFields_ = append(v.QueryInfo_.SelectFields_, sfield)
 		return in, true
+	case *ast.AggregateFuncExpr:
+		v.QueryInfo_.SelectFields_ = append(v.QueryInfo_.SelectFields_, aggSelectFieldOf(node))
+		return in, true
 	default:
 	}
 	return in, false
@@ -60,3 +70,25 @@ func (v *SelectFieldsVisitor) Enter(in ast.Node) (ast.Node, bool) {
 func (v *SelectFieldsVisitor) Leave(in ast.Node) (ast.Node, bool) {
 	return in, true
 }
+
+// returns nil when the aggregate function is not supported
+func aggSelectFieldOf(node *ast.AggregateFuncExpr) *SelectFieldExpression {
+	av := new(AggFuncVisitor)
+	node.Accept(av)
+	switch strings.ToLower(node.F) {
+	case "count":
+		if node.Distinct {
+			return &SelectFieldExpression{true, plans.COUNT_DISTINCT_AGGREGATE, av.TableName_, av.ColumnName_, nil, nil, nil}
+		}
+		return &SelectFieldExpression{true, plans.COUNT_AGGREGATE, av.TableName_, av.ColumnName_, nil, nil, nil}
+	case "max":
+		return &SelectFieldExpression{true, plans.MAX_AGGREGATE, av.TableName_, av.ColumnName_, nil, nil, nil}
+	case "min":
+		return &SelectFieldExpression{true, plans.MIN_AGGREGATE, av.TableName_, av.ColumnName_, nil, nil, nil}
+	case "sum":
+		return &SelectFieldExpression{true, plans.SUM_AGGREGATE, av.TableName_, av.ColumnName_, nil, nil, nil}
+	case "avg":
+		return &SelectFieldExpression{true, plans.AVG_AGGREGATE, av.TableName_, av.ColumnName_, nil, nil, nil}
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package optimizer
+
+import (
+	"errors"
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/ryogrid/SamehadaDB/lib/execution/expression"
+	"github.com/ryogrid/SamehadaDB/lib/execution/plans"
+	"github.com/ryogrid/SamehadaDB/lib/parser"
+	"github.com/ryogrid/SamehadaDB/lib/storage/index/index_constants"
+	"github.com/ryogrid/SamehadaDB/lib/storage/table/column"
+	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
+	"github.com/ryogrid/SamehadaDB/lib/types"
+	"math"
+	"strings"
+)
+
+var UnsupportedAggregationErr = errors.New("specified aggregate function is not supported!")
+var NotGroupedColumnErr = errors.New("column must appear in GROUP BY clause or be used in aggregate function!")
+
+// IncludesAggregation returns true when SELECT query has aggregate functions or GROUP BY clause
+func IncludesAggregation(qi *parser.QueryInfo) bool {
+	if len(qi.GroupByCols_) > 0 {
+		return true
+	}
+	for _, sfield := range qi.SelectFields_ {
+		if sfield != nil && sfield.IsAgg_ {
+			return true
+		}
+	}
+	return false
+}
+
+// returns columns referred by GROUP BY and HAVING clause. they should be output from child plan of aggregation
+// in addition to columns on SELECT clause
+func touchedColumnsOfAggregation(qi *parser.QueryInfo) mapset.Set[string] {
+	ret := mapset.NewSet[string]()
+	for _, colName := range qi.GroupByCols_ {
+		ret.Add(strings.ToLower(*colName))
+	}
+	if qi.HavingExpression_ != nil && qi.HavingExpression_.Left_ != nil {
+		ret = ret.Union(qi.HavingExpression_.TouchedColumns())
+	}
+	return ret
+}
+
+func qualifiedColName(sfield *parser.SelectFieldExpression) string {
+	if sfield.TableName_ != nil {
+		return *sfield.TableName_ + "." + *sfield.ColName_
+	}
+	return *sfield.ColName_
+}
+
+// returns column name of aggregation result on output schema (ex: "count(*)", "sum(table_name.column_name)")
+func aggregateColName(sfield *parser.SelectFieldExpression) string {
+	colName := qualifiedColName(sfield)
+	switch sfield.AggType_ {
+	case plans.COUNT_AGGREGATE:
+		return "count(" + colName + ")"
+	case plans.COUNT_DISTINCT_AGGREGATE:
+		return "count(distinct " + colName + ")"
+	case plans.SUM_AGGREGATE:
+		return "sum(" + colName + ")"
+	case plans.MIN_AGGREGATE:
+		return "min(" + colName + ")"
+	case plans.MAX_AGGREGATE:
+		return "max(" + colName + ")"
+	default: // AVG
+		return "avg(" + colName + ")"
+	}
+}
+
+// aggregationBuilder collects group by terms and aggregates which are referred by SELECT and HAVING clause
+type aggregationBuilder struct {
+	childSchema  *schema.Schema
+	groupByNames []string
+	groupBys     []expression.Expression
+	aggNames     []string
+	aggregates   []expression.Expression
+	aggTypes     []plans.AggregationType
+}
+
+func (ab *aggregationBuilder) columnValueOf(colName string) (*expression.ColumnValue, error) {
+	colIdx := ab.childSchema.GetColIndex(colName)
+	if colIdx == math.MaxUint32 {
+		return nil, InvalidColNameErr
+	}
+	return expression.NewColumnValue(0, colIdx, ab.childSchema.GetColumn(colIdx).GetType()).(*expression.ColumnValue), nil
+}
+
+// returns AggregateValueExpression which refers to group by term of colName
+func (ab *aggregationBuilder) groupByTermOf(colName string) (expression.Expression, error) {
+	for idx, name := range ab.groupByNames {
+		if name == strings.ToLower(colName) {
+			return expression.NewAggregateValueExpression(true, uint32(idx), ab.groupBys[idx].GetReturnType()), nil
+		}
+	}
+	return nil, NotGroupedColumnErr
+}
+
+// returns AggregateValueExpression which refers to result of sfield.
+// same aggregation is shared between SELECT and HAVING clause
+func (ab *aggregationBuilder) aggregateOf(sfield *parser.SelectFieldExpression) (expression.Expression, error) {
+	if sfield == nil {
+		return nil, UnsupportedAggregationErr
+	}
+	aggName := aggregateColName(sfield)
+	aggIdx := -1
+	for idx, name := range ab.aggNames {
+		if name == aggName {
+			aggIdx = idx
+			break
+		}
+	}
+	if aggIdx == -1 {
+		var aggExp expression.Expression
+		if *sfield.ColName_ == "*" {
+			if sfield.AggType_ != plans.COUNT_AGGREGATE {
+				return nil, UnsupportedAggregationErr
+			}
+			// every row is counted
+			aggExp = expression.NewConstantValue(types.NewInteger(1), types.Integer)
+		} else {
+			colVal, err := ab.columnValueOf(qualifiedColName(sfield))
+			if err != nil {
+				return nil, err
+			}
+			if (sfield.AggType_ == plans.SUM_AGGREGATE || sfield.AggType_ == plans.AVG_AGGREGATE) &&
+				!colVal.GetReturnType().IsNumeric() {
+				return nil, UnsupportedAggregationErr
+			}
+			aggExp = colVal
+		}
+		ab.aggNames = append(ab.aggNames, aggName)
+		ab.aggregates = append(ab.aggregates, aggExp)
+		ab.aggTypes = append(ab.aggTypes, sfield.AggType_)
+		aggIdx = len(ab.aggNames) - 1
+	}
+
+	retType := ab.aggregates[aggIdx].GetReturnType()
+	switch sfield.AggType_ {
+	case plans.COUNT_AGGREGATE, plans.COUNT_DISTINCT_AGGREGATE:
+		retType = types.Integer
+	case plans.AVG_AGGREGATE:
+		retType = plans.AvgTypeOf(retType)
+	}
+	return expression.NewAggregateValueExpression(false, uint32(aggIdx), retType), nil
+}
+
+// converts HAVING clause to expression which is evaluated with EvaluateAggregate
+func (ab *aggregationBuilder) convHavingExpr(exp *parser.BinaryOpExpression) (expression.Expression, error) {
+	if exp.GetType() == parser.Logical {
+		left, err := ab.convHavingExpr(exp.Left_.(*parser.BinaryOpExpression))
+		if err != nil {
+			return nil, err
+		}
+		right, err := ab.convHavingExpr(exp.Right_.(*parser.BinaryOpExpression))
+		if err != nil {
+			return nil, err
+		}
+		return expression.NewLogicalOp(left, right, exp.LogicalOperationType_, types.Boolean), nil
+	}
+
+	convLeaf := func(leaf interface{}) (expression.Expression, error) {
+		switch casted := leaf.(type) {
+		case *parser.SelectFieldExpression:
+			return ab.aggregateOf(casted)
+		case *string:
+			return ab.groupByTermOf(*casted)
+		case *types.Value:
+			return expression.NewConstantValue(*casted.GetDeepCopy(), casted.ValueType()), nil
+		default:
+			return nil, UnsupportedAggregationErr
+		}
+	}
+	left, err := convLeaf(exp.Left_)
+	if err != nil {
+		return nil, err
+	}
+	right, err := convLeaf(exp.Right_)
+	if err != nil {
+		return nil, err
+	}
+	// integer literal is compared with Float result (ex: AVG(col) > 10)
+	if left.GetReturnType() == types.Float && right.GetType() == expression.EXPRESSION_TYPE_CONSTANT_VALUE {
+		if val := right.(*expression.ConstantValue).GetValue(); val.ValueType() == types.Integer && !val.IsNull() {
+			right = expression.NewConstantValue(types.NewFloat(float32(val.ToInteger())), types.Float)
+		}
+	}
+	return expression.NewComparison(left, right, exp.ComparisonOperationType_, types.Boolean), nil
+}
+
+// AttachAggregation attaches AggregationPlanNode which processes GROUP BY clause, HAVING clause
+// and aggregate functions on SELECT clause to child. column names on qi should be qualified with table name
+func AttachAggregation(qi *parser.QueryInfo, child plans.Plan) (plans.Plan, error) {
+	ab := &aggregationBuilder{childSchema: child.OutputSchema()}
+	for _, colName := range qi.GroupByCols_ {
+		colVal, err := ab.columnValueOf(*colName)
+		if err != nil {
+			return nil, err
+		}
+		ab.groupByNames = append(ab.groupByNames, strings.ToLower(*colName))
+		ab.groupBys = append(ab.groupBys, colVal)
+	}
+
+	outColDefs := make([]*column.Column, 0)
+	for _, sfield := range qi.SelectFields_ {
+		var outExp expression.Expression
+		var colName string
+		var err error
+		if sfield != nil && sfield.Calc_ != nil {
+			// calculation with result of aggregation is not supported
+			return nil, UnsupportedExpressionErr
+		}
+		if sfield == nil || sfield.IsAgg_ {
+			outExp, err = ab.aggregateOf(sfield)
+			if err == nil {
+				colName = aggregateColName(sfield)
+			}
+		} else {
+			colName = qualifiedColName(sfield)
+			outExp, err = ab.groupByTermOf(colName)
+		}
+		if err != nil {
+			return nil, err
+		}
+		// AggregationExecutor expects expression of output column is not pointer
+		outColDefs = append(outColDefs, column.NewColumn(colName, outExp.GetReturnType(), false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), *outExp.(*expression.AggregateValueExpression)))
+	}
+
+	var having expression.Expression = nil
+	if qi.HavingExpression_ != nil && qi.HavingExpression_.Left_ != nil {
+		var err error
+		having, err = ab.convHavingExpr(qi.HavingExpression_)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return plans.NewAggregationPlanNode(schema.NewSchema(outColDefs), child, having, ab.groupBys, ab.aggregates, ab.aggTypes), nil
+}
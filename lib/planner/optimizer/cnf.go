@@ -0,0 +1,95 @@
+package optimizer
+
+import (
+	"github.com/ryogrid/SamehadaDB/lib/execution/expression"
+	"github.com/ryogrid/SamehadaDB/lib/parser"
+)
+
+// upper limit of clause count which is generated by distributing OR over AND.
+// the conversion can make exponential number of clauses. when the limit is exceeded,
+// OR expression is not distributed and treated as one clause
+const maxCNFClauseNum = 64
+
+func isEmptyBinaryOpExp(exp *parser.BinaryOpExpression) bool {
+	return exp == nil || (exp.Left_ == nil && exp.Right_ == nil)
+}
+
+// returns clauses of CNF. each clause is list of items which are connected with OR.
+// item is comparison or IS NULL, or OR expression which was not distributed
+func cnfClausesOf(exp *parser.BinaryOpExpression) [][]*parser.BinaryOpExpression {
+	if exp.GetType() != parser.Logical {
+		return [][]*parser.BinaryOpExpression{{exp}}
+	}
+
+	left := cnfClausesOf(exp.Left_.(*parser.BinaryOpExpression))
+	right := cnfClausesOf(exp.Right_.(*parser.BinaryOpExpression))
+	if exp.LogicalOperationType_ == expression.AND {
+		return append(left, right...)
+	}
+
+	// (a AND b) OR (c AND d) => (a OR c) AND (a OR d) AND (b OR c) AND (b OR d)
+	if len(left)*len(right) > maxCNFClauseNum {
+		return [][]*parser.BinaryOpExpression{{exp}}
+	}
+	ret := make([][]*parser.BinaryOpExpression, 0, len(left)*len(right))
+	for _, lClause := range left {
+		for _, rClause := range right {
+			clause := make([]*parser.BinaryOpExpression, 0, len(lClause)+len(rClause))
+			clause = append(clause, lClause...)
+			clause = append(clause, rClause...)
+			ret = append(ret, clause)
+		}
+	}
+	return ret
+}
+
+// NormalizeToCNF converts predicate to conjunctive normal form (AND of ORs) which
+// has arbitrary nested parentheses. optimizer can push down each clause to scan of
+// tables which the clause touches and use index union for clause which consists of OR
+func NormalizeToCNF(exp *parser.BinaryOpExpression) *parser.BinaryOpExpression {
+	if isEmptyBinaryOpExp(exp) {
+		return exp
+	}
+
+	var ret *parser.BinaryOpExpression = nil
+	for _, clause := range cnfClausesOf(exp) {
+		// items are deep copied because same item is shared among clauses when OR is distributed
+		orExp := clause[0].GetDeepCopy()
+		for _, item := range clause[1:] {
+			orExp = &parser.BinaryOpExpression{expression.OR, -1, orExp, item.GetDeepCopy()}
+		}
+		if ret == nil {
+			ret = orExp
+		} else {
+			ret = ret.AppendBinaryOpExpWithAnd(orExp)
+		}
+	}
+	return ret
+}
+
+// returns items connected with OR. ok is false when exp includes AND under OR
+func disjunctItemsOf(exp *parser.BinaryOpExpression) (items []*parser.BinaryOpExpression, ok bool) {
+	if exp.GetType() != parser.Logical {
+		return []*parser.BinaryOpExpression{exp}, true
+	}
+	if exp.LogicalOperationType_ != expression.OR {
+		return nil, false
+	}
+	left, okL := disjunctItemsOf(exp.Left_.(*parser.BinaryOpExpression))
+	right, okR := disjunctItemsOf(exp.Right_.(*parser.BinaryOpExpression))
+	if !okL || !okR {
+		return nil, false
+	}
+	return append(left, right...), true
+}
+
+// returns clauses connected with AND on top level of exp. empty expression is not included
+func conjunctsOf(exp *parser.BinaryOpExpression) []*parser.BinaryOpExpression {
+	if isEmptyBinaryOpExp(exp) {
+		return []*parser.BinaryOpExpression{}
+	}
+	if exp.GetType() == parser.Logical && exp.LogicalOperationType_ == expression.AND {
+		return append(conjunctsOf(exp.Left_.(*parser.BinaryOpExpression)), conjunctsOf(exp.Right_.(*parser.BinaryOpExpression))...)
+	}
+	return []*parser.BinaryOpExpression{exp}
+}
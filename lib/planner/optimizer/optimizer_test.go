@@ -444,3 +444,348 @@ func TestSimplePlanOptimization(t *testing.T) {
 
 	// "select * from Sc1, Sc4 where Sc1.c1 = Sc4.c1 and Sc4.c1 = 2;" // Asterisk (Not supported now...)
 }
+
+func containsPlanType(plan plans.Plan, planType plans.PlanType) bool {
+	if plan.GetType() == planType {
+		return true
+	}
+	for _, child := range plan.GetChildren() {
+		if child != nil && containsPlanType(child, planType) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCompositeIndexScan(t *testing.T) {
+	diskManager := disk.NewDiskManagerTest()
+	defer diskManager.ShutDown()
+	log_mgr := recovery.NewLogManager(&diskManager)
+	bpm := buffer.NewBufferPoolManager(common.BufferPoolMaxFrameNumForTest, diskManager, log_mgr)
+	lock_mgr := access.NewLockManager(access.REGULAR, access.DETECTION)
+	txn_mgr := access.NewTransactionManager(lock_mgr, log_mgr)
+
+	txn := txn_mgr.Begin(nil)
+	c := catalog.BootstrapCatalog(bpm, log_mgr, lock_mgr, txn)
+	exec_ctx := executors.NewExecutorContext(c, bpm, txn)
+
+	Sc5Meta := &SetupTableMeta{
+		"Sc5",
+		1000,
+		[]*ColumnMeta{
+			{"f1", types.Integer, index_constants.INDEX_KIND_INVALID},
+			{"f2", types.Integer, index_constants.INDEX_KIND_INVALID},
+			{"f3", types.Varchar, index_constants.INDEX_KIND_INVALID},
+		},
+		[]ColValGenFunc{
+			func(idx int) interface{} { return int32(idx % 10) },
+			func(idx int) interface{} { return int32(idx % 100) },
+			func(idx int) interface{} { return "f3-" + strconv.Itoa(idx) },
+		},
+	}
+	tm5 := SetupTableWithMetadata(exec_ctx, Sc5Meta)
+	testingpkg.SimpleAssert(t, c.CreateCompositeIndex("f1_f2_idx", "Sc5", []string{"f1", "f2"}, false, txn) == nil)
+	testingpkg.SimpleAssert(t, c.CreateCompositeIndex("f2_f2_idx", "Sc5", []string{"f2", "f2"}, false, txn) == catalog.IndexColumnDuplicatedErr)
+	tm5.GetStatistics().Update(tm5, txn)
+	txn_mgr.Commit(c, txn)
+
+	testAQuery := func(queryStr string, isIndexUsed bool, expectedRows int) {
+		queryInfo, _ := parser.ProcessSQLStr(&queryStr)
+		queryInfo, _ = RewriteQueryInfo(c, queryInfo)
+		solution, err := NewSelingerOptimizer(queryInfo, c).Optimize()
+		testingpkg.SimpleAssert(t, err == nil)
+		printBestPlan("CompositeIndex", queryStr, solution)
+		testingpkg.SimpleAssert(t, containsPlanType(solution, plans.CompositeIndexRangeScan) == isIndexUsed)
+
+		txn_ := txn_mgr.Begin(nil)
+		execRslt := (&executors.ExecutionEngine{}).Execute(solution, executors.NewExecutorContext(c, bpm, txn_))
+		txn_mgr.Commit(c, txn_)
+		testingpkg.SimpleAssert(t, len(execRslt) == expectedRows)
+	}
+
+	testAQuery("select Sc5.f3 from Sc5 where Sc5.f1 = 3 and Sc5.f2 = 33;", true, 10)
+	testAQuery("select Sc5.f3 from Sc5 where Sc5.f2 = 33 and Sc5.f1 = 3;", true, 10)
+	testAQuery("select Sc5.f3 from Sc5 where Sc5.f1 = 3 and Sc5.f2 >= 33 and Sc5.f2 < 63;", true, 30)
+	testAQuery("select Sc5.f3 from Sc5 where Sc5.f1 = 3 and Sc5.f2 = 34;", true, 0)
+	// first key column has no condition
+	testAQuery("select Sc5.f3 from Sc5 where Sc5.f2 = 33;", false, 10)
+}
+
+func TestOrderByAndLimit(t *testing.T) {
+	diskManager := disk.NewDiskManagerTest()
+	defer diskManager.ShutDown()
+	log_mgr := recovery.NewLogManager(&diskManager)
+	bpm := buffer.NewBufferPoolManager(common.BufferPoolMaxFrameNumForTest, diskManager, log_mgr)
+	lock_mgr := access.NewLockManager(access.REGULAR, access.DETECTION)
+	txn_mgr := access.NewTransactionManager(lock_mgr, log_mgr)
+
+	txn := txn_mgr.Begin(nil)
+	c := catalog.BootstrapCatalog(bpm, log_mgr, lock_mgr, txn)
+	exec_ctx := executors.NewExecutorContext(c, bpm, txn)
+
+	Sc6Meta := &SetupTableMeta{
+		"Sc6",
+		1000,
+		[]*ColumnMeta{
+			{"f1", types.Integer, index_constants.INDEX_KIND_SKIP_LIST},
+			{"f2", types.Integer, index_constants.INDEX_KIND_INVALID},
+		},
+		[]ColValGenFunc{
+			func(idx int) interface{} { return int32(999 - idx) },
+			func(idx int) interface{} { return int32(idx % 100) },
+		},
+	}
+	tm6 := SetupTableWithMetadata(exec_ctx, Sc6Meta)
+	tm6.GetStatistics().Update(tm6, txn)
+	txn_mgr.Commit(c, txn)
+
+	testAQuery := func(queryStr string, isSortNeeded bool, expectedVals []int32) {
+		queryInfo, _ := parser.ProcessSQLStr(&queryStr)
+		queryInfo, _ = RewriteQueryInfo(c, queryInfo)
+		solution, err := NewSelingerOptimizer(queryInfo, c).Optimize()
+		testingpkg.SimpleAssert(t, err == nil)
+		printBestPlan("OrderByAndLimit", queryStr, solution)
+		testingpkg.SimpleAssert(t, containsPlanType(solution, plans.Orderby) == isSortNeeded)
+
+		txn_ := txn_mgr.Begin(nil)
+		execRslt := (&executors.ExecutionEngine{}).Execute(solution, executors.NewExecutorContext(c, bpm, txn_))
+		txn_mgr.Commit(c, txn_)
+		testingpkg.SimpleAssert(t, len(execRslt) == len(expectedVals))
+		for ii, val := range expectedVals {
+			testingpkg.SimpleAssert(t, execRslt[ii].GetValue(solution.OutputSchema(), 0).ToInteger() == val)
+		}
+	}
+
+	// index range scan yields records in order of f1
+	testAQuery("select Sc6.f1 from Sc6 where Sc6.f1 >= 10 and Sc6.f1 < 15 order by Sc6.f1;", false, []int32{10, 11, 12, 13, 14})
+	testAQuery("select Sc6.f1 from Sc6 where Sc6.f1 >= 10 and Sc6.f1 < 15 order by Sc6.f1 desc;", true, []int32{14, 13, 12, 11, 10})
+	testAQuery("select Sc6.f1 from Sc6 where Sc6.f1 >= 10 and Sc6.f1 < 100 order by Sc6.f1 limit 3 offset 2;", false, []int32{12, 13, 14})
+	// sort by column which is not on SELECT clause
+	testAQuery("select Sc6.f1 from Sc6 where Sc6.f2 = 5 order by Sc6.f2, Sc6.f1 limit 4;", true, []int32{94, 194, 294, 394})
+	testAQuery("select Sc6.f2 from Sc6 where Sc6.f2 < 3 order by f2 desc limit 2;", true, []int32{2, 2})
+}
+
+func TestORAndParentheses(t *testing.T) {
+	diskManager := disk.NewDiskManagerTest()
+	defer diskManager.ShutDown()
+	log_mgr := recovery.NewLogManager(&diskManager)
+	bpm := buffer.NewBufferPoolManager(common.BufferPoolMaxFrameNumForTest, diskManager, log_mgr)
+	lock_mgr := access.NewLockManager(access.REGULAR, access.DETECTION)
+	txn_mgr := access.NewTransactionManager(lock_mgr, log_mgr)
+
+	txn := txn_mgr.Begin(nil)
+	c := catalog.BootstrapCatalog(bpm, log_mgr, lock_mgr, txn)
+	exec_ctx := executors.NewExecutorContext(c, bpm, txn)
+
+	Sc7Meta := &SetupTableMeta{
+		"Sc7",
+		1000,
+		[]*ColumnMeta{
+			{"f1", types.Integer, index_constants.INDEX_KIND_SKIP_LIST},
+			{"f2", types.Integer, index_constants.INDEX_KIND_HASH},
+			{"f3", types.Integer, index_constants.INDEX_KIND_INVALID},
+		},
+		[]ColValGenFunc{
+			func(idx int) interface{} { return int32(idx) },
+			func(idx int) interface{} { return int32(idx % 100) },
+			func(idx int) interface{} { return int32(idx % 7) },
+		},
+	}
+	tm7 := SetupTableWithMetadata(exec_ctx, Sc7Meta)
+	tm7.GetStatistics().Update(tm7, txn)
+	Sc8Meta := &SetupTableMeta{
+		"Sc8",
+		20,
+		[]*ColumnMeta{
+			{"g1", types.Integer, index_constants.INDEX_KIND_INVALID},
+		},
+		[]ColValGenFunc{
+			func(idx int) interface{} { return int32(idx) },
+		},
+	}
+	tm8 := SetupTableWithMetadata(exec_ctx, Sc8Meta)
+	tm8.GetStatistics().Update(tm8, txn)
+	txn_mgr.Commit(c, txn)
+
+	testAQuery := func(queryStr string, isUnionUsed bool, expectedRows int) {
+		queryInfo, _ := parser.ProcessSQLStr(&queryStr)
+		queryInfo, err := RewriteQueryInfo(c, queryInfo)
+		testingpkg.SimpleAssert(t, err == nil)
+		solution, err := NewSelingerOptimizer(queryInfo, c).Optimize()
+		testingpkg.SimpleAssert(t, err == nil)
+		printBestPlan("ORAndParentheses", queryStr, solution)
+		testingpkg.SimpleAssert(t, containsPlanType(solution, plans.IndexUnion) == isUnionUsed)
+
+		txn_ := txn_mgr.Begin(nil)
+		execRslt := (&executors.ExecutionEngine{}).Execute(solution, executors.NewExecutorContext(c, bpm, txn_))
+		txn_mgr.Commit(c, txn_)
+		testingpkg.SimpleAssert(t, len(execRslt) == expectedRows)
+	}
+
+	testAQuery("select Sc7.f1 from Sc7 where Sc7.f1 = 5 or Sc7.f1 = 500;", true, 2)
+	testAQuery("select Sc7.f1 from Sc7 where Sc7.f1 < 3 or Sc7.f2 = 50;", true, 13)
+	// records which match both conditions are emitted once
+	testAQuery("select Sc7.f1 from Sc7 where Sc7.f1 < 10 or Sc7.f2 = 5;", true, 19)
+	testAQuery("select Sc7.f1 from Sc7 where (Sc7.f1 = 1 or Sc7.f1 = 2) and Sc7.f3 = 1;", true, 1)
+	// f3 has no index
+	testAQuery("select Sc7.f1 from Sc7 where Sc7.f1 < 10 or Sc7.f3 = 1;", false, 151)
+	// nested parentheses and column names without table name
+	testAQuery("select f1 from Sc7 where ((f1 = 1 and f3 = 1) or (f1 = 3 and f3 = 2)) or f1 = 999;", true, 2)
+	testAQuery("select f1 from Sc7 where f1 = f2 and (f3 = 0 or f3 = 1);", false, 30)
+	// conditions which touch both tables are checked on join
+	testAQuery("select Sc7.f1, Sc8.g1 from Sc7, Sc8 where Sc7.f1 = Sc8.g1 and (Sc7.f2 = 3 or Sc8.g1 = 10);", false, 2)
+	testAQuery("select Sc7.f1, g1 from Sc7, Sc8 where Sc7.f1 < g1 and g1 < 3;", false, 3)
+}
+
+func TestOuterJoinOptimization(t *testing.T) {
+	diskManager := disk.NewDiskManagerTest()
+	defer diskManager.ShutDown()
+	log_mgr := recovery.NewLogManager(&diskManager)
+	bpm := buffer.NewBufferPoolManager(common.BufferPoolMaxFrameNumForTest, diskManager, log_mgr)
+	lock_mgr := access.NewLockManager(access.REGULAR, access.DETECTION)
+	txn_mgr := access.NewTransactionManager(lock_mgr, log_mgr)
+
+	txn := txn_mgr.Begin(nil)
+	c := catalog.BootstrapCatalog(bpm, log_mgr, lock_mgr, txn)
+	exec_ctx := executors.NewExecutorContext(c, bpm, txn)
+
+	Sc9Meta := &SetupTableMeta{
+		"Sc9",
+		1000,
+		[]*ColumnMeta{
+			{"h1", types.Integer, index_constants.INDEX_KIND_SKIP_LIST},
+			{"h2", types.Integer, index_constants.INDEX_KIND_INVALID},
+		},
+		[]ColValGenFunc{
+			func(idx int) interface{} { return int32(idx) },
+			func(idx int) interface{} { return int32(idx % 50) },
+		},
+	}
+	tm9 := SetupTableWithMetadata(exec_ctx, Sc9Meta)
+	tm9.GetStatistics().Update(tm9, txn)
+	// half of records of Sc10 have no matching record of Sc9
+	Sc10Meta := &SetupTableMeta{
+		"Sc10",
+		20,
+		[]*ColumnMeta{
+			{"k1", types.Integer, index_constants.INDEX_KIND_INVALID},
+			{"k2", types.Integer, index_constants.INDEX_KIND_INVALID},
+		},
+		[]ColValGenFunc{
+			func(idx int) interface{} { return int32(idx * 100) },
+			func(idx int) interface{} { return int32(idx % 2) },
+		},
+	}
+	tm10 := SetupTableWithMetadata(exec_ctx, Sc10Meta)
+	tm10.GetStatistics().Update(tm10, txn)
+	txn_mgr.Commit(c, txn)
+
+	testAQuery := func(queryStr string, expectedRows int) plans.Plan {
+		queryInfo, _ := parser.ProcessSQLStr(&queryStr)
+		queryInfo, err := RewriteQueryInfo(c, queryInfo)
+		testingpkg.SimpleAssert(t, err == nil)
+		solution, err := NewSelingerOptimizer(queryInfo, c).Optimize()
+		testingpkg.SimpleAssert(t, err == nil)
+		printBestPlan("OuterJoin", queryStr, solution)
+
+		txn_ := txn_mgr.Begin(nil)
+		execRslt := (&executors.ExecutionEngine{}).Execute(solution, executors.NewExecutorContext(c, bpm, txn_))
+		txn_mgr.Commit(c, txn_)
+		testingpkg.SimpleAssert(t, len(execRslt) == expectedRows)
+		return solution
+	}
+
+	// index of right table can be used for LEFT OUTER JOIN
+	solution := testAQuery("select Sc10.k1, Sc9.h2 from Sc10 left join Sc9 on Sc10.k1 = Sc9.h1;", 20)
+	testingpkg.SimpleAssert(t, containsPlanType(solution, plans.IndexJoin))
+	solution = testAQuery("select Sc10.k1, Sc9.h2 from Sc9 right join Sc10 on Sc9.h1 = Sc10.k1;", 20)
+	testingpkg.SimpleAssert(t, containsPlanType(solution, plans.IndexJoin))
+	testAQuery("select Sc10.k1 from Sc10 left join Sc9 on Sc10.k1 = Sc9.h1 where Sc9.h1 is null;", 10)
+	// condition on preserved side is pushed down, but condition on null side is not
+	testAQuery("select Sc10.k1 from Sc10 left join Sc9 on Sc10.k1 = Sc9.h1 where Sc10.k2 = 0 and Sc9.h2 = 0;", 5)
+	testAQuery("select Sc10.k1 from Sc10 left join Sc9 on Sc10.k1 = Sc9.h1 and Sc9.h2 = 1;", 20)
+	testAQuery("select Sc9.h1, Sc10.k1 from Sc9 full outer join Sc10 on Sc9.h1 = Sc10.k1;", 1010)
+	// join without equality condition
+	testAQuery("select Sc10.k1, Sc9.h1 from Sc10 left join Sc9 on Sc10.k1 < Sc9.h1 and Sc9.h1 < 5;", 23)
+}
+
+func TestSubqueryOptimization(t *testing.T) {
+	diskManager := disk.NewDiskManagerTest()
+	defer diskManager.ShutDown()
+	log_mgr := recovery.NewLogManager(&diskManager)
+	bpm := buffer.NewBufferPoolManager(common.BufferPoolMaxFrameNumForTest, diskManager, log_mgr)
+	lock_mgr := access.NewLockManager(access.REGULAR, access.DETECTION)
+	txn_mgr := access.NewTransactionManager(lock_mgr, log_mgr)
+
+	txn := txn_mgr.Begin(nil)
+	c := catalog.BootstrapCatalog(bpm, log_mgr, lock_mgr, txn)
+	exec_ctx := executors.NewExecutorContext(c, bpm, txn)
+
+	Sc11Meta := &SetupTableMeta{
+		"Sc11",
+		1000,
+		[]*ColumnMeta{
+			{"h1", types.Integer, index_constants.INDEX_KIND_SKIP_LIST},
+			{"h2", types.Integer, index_constants.INDEX_KIND_INVALID},
+		},
+		[]ColValGenFunc{
+			func(idx int) interface{} { return int32(idx) },
+			func(idx int) interface{} { return int32(idx % 50) },
+		},
+	}
+	tm11 := SetupTableWithMetadata(exec_ctx, Sc11Meta)
+	tm11.GetStatistics().Update(tm11, txn)
+	// half of records of Sc12 have no matching record of Sc11
+	Sc12Meta := &SetupTableMeta{
+		"Sc12",
+		20,
+		[]*ColumnMeta{
+			{"k1", types.Integer, index_constants.INDEX_KIND_INVALID},
+			{"k2", types.Integer, index_constants.INDEX_KIND_INVALID},
+		},
+		[]ColValGenFunc{
+			func(idx int) interface{} { return int32(idx * 100) },
+			func(idx int) interface{} { return int32(idx % 2) },
+		},
+	}
+	tm12 := SetupTableWithMetadata(exec_ctx, Sc12Meta)
+	tm12.GetStatistics().Update(tm12, txn)
+	txn_mgr.Commit(c, txn)
+
+	testAQuery := func(queryStr string, expectedRows int) plans.Plan {
+		queryInfo, _ := parser.ProcessSQLStr(&queryStr)
+		queryInfo, err := RewriteQueryInfo(c, queryInfo)
+		testingpkg.SimpleAssert(t, err == nil)
+		solution, err := NewSelingerOptimizer(queryInfo, c).Optimize()
+		testingpkg.SimpleAssert(t, err == nil)
+		printBestPlan("Subquery", queryStr, solution)
+
+		txn_ := txn_mgr.Begin(nil)
+		execRslt := (&executors.ExecutionEngine{}).Execute(solution, executors.NewExecutorContext(c, bpm, txn_))
+		txn_mgr.Commit(c, txn_)
+		testingpkg.SimpleAssert(t, len(execRslt) == expectedRows)
+		return solution
+	}
+
+	// subquery which scans one table is joined with index of the table
+	solution := testAQuery("select Sc12.k1 from Sc12 where Sc12.k1 in (select Sc11.h1 from Sc11);", 10)
+	testingpkg.SimpleAssert(t, containsPlanType(solution, plans.IndexJoin))
+	solution = testAQuery("select Sc12.k1 from Sc12 where exists (select * from Sc11 where Sc11.h1 = Sc12.k1 and Sc11.h2 = 0);", 10)
+	testingpkg.SimpleAssert(t, containsPlanType(solution, plans.IndexJoin))
+	testAQuery("select Sc12.k1 from Sc12 where not exists (select * from Sc11 where Sc11.h1 = Sc12.k1);", 10)
+	testAQuery("select Sc12.k1 from Sc12 where Sc12.k1 not in (select Sc11.h1 from Sc11 where Sc11.h1 < 500);", 15)
+	testAQuery("select Sc12.k1, (select Sc11.h2 from Sc11 where Sc11.h1 = Sc12.k1) from Sc12;", 20)
+	testAQuery("select Sc12.k1 from Sc12 where Sc12.k1 < (select max(Sc11.h1) from Sc11 where Sc11.h2 = 0);", 10)
+	testAQuery("select d.k2 from (select Sc12.k2 from Sc12 where Sc12.k1 < 500) as d where d.k2 = 1;", 2)
+
+	// subquery under OR and subquery which returns multiple columns
+	queryStr := "select Sc12.k1 from Sc12 where Sc12.k2 = 0 or Sc12.k1 in (select Sc11.h1 from Sc11);"
+	queryInfo, _ := parser.ProcessSQLStr(&queryStr)
+	queryInfo, _ = RewriteQueryInfo(c, queryInfo)
+	_, err := NewSelingerOptimizer(queryInfo, c).Optimize()
+	testingpkg.SimpleAssert(t, err == UnsupportedSubqueryErr)
+	queryStr = "select Sc12.k1 from Sc12 where Sc12.k1 in (select Sc11.h1, Sc11.h2 from Sc11);"
+	queryInfo, _ = parser.ProcessSQLStr(&queryStr)
+	_, err = RewriteQueryInfo(c, queryInfo)
+	testingpkg.SimpleAssert(t, err == SubqueryColumnNumErr)
+}
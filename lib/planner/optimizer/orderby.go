@@ -0,0 +1,121 @@
+package optimizer
+
+import (
+	"errors"
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/ryogrid/SamehadaDB/lib/catalog"
+	"github.com/ryogrid/SamehadaDB/lib/execution/plans"
+	"github.com/ryogrid/SamehadaDB/lib/parser"
+	"github.com/ryogrid/SamehadaDB/lib/storage/index/index_constants"
+	"math"
+	"strings"
+)
+
+var InvalidOrderByItemErr = errors.New("item of ORDER BY clause must be column or aggregate function on SELECT clause!")
+
+// returns columns referred by ORDER BY clause. they should be output from scans
+// in addition to columns on SELECT clause
+func touchedColumnsOfOrderBy(qi *parser.QueryInfo) mapset.Set[string] {
+	ret := mapset.NewSet[string]()
+	for _, obe := range qi.OrderByExpressions_ {
+		if obe.ColName_ != nil {
+			ret.Add(strings.ToLower(*obe.ColName_))
+		} else if obe.AggField_ != nil {
+			ret = ret.Union(obe.AggField_.TouchedColumns())
+		}
+	}
+	return ret
+}
+
+// returns field on SELECT clause whose alias is name. nil is returned when no field has the alias
+func selectFieldOfAlias(qi *parser.QueryInfo, name *string) *parser.SelectFieldExpression {
+	if name == nil {
+		return nil
+	}
+	for _, sfield := range qi.SelectFields_ {
+		if sfield != nil && sfield.Alias_ != nil && strings.EqualFold(*sfield.Alias_, *name) {
+			return sfield
+		}
+	}
+	return nil
+}
+
+// returns true when ORDER BY clause has calculated value on SELECT clause (ex: SELECT a + b AS c ... ORDER BY c)
+func isOrderedByCalcField(qi *parser.QueryInfo) bool {
+	for _, obe := range qi.OrderByExpressions_ {
+		if sfield := selectFieldOfAlias(qi, obe.ColName_); sfield != nil && sfield.Calc_ != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// returns true when records emitted from plan are already sorted in the order which ORDER BY clause requests.
+// it is only the case that ORDER BY clause has one ascending item and plan scans index of the column
+// with range scan (hash index can't be used for range scan and composite index is not checked now)
+func isSortedByIndexScan(c *catalog.Catalog, qi *parser.QueryInfo, plan plans.Plan) bool {
+	if len(qi.OrderByExpressions_) != 1 || qi.OrderByExpressions_[0].IsDesc_ || qi.OrderByExpressions_[0].ColName_ == nil {
+		return false
+	}
+	// selection and projection keep the order of records
+	for plan.GetType() == plans.Selection || plan.GetType() == plans.Projection {
+		plan = plan.GetChildAt(0)
+	}
+	if plan.GetType() != plans.IndexRangeScan {
+		return false
+	}
+	scan := plan.(*plans.RangeScanWithIndexPlanNode)
+	col := c.GetTableByOID(scan.GetTableOID()).Schema().GetColumn(uint32(scan.GetColIdx()))
+	switch col.IndexKind() {
+	case index_constants.INDEX_KIND_SKIP_LIST, index_constants.INDEX_KIND_UNIQ_SKIP_LIST, index_constants.INDEX_KIND_BTREE:
+		return col.GetColumnName() == strings.ToLower(*qi.OrderByExpressions_[0].ColName_)
+	default:
+		return false
+	}
+}
+
+// AttachOrderBy attaches OrderbyPlanNode which sorts records of child in the order of ORDER BY clause.
+// items of the clause are searched from output schema of child. when child is already sorted, child is returned as it is
+func AttachOrderBy(c *catalog.Catalog, qi *parser.QueryInfo, child plans.Plan) (plans.Plan, error) {
+	if len(qi.OrderByExpressions_) == 0 || isSortedByIndexScan(c, qi, child) {
+		return child, nil
+	}
+
+	colIdxs := make([]int, 0)
+	orderTypes := make([]plans.OrderbyType, 0)
+	nullsFirsts := make([]bool, 0)
+	for _, obe := range qi.OrderByExpressions_ {
+		var colName string
+		if obe.ColName_ != nil {
+			colName = *obe.ColName_
+		} else if obe.AggField_ != nil {
+			colName = aggregateColName(obe.AggField_)
+		} else {
+			return nil, UnsupportedAggregationErr
+		}
+		colIdx := child.OutputSchema().GetColIndex(colName)
+		if colIdx == math.MaxUint32 {
+			return nil, InvalidOrderByItemErr
+		}
+		colIdxs = append(colIdxs, int(colIdx))
+		if obe.IsDesc_ {
+			orderTypes = append(orderTypes, plans.DESC)
+		} else {
+			orderTypes = append(orderTypes, plans.ASC)
+		}
+		nullsFirsts = append(nullsFirsts, obe.NullsFirst_)
+	}
+	return plans.NewOrderbyPlanNode(child.OutputSchema(), child, colIdxs, orderTypes, nullsFirsts), nil
+}
+
+// AttachLimit attaches LimitPlanNode to child when LIMIT clause is specified
+func AttachLimit(qi *parser.QueryInfo, child plans.Plan) plans.Plan {
+	if qi.LimitNum_ < 0 {
+		return child
+	}
+	offset := uint32(0)
+	if qi.OffsetNum_ > 0 {
+		offset = uint32(qi.OffsetNum_)
+	}
+	return plans.NewLimitPlanNode(child, uint32(qi.LimitNum_), offset)
+}
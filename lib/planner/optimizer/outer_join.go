@@ -0,0 +1,205 @@
+package optimizer
+
+import (
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/ryogrid/SamehadaDB/lib/execution/plans"
+	"github.com/ryogrid/SamehadaDB/lib/parser"
+	"strings"
+)
+
+// joinScope is a join listed on QueryInfo.Joins_ (outer join or inner join in one side of outer join).
+// table names are stored in lowercase like column names which TouchedColumns returns
+type joinScope struct {
+	joinType    plans.JoinType
+	leftTables  mapset.Set[string]
+	rightTables mapset.Set[string]
+	onConjuncts []*parser.BinaryOpExpression
+	// tables referred by ON clause
+	onTables mapset.Set[string]
+}
+
+// conjunct of WHERE clause or ON clause of inner join which is listed on QueryInfo.Joins_
+type conjunctItem struct {
+	exp    *parser.BinaryOpExpression
+	tables mapset.Set[string]
+	// tables of the inner join. nil means the item is on WHERE clause
+	scope mapset.Set[string]
+}
+
+func lowerTableSet(tables []*string) mapset.Set[string] {
+	ret := mapset.NewSet[string]()
+	for _, table := range tables {
+		ret.Add(strings.ToLower(*table))
+	}
+	return ret
+}
+
+func lowerSet(tables mapset.Set[string]) mapset.Set[string] {
+	ret := mapset.NewSet[string]()
+	for _, table := range tables.ToSlice() {
+		ret.Add(strings.ToLower(table))
+	}
+	return ret
+}
+
+// returns tables which exp refers. column names on exp should be qualified with table name
+func touchedTablesOf(exp *parser.BinaryOpExpression) mapset.Set[string] {
+	ret := mapset.NewSet[string]()
+	for _, colName := range exp.TouchedColumns().ToSlice() {
+		ret.Add(strings.Split(colName, ".")[0])
+	}
+	return ret
+}
+
+func newJoinScope(join *parser.JoinExpression) *joinScope {
+	ret := &joinScope{join.JoinType_, lowerTableSet(join.LeftTables_), lowerTableSet(join.RightTables_),
+		conjunctsOf(join.OnExpression_), mapset.NewSet[string]()}
+	for _, exp := range ret.onConjuncts {
+		ret.onTables = ret.onTables.Union(touchedTablesOf(exp))
+	}
+	return ret
+}
+
+func (js *joinScope) tables() mapset.Set[string] {
+	return js.leftTables.Union(js.rightTables)
+}
+
+// returns tables which may be padded with NULL by the join
+func (js *joinScope) nullSide() mapset.Set[string] {
+	switch js.joinType {
+	case plans.LEFT_OUTER_JOIN:
+		return js.rightTables
+	case plans.RIGHT_OUTER_JOIN:
+		return js.leftTables
+	case plans.FULL_OUTER_JOIN:
+		return js.tables()
+	default:
+		return mapset.NewSet[string]()
+	}
+}
+
+// checks whether records of *tables* can be joined before the outer join is finished.
+// tables on null side should be joined only with each other until the outer join is done,
+// and the outer join needs tables on preserved side which are referred by ON clause.
+// e.g. A LEFT JOIN B ON A.a = B.b LEFT JOIN C ON B.b = C.c: {B, C} is invalid because C is padded with NULL
+// when B is, but inner join of B and C drops records of B which have no matching record of C
+func (js *joinScope) isValidTableSet(tables mapset.Set[string]) bool {
+	isValidWithNullSide := func(nullSide mapset.Set[string], preservedSide mapset.Set[string]) bool {
+		return !containsAny(tables, nullSide) || tables.IsSubset(nullSide) ||
+			tables.IsSuperset(nullSide.Union(js.onTables.Intersect(preservedSide)))
+	}
+	switch js.joinType {
+	case plans.LEFT_OUTER_JOIN:
+		return isValidWithNullSide(js.rightTables, js.leftTables)
+	case plans.RIGHT_OUTER_JOIN:
+		return isValidWithNullSide(js.leftTables, js.rightTables)
+	case plans.FULL_OUTER_JOIN:
+		return !containsAny(tables, js.tables()) || tables.IsSubset(js.leftTables) ||
+			tables.IsSubset(js.rightTables) || tables.IsSuperset(js.tables())
+	default:
+		return true
+	}
+}
+
+// checks whether the outer join has been processed in plan which joins *tables*.
+// tables should be valid (see isValidTableSet)
+func (js *joinScope) isDoneAt(tables mapset.Set[string]) bool {
+	switch js.joinType {
+	case plans.LEFT_OUTER_JOIN, plans.RIGHT_OUTER_JOIN:
+		return tables.IsProperSuperset(js.nullSide())
+	case plans.FULL_OUTER_JOIN:
+		return tables.IsSuperset(js.tables())
+	default:
+		return true
+	}
+}
+
+// when join of *left* and *right* is the outer join, returns join type for the join plan
+// which has left and right as children
+func (js *joinScope) joinTypeOfStep(left mapset.Set[string], right mapset.Set[string]) (plans.JoinType, bool) {
+	switch js.joinType {
+	case plans.LEFT_OUTER_JOIN, plans.RIGHT_OUTER_JOIN:
+		if right.Equal(js.nullSide()) {
+			return plans.LEFT_OUTER_JOIN, true
+		} else if left.Equal(js.nullSide()) {
+			return plans.RIGHT_OUTER_JOIN, true
+		}
+	case plans.FULL_OUTER_JOIN:
+		if (left.Equal(js.leftTables) && right.Equal(js.rightTables)) ||
+			(left.Equal(js.rightTables) && right.Equal(js.leftTables)) {
+			return plans.FULL_OUTER_JOIN, true
+		}
+	}
+	return plans.INNER_JOIN, false
+}
+
+// checks whether item can be evaluated on plan which joins *tables*.
+// item which refers tables on null side of outer join should be evaluated after the outer join
+// except for ON clause of inner join which is in the null side
+func (so *SelingerOptimizer) isAvailableAt(item *conjunctItem, tables mapset.Set[string]) bool {
+	if !item.tables.IsSubset(tables) {
+		return false
+	}
+	for _, js := range so.joinScopes {
+		nullSide := js.nullSide()
+		if !containsAny(item.tables, nullSide) || (item.scope != nil && item.scope.IsSubset(nullSide)) {
+			continue
+		}
+		if !js.isDoneAt(tables) {
+			return false
+		}
+	}
+	return true
+}
+
+// returns conjuncts which can be evaluated on plan which joins *tables* but can't on plans of *parts*
+func (so *SelingerOptimizer) conjunctsAvailableAt(tables mapset.Set[string], parts ...mapset.Set[string]) []*parser.BinaryOpExpression {
+	ret := make([]*parser.BinaryOpExpression, 0)
+	for _, item := range so.conjuncts {
+		if !so.isAvailableAt(item, tables) {
+			continue
+		}
+		isAvailableAtPart := false
+		for _, part := range parts {
+			if so.isAvailableAt(item, part) {
+				isAvailableAtPart = true
+				break
+			}
+		}
+		if !isAvailableAtPart {
+			ret = append(ret, item.exp.GetDeepCopy())
+		}
+	}
+	return ret
+}
+
+func (so *SelingerOptimizer) isValidTableSet(tables mapset.Set[string]) bool {
+	for _, js := range so.joinScopes {
+		if !js.isValidTableSet(tables) {
+			return false
+		}
+	}
+	return true
+}
+
+// returns outer join which is processed with join of *left* and *right*. nil is returned for inner join
+func (so *SelingerOptimizer) outerJoinOfStep(left mapset.Set[string], right mapset.Set[string]) (*joinScope, plans.JoinType) {
+	for _, js := range so.joinScopes {
+		if joinType, ok := js.joinTypeOfStep(left, right); ok {
+			return js, joinType
+		}
+	}
+	return nil, plans.INNER_JOIN
+}
+
+// returns expression which connects exps with AND. empty expression is returned when exps is empty
+func conjunctionOf(exps []*parser.BinaryOpExpression) *parser.BinaryOpExpression {
+	if len(exps) == 0 {
+		return new(parser.BinaryOpExpression)
+	}
+	ret := exps[0]
+	for _, exp := range exps[1:] {
+		ret = ret.AppendBinaryOpExpWithAnd(exp)
+	}
+	return ret
+}
@@ -3,7 +3,6 @@ package optimizer
 import (
 	"errors"
 	mapset "github.com/deckarep/golang-set/v2"
-	"github.com/golang-collections/collections/stack"
 	"github.com/notEpsilon/go-pair"
 	"github.com/ryogrid/SamehadaDB/lib/catalog"
 	"github.com/ryogrid/SamehadaDB/lib/common"
@@ -11,6 +10,7 @@ import (
 	"github.com/ryogrid/SamehadaDB/lib/execution/plans"
 	"github.com/ryogrid/SamehadaDB/lib/parser"
 	"github.com/ryogrid/SamehadaDB/lib/samehada/samehada_util"
+	"github.com/ryogrid/SamehadaDB/lib/storage/index/index_constants"
 	"github.com/ryogrid/SamehadaDB/lib/storage/table/column"
 	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
 	"github.com/ryogrid/SamehadaDB/lib/types"
@@ -54,6 +54,9 @@ func NewRange(valType types.TypeID) *Range {
 	case types.Boolean:
 		retRange.Min = samehada_util.GetPonterOfValue(types.NewBoolean(false)).SetInfMin()
 		retRange.Max = samehada_util.GetPonterOfValue(types.NewBoolean(true)).SetInfMax()
+	case types.BigInt, types.Double, types.Decimal, types.Timestamp, types.Blob:
+		retRange.Min = samehada_util.GetPonterOfValue(types.NewZeroValueOf(valType)).SetInfMin()
+		retRange.Max = samehada_util.GetPonterOfValue(types.NewZeroValueOf(valType)).SetInfMax()
 	default:
 		panic("invalid type")
 	}
@@ -123,12 +126,59 @@ func (r *Range) Update(op expression.ComparisonType, rhs *types.Value, dir Direc
 type SelingerOptimizer struct {
 	qi *parser.QueryInfo
 	c  *catalog.Catalog
+	// constraints on join order which come from outer joins
+	joinScopes []*joinScope
+	// conditions which are evaluated on scans or inner joins
+	conjuncts []*conjunctItem
+	// tables on FROM clause (JoinTables_ and alias of derived tables)
+	tables []*string
+	// derived tables and subqueries on WHERE and SELECT clause. their columns are not on catalog
+	virtualTables mapset.Set[string]
+	// plans of derived tables whose columns are renamed. key is alias of the table
+	derivedPlans map[string]plans.Plan
+	// conditions of WHERE clause which include subquery. they are evaluated after joins of subqueries
+	subqueryConjuncts []*parser.BinaryOpExpression
+	// subqueries on WHERE clause and SELECT clause in this order
+	subqueryJoins []*subqueryJoin
 }
 
+// attention: qi should be processed with RewriteQueryInfo before
 func NewSelingerOptimizer(qi *parser.QueryInfo, c *catalog.Catalog) *SelingerOptimizer {
 	ret := new(SelingerOptimizer)
 	ret.qi = qi
 	ret.c = c
+	ret.joinScopes = make([]*joinScope, 0)
+	ret.conjuncts = make([]*conjunctItem, 0)
+	for _, join := range qi.Joins_ {
+		js := newJoinScope(join)
+		ret.joinScopes = append(ret.joinScopes, js)
+		if js.joinType == plans.INNER_JOIN {
+			// ON clause of inner join in one side of outer join
+			for _, exp := range js.onConjuncts {
+				ret.conjuncts = append(ret.conjuncts, &conjunctItem{exp, touchedTablesOf(exp), js.tables()})
+			}
+		}
+	}
+	for _, exp := range conjunctsOf(qi.WhereExpression_) {
+		if includesSubquery(exp) {
+			ret.subqueryConjuncts = append(ret.subqueryConjuncts, exp)
+			continue
+		}
+		ret.conjuncts = append(ret.conjuncts, &conjunctItem{exp, touchedTablesOf(exp), nil})
+	}
+	ret.tables = append([]*string{}, qi.JoinTables_...)
+	ret.virtualTables = mapset.NewSet[string]()
+	for _, derived := range qi.DerivedTables_ {
+		ret.tables = append(ret.tables, derived.Name_)
+		ret.virtualTables.Add(strings.ToLower(*derived.Name_))
+	}
+	for _, sq := range qi.Subqueries() {
+		if sq.Name_ != nil {
+			ret.virtualTables.Add(strings.ToLower(*sq.Name_))
+		}
+	}
+	ret.derivedPlans = make(map[string]plans.Plan)
+	ret.subqueryJoins = make([]*subqueryJoin, 0)
 	return ret
 }
 
@@ -141,16 +191,94 @@ func containsAny(map1 mapset.Set[string], map2 mapset.Set[string]) bool {
 	}
 }
 
-func touchOnly(from *catalog.TableMetadata, where expression.Expression, colName string) bool {
-	if where.GetType() == expression.EXPRESSION_TYPE_COLUMN_VALUE {
-		cv := where.(*expression.ColumnValue)
-		return from.Schema().GetColumn(cv.GetColIndex()).GetColumnName() == colName
-	} else if where.GetType() == expression.EXPRESSION_TYPE_LOGICAL_OP || where.GetType() == expression.EXPRESSION_TYPE_COMPARISON {
-		return touchOnly(from, where.GetChildAt(0), colName) && touchOnly(from, where.GetChildAt(1), colName)
+// returns true when all columns which exp touches are on sc
+func touchOnlySchema(sc *schema.Schema, exp *parser.BinaryOpExpression) bool {
+	for _, colName := range exp.TouchedColumns().ToSlice() {
+		if sc.GetColIndex(colName) == math.MaxUint32 {
+			return false
+		}
 	}
 	return true
 }
 
+// when exp is comparison between column of sc and not NULL constant which can narrow range of the column,
+// returns index of the column, operator, the constant and which side of exp the constant is on
+func colCompareConstOf(sc *schema.Schema, exp *parser.BinaryOpExpression) (uint32, expression.ComparisonType, *types.Value, Direction, bool) {
+	if exp.GetType() != parser.Compare || exp.ComparisonOperationType_ == expression.NotEqual ||
+		exp.ComparisonOperationType_ == expression.Like || exp.ComparisonOperationType_ == expression.NotLike {
+		return math.MaxUint32, -1, nil, DIR_RIGHT, false
+	}
+	var colName interface{}
+	var val interface{}
+	var dir Direction
+	if samehada_util.IsColumnName(exp.Left_) && samehada_util.IsConstantValue(exp.Right_) {
+		colName, val, dir = exp.Left_, exp.Right_, DIR_RIGHT
+	} else if samehada_util.IsColumnName(exp.Right_) && samehada_util.IsConstantValue(exp.Left_) {
+		colName, val, dir = exp.Right_, exp.Left_, DIR_LEFT
+	} else {
+		return math.MaxUint32, -1, nil, DIR_RIGHT, false
+	}
+	colIdx := sc.GetColIndex(*colName.(*string))
+	if colIdx == math.MaxUint32 || val.(*types.Value).IsNull() {
+		return math.MaxUint32, -1, nil, DIR_RIGHT, false
+	}
+	// constant is converted to type of the column (ex: Integer 1 => Float 1.0).
+	// conversion to approximate type is allowed only when comparison is done on the type
+	colType := sc.GetColumn(colIdx).GetType()
+	convedVal, ok := val.(*types.Value).CoerceTo(colType)
+	isApproximate := colType == types.Float || colType == types.Double
+	if !ok || (isApproximate && types.CommonTypeOf(colType, val.(*types.Value).ValueType()) != colType) {
+		// ex: integer column is compared with 1.5. range of index can't be narrowed with the constant
+		return math.MaxUint32, -1, nil, DIR_RIGHT, false
+	}
+	return colIdx, exp.ComparisonOperationType_, convedVal, dir, true
+}
+
+// returns scan plan which uses index of key column. nil is returned when index can't process span
+func makeIndexScanPlan(c *catalog.Catalog, from *catalog.TableMetadata, key int, span *Range) plans.Plan {
+	sc := from.Schema()
+	if sc.GetColumn(uint32(key)).IndexKind() == index_constants.INDEX_KIND_HASH {
+		// hash index can't be used for range scan. only equality condition is available
+		if !(span.MinInclusive && span.MaxInclusive && span.Min.CompareEquals(*span.Max)) {
+			return nil
+		}
+		pointScanPred := expression.NewComparison(
+			expression.NewColumnValue(0, uint32(key), sc.GetColumn(uint32(key)).GetType()),
+			expression.NewConstantValue(*span.Min, span.Min.ValueType()),
+			expression.Equal, types.Boolean)
+		return plans.NewPointScanWithIndexPlanNode(c, sc, pointScanPred.(*expression.Comparison), from.OID())
+	}
+	return plans.NewRangeScanWithIndexPlanNode(c, sc, from.OID(), int32(key), nil, span.Min, span.Max)
+}
+
+// returns IndexUnionPlanNode which has index scan for each item of orClause.
+// nil is returned when some item can't be processed with index scan
+func makeIndexUnionPlan(c *catalog.Catalog, from *catalog.TableMetadata, candidates map[int]int, orClause *parser.BinaryOpExpression) plans.Plan {
+	sc := from.Schema()
+	items, ok := disjunctItemsOf(orClause)
+	if !ok {
+		return nil
+	}
+	children := make([]plans.Plan, 0)
+	for _, item := range items {
+		colIdx, op, val, dir, ok2 := colCompareConstOf(sc, item)
+		if !ok2 {
+			return nil
+		}
+		if _, ok3 := candidates[int(colIdx)]; !ok3 {
+			return nil
+		}
+		span := NewRange(sc.GetColumn(colIdx).GetType())
+		span.Update(op, val, dir)
+		child := makeIndexScanPlan(c, from, int(colIdx), span)
+		if child == nil {
+			return nil
+		}
+		children = append(children, child)
+	}
+	return plans.NewIndexUnionPlanNode(c, sc, from.OID(), children)
+}
+
 // attention: caller should pass *where* args which is deep copied
 func (so *SelingerOptimizer) findBestScan(outNeededCols []*column.Column, where *parser.BinaryOpExpression, from *catalog.TableMetadata, c *catalog.Catalog, stats *catalog.TableStatistics) (plans.Plan, error) {
 	availableKeyIndex := func() map[int]int {
@@ -175,39 +303,36 @@ func (so *SelingerOptimizer) findBestScan(outNeededCols []*column.Column, where
 	for key := range candidates {
 		ranges[key] = NewRange(sc.GetColumn(uint32(key)).GetType())
 	}
+	// key columns of composite indexes also need range
+	for _, compositeIndex := range from.CompositeIndexes() {
+		for _, key := range compositeIndex.GetKeyAttrs() {
+			if _, ok := ranges[int(key)]; !ok {
+				ranges[int(key)] = NewRange(sc.GetColumn(key).GetType())
+			}
+		}
+	}
 
-	stack_ := stack.New()
-	stack_.Push(where)
 	relatedOps := make([]*parser.BinaryOpExpression, 0)
-	for stack_.Len() > 0 {
-		exp := stack_.Pop().(*parser.BinaryOpExpression)
+	// column index whose range is narrowed by each item of relatedOps. -1 means the item is not reflected to any range
+	rangeColIdxs := make([]int, 0)
+	// clauses which consist of OR. they may be processed with index union
+	orClauses := make([]*parser.BinaryOpExpression, 0)
+	for _, exp := range conjunctsOf(where) {
+		if !touchOnlySchema(sc, exp) {
+			// join condition or condition for other table
+			continue
+		}
+		relatedOps = append(relatedOps, exp)
+		rangeColIdx := -1
 		if exp.GetType() == parser.Logical {
-			if exp.LogicalOperationType_ == expression.AND {
-				stack_.Push(exp.Left_)
-				stack_.Push(exp.Right_)
-				continue
-			} else {
-				panic("OR on predicate is not supported now!")
-			}
-		} else if exp.GetType() == parser.Compare {
-			if samehada_util.IsColumnName(exp.Left_) && samehada_util.IsConstantValue(exp.Right_) {
-				colIdx := sc.GetColIndex(*exp.Left_.(*string))
-				if colIdx != math.MaxUint32 {
-					relatedOps = append(relatedOps, exp)
-					if rng, ok := ranges[int(colIdx)]; ok {
-						rng.Update(exp.ComparisonOperationType_, exp.Right_.(*types.Value), DIR_RIGHT)
-					}
-				}
-			} else if samehada_util.IsColumnName(exp.Right_) && samehada_util.IsConstantValue(exp.Left_) {
-				colIdx := sc.GetColIndex(*exp.Right_.(*string))
-				if colIdx != math.MaxUint32 {
-					relatedOps = append(relatedOps, exp)
-					if rng, ok := ranges[int(colIdx)]; ok {
-						rng.Update(exp.ComparisonOperationType_, exp.Left_.(*types.Value), DIR_LEFT)
-					}
-				}
+			orClauses = append(orClauses, exp)
+		} else if colIdx, op, val, dir, ok := colCompareConstOf(sc, exp); ok {
+			if rng, ok2 := ranges[int(colIdx)]; ok2 {
+				rng.Update(op, val, dir)
+				rangeColIdx = int(colIdx)
 			}
 		}
+		rangeColIdxs = append(rangeColIdxs, rangeColIdx)
 	}
 
 	// Expression scan_exp;
@@ -223,17 +348,22 @@ func (so *SelingerOptimizer) findBestScan(outNeededCols []*column.Column, where
 
 	// Build all IndexScan.
 	for key, span := range ranges {
-		if span.Empty() {
+		if _, ok := candidates[key]; !ok || span.Empty() {
 			continue
 		}
 
-		isPredicateCheckNeeded := false
-		newPlan := plans.NewRangeScanWithIndexPlanNode(c, sc, from.OID(), int32(key), nil, span.Min, span.Max)
-		if !span.MinInclusive || !span.MaxInclusive {
-			// Range scan is not inclusive, so we need to check predicate
-			isPredicateCheckNeeded = true
+		newPlan := makeIndexScanPlan(c, from, key, span)
+		if newPlan == nil {
+			continue
 		}
-		if !touchOnly(from, scanExp, sc.GetColumn(uint32(key)).GetColumnName()) || isPredicateCheckNeeded {
+		// Range scan is not inclusive, so we need to check predicate
+		isPredicateCheckNeeded := !span.MinInclusive || !span.MaxInclusive
+		for _, rangeColIdx := range rangeColIdxs {
+			if rangeColIdx != key {
+				isPredicateCheckNeeded = true
+			}
+		}
+		if isPredicateCheckNeeded {
 			// when scanExp includes item which is not related to current index key, add selection about these
 			// e.g. index key is a and scanExp is (1 <= a AND a <= 10 AND c = 2), then add selection (1 <= a AND a <= 10 AND c = 2) to newPlan
 			//      currently, though selection related column a is needless, but it is included...
@@ -248,6 +378,65 @@ func (so *SelingerOptimizer) findBestScan(outNeededCols []*column.Column, where
 		}
 	}
 
+	// Build IndexUnion.
+	// when every item of OR clause can be processed with index scan, union of the scans can be used
+	// e.g. scanExp is ((a = 1 OR b < 10) AND c = 2) and a and b have index, then
+	//      union of index scans of a and b is filtered with selection (whole scanExp)
+	for _, orClause := range orClauses {
+		newPlan := makeIndexUnionPlan(c, from, candidates, orClause)
+		if newPlan == nil {
+			continue
+		}
+		newPlan = plans.NewSelectionPlanNode(newPlan, scanExp)
+		if len(outNeededCols) != int(newPlan.OutputSchema().GetColumnCount()) {
+			newPlan = plans.NewProjectionPlanNode(newPlan, schema.NewSchema(outNeededCols))
+		}
+		if newPlan.AccessRowCount(c) < minimamCost {
+			bestScan = newPlan
+			minimamCost = newPlan.AccessRowCount(c)
+		}
+	}
+
+	// Build scans with composite index.
+	// values of first key columns should be fixed by equality conditions and the next key column
+	// can have range condition. e.g. index key is (a, b, c) and scanExp is (a = 1 AND b > 10)
+	for _, compositeIndex := range from.CompositeIndexes() {
+		prefixVals := make([]*types.Value, 0)
+		var span *Range = nil
+		for _, key := range compositeIndex.GetKeyAttrs() {
+			rng := ranges[int(key)]
+			if rng.Min.IsInfMin() && rng.Max.IsInfMax() {
+				// no condition on the column
+				break
+			}
+			if rng.MinInclusive && rng.MaxInclusive && rng.Min.CompareEquals(*rng.Max) {
+				prefixVals = append(prefixVals, rng.Min)
+				continue
+			}
+			span = rng
+			break
+		}
+		if len(prefixVals) == 0 && span == nil {
+			continue
+		}
+
+		var newPlan plans.Plan
+		if span != nil {
+			newPlan = plans.NewRangeScanWithCompositeIndexPlanNode(c, sc, from.OID(), *compositeIndex.GetName(), prefixVals, span.Min, span.Max)
+		} else {
+			newPlan = plans.NewRangeScanWithCompositeIndexPlanNode(c, sc, from.OID(), *compositeIndex.GetName(), prefixVals, nil, nil)
+		}
+		// range of the scan is inclusive and may contain NULL. so, predicate check is always needed
+		newPlan = plans.NewSelectionPlanNode(newPlan, scanExp)
+		if len(outNeededCols) != int(newPlan.OutputSchema().GetColumnCount()) {
+			newPlan = plans.NewProjectionPlanNode(newPlan, schema.NewSchema(outNeededCols))
+		}
+		if newPlan.AccessRowCount(c) < minimamCost {
+			bestScan = newPlan
+			minimamCost = newPlan.AccessRowCount(c)
+		}
+	}
+
 	fullScanPlan := plans.NewSeqScanPlanNode(c, sc, nil, from.OID())
 	if scanExp != nil {
 		fullScanPlan = plans.NewSelectionPlanNode(fullScanPlan, scanExp)
@@ -271,6 +460,18 @@ func (so *SelingerOptimizer) findBestScans() map[string]CostAndPlan {
 	for _, item := range so.qi.SelectFields_ {
 		touchedColumns = touchedColumns.Union(item.TouchedColumns())
 	}
+	// columns which are referred only by GROUP BY, HAVING or ORDER BY clause
+	touchedColumns = touchedColumns.Union(touchedColumnsOfAggregation(so.qi))
+	touchedColumns = touchedColumns.Union(touchedColumnsOfOrderBy(so.qi))
+	for _, join := range so.qi.Joins_ {
+		touchedColumns = touchedColumns.Union(join.OnExpression_.TouchedColumns())
+	}
+	// columns of outer query which are referred by correlated subqueries
+	for _, sj := range so.subqueryJoins {
+		for _, exp := range sj.onConjuncts {
+			touchedColumns = touchedColumns.Union(exp.TouchedColumns())
+		}
+	}
 	for _, from := range so.qi.JoinTables_ {
 		tbl := so.c.GetTableByName(*from)
 		stats := so.c.GetTableByName(*from).GetStatistics()
@@ -284,45 +485,101 @@ func (so *SelingerOptimizer) findBestScans() map[string]CostAndPlan {
 				}
 			}
 		}
-		scan, _ := so.findBestScan(projectTarget, so.qi.WhereExpression_.GetDeepCopy(), tbl, so.c, stats)
+		if len(projectTarget) == 0 {
+			// no column is referred (ex: SELECT COUNT(*) FROM t). rows are passed as they are
+			for ii := 0; ii < int(tbl.GetColumnNum()); ii++ {
+				projectTarget = append(projectTarget, tbl.Schema().GetColumn(uint32(ii)))
+			}
+		}
+		// conditions on tables which may be padded with NULL by outer join are not pushed down
+		where := conjunctionOf(so.conjunctsAvailableAt(lowerTableSet([]*string{from})))
+		scan, _ := so.findBestScan(projectTarget, where, tbl, so.c, stats)
 		optimalPlans[samehada_util.StrSetToString(samehada_util.MakeSet([]*string{from}))] = CostAndPlan{scan.AccessRowCount(so.c), scan}
 	}
+	// derived tables are planned beforehand. conditions on them are checked with selection
+	for _, derived := range so.qi.DerivedTables_ {
+		scan := so.derivedPlans[*derived.Name_]
+		where := so.conjunctsAvailableAt(lowerTableSet([]*string{derived.Name_}))
+		if len(where) > 0 {
+			scan = plans.NewSelectionPlanNode(scan, parser.ConvParsedBinaryOpExprToExpIFOne(scan.OutputSchema(), conjunctionOf(where)))
+		}
+		optimalPlans[samehada_util.StrSetToString(samehada_util.MakeSet([]*string{derived.Name_}))] = CostAndPlan{scan.AccessRowCount(so.c), scan}
+	}
 
 	return optimalPlans
 }
 
-// attention: caller should pass *where* args which is deep copied
-func (so *SelingerOptimizer) findBestJoinInner(where *parser.BinaryOpExpression, left plans.Plan, right plans.Plan, c *catalog.Catalog) (plans.Plan, error) {
+// splits conditions into one equality condition between columns of left and right, which is used as join key, and others
+func splitJoinKey(exps []*parser.BinaryOpExpression, leftSc *schema.Schema, rightSc *schema.Schema) ([]pair.Pair[*string, *string], []*parser.BinaryOpExpression) {
 	equals := make([]pair.Pair[*string, *string], 0)
-	exp := stack.New()
-	exp.Push(where)
-	var relatedExp = make([]*parser.BinaryOpExpression, 0)
-	for exp.Len() > 0 {
-		here := exp.Pop().(*parser.BinaryOpExpression)
-		if here.GetType() == parser.Compare {
-			if here.ComparisonOperationType_ == expression.Equal && samehada_util.IsColumnName(here.Left_) && samehada_util.IsColumnName(here.Right_) {
-				cvL := here.Left_.(*string)
-				cvR := here.Right_.(*string)
-				if left.OutputSchema().IsHaveColumn(cvL) && right.OutputSchema().IsHaveColumn(cvR) {
-					equals = append(equals, pair.Pair[*string, *string]{cvL, cvR})
-					relatedExp = append(relatedExp, here)
-				} else if right.OutputSchema().IsHaveColumn(cvL) && left.OutputSchema().IsHaveColumn(cvR) {
-					equals = append(equals, pair.Pair[*string, *string]{cvR, cvL})
-					relatedExp = append(relatedExp, here)
-				}
-			}
-			// note:
-			// ignore case that *here* variable represents a constant value
-		} else if here.GetType() == parser.Logical {
-			if here.LogicalOperationType_ == expression.AND {
-				exp.Push(here.Left_)
-				exp.Push(here.Right_)
+	others := make([]*parser.BinaryOpExpression, 0)
+	for _, here := range exps {
+		// only one equality condition is used as key of join
+		if len(equals) == 0 && here.GetType() == parser.Compare && here.ComparisonOperationType_ == expression.Equal &&
+			samehada_util.IsColumnName(here.Left_) && samehada_util.IsColumnName(here.Right_) {
+			cvL := here.Left_.(*string)
+			cvR := here.Right_.(*string)
+			if leftSc.IsHaveColumn(cvL) && rightSc.IsHaveColumn(cvR) {
+				equals = append(equals, pair.Pair[*string, *string]{cvL, cvR})
+				continue
+			} else if rightSc.IsHaveColumn(cvL) && leftSc.IsHaveColumn(cvR) {
+				equals = append(equals, pair.Pair[*string, *string]{cvR, cvL})
+				continue
 			}
 		}
+		others = append(others, here)
+	}
+	return equals, others
+}
+
+// returns OID and schema of table which plan scans without any condition.
+// IndexJoinExecutor does point scans of the table directly, so conditions on the plan would be lost
+// columns of the returned schema are same as the table, but they are renamed when the scan is renamed
+// (ex: derived table and subquery)
+func unfilteredScanOf(plan plans.Plan) (uint32, *schema.Schema, bool) {
+	var renamedSchema *schema.Schema = nil
+	if plan.GetType() == plans.Projection {
+		if plan.(*plans.ProjectionPlanNode).IsRenaming() {
+			renamedSchema = plan.OutputSchema()
+		}
+		plan = plan.GetChildAt(0)
+	}
+	if plan.GetType() != plans.SeqScan || plan.(*plans.SeqScanPlanNode).GetPredicate() != nil {
+		return math.MaxUint32, nil, false
+	}
+	if renamedSchema != nil {
+		return plan.GetTableOID(), renamedSchema, true
+	}
+	return plan.GetTableOID(), plan.OutputSchema(), true
+}
+
+// where: conditions which should be checked on this join. they are evaluated after the join
+// outerJoin: outer join which is processed with this join (nil for inner join). joinType is type of the join
+// when left and right are children of join plan
+// attention: caller should pass *where* args which is deep copied
+func (so *SelingerOptimizer) findBestJoinInner(where []*parser.BinaryOpExpression, outerJoin *joinScope, joinType plans.JoinType, left plans.Plan, right plans.Plan, c *catalog.Catalog) (plans.Plan, error) {
+	leftSc := left.OutputSchema()
+	rightSc := right.OutputSchema()
+	var equals []pair.Pair[*string, *string]
+	// conditions which are not processed with join key. on outer join, they are evaluated with join
+	// because records which don't satisfy ON clause should be padded with NULL
+	var relatedExp []*parser.BinaryOpExpression
+	var joinPredExp []*parser.BinaryOpExpression
+	if outerJoin == nil {
+		equals, relatedExp = splitJoinKey(where, leftSc, rightSc)
+	} else {
+		equals, joinPredExp = splitJoinKey(outerJoin.onConjuncts, leftSc, rightSc)
+		relatedExp = where
 	}
 
-	candidates := make([]plans.Plan, 0)
-	// equols is bigger than 1 case is not supported now
+	type candidate struct {
+		plan     plans.Plan
+		joinType plans.JoinType
+		// ON clause of outer join which is not processed with join key
+		joinPredExp []*parser.BinaryOpExpression
+	}
+	candidates := make([]candidate, 0)
+	// other equality conditions are checked with selection
 	if len(equals) == 1 {
 		left_cols := make([]*string, 0)
 		right_cols := make([]*string, 0)
@@ -333,31 +590,29 @@ func (so *SelingerOptimizer) findBestJoinInner(where *parser.BinaryOpExpression,
 
 		// HashJoin
 		var tmpPlan plans.Plan = plans.NewHashJoinPlanNodeWithChilds(left, parser.ConvColumnStrsToExpIfOnes(so.c, left, left_cols, true), right, parser.ConvColumnStrsToExpIfOnes(so.c, right, right_cols, false))
-		candidates = append(candidates, tmpPlan)
-		//add left / right reversed pattern too
-		tmpPlan = plans.NewHashJoinPlanNodeWithChilds(right, parser.ConvColumnStrsToExpIfOnes(so.c, right, right_cols, true), left, parser.ConvColumnStrsToExpIfOnes(so.c, left, left_cols, false))
-		candidates = append(candidates, tmpPlan)
+		candidates = append(candidates, candidate{tmpPlan, joinType, joinPredExp})
+		//add left / right reversed pattern too (semi join, etc... can't be reversed)
+		if joinType.IsReversible() {
+			tmpPlan = plans.NewHashJoinPlanNodeWithChilds(right, parser.ConvColumnStrsToExpIfOnes(so.c, right, right_cols, true), left, parser.ConvColumnStrsToExpIfOnes(so.c, left, left_cols, false))
+			candidates = append(candidates, candidate{tmpPlan, joinType.Reverse(), joinPredExp})
+		}
 
 		// IndexJoin
 
-		// checks whether right Plan deal only one table (join or aggregation on tree is NG)
-		if right.GetTableOID() != math.MaxUint32 || right.GetType() == plans.Projection {
-			rightSchema := right.OutputSchema()
-			rightOID := right.GetTableOID()
-			if right.GetType() == plans.Projection {
-				// when right plan is ProjectionPlan, using child plan's schema and OID is needed to get target table info
-				rightSchema = right.GetChildAt(0).OutputSchema()
-				rightOID = right.GetChildAt(0).GetTableOID()
-			}
+		// checks whether right Plan deal only one table without condition (join or aggregation on tree is NG).
+		// records of right table are not padded with NULL on IndexJoin. so it can't process RIGHT and FULL OUTER JOIN
+		if rightOID, rightSchema, ok := unfilteredScanOf(right); ok && !joinType.PreservesRight() {
 			if len(so.c.GetTableByOID(rightOID).Indexes()) > 0 {
 				for index_idx, right_index := range so.c.GetTableByOID(rightOID).Indexes() {
 					if right_index == nil {
 						continue
 					}
 					for idx, rcol := range right_cols {
-						if right_index.GetTupleSchema().GetColumn(uint32(index_idx)).GetColumnName() == *rcol {
+						// rightSchema has same columns as the table (they may be renamed)
+						if rightSchema.GetColumn(uint32(index_idx)).GetColumnName() == strings.ToLower(*rcol) {
 							// right scan plan is not used because IndexJoinExecutor does point scans internally
-							candidates = append(candidates, plans.NewIndexJoinPlanNode(so.c, left, parser.ConvColumnStrsToExpIfOnes(so.c, left, []*string{left_cols[idx]}, true), rightSchema, rightOID, parser.ConvColumnStrsToExpIfOnes(so.c, nil, []*string{rcol}, false)))
+							rightKey := expression.NewColumnValue(1, uint32(index_idx), rightSchema.GetColumn(uint32(index_idx)).GetType())
+							candidates = append(candidates, candidate{plans.NewIndexJoinPlanNode(so.c, left, parser.ConvColumnStrsToExpIfOnes(so.c, left, []*string{left_cols[idx]}, true), rightSchema, rightOID, []expression.Expression{rightKey}), joinType, joinPredExp})
 						}
 					}
 				}
@@ -369,10 +624,33 @@ func (so *SelingerOptimizer) findBestJoinInner(where *parser.BinaryOpExpression,
 	if len(candidates) == 0 {
 		// append NestedLoopJoinPlan without table concatinating predicate
 		// for avoiding no condidate situation
-		candidates = append(candidates, plans.NewNestedLoopJoinPlanNode([]plans.Plan{left, right}))
+		var joinPredExp_ []*parser.BinaryOpExpression
+		if outerJoin != nil {
+			joinPredExp_ = outerJoin.onConjuncts
+		}
+		candidates = append(candidates, candidate{plans.NewNestedLoopJoinPlanNode([]plans.Plan{left, right}), joinType, joinPredExp_})
 	}
 
-	// attach more selection if we can
+	ret := make([]plans.Plan, 0, len(candidates))
+	for _, cand := range candidates {
+		if cand.joinType != plans.INNER_JOIN || len(cand.joinPredExp) > 0 {
+			var joinPred expression.Expression = nil
+			if len(cand.joinPredExp) > 0 {
+				joinPred = parser.ConvParsedBinaryOpExprToExpIFOne(cand.plan.OutputSchema(), conjunctionOf(cand.joinPredExp))
+			}
+			switch p := cand.plan.(type) {
+			case *plans.HashJoinPlanNode:
+				p.SetJoinAttr(cand.joinType, joinPred)
+			case *plans.IndexJoinPlanNode:
+				p.SetJoinAttr(cand.joinType, joinPred)
+			case *plans.NestedLoopJoinPlanNode:
+				p.SetJoinAttr(cand.joinType, joinPred)
+			}
+		}
+		ret = append(ret, cand.plan)
+	}
+
+	// attach selection of conditions which are not processed with join
 	if len(relatedExp) > 0 {
 		finalSelection := relatedExp[len(relatedExp)-1]
 		relatedExp = relatedExp[:len(relatedExp)-1]
@@ -381,25 +659,21 @@ func (so *SelingerOptimizer) findBestJoinInner(where *parser.BinaryOpExpression,
 			finalSelection = &parser.BinaryOpExpression{expression.AND, -1, finalSelection, exp_}
 		}
 
-		orgLen := len(candidates)
-		for ii := 0; ii < orgLen; ii++ {
-			attachExp := parser.ConvParsedBinaryOpExprToExpIFOne(candidates[ii].OutputSchema(), finalSelection)
-			// Note:
-			// when candidates[ii] is HashJoinPlanNode or IndexJoinPlanNode, predicate items which
-			// are already applied on join process are attached. but ignore the duplication here...
-			candidates = append(candidates, plans.NewSelectionPlanNode(candidates[ii], attachExp))
+		for ii := 0; ii < len(ret); ii++ {
+			attachExp := parser.ConvParsedBinaryOpExprToExpIFOne(ret[ii].OutputSchema(), finalSelection)
+			ret[ii] = plans.NewSelectionPlanNode(ret[ii], attachExp)
 		}
 	}
 
-	sort.Slice(candidates, func(i, j int) bool {
-		return candidates[i].AccessRowCount(c) < candidates[j].AccessRowCount(c)
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].AccessRowCount(c) < ret[j].AccessRowCount(c)
 	})
 
-	return candidates[0], nil
+	return ret[0], nil
 }
 
-func (so *SelingerOptimizer) findBestJoin(optimalPlans map[string]CostAndPlan) plans.Plan {
-	for ii := 1; ii < len(so.qi.JoinTables_); ii += 1 {
+func (so *SelingerOptimizer) findBestJoin(optimalPlans map[string]CostAndPlan) (plans.Plan, error) {
+	for ii := 1; ii < len(so.tables); ii += 1 {
 		for baseTableFromOrg, baseTableCP := range optimalPlans {
 			baseTableFrom := samehada_util.StringToMapset(baseTableFromOrg)
 			for joinTableFromOrg, joinTableCP := range optimalPlans {
@@ -412,9 +686,16 @@ func (so *SelingerOptimizer) findBestJoin(optimalPlans map[string]CostAndPlan) p
 				// Note: for making left-deep Selinger, checking joinTableFrom.Cardinality() == 1 is needed here
 				//       current impl can construct bushy plan tree, but it searches more candidates than left-deep Selinger
 
-				bestJoinPlan, _ := so.findBestJoinInner(so.qi.WhereExpression_.GetDeepCopy(), baseTableCP.plan, joinTableCP.plan, so.c)
-
 				joinedTables := baseTableFrom.Union(joinTableFrom)
+				baseTables, joinTables, tables := lowerSet(baseTableFrom), lowerSet(joinTableFrom), lowerSet(joinedTables)
+				if !so.isValidTableSet(tables) {
+					// the join changes result of outer join
+					continue
+				}
+				outerJoin, joinType := so.outerJoinOfStep(baseTables, joinTables)
+				where := so.conjunctsAvailableAt(tables, baseTables, joinTables)
+				bestJoinPlan, _ := so.findBestJoinInner(where, outerJoin, joinType, baseTableCP.plan, joinTableCP.plan, so.c)
+
 				common.SH_Assert(1 < joinedTables.Cardinality(), "joinedTables.Cardinality() is illegal!")
 				cost := bestJoinPlan.AccessRowCount(so.c)
 
@@ -426,30 +707,70 @@ func (so *SelingerOptimizer) findBestJoin(optimalPlans map[string]CostAndPlan) p
 			}
 		}
 	}
-	optimalPlan, ok := optimalPlans[samehada_util.StrSetToString(samehada_util.MakeSet(so.qi.JoinTables_))]
+	optimalPlan, ok := optimalPlans[samehada_util.StrSetToString(samehada_util.MakeSet(so.tables))]
 	samehada_util.SHAssert(ok, "plan which includes all tables is not found")
 
-	solution := optimalPlan.plan
+	solution := so.attachSubqueries(optimalPlan.plan)
+	var err error
+	if IncludesAggregation(so.qi) {
+		// aggregation outputs only columns on SELECT clause
+		if solution, err = AttachAggregation(so.qi, solution); err != nil {
+			return nil, err
+		}
+		if solution, err = AttachOrderBy(so.c, so.qi, solution); err != nil {
+			return nil, err
+		}
+		return AttachLimit(so.qi, solution), nil
+	}
+	// sort is done before final projection because columns on ORDER BY clause may not be on SELECT clause.
+	// but calculated values on SELECT clause are available after the projection
+	isSortedByCalc := isOrderedByCalcField(so.qi)
+	if !isSortedByCalc {
+		if solution, err = AttachOrderBy(so.c, so.qi, solution); err != nil {
+			return nil, err
+		}
+	}
 	// Attach final projection and emit the result
-	if int(solution.OutputSchema().GetColumnCount()) > len(so.qi.SelectFields_) {
-		solution = plans.NewProjectionPlanNode(solution, parser.ConvParsedSelectionExprToSchema(so.c, so.qi.SelectFields_))
+	// (order of columns may differ from SELECT clause when children of join are swapped)
+	outSchema, exprs := so.outSchemaOf(solution)
+	if exprs != nil {
+		solution = plans.NewCalcProjectionPlanNode(solution, outSchema, exprs)
+	} else if !hasSameColumns(solution.OutputSchema(), outSchema) {
+		solution = plans.NewProjectionPlanNode(solution, outSchema)
+	}
+	if isSortedByCalc {
+		if solution, err = AttachOrderBy(so.c, so.qi, solution); err != nil {
+			return nil, err
+		}
 	}
 
-	return solution
+	return AttachLimit(so.qi, solution), nil
 }
 
-// TODO: (SDB) [OPT] caller should check predicate whether it is optimizable and if not, caller can't call this function (SelingerOptimizer::Optimize)
-//                   cases below are not supported now.
-//                   - predicate including bracket or OR operation or column name without table name prefix
-//                   - projection including asterisk or aggregate operation
+func hasSameColumns(sc1 *schema.Schema, sc2 *schema.Schema) bool {
+	if sc1.GetColumnCount() != sc2.GetColumnCount() {
+		return false
+	}
+	for ii := uint32(0); ii < sc1.GetColumnCount(); ii++ {
+		if sc1.GetColumn(ii).GetColumnName() != sc2.GetColumn(ii).GetColumnName() {
+			return false
+		}
+	}
+	return true
+}
 
-// TODO: (SDB) [OPT] adding support of ON clause (Optimize, findBestJoin, findBestJoinInner, findBestScans, findBestScan)
+// attention: column names on qi should be qualified with table name and predicate should be normalized to CNF
+// (caller should call RewriteQueryInfo before this function)
 func (so *SelingerOptimizer) Optimize() (plans.Plan, error) {
+	if err := so.prepareSubqueries(); err != nil {
+		return nil, err
+	}
 	optimalPlans := so.findBestScans()
-	return so.findBestJoin(optimalPlans), nil
+	return so.findBestJoin(optimalPlans)
 }
 
 var CantTableIdentifedErr = errors.New("tableName can't be identified!")
+var UnsupportedExpressionErr = errors.New("specified expression or function is not supported!")
 var InvalidColNameErr = errors.New("invalid column name!")
 var InvalidTableNameErr = errors.New("invalid table name!")
 
@@ -469,6 +790,27 @@ func attachTableNameIfNeeded(tableMap map[string][]*string, tgtStr *string) (*st
 	}
 }
 
+// when sfield.TableName_ is empty, set appropriate value
+func attachTableNameToSelectField(tableMap map[string][]*string, sfield *parser.SelectFieldExpression) error {
+	if sfield.Calc_ != nil {
+		return rewiteColNameStrOfBinaryOpExp(tableMap, sfield.Calc_)
+	}
+	if sfield.ColName_ == nil {
+		return UnsupportedExpressionErr
+	}
+	if sfield.TableName_ != nil || *sfield.ColName_ == "*" {
+		return nil
+	}
+	if val, ok := tableMap[*sfield.ColName_]; ok {
+		if len(val) == 1 {
+			sfield.TableName_ = val[0]
+			return nil
+		}
+		return CantTableIdentifedErr
+	}
+	return InvalidColNameErr
+}
+
 func rewiteColNameStrOfBinaryOpExp(tableMap map[string][]*string, exp interface{}) error {
 	switch casted := exp.(type) {
 	case *parser.BinaryOpExpression:
@@ -482,11 +824,41 @@ func rewiteColNameStrOfBinaryOpExp(tableMap map[string][]*string, exp interface{
 			return err
 		}
 		if str, ok := casted.Right_.(*string); ok {
-			casted.Right_, err = attachTableNameIfNeeded(nil, str)
+			casted.Right_, err = attachTableNameIfNeeded(tableMap, str)
 		} else {
 			err = rewiteColNameStrOfBinaryOpExp(tableMap, casted.Right_)
 		}
 		return err
+	case *parser.SelectFieldExpression:
+		// aggregate function on HAVING clause
+		if casted == nil {
+			return UnsupportedAggregationErr
+		}
+		return attachTableNameToSelectField(tableMap, casted)
+	case *parser.CalcExpression:
+		if casted == nil {
+			return UnsupportedExpressionErr
+		}
+		for idx, arg := range casted.Args_ {
+			var err error
+			if str, ok := arg.(*string); ok {
+				casted.Args_[idx], err = attachTableNameIfNeeded(tableMap, str)
+			} else {
+				err = rewiteColNameStrOfBinaryOpExp(tableMap, arg)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case *parser.SubqueryExpression:
+		// columns in the subquery are rewritten with rewriteSubqueries
+		if casted.InColName_ != nil {
+			var err error
+			casted.InColName_, err = attachTableNameIfNeeded(tableMap, casted.InColName_)
+			return err
+		}
+		return nil
 	case *types.Value:
 		// do nothing
 		return nil
@@ -497,19 +869,6 @@ func rewiteColNameStrOfBinaryOpExp(tableMap map[string][]*string, exp interface{
 	}
 }
 
-func CheckIncludesORInPredicate(exp interface{}) bool {
-	switch casted := exp.(type) {
-	case *parser.BinaryOpExpression:
-		if casted.LogicalOperationType_ == expression.OR {
-			return true
-		} else {
-			return CheckIncludesORInPredicate(casted.Left_) || CheckIncludesORInPredicate(casted.Right_)
-		}
-	default:
-		return false
-	}
-}
-
 func genTableMapAndColList(c *catalog.Catalog, qi *parser.QueryInfo) (map[string][]*string, []*parser.SelectFieldExpression, error) {
 	tableMap := make(map[string][]*string, 0)
 	colList := make([]*parser.SelectFieldExpression, 0)
@@ -526,7 +885,7 @@ func genTableMapAndColList(c *catalog.Catalog, qi *parser.QueryInfo) (map[string
 			if strings.Contains(colName, ".") {
 				splited := strings.Split(colName, ".")
 				colName = splited[1]
-				colList = append(colList, &parser.SelectFieldExpression{false, -1, &splited[0], &colName})
+				colList = append(colList, &parser.SelectFieldExpression{false, -1, &splited[0], &colName, nil, nil, nil})
 			} else {
 				panic("invalid column name")
 			}
@@ -537,35 +896,78 @@ func genTableMapAndColList(c *catalog.Catalog, qi *parser.QueryInfo) (map[string
 			}
 		}
 	}
+	// columns of derived tables are named with alias of the table (see derivedColNameOf)
+	aliases := lowerTableSet(qi.JoinTables_)
+	for _, derived := range qi.DerivedTables_ {
+		if derived.Name_ == nil || *derived.Name_ == "" || aliases.Contains(strings.ToLower(*derived.Name_)) {
+			return nil, nil, InvalidDerivedTableErr
+		}
+		aliases.Add(strings.ToLower(*derived.Name_))
+		colNames := mapset.NewSet[string]()
+		for _, sfield := range derived.Query_.SelectFields_ {
+			colName := strings.SplitN(derivedColNameOf(*derived.Name_, sfield), ".", 2)[1]
+			if !colNames.Add(colName) {
+				return nil, nil, InvalidDerivedTableErr
+			}
+			colList = append(colList, &parser.SelectFieldExpression{false, -1, derived.Name_, &colName, nil, nil, nil})
+			tableMap[colName] = append(tableMap[colName], derived.Name_)
+		}
+	}
 	return tableMap, colList, nil
 }
 
-// add table name prefix to column name if column name doesn't have it
-// and attach predicate of ON clause to one of WHERE clause
+// add table name prefix to column name if column name doesn't have it,
+// attach predicate of ON clause of inner join to one of WHERE clause and normalize the predicates to CNF
+// (ON clauses on Joins_ are not attached because they should be evaluated on the join)
+// subqueries in qi are also rewritten and named (see rewriteSubqueries)
 // ATTENTION: this func modifies *qi* arg
 func RewriteQueryInfo(c *catalog.Catalog, qi *parser.QueryInfo) (*parser.QueryInfo, error) {
+	return rewriteQueryInfo(c, qi, nil, new(int))
+}
+
+// outerTableMap is tableMap of outer query when qi is subquery on WHERE or SELECT clause.
+// columns of outer query can be referred in the subquery (correlated subquery)
+func rewriteQueryInfo(c *catalog.Catalog, qi *parser.QueryInfo, outerTableMap map[string][]*string, subqueryCnt *int) (*parser.QueryInfo, error) {
+	// derived tables can't refer columns of outer query
+	for _, derived := range qi.DerivedTables_ {
+		if _, err := rewriteQueryInfo(c, derived.Query_, nil, subqueryCnt); err != nil {
+			return nil, err
+		}
+	}
+	// query of INSERT ... SELECT is independent of the target table
+	if qi.SelectQuery_ != nil {
+		if _, err := rewriteQueryInfo(c, qi.SelectQuery_, nil, subqueryCnt); err != nil {
+			return nil, err
+		}
+	}
 	tableMap, colList, err := genTableMapAndColList(c, qi)
 	if err != nil {
 		return nil, err
 	}
+	for colName, tables := range outerTableMap {
+		// column of inner query hides same name column of outer query
+		if _, ok := tableMap[colName]; !ok {
+			tableMap[colName] = tables
+		}
+	}
+	if err = rewriteSubqueries(c, qi, tableMap, subqueryCnt); err != nil {
+		return nil, err
+	}
 	// SelectFields_
 	// when SelectFields_[x].TableName_ is empty, set appropriate value
 	for _, sfield := range qi.SelectFields_ {
-		if sfield.TableName_ == nil && *sfield.ColName_ != "*" {
-			if val, ok := tableMap[*sfield.ColName_]; ok {
-				if len(val) == 1 {
-					sfield.TableName_ = val[0]
-				} else {
-					return nil, CantTableIdentifedErr
-				}
-			} else {
-				return nil, InvalidColNameErr
-			}
+		if sfield == nil {
+			// aggregate function which is not supported
+			return nil, UnsupportedAggregationErr
+		}
+		if err = attachTableNameToSelectField(tableMap, sfield); err != nil {
+			return nil, err
 		}
 	}
 	// replace asterisk to column names (one asterisk only)
 	for ii := 0; ii < len(qi.SelectFields_); ii++ {
-		if *qi.SelectFields_[ii].ColName_ == "*" {
+		// asterisk of COUNT(*) is not replaced
+		if !qi.SelectFields_[ii].IsAgg_ && qi.SelectFields_[ii].ColName_ != nil && *qi.SelectFields_[ii].ColName_ == "*" {
 			qi.SelectFields_ = append(qi.SelectFields_[:ii], qi.SelectFields_[ii+1:]...)
 			qi.SelectFields_ = slices.Insert(qi.SelectFields_, ii, colList...)
 			break
@@ -575,6 +977,21 @@ func RewriteQueryInfo(c *catalog.Catalog, qi *parser.QueryInfo) (*parser.QueryIn
 	if *qi.QueryType_ == parser.DELETE || *qi.QueryType_ == parser.UPDATE {
 		qi.SelectFields_ = colList
 	}
+	// ReturningFields_ (columns of the target table only)
+	if qi.ReturningFields_ != nil {
+		fields := make([]*parser.SelectFieldExpression, 0)
+		for _, sfield := range qi.ReturningFields_ {
+			if *sfield.ColName_ == "*" {
+				fields = append(fields, colList...)
+				continue
+			}
+			if err = attachTableNameToSelectField(tableMap, sfield); err != nil {
+				return nil, err
+			}
+			fields = append(fields, sfield)
+		}
+		qi.ReturningFields_ = fields
+	}
 
 	// OnExpressions_
 	err = rewiteColNameStrOfBinaryOpExp(tableMap, qi.OnExpressions_)
@@ -582,16 +999,68 @@ func RewriteQueryInfo(c *catalog.Catalog, qi *parser.QueryInfo) (*parser.QueryIn
 		return nil, err
 	}
 
+	// Joins_
+	for _, join := range qi.Joins_ {
+		err = rewiteColNameStrOfBinaryOpExp(tableMap, join.OnExpression_)
+		if err != nil {
+			return nil, err
+		}
+		join.OnExpression_ = NormalizeToCNF(join.OnExpression_)
+	}
+
 	// WhereExpression_
 	err = rewiteColNameStrOfBinaryOpExp(tableMap, qi.WhereExpression_)
 	if err != nil {
 		return nil, err
 	}
 
-	if !(qi.OnExpressions_.Left_ == nil && qi.OnExpressions_.Right_ == nil) {
+	// GroupByCols_
+	for ii, colName := range qi.GroupByCols_ {
+		qi.GroupByCols_[ii], err = attachTableNameIfNeeded(tableMap, colName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// OrderByExpressions_
+	for _, obe := range qi.OrderByExpressions_ {
+		if sfield := selectFieldOfAlias(qi, obe.ColName_); sfield != nil {
+			// alias on SELECT clause. calculated value is sorted after final projection
+			if sfield.IsAgg_ {
+				obe.ColName_, obe.AggField_ = nil, sfield
+			} else if sfield.Calc_ == nil {
+				colName := qualifiedColName(sfield)
+				obe.ColName_ = &colName
+			}
+		} else if obe.ColName_ != nil {
+			obe.ColName_, err = attachTableNameIfNeeded(tableMap, obe.ColName_)
+		} else if obe.AggField_ != nil {
+			err = attachTableNameToSelectField(tableMap, obe.AggField_)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// HavingExpression_
+	if qi.HavingExpression_ != nil {
+		err = rewiteColNameStrOfBinaryOpExp(tableMap, qi.HavingExpression_)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !isEmptyBinaryOpExp(qi.OnExpressions_) {
 		// attach predicate of ON clause to one of WHERE clause
-		qi.WhereExpression_ = qi.WhereExpression_.AppendBinaryOpExpWithAnd(qi.OnExpressions_)
+		if isEmptyBinaryOpExp(qi.WhereExpression_) {
+			qi.WhereExpression_ = qi.OnExpressions_
+		} else {
+			qi.WhereExpression_ = qi.WhereExpression_.AppendBinaryOpExpWithAnd(qi.OnExpressions_)
+		}
 	}
 
+	// predicate which includes OR and parentheses is converted to AND of ORs
+	qi.WhereExpression_ = NormalizeToCNF(qi.WhereExpression_)
+
 	return qi, nil
 }
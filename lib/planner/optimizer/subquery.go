@@ -0,0 +1,524 @@
+package optimizer
+
+import (
+	"errors"
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/ryogrid/SamehadaDB/lib/catalog"
+	"github.com/ryogrid/SamehadaDB/lib/execution/expression"
+	"github.com/ryogrid/SamehadaDB/lib/execution/plans"
+	"github.com/ryogrid/SamehadaDB/lib/parser"
+	"github.com/ryogrid/SamehadaDB/lib/storage/index/index_constants"
+	"github.com/ryogrid/SamehadaDB/lib/storage/table/column"
+	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
+	"github.com/ryogrid/SamehadaDB/lib/types"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var UnsupportedSubqueryErr = errors.New("subquery is not supported in this context!")
+var InvalidDerivedTableErr = errors.New("derived table must have unique alias and column names!")
+var SubqueryColumnNumErr = errors.New("subquery must return only one column!")
+
+// subqueryJoin is a subquery on WHERE or SELECT clause which is decorrelated to join with result of outer query.
+// EXISTS and IN subquery are processed with semi join or anti join, and scalar subquery is processed with
+// LEFT_SINGLE_JOIN. columns of the subquery are renamed to "<name of subquery>.<column name>"
+type subqueryJoin struct {
+	sq       *parser.SubqueryExpression
+	joinType plans.JoinType
+	// plan of the subquery whose columns are renamed
+	plan plans.Plan
+	// correlated conditions (columns of the subquery are renamed). they are evaluated on the join
+	onConjuncts []*parser.BinaryOpExpression
+	// when the subquery scans one table without aggregation, the table can be joined with index join.
+	// in the case, unfiltered scan of the table and conditions of the subquery (renamed) are set
+	unfilteredPlan plans.Plan
+	localConjuncts []*parser.BinaryOpExpression
+	// column which has result of IN and scalar subquery
+	valueColName string
+}
+
+// returns true when exp includes subquery
+func includesSubquery(exp interface{}) bool {
+	switch casted := exp.(type) {
+	case *parser.BinaryOpExpression:
+		return casted != nil && (includesSubquery(casted.Left_) || includesSubquery(casted.Right_))
+	case *parser.SubqueryExpression:
+		return true
+	case *parser.CalcExpression:
+		if casted != nil {
+			for _, arg := range casted.Args_ {
+				if includesSubquery(arg) {
+					return true
+				}
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// returns subqueries which are included in exp
+func subqueriesOf(exp interface{}) []*parser.SubqueryExpression {
+	switch casted := exp.(type) {
+	case *parser.BinaryOpExpression:
+		if casted == nil {
+			return []*parser.SubqueryExpression{}
+		}
+		return append(subqueriesOf(casted.Left_), subqueriesOf(casted.Right_)...)
+	case *parser.SubqueryExpression:
+		return []*parser.SubqueryExpression{casted}
+	case *parser.CalcExpression:
+		ret := []*parser.SubqueryExpression{}
+		if casted != nil {
+			for _, arg := range casted.Args_ {
+				ret = append(ret, subqueriesOf(arg)...)
+			}
+		}
+		return ret
+	default:
+		return []*parser.SubqueryExpression{}
+	}
+}
+
+// returns column name of sfield on output schema of query (ex: "table_name.column_name", "count(*)")
+func outputColNameOf(sfield *parser.SelectFieldExpression) string {
+	if sfield.Calc_ != nil {
+		return strings.ToLower(*sfield.Alias_)
+	}
+	if sfield.IsAgg_ {
+		return aggregateColName(sfield)
+	}
+	return qualifiedColName(sfield)
+}
+
+// returns column name of sfield on derived table. it is qualified with alias of the table and
+// column name on AS clause is used if specified (ex: SELECT d.cnt FROM (SELECT COUNT(*) AS cnt FROM t) AS d)
+func derivedColNameOf(alias string, sfield *parser.SelectFieldExpression) string {
+	if sfield.Alias_ != nil {
+		return strings.ToLower(alias + "." + *sfield.Alias_)
+	}
+	if sfield.IsAgg_ {
+		return strings.ToLower(alias + "." + aggregateColName(sfield))
+	}
+	return strings.ToLower(alias + "." + *sfield.ColName_)
+}
+
+// returns tables which are referred by qi except for WHERE clause
+func touchedTablesOfQuery(qi *parser.QueryInfo) mapset.Set[string] {
+	touchedColumns := touchedColumnsOfAggregation(qi).Union(touchedColumnsOfOrderBy(qi))
+	for _, sfield := range qi.SelectFields_ {
+		touchedColumns = touchedColumns.Union(sfield.TouchedColumns())
+	}
+	for _, join := range qi.Joins_ {
+		touchedColumns = touchedColumns.Union(join.OnExpression_.TouchedColumns())
+	}
+	ret := mapset.NewSet[string]()
+	for _, colName := range touchedColumns.ToSlice() {
+		if strings.Contains(colName, ".") {
+			ret.Add(strings.Split(colName, ".")[0])
+		}
+	}
+	return ret
+}
+
+// replaces operands on exp (BinaryOpExpression or CalcExpression) with replace func recursively
+func replaceOperandsOf(exp interface{}, replace func(operand interface{}) interface{}) {
+	switch casted := exp.(type) {
+	case *parser.BinaryOpExpression:
+		casted.Left_ = replace(casted.Left_)
+		casted.Right_ = replace(casted.Right_)
+		replaceOperandsOf(casted.Left_, replace)
+		replaceOperandsOf(casted.Right_, replace)
+	case *parser.CalcExpression:
+		if casted == nil {
+			return
+		}
+		for idx, arg := range casted.Args_ {
+			casted.Args_[idx] = replace(arg)
+			replaceOperandsOf(casted.Args_[idx], replace)
+		}
+	}
+}
+
+// returns copy of exp whose columns of *tables* are renamed to "<prefix>.<column name>"
+func renameColumnsOf(exp *parser.BinaryOpExpression, tables mapset.Set[string], prefix string) *parser.BinaryOpExpression {
+	ret := exp.GetDeepCopy()
+	replaceOperandsOf(ret, func(operand interface{}) interface{} {
+		if casted, ok := operand.(*string); ok {
+			colName := strings.ToLower(*casted)
+			if strings.Contains(colName, ".") && tables.Contains(strings.Split(colName, ".")[0]) {
+				renamed := prefix + "." + colName
+				return &renamed
+			}
+		}
+		return operand
+	})
+	return ret
+}
+
+// replaces subqueries on exp with column which has result of the subquery
+func (so *SelingerOptimizer) replaceSubqueries(exp interface{}) {
+	replaceOperandsOf(exp, func(operand interface{}) interface{} {
+		if casted, ok := operand.(*parser.SubqueryExpression); ok {
+			for _, sj := range so.subqueryJoins {
+				if *sj.sq.Name_ == *casted.Name_ {
+					colName := sj.valueColName
+					return &colName
+				}
+			}
+		}
+		return operand
+	})
+}
+
+// returns copy of exp whose subqueries are replaced with column which has result of the subquery
+func (so *SelingerOptimizer) replaceSubqueriesOf(exp *parser.BinaryOpExpression) *parser.BinaryOpExpression {
+	ret := exp.GetDeepCopy()
+	so.replaceSubqueries(ret)
+	return ret
+}
+
+// returns column names of plan which are renamed to "<prefix>.<column name>"
+func renamedColNamesOf(sc *schema.Schema, prefix string) []string {
+	ret := make([]string, 0, sc.GetColumnCount())
+	for _, col := range sc.GetColumns() {
+		ret = append(ret, prefix+"."+col.GetColumnName())
+	}
+	return ret
+}
+
+// names subqueries on WHERE and SELECT clause of qi ("subquery#<number>") and rewrites them
+// with tableMap of qi. name of subquery is used as table name of its columns on SELECT clause
+func rewriteSubqueries(c *catalog.Catalog, qi *parser.QueryInfo, tableMap map[string][]*string, subqueryCnt *int) error {
+	// subqueries on ON clause is merged to WHERE clause after rewriting. so, they are not supported also
+	if includesSubquery(qi.HavingExpression_) || includesSubquery(qi.OnExpressions_) {
+		return UnsupportedSubqueryErr
+	}
+	for _, join := range qi.Joins_ {
+		if includesSubquery(join.OnExpression_) {
+			return UnsupportedSubqueryErr
+		}
+	}
+
+	for _, sq := range qi.Subqueries() {
+		if sq.SubqueryType_ == parser.DERIVED_TABLE {
+			continue
+		}
+		*subqueryCnt++
+		name := "subquery#" + strconv.Itoa(*subqueryCnt)
+		sq.Name_ = &name
+		if _, err := rewriteQueryInfo(c, sq.Query_, tableMap, subqueryCnt); err != nil {
+			return err
+		}
+		if sq.SubqueryType_ != parser.EXISTS_SUBQUERY && len(sq.Query_.SelectFields_) != 1 {
+			return SubqueryColumnNumErr
+		}
+	}
+	for _, sfield := range qi.SelectFields_ {
+		if sfield != nil && sfield.Subquery_ != nil {
+			colName := outputColNameOf(sfield.Subquery_.Query_.SelectFields_[0])
+			sfield.TableName_ = sfield.Subquery_.Name_
+			sfield.ColName_ = &colName
+		}
+	}
+	return nil
+}
+
+// plans derived tables and subqueries on WHERE and SELECT clause. they should be prepared before findBestScans
+func (so *SelingerOptimizer) prepareSubqueries() error {
+	if len(so.qi.Subqueries()) == 0 {
+		return nil
+	}
+	// DELETE and UPDATE need RID of records. it is lost with joins of subqueries
+	if *so.qi.QueryType_ != parser.SELECT {
+		return UnsupportedSubqueryErr
+	}
+
+	for _, derived := range so.qi.DerivedTables_ {
+		plan, err := NewSelingerOptimizer(derived.Query_, so.c).Optimize()
+		if err != nil {
+			return err
+		}
+		colNames := make([]string, 0, len(derived.Query_.SelectFields_))
+		for _, sfield := range derived.Query_.SelectFields_ {
+			colNames = append(colNames, derivedColNameOf(*derived.Name_, sfield))
+		}
+		so.derivedPlans[*derived.Name_] = plans.NewRenamingProjectionPlanNode(plan, colNames)
+	}
+
+	outerTables := lowerTableSet(so.tables)
+	for _, exp := range so.subqueryConjuncts {
+		// subquery under OR can't be processed with semi join
+		if exp.GetType() == parser.Logical {
+			return UnsupportedSubqueryErr
+		}
+		for _, sq := range subqueriesOf(exp) {
+			sj, err := so.newSubqueryJoin(sq, outerTables)
+			if err != nil {
+				return err
+			}
+			so.subqueryJoins = append(so.subqueryJoins, sj)
+		}
+	}
+	for _, sfield := range so.qi.SelectFields_ {
+		sqs := subqueriesOf(sfield.Calc_)
+		if sfield.Subquery_ != nil {
+			sqs = append(sqs, sfield.Subquery_)
+		}
+		if len(sqs) == 0 {
+			continue
+		}
+		if IncludesAggregation(so.qi) {
+			return UnsupportedSubqueryErr
+		}
+		for _, sq := range sqs {
+			sj, err := so.newSubqueryJoin(sq, outerTables)
+			if err != nil {
+				return err
+			}
+			so.subqueryJoins = append(so.subqueryJoins, sj)
+		}
+	}
+	return nil
+}
+
+// plans sq which is decorrelated. columns of outerTables can be referred on WHERE clause of sq.
+// correlated conditions are evaluated on the join, and columns of sq which are referred by them are added to
+// SELECT clause of sq. correlated scalar subquery which has aggregation is grouped by the columns
+// (ex: SELECT MAX(b) FROM t2 WHERE t2.c = t1.c => SELECT MAX(b), t2.c FROM t2 GROUP BY t2.c)
+func (so *SelingerOptimizer) newSubqueryJoin(sq *parser.SubqueryExpression, outerTables mapset.Set[string]) (*subqueryJoin, error) {
+	sub := sq.Query_.GetDeepCopy()
+	prefix := strings.ToLower(*sq.Name_)
+	innerTables := lowerTableSet(sub.JoinTables_)
+	for _, derived := range sub.DerivedTables_ {
+		innerTables.Add(strings.ToLower(*derived.Name_))
+	}
+	// subqueries nested in sq are also tables of sq
+	subTables := innerTables.Clone()
+	for _, nested := range sub.Subqueries() {
+		if nested.Name_ != nil {
+			subTables.Add(strings.ToLower(*nested.Name_))
+		}
+	}
+	if !touchedTablesOfQuery(sub).IsSubset(subTables) {
+		return nil, UnsupportedSubqueryErr
+	}
+
+	localConjuncts := make([]*parser.BinaryOpExpression, 0)
+	correlated := make([]*parser.BinaryOpExpression, 0)
+	for _, exp := range conjunctsOf(sub.WhereExpression_) {
+		tables := touchedTablesOf(exp)
+		if tables.IsSubset(subTables) {
+			localConjuncts = append(localConjuncts, exp)
+		} else if !includesSubquery(exp) && tables.IsSubset(innerTables.Union(outerTables)) {
+			correlated = append(correlated, exp)
+		} else {
+			// subquery which refers columns of outer query of outer query, etc...
+			return nil, UnsupportedSubqueryErr
+		}
+	}
+
+	var joinType plans.JoinType
+	switch sq.SubqueryType_ {
+	case parser.EXISTS_SUBQUERY, parser.IN_SUBQUERY:
+		joinType = plans.SEMI_JOIN
+		if sq.Not_ {
+			joinType = plans.ANTI_JOIN
+		}
+		if sq.SubqueryType_ == parser.IN_SUBQUERY && sq.InColName_ == nil {
+			return nil, UnsupportedSubqueryErr
+		}
+	case parser.SCALAR_SUBQUERY:
+		joinType = plans.LEFT_SINGLE_JOIN
+	default:
+		return nil, UnsupportedSubqueryErr
+	}
+
+	if len(correlated) > 0 {
+		if sub.LimitNum_ >= 0 {
+			return nil, UnsupportedSubqueryErr
+		}
+		innerCols := make([]string, 0)
+		for _, exp := range correlated {
+			for _, colName := range exp.TouchedColumns().ToSlice() {
+				if innerTables.Contains(strings.Split(colName, ".")[0]) {
+					innerCols = append(innerCols, colName)
+				}
+			}
+		}
+		if IncludesAggregation(sub) {
+			if !isDecorrelatableAggregation(sq, sub, correlated, innerTables) {
+				return nil, UnsupportedSubqueryErr
+			}
+			sub.GroupByCols_ = make([]*string, 0, len(innerCols))
+		}
+		for _, colName := range innerCols {
+			splited := strings.Split(colName, ".")
+			sub.SelectFields_ = append(sub.SelectFields_, &parser.SelectFieldExpression{false, -1, &splited[0], &splited[1], nil, nil, nil})
+			if IncludesAggregation(sub) {
+				qualified := colName
+				sub.GroupByCols_ = append(sub.GroupByCols_, &qualified)
+			}
+		}
+		sub.WhereExpression_ = conjunctionOf(localConjuncts)
+		sub.OrderByExpressions_ = make([]*parser.OrderByExpression, 0)
+	}
+
+	subPlan, err := NewSelingerOptimizer(sub, so.c).Optimize()
+	if err != nil {
+		return nil, err
+	}
+	ret := &subqueryJoin{sq: sq, joinType: joinType}
+	ret.plan = plans.NewRenamingProjectionPlanNode(subPlan, renamedColNamesOf(subPlan.OutputSchema(), prefix))
+	ret.valueColName = prefix + "." + subPlan.OutputSchema().GetColumn(0).GetColumnName()
+	for _, exp := range correlated {
+		ret.onConjuncts = append(ret.onConjuncts, renameColumnsOf(exp, innerTables, prefix))
+	}
+
+	if len(sub.JoinTables_) == 1 && len(sub.DerivedTables_) == 0 && len(sub.Joins_) == 0 && len(sub.Subqueries()) == 0 &&
+		!IncludesAggregation(sub) && sub.LimitNum_ < 0 {
+		tm := so.c.GetTableByName(*sub.JoinTables_[0])
+		scan := plans.NewSeqScanPlanNode(so.c, tm.Schema(), nil, tm.OID())
+		ret.unfilteredPlan = plans.NewRenamingProjectionPlanNode(scan, renamedColNamesOf(tm.Schema(), prefix))
+		for _, exp := range localConjuncts {
+			ret.localConjuncts = append(ret.localConjuncts, renameColumnsOf(exp, innerTables, prefix))
+		}
+	}
+	return ret, nil
+}
+
+// correlated subquery which has aggregation can be decorrelated with GROUP BY when it is scalar subquery
+// and correlated conditions are equality between columns of the subquery and outer query.
+// COUNT is not supported because it returns 0 (not NULL) for record of outer query which matches nothing
+func isDecorrelatableAggregation(sq *parser.SubqueryExpression, sub *parser.QueryInfo, correlated []*parser.BinaryOpExpression, innerTables mapset.Set[string]) bool {
+	if sq.SubqueryType_ != parser.SCALAR_SUBQUERY || len(sub.GroupByCols_) > 0 || !isEmptyBinaryOpExp(sub.HavingExpression_) {
+		return false
+	}
+	for _, sfield := range sub.SelectFields_ {
+		if sfield.IsAgg_ && (sfield.AggType_ == plans.COUNT_AGGREGATE || sfield.AggType_ == plans.COUNT_DISTINCT_AGGREGATE) {
+			return false
+		}
+	}
+	for _, exp := range correlated {
+		if exp.GetType() != parser.Compare || exp.ComparisonOperationType_ != expression.Equal {
+			return false
+		}
+		left, okL := exp.Left_.(*string)
+		right, okR := exp.Right_.(*string)
+		if !okL || !okR {
+			return false
+		}
+		isInnerL := innerTables.Contains(strings.Split(strings.ToLower(*left), ".")[0])
+		isInnerR := innerTables.Contains(strings.Split(strings.ToLower(*right), ".")[0])
+		if isInnerL == isInnerR {
+			return false
+		}
+	}
+	return true
+}
+
+func isNotNullColumn(sc *schema.Schema, colName string) bool {
+	colIdx := sc.GetColIndex(colName)
+	return colIdx != math.MaxUint32 && sc.GetColumn(colIdx).IsNotNull()
+}
+
+// returns IS NULL predicate of colName
+func isNullExpOf(colName string) *parser.BinaryOpExpression {
+	nullVal := types.NewNull()
+	return &parser.BinaryOpExpression{-1, expression.Equal, &colName, &nullVal}
+}
+
+// joins subquery to left with semi join, anti join or LEFT_SINGLE_JOIN
+func (so *SelingerOptimizer) joinSubquery(left plans.Plan, sj *subqueryJoin) plans.Plan {
+	onConjuncts := append([]*parser.BinaryOpExpression{}, sj.onConjuncts...)
+	if sj.sq.SubqueryType_ == parser.IN_SUBQUERY {
+		inColName := strings.ToLower(*sj.sq.InColName_)
+		valueColName := sj.valueColName
+		var inExp = &parser.BinaryOpExpression{-1, expression.Equal, &inColName, &valueColName}
+		if sj.sq.Not_ && !(isNotNullColumn(left.OutputSchema(), inColName) && isNotNullColumn(sj.plan.OutputSchema(), valueColName)) {
+			// a NOT IN (SELECT b ...) is not true when a is NULL or b has NULL
+			inExp = &parser.BinaryOpExpression{expression.OR, -1, inExp, isNullExpOf(inColName)}
+			inExp = &parser.BinaryOpExpression{expression.OR, -1, inExp, isNullExpOf(valueColName)}
+		}
+		onConjuncts = append(onConjuncts, inExp)
+	}
+
+	scope := &joinScope{joinType: sj.joinType, onConjuncts: onConjuncts}
+	ret, _ := so.findBestJoinInner(nil, scope, sj.joinType, left, sj.plan, so.c)
+	if sj.unfilteredPlan != nil {
+		// conditions of the subquery are evaluated on the join for index join
+		scope = &joinScope{joinType: sj.joinType, onConjuncts: append(onConjuncts, sj.localConjuncts...)}
+		candidate, _ := so.findBestJoinInner(nil, scope, sj.joinType, left, sj.unfilteredPlan, so.c)
+		if candidate.AccessRowCount(so.c) < ret.AccessRowCount(so.c) {
+			ret = candidate
+		}
+	}
+	return ret
+}
+
+// joins subqueries on WHERE clause and evaluates conditions which include them. then subqueries on
+// SELECT clause are joined because they should be evaluated only for records which satisfy WHERE clause
+func (so *SelingerOptimizer) attachSubqueries(solution plans.Plan) plans.Plan {
+	if len(so.subqueryJoins) == 0 {
+		return solution
+	}
+	whereSubqueries := mapset.NewSet[string]()
+	for _, exp := range so.subqueryConjuncts {
+		for _, sq := range subqueriesOf(exp) {
+			whereSubqueries.Add(*sq.Name_)
+		}
+	}
+	for _, sj := range so.subqueryJoins {
+		if whereSubqueries.Contains(*sj.sq.Name_) {
+			solution = so.joinSubquery(solution, sj)
+		}
+	}
+	// conditions which compare result of scalar subquery
+	exps := make([]*parser.BinaryOpExpression, 0)
+	for _, exp := range so.subqueryConjuncts {
+		if exp.GetType() != parser.Subquery {
+			exps = append(exps, so.replaceSubqueriesOf(exp))
+		}
+	}
+	if len(exps) > 0 {
+		solution = plans.NewSelectionPlanNode(solution, parser.ConvParsedBinaryOpExprToExpIFOne(solution.OutputSchema(), conjunctionOf(exps)))
+	}
+	for _, sj := range so.subqueryJoins {
+		if !whereSubqueries.Contains(*sj.sq.Name_) {
+			solution = so.joinSubquery(solution, sj)
+		}
+	}
+	return solution
+}
+
+// returns output schema of final projection. columns of derived tables and subqueries are copied from solution.
+// when SELECT clause has calculated values, expressions which calculate each column are also returned
+// (nil for column which is copied from solution)
+func (so *SelingerOptimizer) outSchemaOf(solution plans.Plan) (*schema.Schema, []expression.Expression) {
+	outColDefs := make([]*column.Column, 0, len(so.qi.SelectFields_))
+	var exprs []expression.Expression = nil
+	for idx, sfield := range so.qi.SelectFields_ {
+		if sfield.Calc_ != nil {
+			if exprs == nil {
+				exprs = make([]expression.Expression, len(so.qi.SelectFields_))
+			}
+			// qi is not modified
+			calc := sfield.Calc_.GetDeepCopy()
+			so.replaceSubqueries(calc)
+			exprs[idx] = parser.ConvCalcExpToExpIFOne(solution.OutputSchema(), calc)
+			outColDefs = append(outColDefs, column.NewColumn(outputColNameOf(sfield), exprs[idx].GetReturnType(), false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), nil))
+			continue
+		}
+		if !so.virtualTables.Contains(strings.ToLower(*sfield.TableName_)) {
+			outColDefs = append(outColDefs, parser.ConvParsedSelectionExprToSchema(so.c, []*parser.SelectFieldExpression{sfield}).GetColumn(0))
+			continue
+		}
+		col := solution.OutputSchema().GetColumn(solution.OutputSchema().GetColIndex(qualifiedColName(sfield)))
+		outCol := column.NewColumn(col.GetColumnName(), col.GetType(), false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), nil)
+		outCol.SetIsNotNull(col.IsNotNull())
+		outColDefs = append(outColDefs, outCol)
+	}
+	return schema.NewSchema(outColDefs), exprs
+}
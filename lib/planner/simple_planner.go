@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"github.com/ryogrid/SamehadaDB/lib/catalog"
-	"github.com/ryogrid/SamehadaDB/lib/execution/expression"
 	"github.com/ryogrid/SamehadaDB/lib/execution/plans"
 	"github.com/ryogrid/SamehadaDB/lib/parser"
 	"github.com/ryogrid/SamehadaDB/lib/planner/optimizer"
@@ -13,7 +12,6 @@ import (
 	"github.com/ryogrid/SamehadaDB/lib/storage/index/index_constants"
 	"github.com/ryogrid/SamehadaDB/lib/storage/table/column"
 	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
-	"github.com/ryogrid/SamehadaDB/lib/testing/testing_tbl_gen"
 	"github.com/ryogrid/SamehadaDB/lib/types"
 	"math"
 	"strings"
@@ -34,11 +32,28 @@ func (pner *SimplePlanner) MakePlan(qi *parser.QueryInfo, txn *access.Transactio
 	pner.qi = qi
 	pner.txn = txn
 
+	// table can be dropped after parsing (ex: prepared statement)
+	for _, tblName := range pner.qi.JoinTables_ {
+		if pner.catalog_.GetTableByName(*tblName) == nil {
+			return PrintAndCreateError("table " + *tblName + " not found.")
+		}
+	}
+
 	switch *pner.qi.QueryType_ {
 	case parser.SELECT:
 		return pner.MakeSelectPlan()
 	case parser.CREATE_TABLE:
 		return pner.MakeCreateTablePlan()
+	case parser.CREATE_INDEX:
+		return pner.MakeCreateIndexPlan()
+	case parser.DROP_INDEX:
+		return pner.MakeDropIndexPlan()
+	case parser.DROP_TABLE:
+		return pner.MakeDropTablePlan()
+	case parser.TRUNCATE:
+		return pner.MakeTruncatePlan()
+	case parser.ALTER_TABLE:
+		return pner.MakeAlterTablePlan()
 	case parser.INSERT:
 		return pner.MakeInsertPlan()
 	case parser.DELETE:
@@ -50,229 +65,175 @@ func (pner *SimplePlanner) MakePlan(qi *parser.QueryInfo, txn *access.Transactio
 	}
 }
 
-func (pner *SimplePlanner) MakeSelectPlanWithoutJoin() (error, plans.Plan) {
-	tblName := *pner.qi.JoinTables_[0]
-	tableMetadata := pner.catalog_.GetTableByName(tblName)
-
-	tgtTblSchema := tableMetadata.Schema()
-	tgtTblColumns := tgtTblSchema.GetColumns()
-	hasWhere := pner.qi.WhereExpression_.Left_ != nil && pner.qi.WhereExpression_.Right_ != nil
-
-	outColDefs := make([]*column.Column, 0)
-	var outSchema *schema.Schema = nil
-	if !(len(pner.qi.SelectFields_) == 1 && *pner.qi.SelectFields_[0].ColName_ == "*") {
-		// column existance check
-		for _, sfield := range pner.qi.SelectFields_ {
-			colName := sfield.ColName_
-			isOk := false
-			for _, existCol := range tgtTblColumns {
-				if strings.Split(existCol.GetColumnName(), ".")[1] == *colName {
-					isOk = true
-					outColDefs = append(outColDefs, column.NewColumn(*colName, existCol.GetType(), false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), existCol.GetExpr()))
-					break
-				}
-			}
-			if !isOk {
-				return PrintAndCreateError("column " + *colName + " does not exist.")
-			}
-		}
-		// Attention: this method call modifies passed Column objects
-		outSchema = schema.NewSchema(outColDefs)
-	} else {
-		outSchema = tgtTblSchema
-	}
-
-	var predicate expression.Expression = nil
-	if hasWhere {
-		predicate = pner.ConstructPredicate([]*schema.Schema{tgtTblSchema})
-	}
-
-	return nil, plans.NewSeqScanPlanNode(pner.catalog_, outSchema, predicate, tableMetadata.OID())
-}
-
 func (pner *SimplePlanner) MakeOptimizedSelectPlanWithJoin() (error, plans.Plan) {
 	optPlan, err := optimizer.NewSelingerOptimizer(pner.qi, pner.catalog_).Optimize()
 	return err, optPlan
 }
 
-func (pner *SimplePlanner) MakeSelectPlanWithJoin() (error, plans.Plan) {
-	tblNameL := *pner.qi.JoinTables_[0]
-	tableMetadataL := pner.catalog_.GetTableByName(tblNameL)
-	tgtTblSchemaL := tableMetadataL.Schema()
-	tgtTblColumnsL := tgtTblSchemaL.GetColumns()
-
-	tblNameR := *pner.qi.JoinTables_[1]
-	tableMetadataR := pner.catalog_.GetTableByName(tblNameR)
-	tgtTblSchemaR := tableMetadataR.Schema()
-	tgtTblColumnsR := tgtTblSchemaR.GetColumns()
-
-	hasWhere := pner.qi.WhereExpression_.Left_ != nil && pner.qi.WhereExpression_.Right_ != nil
-
-	var outSchemaL *schema.Schema
-	var scanPlanL plans.Plan
-	{
-		var columns = make([]*column.Column, 0)
-		for _, col := range tgtTblColumnsL {
-			columns = append(columns, column.NewColumn(col.GetColumnName(), col.GetType(), false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), col.GetExpr()))
-		}
-		outSchemaL = schema.NewSchema(columns)
-		scanPlanL = plans.NewSeqScanPlanNode(pner.catalog_, outSchemaL, nil, tableMetadataL.OID())
+func (pner *SimplePlanner) MakeSelectPlan() (error, plans.Plan) {
+	return pner.MakeOptimizedSelectPlanWithJoin()
+}
+
+func (pner *SimplePlanner) MakeCreateTablePlan() (error, plans.Plan) {
+	if pner.catalog_.GetTableByName(*pner.qi.NewTable_) != nil {
+		return PrintAndCreateError("already " + *pner.qi.NewTable_ + " exists.")
 	}
 
-	var outSchemaR *schema.Schema
-	var scanPlanR plans.Plan
-	{
-		var columns = make([]*column.Column, 0)
-		for _, col := range tgtTblColumnsR {
-			columns = append(columns, column.NewColumn(col.GetColumnName(), col.GetType(), false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), col.GetExpr()))
+	columns := make([]*column.Column, 0)
+	checkExprStrs := append([]*string{}, pner.qi.CheckExprStrs_...)
+	for _, cdefExp := range pner.qi.ColDefExpressions_ {
+		err, column_ := newColumnOfColDef(cdefExp)
+		if err != nil {
+			return err, nil
 		}
-		outSchemaR = schema.NewSchema(columns)
-		scanPlanR = plans.NewSeqScanPlanNode(pner.catalog_, outSchemaR, nil, tableMetadataR.OID())
+		columns = append(columns, column_)
+		//columns = append(columns, column.NewColumn(*cdefExp.ColName_, *cdefExp.ColType_, true, index_constants.INDEX_KIND_BTREE, types.PageID(-1), nil))
+		checkExprStrs = append(checkExprStrs, cdefExp.CheckExprStrs_...)
 	}
+	schema_ := schema.NewSchema(columns)
 
-	var joinPlan *plans.HashJoinPlanNode
-	var outFinal *schema.Schema
-	var filterOut *schema.Schema
-	{
-		finalOutCols := make([]*column.Column, 0)
-
-		// new columns have tuple index of 0 because they are the left side of the join
-		colValL := expression.MakeColumnValueExpression(outSchemaL, 0, *pner.qi.OnExpressions_.Left_.(*string))
-		// new columns have tuple index of 1 because they are the right side of the join
-		colValR := expression.MakeColumnValueExpression(outSchemaR, 1, *pner.qi.OnExpressions_.Right_.(*string))
-
-		for _, colDef := range tgtTblColumnsL {
-			col := column.NewColumn(colDef.GetColumnName(), colDef.GetType(), false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), colDef.GetExpr())
-			col.SetIsLeft(true)
-			finalOutCols = append(finalOutCols, col)
-		}
-		for _, colDef := range tgtTblColumnsR {
-			col := column.NewColumn(colDef.GetColumnName(), colDef.GetType(), false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), colDef.GetExpr())
-			col.SetIsLeft(false)
-			finalOutCols = append(finalOutCols, col)
+	// PRIMARY KEY, UNIQUE and INDEX specified as table constraints.
+	// constraint on a column is set to its index and composite index is created for multiple columns
+	compositeIdxDefs := make([]*parser.IndexDefExpression, 0)
+	for _, idxDef := range pner.qi.IndexDefExpressions_ {
+		if idxDef.IndexKind_ == index_constants.INDEX_KIND_INVALID {
+			return PrintAndCreateError("specified constraint is not supported. PRIMARY KEY, UNIQUE, CHECK and INDEX are available.")
 		}
-		// output schema of HashJoinExecutor
-		outFinal = schema.NewSchema(finalOutCols)
-
-		if hasWhere {
-			// query has WHERE clause
-			if len(pner.qi.SelectFields_) == 1 && *pner.qi.SelectFields_[0].ColName_ == "*" {
-				// both schema includes all columns
-				filterOut = outFinal
-			} else {
-				filterOutCols := make([]*column.Column, 0)
-				// column existance check
-				for _, sfield := range pner.qi.SelectFields_ {
-					colName := sfield.ColName_
-					tblName := sfield.TableName_
-
-					if *tblName != tblNameL && *tblName != tblNameR {
-						return PrintAndCreateError("specified selection " + *tblName + "." + *colName + " is invalid.")
-					}
-
-					if pner.catalog_.GetTableByName(*tblName) == nil {
-						return PrintAndCreateError("table " + *tblName + " does not exist.")
-					}
-
-					tmpSchema := pner.catalog_.GetTableByName(*tblName).Schema()
-					colIdx := tmpSchema.GetColIndex(*colName)
-					if colIdx == math.MaxUint32 {
-						return PrintAndCreateError("column " + *colName + " does not exist on " + *tblName + ".")
-					}
-
-					colDef := tmpSchema.GetColumn(colIdx)
-					col := column.NewColumn(*tblName+"."+*colName, colDef.GetType(), false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), colDef.GetExpr())
-					if *tblName == tblNameL {
-						col.SetIsLeft(true)
-					} else { // Right
-						col.SetIsLeft(false)
-					}
-					// Attention: this method call modifies passed Column objects
-					filterOutCols = append(filterOutCols, col)
-				}
-				filterOut = schema.NewSchema(finalOutCols)
+		for _, colName := range idxDef.Colnames_ {
+			if schema_.GetColIndex(*colName) == math.MaxUint32 {
+				return PrintAndCreateError("column " + *colName + " on constraint does not exist.")
 			}
 		}
+		if len(idxDef.Colnames_) > 1 {
+			compositeIdxDefs = append(compositeIdxDefs, idxDef)
+		} else if idxDef.IndexKind_ == index_constants.INDEX_KIND_UNIQ_SKIP_LIST {
+			schema_.GetColumn(schema_.GetColIndex(*idxDef.Colnames_[0])).SetIndexKind(index_constants.INDEX_KIND_UNIQ_SKIP_LIST)
+		}
+	}
 
-		onPredicate := testing_tbl_gen.MakeComparisonExpression(colValL, colValR, expression.Equal)
+	checks := make([]*catalog.CheckConstraint, 0)
+	for _, exprStr := range checkExprStrs {
+		expr, err := parser.ParseCheckExpr(schema_, *exprStr)
+		if err != nil {
+			return PrintAndCreateError("CHECK constraint " + *exprStr + " is invalid: " + err.Error())
+		}
+		checks = append(checks, catalog.NewCheckConstraint(*exprStr, expr))
+	}
 
-		var left_keys []expression.Expression
-		left_keys = append(left_keys, colValL)
-		var right_keys []expression.Expression
-		right_keys = append(right_keys, colValR)
+	pner.catalog_.CreateTableWithChecks(*pner.qi.NewTable_, schema_, checks, pner.txn)
 
-		scanPlans_ := []plans.Plan{scanPlanL, scanPlanR}
-		joinPlan = plans.NewHashJoinPlanNode(outFinal, scanPlans_, onPredicate,
-			left_keys, right_keys)
+	for _, idxDef := range compositeIdxDefs {
+		colNames := make([]string, 0)
+		for _, colName := range idxDef.Colnames_ {
+			colNames = append(colNames, *colName)
+		}
+		indexName := *idxDef.IndexName_
+		if indexName == "" {
+			indexName = strings.Join(colNames, "_") + "_index"
+		}
+		isUnique := idxDef.IndexKind_ == index_constants.INDEX_KIND_UNIQ_SKIP_LIST
+		if err := pner.catalog_.CreateCompositeIndex(indexName, *pner.qi.NewTable_, colNames, isUnique, pner.txn); err != nil {
+			return err, nil
+		}
 	}
 
-	if hasWhere {
-		whereExp := pner.ConstructPredicate([]*schema.Schema{outFinal})
-		// filter joined recoreds with predicate which is specified on WHERE clause if needed
+	return nil, nil
+}
 
-		filterPlan := plans.NewSelectionPlanNode(joinPlan, whereExp)
-		finalPlan := plans.NewProjectionPlanNode(filterPlan, filterOut)
-		return nil, finalPlan
-	} else {
-		// has no WHERE clause
-		return nil, joinPlan
+// all columns of the table created with SQL have skip list index and
+// the index is unique one when the column has PRIMARY KEY or UNIQUE constraint.
+// but TEXT, BLOB and JSON column does not have it if it has no constraint because large value can't be a key
+func newColumnOfColDef(cdefExp *parser.ColDefExpression) (error, *column.Column) {
+	if cdefExp.Length_ > types.MaxVariableLengthSize {
+		err, _ := PrintAndCreateError(fmt.Sprintf("length of column %s is too long. max is %d.", *cdefExp.ColName_, types.MaxVariableLengthSize))
+		return err, nil
+	}
+	hasIndex := true
+	indexKind := index_constants.INDEX_KIND_SKIP_LIST
+	if cdefExp.IsUnique_ {
+		indexKind = index_constants.INDEX_KIND_UNIQ_SKIP_LIST
+	} else if cdefExp.IsLargeObject_ {
+		hasIndex = false
+		indexKind = index_constants.INDEX_KIND_INVALID
+	}
+	column_ := column.NewColumn(*cdefExp.ColName_, *cdefExp.ColType_, hasIndex, indexKind, types.PageID(-1), nil)
+	column_.SetIsNotNull(cdefExp.IsNotNull_)
+	if cdefExp.Length_ > 0 {
+		// declared length is stored to catalog and checked at INSERT and UPDATE
+		column_.SetVariableLength(cdefExp.Length_)
 	}
+	return nil, column_
 }
 
-func (pner *SimplePlanner) MakeSelectPlan() (error, plans.Plan) {
-	if optimizer.CheckIncludesORInPredicate(pner.qi.WhereExpression_) {
-		// optimizer does not support OR, so use planning logic without optimization...
-		if len(pner.qi.JoinTables_) == 1 {
-			return pner.MakeSelectPlanWithoutJoin()
-		} else {
-			return pner.MakeSelectPlanWithJoin()
+// index is created on this function like CREATE TABLE. so, returned plan is always nil
+func (pner *SimplePlanner) MakeCreateIndexPlan() (error, plans.Plan) {
+	idxDef := pner.qi.IndexDefExpressions_[0]
+	if idxDef.IndexKind_ == index_constants.INDEX_KIND_INVALID {
+		return PrintAndCreateError("specified index type is not supported. HASH, SKIPLIST, UNIQUE SKIPLIST and BTREE are available.")
+	}
+	if len(idxDef.Colnames_) > 1 {
+		isUnique := idxDef.IndexKind_ == index_constants.INDEX_KIND_UNIQ_SKIP_LIST
+		if idxDef.IndexKind_ != index_constants.INDEX_KIND_SKIP_LIST && !isUnique {
+			return PrintAndCreateError("index on multiple columns supports only SKIPLIST and UNIQUE SKIPLIST.")
 		}
-	} else {
-		return pner.MakeOptimizedSelectPlanWithJoin()
+		colNames := make([]string, 0)
+		for _, colName := range idxDef.Colnames_ {
+			colNames = append(colNames, *colName)
+		}
+		err := pner.catalog_.CreateCompositeIndex(*idxDef.IndexName_, *pner.qi.JoinTables_[0], colNames, isUnique, pner.txn)
+		return err, nil
 	}
-}
 
-// TODO: (SDB) duplicated functionality with expression.ConvParsedBinaryOpExprToExpIFOne func???
-func processPredicateTreeNode(node *parser.BinaryOpExpression, tgtTblSchemas []*schema.Schema) expression.Expression {
-	if node.LogicalOperationType_ != -1 { // node of logical operation
-		left_side_pred := processPredicateTreeNode(node.Left_.(*parser.BinaryOpExpression), tgtTblSchemas)
-		right_side_pred := processPredicateTreeNode(node.Right_.(*parser.BinaryOpExpression), tgtTblSchemas)
-		return expression.NewLogicalOp(left_side_pred, right_side_pred, node.LogicalOperationType_, types.Boolean)
-	} else { // node of compare operation
-		colName := *node.Left_.(*string)
-		specfiedVal := node.Right_.(*types.Value)
-
-		// TODO: (SDB) need to validate specified table name prefix, column name and literal (processPredicateTreeNode)
-		//             without use of panic function
-
-		tmpColIdx := tgtTblSchemas[0].GetColIndex(colName)
+	err := pner.catalog_.CreateIndex(*idxDef.IndexName_, *pner.qi.JoinTables_[0], *idxDef.Colnames_[0], idxDef.IndexKind_, pner.txn)
+	return err, nil
+}
 
-		tmpColVal := expression.NewColumnValue(0, tmpColIdx, specfiedVal.ValueType())
-		constVal := expression.NewConstantValue(*specfiedVal, specfiedVal.ValueType())
+// index is dropped on this function like CREATE TABLE. so, returned plan is always nil
+func (pner *SimplePlanner) MakeDropIndexPlan() (error, plans.Plan) {
+	err := pner.catalog_.DropIndex(*pner.qi.IndexDefExpressions_[0].IndexName_, *pner.qi.JoinTables_[0], pner.txn)
+	return err, nil
+}
 
-		return expression.NewComparison(tmpColVal, constVal, node.ComparisonOperationType_, types.Boolean)
+// table is dropped on this function like CREATE TABLE. so, returned plan is always nil
+func (pner *SimplePlanner) MakeDropTablePlan() (error, plans.Plan) {
+	if len(pner.qi.JoinTables_) != 1 {
+		return PrintAndCreateError("dropping multiple tables at once is not supported.")
 	}
+	err := pner.catalog_.DropTable(*pner.qi.JoinTables_[0], pner.txn)
+	return err, nil
 }
 
-func (pner *SimplePlanner) ConstructPredicate(tgtTblSchemas []*schema.Schema) expression.Expression {
-	return processPredicateTreeNode(pner.qi.WhereExpression_, tgtTblSchemas)
+// tuples are removed on this function like CREATE TABLE. so, returned plan is always nil
+func (pner *SimplePlanner) MakeTruncatePlan() (error, plans.Plan) {
+	err := pner.catalog_.TruncateTable(*pner.qi.JoinTables_[0], pner.txn)
+	return err, nil
 }
 
-func (pner *SimplePlanner) MakeCreateTablePlan() (error, plans.Plan) {
-	if pner.catalog_.GetTableByName(*pner.qi.NewTable_) != nil {
-		return PrintAndCreateError("already " + *pner.qi.NewTable_ + " exists.")
+// table is altered on this function like CREATE TABLE. so, returned plan is always nil
+func (pner *SimplePlanner) MakeAlterTablePlan() (error, plans.Plan) {
+	if len(pner.qi.ColDefExpressions_) == 0 && len(pner.qi.DropColumns_) == 0 {
+		return PrintAndCreateError("specified alteration is not supported. ADD COLUMN and DROP COLUMN are available.")
 	}
 
-	columns := make([]*column.Column, 0)
+	addColumns := make([]*column.Column, 0)
 	for _, cdefExp := range pner.qi.ColDefExpressions_ {
-		columns = append(columns, column.NewColumn(*cdefExp.ColName_, *cdefExp.ColType_, true, index_constants.INDEX_KIND_SKIP_LIST, types.PageID(-1), nil))
-		//columns = append(columns, column.NewColumn(*cdefExp.ColName_, *cdefExp.ColType_, true, index_constants.INDEX_KIND_BTREE, types.PageID(-1), nil))
+		if len(cdefExp.CheckExprStrs_) > 0 {
+			return PrintAndCreateError("CHECK constraint can't be added with ALTER TABLE.")
+		}
+		// index is created as columns defined at CREATE TABLE
+		err, column_ := newColumnOfColDef(cdefExp)
+		if err != nil {
+			return err, nil
+		}
+		addColumns = append(addColumns, column_)
+	}
+	dropColNames := make([]string, 0)
+	for _, colName := range pner.qi.DropColumns_ {
+		dropColNames = append(dropColNames, *colName)
 	}
-	schema_ := schema.NewSchema(columns)
-
-	pner.catalog_.CreateTable(*pner.qi.NewTable_, schema_, pner.txn)
 
-	return nil, nil
+	err := pner.catalog_.AlterTable(*pner.qi.JoinTables_[0], addColumns, dropColNames, pner.txn)
+	return err, nil
 }
 
 func PrintAndCreateError(msg string) (error, plans.Plan) {
@@ -287,66 +248,112 @@ func (pner *SimplePlanner) MakeInsertPlan() (error, plans.Plan) {
 	}
 
 	schema_ := tableMetadata.Schema()
-	tgtColNum := len(pner.qi.TargetCols_)
-	insRows := make([][]types.Value, 0)
-	insertRowCnt := 0
-	valCnt := 0
-	row := make([]types.Value, 0)
-	for idx, colName := range pner.qi.TargetCols_ {
-		val := pner.qi.Values_[idx-(tgtColNum*insertRowCnt)]
-		if schema_.GetColIndex(*colName) == math.MaxUint32 {
+	// all columns are target when column names are omitted
+	tgtColIdxs := make([]int, 0)
+	for _, colName := range pner.qi.TargetCols_ {
+		colIdx := schema_.GetColIndex(*colName)
+		if colIdx == math.MaxUint32 {
 			return PrintAndCreateError("specified column name " + *colName + " does not exist on table " + *pner.qi.JoinTables_[0] + ".")
 		}
-		valType := schema_.GetColumn(schema_.GetColIndex(*colName)).GetType()
-		if val.ValueType() != valType {
-			return PrintAndCreateError("data type of " + *colName + " is wrong.")
+		tgtColIdxs = append(tgtColIdxs, int(colIdx))
+	}
+	if len(tgtColIdxs) == 0 {
+		for ii := range schema_.GetColumns() {
+			tgtColIdxs = append(tgtColIdxs, ii)
+		}
+	}
+
+	var insertPlan plans.Plan
+	if pner.qi.SelectQuery_ != nil {
+		// INSERT ... SELECT
+		err, selectPlan := NewSimplePlanner(pner.catalog_, pner.bpm).MakePlan(pner.qi.SelectQuery_, pner.txn)
+		if err != nil {
+			return err, nil
+		}
+		if int(selectPlan.OutputSchema().GetColumnCount()) != len(tgtColIdxs) {
+			return PrintAndCreateError("column count of SELECT doesn't match with columns to insert.")
+		}
+		insertPlan = plans.NewInsertPlanNodeWithChild(selectPlan, tgtColIdxs, tableMetadata.OID())
+	} else {
+		if len(pner.qi.ValueRowLens_) == 0 {
+			return PrintAndCreateError("values to insert must be specified.")
 		}
-		row = append(row, *val)
-		valCnt++
-		if valCnt == tgtColNum {
-			// to next record
+		if len(pner.qi.Values_) != len(pner.qi.ValueRowLens_)*len(tgtColIdxs) {
+			// count of values of a row is wrong or values include not supported expression (ex: 1 + 2)
+			return PrintAndCreateError("count of values doesn't match with columns to insert.")
+		}
+		insRows := make([][]types.Value, 0)
+		valIdx := 0
+		for _, rowLen := range pner.qi.ValueRowLens_ {
+			if rowLen != len(tgtColIdxs) {
+				return PrintAndCreateError("count of values doesn't match with columns to insert.")
+			}
+			// values are placed in order of columns of the table and omitted columns are filled with NULL
+			row := make([]types.Value, schema_.GetColumnCount())
+			for ii := range row {
+				row[ii] = types.NewNullOfType(schema_.GetColumn(uint32(ii)).GetType())
+			}
+			for _, colIdx := range tgtColIdxs {
+				val := pner.qi.Values_[valIdx]
+				valIdx++
+				if val.IsNull() {
+					continue
+				}
+				// ex: Integer literal is converted for BigInt column
+				col := schema_.GetColumn(uint32(colIdx))
+				convedVal, err := catalog.CoerceValueForColumn(col, val, col.GetColumnName())
+				if err != nil {
+					fmt.Println(err)
+					return err, nil
+				}
+				row[colIdx] = *convedVal
+			}
 			insRows = append(insRows, row)
-			valCnt = 0
-			insertRowCnt++
-			row = make([]types.Value, 0)
 		}
+		insertPlan = plans.NewInsertPlanNode(insRows, tableMetadata.OID())
 	}
 
-	return nil, plans.NewInsertPlanNode(insRows, tableMetadata.OID())
+	return pner.setReturningSchema(insertPlan, tableMetadata)
 }
 
-func (pner *SimplePlanner) MakeDeletePlan() (error, plans.Plan) {
-	if optimizer.CheckIncludesORInPredicate(pner.qi.WhereExpression_) {
-		// optimizer does not support OR, so use planning logic without optimization...
-		tableMetadata := pner.catalog_.GetTableByName(*pner.qi.JoinTables_[0])
-		if tableMetadata == nil {
-			return PrintAndCreateError("table " + *pner.qi.JoinTables_[0] + " not found.")
+// affected tuples of INSERT, UPDATE and DELETE are projected to output schema of the plan
+// when RETURNING clause is specified
+func (pner *SimplePlanner) setReturningSchema(plan plans.Plan, tableMetadata *catalog.TableMetadata) (error, plans.Plan) {
+	if pner.qi.ReturningFields_ == nil {
+		return nil, plan
+	}
+	tableSchema := tableMetadata.Schema()
+	cols := make([]*column.Column, 0)
+	for _, sfield := range pner.qi.ReturningFields_ {
+		colName := *sfield.ColName_
+		if sfield.TableName_ != nil {
+			colName = *sfield.TableName_ + "." + colName
 		}
-
-		tgtTblSchema := tableMetadata.Schema()
-
-		expression_ := pner.ConstructPredicate([]*schema.Schema{tgtTblSchema})
-		seqScanPlanP := plans.NewSeqScanPlanNode(pner.catalog_, tgtTblSchema, expression_, tableMetadata.OID())
-		deletePlan := plans.NewDeletePlanNode(seqScanPlanP)
-
-		return nil, deletePlan
-	} else {
-		_, selectPlan := pner.MakeOptimizedSelectPlanWithJoin()
-		deletePlan := plans.NewDeletePlanNode(selectPlan)
-		return nil, deletePlan
+		colIdx := tableSchema.GetColIndex(colName)
+		if colIdx == math.MaxUint32 {
+			return PrintAndCreateError("column " + colName + " does not exist on table " + *tableMetadata.GetTableName() + ".")
+		}
+		cols = append(cols, tableSchema.GetColumn(colIdx))
 	}
+	plan.(interface{ SetOutputSchema(*schema.Schema) }).SetOutputSchema(schema.NewSchema(cols))
+	return nil, plan
+}
 
+func (pner *SimplePlanner) MakeDeletePlan() (error, plans.Plan) {
+	err, selectPlan := pner.MakeOptimizedSelectPlanWithJoin()
+	if err != nil {
+		return err, nil
+	}
+	deletePlan := plans.NewDeletePlanNode(selectPlan)
+	return pner.setReturningSchema(deletePlan, pner.catalog_.GetTableByName(*pner.qi.JoinTables_[0]))
 }
 
 func (pner *SimplePlanner) MakeUpdatePlan() (error, plans.Plan) {
-
-	// optimizer does not support OR, so use planning logic without optimization...
 	tableMetadata := pner.catalog_.GetTableByName(*pner.qi.JoinTables_[0])
 	if tableMetadata == nil {
 		return PrintAndCreateError("table " + *pner.qi.JoinTables_[0] + " not found.")
 	}
 	tgtTblSchema := tableMetadata.Schema()
-	hasWhere := pner.qi.WhereExpression_.Left_ != nil && pner.qi.WhereExpression_.Right_ != nil
 
 	updateColIdxs := make([]int, 0)
 
@@ -367,20 +374,18 @@ func (pner *SimplePlanner) MakeUpdatePlan() (error, plans.Plan) {
 	}
 	// overwrite elem which is update target
 	for idx, colIdx := range updateColIdxs {
-		updateVals[colIdx] = *pner.qi.SetExpressions_[idx].UpdateValue_
-	}
-
-	var predicate expression.Expression = nil
-	if hasWhere {
-		predicate = pner.ConstructPredicate([]*schema.Schema{tgtTblSchema})
+		convedVal, err := catalog.CoerceValueForColumn(tgtTblSchema.GetColumn(uint32(colIdx)), pner.qi.SetExpressions_[idx].UpdateValue_, *pner.qi.SetExpressions_[idx].ColName_)
+		if err != nil {
+			fmt.Println(err)
+			return err, nil
+		}
+		updateVals[colIdx] = *convedVal
 	}
 
-	var scanPlan plans.Plan
-	if optimizer.CheckIncludesORInPredicate(pner.qi.WhereExpression_) {
-		scanPlan = plans.NewSeqScanPlanNode(pner.catalog_, tgtTblSchema, predicate, tableMetadata.OID())
-	} else {
-		_, scanPlan = pner.MakeOptimizedSelectPlanWithJoin()
+	err, scanPlan := pner.MakeOptimizedSelectPlanWithJoin()
+	if err != nil {
+		return err, nil
 	}
 
-	return nil, plans.NewUpdatePlanNode(updateVals, updateColIdxs, scanPlan)
+	return pner.setReturningSchema(plans.NewUpdatePlanNode(updateVals, updateColIdxs, scanPlan), tableMetadata)
 }
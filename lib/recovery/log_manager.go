@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"sync"
+	"time"
 	"unsafe"
 
 	"github.com/ryogrid/SamehadaDB/lib/common"
@@ -12,9 +13,28 @@ import (
 	"github.com/ryogrid/SamehadaDB/lib/types"
 )
 
+// SyncPolicy decides when written log data is synced to disk (fsync)
+type SyncPolicy int
+
+const (
+	// fsync at each flush. committed transaction is never lost
+	SyncPolicyAlways SyncPolicy = iota
+	// fsync once per sync interval. transactions committed in last interval may be lost at OS crash
+	SyncPolicyInterval
+	// fsync is left to OS
+	SyncPolicyNever
+)
+
+// used with SyncPolicyInterval when interval is not specified
+const DefaultSyncInterval = 100 * time.Millisecond
+
+// flush thread flushes log buffer at least once per this interval even if no one requests
+const flushThInterval = 100 * time.Millisecond
+
 /**
- * LogManager maintains a separate thread that is awakened whenever the log buffer is full or whenever a timeout
+ * LogManager maintains a separate thread that is awakened whenever flush is requested (at commit) or whenever a timeout
  * happens. When the thread is awakened, the log buffer's content is written into the disk log file.
+ * Commit records of transactions which are appended during a write are written together at next write (group commit).
  */
 type LogManager struct {
 	offset         uint32
@@ -29,19 +49,40 @@ type LogManager struct {
 	wlog_mutex      *sync.Mutex
 	disk_manager    *disk.DiskManager //__attribute__((__unused__));
 	isEnableLogging bool
+	syncPolicy      SyncPolicy
+	syncInterval    time.Duration
+	lastSyncTime    time.Time
+	// protects persistent_lsn and isFlushThActive. committers wait on persistCond
+	persistMutex    *sync.Mutex
+	persistCond     *sync.Cond
+	isFlushThActive bool
+	flushReqCh      chan struct{}
+	stopFlushThCh   chan struct{}
+	flushThDoneCh   chan struct{}
 }
 
 func NewLogManager(disk_manager *disk.DiskManager) *LogManager {
+	return NewLogManagerWithBufferSize(disk_manager, common.LogBufferSize)
+}
+
+// bufferSize should be larger than or equal to common.MinLogBufferSize
+func NewLogManagerWithBufferSize(disk_manager *disk.DiskManager, bufferSize uint32) *LogManager {
 	ret := new(LogManager)
 	ret.next_lsn = 0
 	ret.persistent_lsn = common.InvalidLSN
 	ret.disk_manager = disk_manager
-	ret.log_buffer = make([]byte, common.LogBufferSize)
-	ret.flush_buffer = make([]byte, common.LogBufferSize)
+	ret.log_buffer = make([]byte, bufferSize)
+	ret.flush_buffer = make([]byte, bufferSize)
 	ret.latch = common.NewRWLatch()
 	ret.wlog_mutex = new(sync.Mutex)
 	ret.offset = 0
 	ret.isEnableLogging = false
+	ret.syncPolicy = SyncPolicyAlways
+	ret.syncInterval = DefaultSyncInterval
+	ret.lastSyncTime = time.Now()
+	ret.persistMutex = new(sync.Mutex)
+	ret.persistCond = sync.NewCond(ret.persistMutex)
+	ret.isFlushThActive = false
 	return ret
 }
 
@@ -49,14 +90,25 @@ func (log_manager *LogManager) GetNextLSN() types.LSN       { return log_manager
 func (log_manager *LogManager) SetNextLSN(lsnVal types.LSN) { log_manager.next_lsn = lsnVal }
 func (log_manager *LogManager) GetPersistentLSN() types.LSN { return log_manager.persistent_lsn }
 
+// interval is used with SyncPolicyInterval only. this should be called before StartFlushTh
+func (log_manager *LogManager) SetSyncPolicy(policy SyncPolicy, interval time.Duration) {
+	log_manager.syncPolicy = policy
+	if interval > 0 {
+		log_manager.syncInterval = interval
+	}
+}
+
+// Flush writes log buffer to log file. log file is synced according to sync policy
 func (log_manager *LogManager) Flush() {
-	// TODO: (SDB) need fix to occur buffer swap when already running flushing?
+	log_manager.flush(false)
+}
 
-	// For I/O efficiency, ideally flush thread should be used like below
-	// https://github.com/astronaut0131/bustub/blob/master/src/recovery/log_manager.cpp#L39
-	// maybe, blocking can be eliminated because txn must wait for log persistence at commit
-	// https://github.com/astronaut0131/bustub/blob/master/src/concurrency/transaction_manager.cpp#L64
+// same as Flush but log file is always synced regardless of sync policy
+func (log_manager *LogManager) FlushAndSync() {
+	log_manager.flush(true)
+}
 
+func (log_manager *LogManager) flush(forceSync bool) {
 	log_manager.wlog_mutex.Lock()
 	log_manager.latch.WLock()
 
@@ -72,12 +124,112 @@ func (log_manager *LogManager) Flush() {
 	log_manager.latch.WUnlock()
 
 	// fmt.Printf("offset at Flush:%d\n", offset)
-	(*log_manager.disk_manager).WriteLog(log_manager.flush_buffer[:offset])
+	if offset > 0 {
+		(*log_manager.disk_manager).WriteLog(log_manager.flush_buffer[:offset])
+	}
+	if forceSync || log_manager.isSyncNeeded() {
+		(*log_manager.disk_manager).SyncLog()
+		log_manager.lastSyncTime = time.Now()
+	}
 
+	log_manager.persistMutex.Lock()
 	log_manager.persistent_lsn = lsn
+	log_manager.persistCond.Broadcast()
+	log_manager.persistMutex.Unlock()
 	log_manager.wlog_mutex.Unlock()
 }
 
+// enlarges log buffer and flush buffer for storing a log record which has passed size
+func (log_manager *LogManager) growBuffers(size uint32) {
+	log_manager.wlog_mutex.Lock()
+	log_manager.latch.WLock()
+	if size > uint32(len(log_manager.log_buffer)) {
+		newBuf := make([]byte, size)
+		copy(newBuf, log_manager.log_buffer[:log_manager.offset])
+		log_manager.log_buffer = newBuf
+		log_manager.flush_buffer = make([]byte, size)
+	}
+	log_manager.latch.WUnlock()
+	log_manager.wlog_mutex.Unlock()
+}
+
+// caller should have wlog_mutex
+func (log_manager *LogManager) isSyncNeeded() bool {
+	switch log_manager.syncPolicy {
+	case SyncPolicyAlways:
+		return true
+	case SyncPolicyInterval:
+		return time.Since(log_manager.lastSyncTime) >= log_manager.syncInterval
+	default:
+		return false
+	}
+}
+
+// StartFlushTh starts the thread which writes log buffer to log file when flush is requested
+// with WaitForPersistence or flushThInterval elapses
+func (log_manager *LogManager) StartFlushTh() {
+	log_manager.persistMutex.Lock()
+	defer log_manager.persistMutex.Unlock()
+	if log_manager.isFlushThActive {
+		return
+	}
+	log_manager.isFlushThActive = true
+	log_manager.flushReqCh = make(chan struct{}, 1)
+	log_manager.stopFlushThCh = make(chan struct{})
+	log_manager.flushThDoneCh = make(chan struct{})
+
+	go func() {
+		defer close(log_manager.flushThDoneCh)
+		for {
+			select {
+			case <-log_manager.flushReqCh:
+			case <-time.After(flushThInterval):
+			case <-log_manager.stopFlushThCh:
+				return
+			}
+			// records which are appended while previous flush is running are written at once
+			log_manager.Flush()
+		}
+	}()
+}
+
+// StopFlushTh stops the flush thread and flushes remaining records in log buffer with sync.
+// after this call, WaitForPersistence flushes log buffer by itself
+func (log_manager *LogManager) StopFlushTh() {
+	log_manager.persistMutex.Lock()
+	if !log_manager.isFlushThActive {
+		log_manager.persistMutex.Unlock()
+		return
+	}
+	log_manager.isFlushThActive = false
+	close(log_manager.stopFlushThCh)
+	log_manager.persistMutex.Unlock()
+
+	<-log_manager.flushThDoneCh
+	log_manager.FlushAndSync()
+}
+
+// WaitForPersistence returns after the log record which has lsn is written to log file
+// (and synced when sync policy is SyncPolicyAlways).
+// when flush thread is not running, log buffer is flushed by caller
+func (log_manager *LogManager) WaitForPersistence(lsn types.LSN) {
+	log_manager.persistMutex.Lock()
+	for log_manager.persistent_lsn < lsn {
+		if !log_manager.isFlushThActive {
+			log_manager.persistMutex.Unlock()
+			log_manager.Flush()
+			return
+		}
+		select {
+		case log_manager.flushReqCh <- struct{}{}:
+		default:
+			// flush is already requested
+		}
+		log_manager.persistCond.Wait()
+	}
+	log_manager.persistMutex.Unlock()
+}
+
 /*
 * set enable_logging = true
 * Start a separate thread to execute flush to disk operation periodically
@@ -101,8 +253,13 @@ func (log_manager *LogManager) IsEnabledLogging() bool { return log_manager.isEn
 func (log_manager *LogManager) AppendLogRecord(log_record *LogRecord) types.LSN {
 	// First, serialize the must have fields(20 bytes in total)
 
+	if log_record.Size > uint32(len(log_manager.log_buffer)) {
+		// END_CHECKPOINT log record can be larger than log buffer when there are many dirty pages
+		log_manager.growBuffers(log_record.Size)
+	}
+
 	log_manager.latch.WLock()
-	if common.LogBufferSize-log_manager.offset < HEADER_SIZE {
+	if uint32(len(log_manager.log_buffer))-log_manager.offset < HEADER_SIZE {
 		log_manager.latch.WUnlock()
 		log_manager.Flush()
 		log_manager.latch.WLock()
@@ -117,7 +274,7 @@ func (log_manager *LogManager) AppendLogRecord(log_record *LogRecord) types.LSN
 	headerInBytes := log_record.GetLogHeaderData()
 	copy(log_manager.log_buffer[log_manager.offset:], headerInBytes)
 
-	if common.LogBufferSize-log_manager.offset < log_record.Size {
+	if uint32(len(log_manager.log_buffer))-log_manager.offset < log_record.Size {
 		log_manager.latch.WUnlock()
 		log_manager.Flush()
 		log_manager.latch.WLock()
@@ -180,6 +337,21 @@ func (log_manager *LogManager) AppendLogRecord(log_record *LogRecord) types.LSN
 		//pos += uint32(unsafe.Sizeof(log_record.Reuse_page_id))
 		pageIdInBytes := buf.Bytes()
 		copy(log_manager.log_buffer[pos:], pageIdInBytes)
+	} else if log_record.Log_record_type == NEW_OVERFLOW_PAGE {
+		buf := new(bytes.Buffer)
+		binary.Write(buf, binary.LittleEndian, log_record.Page_id)
+		binary.Write(buf, binary.LittleEndian, log_record.Next_page_id)
+		binary.Write(buf, binary.LittleEndian, uint32(len(log_record.Overflow_data)))
+		buf.Write(log_record.Overflow_data)
+		copy(log_manager.log_buffer[pos:], buf.Bytes())
+	} else if log_record.Log_record_type == END_CHECKPOINT {
+		buf := new(bytes.Buffer)
+		binary.Write(buf, binary.LittleEndian, log_record.Begin_checkpoint_lsn)
+		binary.Write(buf, binary.LittleEndian, uint32(len(log_record.Dirty_page_table)))
+		binary.Write(buf, binary.LittleEndian, log_record.Dirty_page_table)
+		binary.Write(buf, binary.LittleEndian, uint32(len(log_record.Active_txn_table)))
+		binary.Write(buf, binary.LittleEndian, log_record.Active_txn_table)
+		copy(log_manager.log_buffer[pos:], buf.Bytes())
 	} else if log_record.Log_record_type == GRACEFUL_SHUTDOWN {
 		// do nothing
 	}
@@ -33,6 +33,12 @@ const (
 	// this log represents last shutdown of the system is graceful
 	// and this log is located at the end of log file
 	GRACEFUL_SHUTDOWN
+	/** Writing data of large tuple to a new overflow page. */
+	NEW_OVERFLOW_PAGE
+	// start of fuzzy checkpoint. tables are recorded to END_CHECKPOINT log
+	BEGIN_CHECKPOINT
+	// end of fuzzy checkpoint. this has dirty page table and active transaction table
+	END_CHECKPOINT
 )
 
 /**
@@ -70,6 +76,18 @@ const (
  *--------------------------
  * | HEADER | greatest_lsn |
  *--------------------------
+ * For new overflow page type log record
+ *------------------------------------------------------------------
+ * | HEADER | page_id | next_page_id | data_size | data(char[] array) |
+ *------------------------------------------------------------------
+ * For begin checkpoint type log record
+ *----------
+ * | HEADER |
+ *----------
+ * For end checkpoint type log record
+ *-----------------------------------------------------------------------------------------------------------------
+ * | HEADER | begin_checkpoint_lsn | dpt_count | (page_id, rec_lsn) * dpt_count | att_count | (txn_id, last_lsn) * att_count |
+ *-----------------------------------------------------------------------------------------------------------------
 */
 
 type LogRecord struct {
@@ -110,6 +128,27 @@ type LogRecord struct {
 
 	// case7: for graceful shutdown operation
 	// nothing LSN is the greatest LSN in the log file
+
+	// case8: for new overflow page operation (Page_id is also used)
+	Next_page_id  types.PageID
+	Overflow_data []byte
+
+	// case9: for end checkpoint operation
+	Begin_checkpoint_lsn types.LSN
+	Dirty_page_table     []DirtyPageEntry
+	Active_txn_table     []ActiveTxnEntry
+}
+
+// entry of dirty page table. modifications of the page whose LSN is smaller than RecLSN are written to disk
+type DirtyPageEntry struct {
+	PageId types.PageID
+	RecLSN types.LSN
+}
+
+// entry of active transaction table. LastLSN is LSN of last log record of the transaction
+type ActiveTxnEntry struct {
+	TxnId   types.TxnID
+	LastLSN types.LSN
 }
 
 // constructor for Transaction type(BEGIN/COMMIT/ABORT)
@@ -169,6 +208,20 @@ func NewLogRecordNewPage(txn_id types.TxnID, prev_lsn types.LSN, log_record_type
 	return ret
 }
 
+// constructor for NEW_OVERFLOW_PAGE type
+func NewLogRecordNewOverflowPage(txn_id types.TxnID, prev_lsn types.LSN, page_id types.PageID, next_page_id types.PageID, data []byte) *LogRecord {
+	ret := new(LogRecord)
+	ret.Txn_id = txn_id
+	ret.Prev_lsn = prev_lsn
+	ret.Log_record_type = NEW_OVERFLOW_PAGE
+	ret.Page_id = page_id
+	ret.Next_page_id = next_page_id
+	ret.Overflow_data = data
+	// calculate log record size
+	ret.Size = HEADER_SIZE + uint32(unsafe.Sizeof(page_id)) + uint32(unsafe.Sizeof(next_page_id)) + uint32(unsafe.Sizeof(uint32(0))) + uint32(len(data))
+	return ret
+}
+
 func NewLogRecordDeallocatePage(page_id types.PageID) *LogRecord {
 	ret := new(LogRecord)
 	ret.Size = HEADER_SIZE
@@ -207,6 +260,42 @@ func NewLogRecordGracefulShutdown() *LogRecord {
 	return ret
 }
 
+func NewLogRecordBeginCheckpoint() *LogRecord {
+	ret := new(LogRecord)
+	ret.Txn_id = types.TxnID(math.MaxInt32)
+	ret.Prev_lsn = -1
+	ret.Log_record_type = BEGIN_CHECKPOINT
+	ret.Size = HEADER_SIZE
+	return ret
+}
+
+func NewLogRecordEndCheckpoint(begin_checkpoint_lsn types.LSN, dirty_page_table []DirtyPageEntry, active_txn_table []ActiveTxnEntry) *LogRecord {
+	ret := new(LogRecord)
+	ret.Txn_id = types.TxnID(math.MaxInt32)
+	ret.Prev_lsn = -1
+	ret.Log_record_type = END_CHECKPOINT
+	ret.Begin_checkpoint_lsn = begin_checkpoint_lsn
+	ret.Dirty_page_table = dirty_page_table
+	ret.Active_txn_table = active_txn_table
+	// calculate log record size
+	ret.Size = HEADER_SIZE + uint32(unsafe.Sizeof(begin_checkpoint_lsn)) +
+		2*uint32(unsafe.Sizeof(uint32(0))) +
+		uint32(len(dirty_page_table))*uint32(unsafe.Sizeof(DirtyPageEntry{})) +
+		uint32(len(active_txn_table))*uint32(unsafe.Sizeof(ActiveTxnEntry{}))
+	return ret
+}
+
+// RedoLSN returns LSN which redo should start from. this is valid for END_CHECKPOINT log record
+func (log_record *LogRecord) RedoLSN() types.LSN {
+	ret := log_record.Begin_checkpoint_lsn
+	for _, entry := range log_record.Dirty_page_table {
+		if entry.RecLSN < ret {
+			ret = entry.RecLSN
+		}
+	}
+	return ret
+}
+
 func (log_record *LogRecord) GetDeleteRID() page.RID          { return log_record.Delete_rid }
 func (log_record *LogRecord) GetInserteTuple() tuple.Tuple    { return log_record.Insert_tuple }
 func (log_record *LogRecord) GetInsertRID() page.RID          { return log_record.Insert_rid }
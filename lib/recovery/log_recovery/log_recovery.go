@@ -63,6 +63,10 @@ func (log_recovery *LogRecovery) DeserializeLogRecord(data []byte, log_record *r
 		// fmt.Println("return false point 2")
 		return false
 	}
+	if len(data) < int(log_record.Size) {
+		// body of the log record is not contained in data
+		return false
+	}
 
 	pos := recovery.HEADER_SIZE
 	if log_record.Log_record_type == recovery.INSERT {
@@ -92,6 +96,30 @@ func (log_recovery *LogRecovery) DeserializeLogRecord(data []byte, log_record *r
 	} else if log_record.Log_record_type == recovery.REUSE_PAGE {
 		binary.Read(bytes.NewBuffer(data[pos:]), binary.LittleEndian, &log_record.Reuse_page_id)
 		pos += uint32(unsafe.Sizeof(log_record.Reuse_page_id))
+	} else if log_record.Log_record_type == recovery.NEW_OVERFLOW_PAGE {
+		binary.Read(bytes.NewBuffer(data[pos:]), binary.LittleEndian, &log_record.Page_id)
+		pos += uint32(unsafe.Sizeof(log_record.Page_id))
+		binary.Read(bytes.NewBuffer(data[pos:]), binary.LittleEndian, &log_record.Next_page_id)
+		pos += uint32(unsafe.Sizeof(log_record.Next_page_id))
+		var dataSize uint32
+		binary.Read(bytes.NewBuffer(data[pos:]), binary.LittleEndian, &dataSize)
+		pos += uint32(unsafe.Sizeof(dataSize))
+		log_record.Overflow_data = make([]byte, dataSize)
+		copy(log_record.Overflow_data, data[pos:pos+dataSize])
+	} else if log_record.Log_record_type == recovery.END_CHECKPOINT {
+		binary.Read(bytes.NewBuffer(data[pos:]), binary.LittleEndian, &log_record.Begin_checkpoint_lsn)
+		pos += uint32(unsafe.Sizeof(log_record.Begin_checkpoint_lsn))
+		var dptCount uint32
+		binary.Read(bytes.NewBuffer(data[pos:]), binary.LittleEndian, &dptCount)
+		pos += uint32(unsafe.Sizeof(dptCount))
+		log_record.Dirty_page_table = make([]recovery.DirtyPageEntry, dptCount)
+		binary.Read(bytes.NewBuffer(data[pos:]), binary.LittleEndian, log_record.Dirty_page_table)
+		pos += dptCount * uint32(unsafe.Sizeof(recovery.DirtyPageEntry{}))
+		var attCount uint32
+		binary.Read(bytes.NewBuffer(data[pos:]), binary.LittleEndian, &attCount)
+		pos += uint32(unsafe.Sizeof(attCount))
+		log_record.Active_txn_table = make([]recovery.ActiveTxnEntry, attCount)
+		binary.Read(bytes.NewBuffer(data[pos:]), binary.LittleEndian, log_record.Active_txn_table)
 	} else if log_record.Log_record_type == recovery.GRACEFUL_SHUTDOWN {
 		// do nothing
 	}
@@ -101,12 +129,43 @@ func (log_recovery *LogRecovery) DeserializeLogRecord(data []byte, log_record *r
 	return true
 }
 
+// calls fn for each log record from startOffset to the end of log file.
+// log_record passed to fn is reused. offset is file offset of the log record
+func (log_recovery *LogRecovery) forEachLogRecord(startOffset uint32, fn func(log_record *recovery.LogRecord, offset uint32)) {
+	var file_offset = startOffset
+	var readBytes uint32
+	for log_recovery.disk_manager.ReadLog(log_recovery.log_buffer, int32(file_offset), &readBytes) {
+		var buffer_offset uint32 = 0
+		var log_record recovery.LogRecord
+		for log_recovery.DeserializeLogRecord(log_recovery.log_buffer[buffer_offset:readBytes], &log_record) {
+			fn(&log_record, file_offset+buffer_offset)
+			buffer_offset += log_record.Size
+		}
+		if buffer_offset == 0 {
+			// log record which is larger than log buffer (END_CHECKPOINT) or incomplete log record at the end
+			if readBytes >= recovery.HEADER_SIZE {
+				recordSize := binary.LittleEndian.Uint32(log_recovery.log_buffer[:readBytes])
+				if recordSize > uint32(len(log_recovery.log_buffer)) {
+					log_recovery.log_buffer = make([]byte, recordSize)
+					continue
+				}
+			}
+			break
+		}
+		// incomplete log record
+		// fmt.Printf("buffer_offset %d\n", buffer_offset)
+		file_offset += buffer_offset
+	}
+}
+
 /*
 *redo phase on TABLE PAGE level(table/table_page.h)
 *read log file from the beginning to end (you must prefetch log records into
 *log buffer to reduce unnecessary I/O operations), remember to compare page's
 *LSN with log_record's sequence number, and also build active_txn table &
 *lsn_mapping table
+* log records are read twice. first scan builds the tables and finds last checkpoint (analysis)
+* and second scan applies log records from redo LSN of the checkpoint
 * first return value: greatest LSN of log entries
 * second return value: when undo operation is needed, value is true
 * third return value: when graceful shutdown is detected, value is true
@@ -114,97 +173,47 @@ func (log_recovery *LogRecovery) DeserializeLogRecord(data []byte, log_record *r
 func (log_recovery *LogRecovery) Redo(txn *access.Transaction) (types.LSN, bool, bool) {
 	greatestLSN := 0
 	log_recovery.log_buffer = make([]byte, common.LogBufferSize)
-	var file_offset uint32 = 0
-	var readBytes uint32
 	isUndoNeeded := true
 	isGracefulShutdown := false
 	reusablePageMap := make(map[types.PageID]bool)
-	for log_recovery.disk_manager.ReadLog(log_recovery.log_buffer, int32(file_offset), &readBytes) {
-		var buffer_offset uint32 = 0
-		var log_record recovery.LogRecord
-		for log_recovery.DeserializeLogRecord(log_recovery.log_buffer[buffer_offset:readBytes], &log_record) {
-			if int(log_record.Lsn) > greatestLSN {
-				greatestLSN = int(log_record.Lsn)
-			}
+	// modifications of log records before this LSN are already written to db file
+	redoLSN := types.LSN(common.InvalidLSN)
+	log_recovery.forEachLogRecord(0, func(log_record *recovery.LogRecord, offset uint32) {
+		if int(log_record.Lsn) > greatestLSN {
+			greatestLSN = int(log_record.Lsn)
+		}
+		log_recovery.active_txn[log_record.Txn_id] = log_record.Lsn
+		log_recovery.lsn_mapping[log_record.Lsn] = int(offset)
+		if log_record.Log_record_type == recovery.BEGIN {
+			// fmt.Println("found BEGIN log record")
 			log_recovery.active_txn[log_record.Txn_id] = log_record.Lsn
-			log_recovery.lsn_mapping[log_record.Lsn] = int(file_offset + buffer_offset)
-			if log_record.Log_record_type == recovery.INSERT {
-				page_ :=
-					access.CastPageAsTablePage(log_recovery.buffer_pool_manager.FetchPage(log_record.Insert_rid.GetPageId()))
-				if page_.GetLSN() < log_record.GetLSN() {
-					log_record.Insert_tuple.SetRID(&log_record.Insert_rid)
-					page_.InsertTuple(&log_record.Insert_tuple, log_recovery.log_manager, nil, txn)
-					page_.SetLSN(log_record.GetLSN())
-				}
-				log_recovery.buffer_pool_manager.UnpinPage(log_record.Insert_rid.GetPageId(), true)
-			} else if log_record.Log_record_type == recovery.APPLYDELETE {
-				page_ :=
-					access.CastPageAsTablePage(log_recovery.buffer_pool_manager.FetchPage(log_record.Delete_rid.GetPageId()))
-				if page_.GetLSN() < log_record.GetLSN() {
-					page_.ApplyDelete(&log_record.Delete_rid, txn, log_recovery.log_manager)
-					page_.SetLSN(log_record.GetLSN())
-				}
-				log_recovery.buffer_pool_manager.UnpinPage(log_record.Delete_rid.GetPageId(), true)
-			} else if log_record.Log_record_type == recovery.MARKDELETE {
-				page_ :=
-					access.CastPageAsTablePage(log_recovery.buffer_pool_manager.FetchPage(log_record.Delete_rid.GetPageId()))
-				if page_.GetLSN() < log_record.GetLSN() {
-					page_.MarkDelete(&log_record.Delete_rid, txn, nil, log_recovery.log_manager)
-					page_.SetLSN(log_record.GetLSN())
-				}
-				log_recovery.buffer_pool_manager.UnpinPage(log_record.Delete_rid.GetPageId(), true)
-			} else if log_record.Log_record_type == recovery.ROLLBACKDELETE {
-				page_ :=
-					access.CastPageAsTablePage(log_recovery.buffer_pool_manager.FetchPage(log_record.Delete_rid.GetPageId()))
-				if page_.GetLSN() < log_record.GetLSN() {
-					page_.RollbackDelete(&log_record.Delete_rid, txn, log_recovery.log_manager)
-					page_.SetLSN(log_record.GetLSN())
-				}
-				log_recovery.buffer_pool_manager.UnpinPage(log_record.Delete_rid.GetPageId(), true)
-			} else if log_record.Log_record_type == recovery.UPDATE {
-				page_ :=
-					access.CastPageAsTablePage(log_recovery.buffer_pool_manager.FetchPage(log_record.Update_rid.GetPageId()))
-				if page_.GetLSN() < log_record.GetLSN() {
-					// UpdateTuple overwrites Old_tuple argument
-					// but it is no problem because log_record is read from log file again in Undo phase
-					page_.UpdateTuple(&log_record.New_tuple, nil, nil, &log_record.Old_tuple, &log_record.Update_rid, txn, nil, log_recovery.log_manager, false)
-					page_.SetLSN(log_record.GetLSN())
-				}
-				log_recovery.buffer_pool_manager.UnpinPage(log_record.Update_rid.GetPageId(), true)
-			} else if log_record.Log_record_type == recovery.BEGIN {
-				// fmt.Println("found BEGIN log record")
-				log_recovery.active_txn[log_record.Txn_id] = log_record.Lsn
-			} else if log_record.Log_record_type == recovery.COMMIT {
-				// fmt.Println("found COMMIT log record")
-				delete(log_recovery.active_txn, log_record.Txn_id)
-			} else if log_record.Log_record_type == recovery.NEW_TABLE_PAGE {
-				var page_id types.PageID
-				//page, _ := log_recovery.buffer_pool_manager.NewPage()
-				new_page := access.CastPageAsTablePage(log_recovery.buffer_pool_manager.FetchPage(log_record.Page_id))
-				page_id = new_page.GetPageId()
-				// fmt.Printf("page_id: %d\n", page_id)
-				new_page.Init(page_id, log_record.Prev_page_id, log_recovery.log_manager, nil, txn, true)
-				//log_recovery.buffer_pool_manager.FlushPage(page_id)
-				log_recovery.buffer_pool_manager.UnpinPage(page_id, true)
-			} else if log_record.Log_record_type == recovery.DEALLOCATE_PAGE {
-				page_id := log_record.Deallocate_page_id
-				reusablePageMap[page_id] = true
-			} else if log_record.Log_record_type == recovery.REUSE_PAGE {
-				page_id := log_record.Reuse_page_id
-				if _, ok := reusablePageMap[page_id]; ok {
-					delete(reusablePageMap, page_id)
-				}
-			} else if log_record.Log_record_type == recovery.GRACEFUL_SHUTDOWN {
-				// undo is not needed
-				isUndoNeeded = false
-				isGracefulShutdown = true
+		} else if log_record.Log_record_type == recovery.COMMIT {
+			// fmt.Println("found COMMIT log record")
+			delete(log_recovery.active_txn, log_record.Txn_id)
+		} else if log_record.Log_record_type == recovery.DEALLOCATE_PAGE {
+			page_id := log_record.Deallocate_page_id
+			reusablePageMap[page_id] = true
+		} else if log_record.Log_record_type == recovery.REUSE_PAGE {
+			page_id := log_record.Reuse_page_id
+			if _, ok := reusablePageMap[page_id]; ok {
+				delete(reusablePageMap, page_id)
 			}
-
-			buffer_offset += log_record.Size
+		} else if log_record.Log_record_type == recovery.END_CHECKPOINT {
+			redoLSN = log_record.RedoLSN()
+		} else if log_record.Log_record_type == recovery.GRACEFUL_SHUTDOWN {
+			// undo is not needed
+			isUndoNeeded = false
+			isGracefulShutdown = true
 		}
-		// incomplete log record
-		// fmt.Printf("buffer_offset %d\n", buffer_offset)
-		file_offset += buffer_offset
+	})
+
+	if redoStartOffset, ok := log_recovery.redoStartOffset(redoLSN); ok {
+		log_recovery.forEachLogRecord(redoStartOffset, func(log_record *recovery.LogRecord, offset uint32) {
+			if log_record.Lsn < 0 || log_record.Lsn < redoLSN {
+				return
+			}
+			log_recovery.redoLogRecord(log_record, txn)
+		})
 	}
 
 	// recovery reusable page ids
@@ -218,6 +227,91 @@ func (log_recovery *LogRecovery) Redo(txn *access.Transaction) (types.LSN, bool,
 	return types.LSN(greatestLSN), isUndoNeeded, isGracefulShutdown
 }
 
+// returns file offset of first log record which should be redone. lsn_mapping should be built already.
+// second return value is false when there is no log record to be redone
+func (log_recovery *LogRecovery) redoStartOffset(redoLSN types.LSN) (uint32, bool) {
+	if redoLSN == common.InvalidLSN {
+		return 0, true
+	}
+	found := false
+	ret := 0
+	for lsn, offset := range log_recovery.lsn_mapping {
+		if lsn >= 0 && lsn >= redoLSN && (!found || offset < ret) {
+			ret = offset
+			found = true
+		}
+	}
+	return uint32(ret), found
+}
+
+// applies modification of a log record to page when page LSN is older than the log record
+func (log_recovery *LogRecovery) redoLogRecord(log_record *recovery.LogRecord, txn *access.Transaction) {
+	if log_record.Log_record_type == recovery.INSERT {
+		page_ :=
+			access.CastPageAsTablePage(log_recovery.buffer_pool_manager.FetchPage(log_record.Insert_rid.GetPageId()))
+		if page_.GetLSN() < log_record.GetLSN() {
+			log_record.Insert_tuple.SetRID(&log_record.Insert_rid)
+			page_.InsertTuple(&log_record.Insert_tuple, log_recovery.log_manager, nil, txn)
+			page_.SetLSN(log_record.GetLSN())
+		}
+		log_recovery.buffer_pool_manager.UnpinPage(log_record.Insert_rid.GetPageId(), true)
+	} else if log_record.Log_record_type == recovery.APPLYDELETE {
+		page_ :=
+			access.CastPageAsTablePage(log_recovery.buffer_pool_manager.FetchPage(log_record.Delete_rid.GetPageId()))
+		if page_.GetLSN() < log_record.GetLSN() {
+			page_.ApplyDelete(&log_record.Delete_rid, txn, log_recovery.log_manager)
+			page_.SetLSN(log_record.GetLSN())
+		}
+		log_recovery.buffer_pool_manager.UnpinPage(log_record.Delete_rid.GetPageId(), true)
+	} else if log_record.Log_record_type == recovery.MARKDELETE {
+		page_ :=
+			access.CastPageAsTablePage(log_recovery.buffer_pool_manager.FetchPage(log_record.Delete_rid.GetPageId()))
+		if page_.GetLSN() < log_record.GetLSN() {
+			page_.MarkDelete(&log_record.Delete_rid, txn, nil, log_recovery.log_manager)
+			page_.SetLSN(log_record.GetLSN())
+		}
+		log_recovery.buffer_pool_manager.UnpinPage(log_record.Delete_rid.GetPageId(), true)
+	} else if log_record.Log_record_type == recovery.ROLLBACKDELETE {
+		page_ :=
+			access.CastPageAsTablePage(log_recovery.buffer_pool_manager.FetchPage(log_record.Delete_rid.GetPageId()))
+		if page_.GetLSN() < log_record.GetLSN() {
+			page_.RollbackDelete(&log_record.Delete_rid, txn, log_recovery.log_manager)
+			page_.SetLSN(log_record.GetLSN())
+		}
+		log_recovery.buffer_pool_manager.UnpinPage(log_record.Delete_rid.GetPageId(), true)
+	} else if log_record.Log_record_type == recovery.UPDATE {
+		page_ :=
+			access.CastPageAsTablePage(log_recovery.buffer_pool_manager.FetchPage(log_record.Update_rid.GetPageId()))
+		if page_.GetLSN() < log_record.GetLSN() {
+			// UpdateTuple overwrites Old_tuple argument
+			// but it is no problem because log_record is read from log file again in Undo phase
+			page_.UpdateTuple(&log_record.New_tuple, nil, nil, &log_record.Old_tuple, &log_record.Update_rid, txn, nil, log_recovery.log_manager, false)
+			page_.SetLSN(log_record.GetLSN())
+		}
+		log_recovery.buffer_pool_manager.UnpinPage(log_record.Update_rid.GetPageId(), true)
+	} else if log_record.Log_record_type == recovery.NEW_TABLE_PAGE {
+		var page_id types.PageID
+		//page, _ := log_recovery.buffer_pool_manager.NewPage()
+		new_page := access.CastPageAsTablePage(log_recovery.buffer_pool_manager.FetchPage(log_record.Page_id))
+		page_id = new_page.GetPageId()
+		// fmt.Printf("page_id: %d\n", page_id)
+		new_page.Init(page_id, log_record.Prev_page_id, log_recovery.log_manager, nil, txn, true)
+		//log_recovery.buffer_pool_manager.FlushPage(page_id)
+		log_recovery.buffer_pool_manager.UnpinPage(page_id, true)
+	} else if log_record.Log_record_type == recovery.NEW_OVERFLOW_PAGE {
+		// overflow page is written only once when it is allocated. so undo is not needed
+		// note: overflow pages of txn which was not finished at crash are not deallocated (leaked)
+		page_ := access.CastPageAsOverflowPage(log_recovery.buffer_pool_manager.FetchPage(log_record.Page_id))
+		if page_ != nil {
+			if page_.GetLSN() < log_record.GetLSN() {
+				page_.Init(log_record.Page_id, log_record.Next_page_id, log_record.Overflow_data)
+				page_.SetLSN(log_record.GetLSN())
+			}
+			log_recovery.buffer_pool_manager.UnpinPage(log_record.Page_id, true)
+		}
+	}
+}
+
 /*
 *undo phase on TABLE PAGE level(table/table_page.h)
 *iterate through active txn map and undo each operation
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/ryogrid/SamehadaDB/lib/common"
+	"github.com/ryogrid/SamehadaDB/lib/recovery"
 	"github.com/ryogrid/SamehadaDB/lib/recovery/log_recovery"
 	"github.com/ryogrid/SamehadaDB/lib/storage/access"
 	"github.com/ryogrid/SamehadaDB/lib/storage/page"
@@ -427,6 +428,163 @@ func TestCheckpoint(t *testing.T) {
 	common.TempSuppressOnMemStorageMutex.Unlock()
 }
 
+func TestFuzzyCheckpoint(t *testing.T) {
+	common.TempSuppressOnMemStorageMutex.Lock()
+	common.TempSuppressOnMemStorage = true
+	if !common.EnableOnMemStorage || common.TempSuppressOnMemStorage {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	samehada_instance := samehada.NewSamehadaInstance(t.Name(), common.BufferPoolMaxFrameNumForTest)
+	txn := samehada_instance.GetTransactionManager().Begin(nil)
+	test_table := access.NewTableHeap(samehada_instance.GetBufferPoolManager(), samehada_instance.GetLogManager(),
+		samehada_instance.GetLockManager(), txn)
+	samehada_instance.GetTransactionManager().Commit(nil, txn)
+
+	col1 := column.NewColumn("a", types.Varchar, false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), nil)
+	col2 := column.NewColumn("b", types.Integer, false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), nil)
+	schema_ := schema.NewSchema([]*column.Column{col1, col2})
+
+	committedRids := make([]*page.RID, 0)
+	txn1 := samehada_instance.GetTransactionManager().Begin(nil)
+	for ii := 0; ii < 100; ii++ {
+		rid, _ := test_table.InsertTuple(ConstructTuple(schema_), txn1, math.MaxUint32, false)
+		committedRids = append(committedRids, rid)
+	}
+	samehada_instance.GetTransactionManager().Commit(nil, txn1)
+
+	// txn2 is running while checkpointing
+	txn2 := samehada_instance.GetTransactionManager().Begin(nil)
+	uncommittedRid, _ := test_table.InsertTuple(ConstructTuple(schema_), txn2, math.MaxUint32, false)
+
+	checkpoint_manager := samehada_instance.GetCheckpointManager()
+	doneCh := make(chan bool)
+	go func() {
+		checkpoint_manager.BeginCheckpoint()
+		checkpoint_manager.EndCheckpoint()
+		doneCh <- true
+	}()
+	select {
+	case <-doneCh:
+	case <-time.After(10 * time.Second):
+		t.Fatal("checkpointing is blocked by running txn")
+	}
+	testingpkg.Assert(t, checkpoint_manager.GetLastRedoLSN() != common.InvalidLSN, "redo LSN should be set")
+	testingpkg.Assert(t, checkpoint_manager.GetLastRedoLSN() <= txn2.GetPrevLSN(), "redo LSN should cover modification of running txn")
+
+	// modifications after checkpoint
+	txn3 := samehada_instance.GetTransactionManager().Begin(nil)
+	for ii := 0; ii < 100; ii++ {
+		rid, _ := test_table.InsertTuple(ConstructTuple(schema_), txn3, math.MaxUint32, false)
+		committedRids = append(committedRids, rid)
+	}
+	samehada_instance.GetTransactionManager().Commit(nil, txn3)
+
+	fmt.Println("Crash...")
+	samehada_instance.CloseFilesForTesting()
+
+	fmt.Println("System restart...")
+	samehada_instance = samehada.NewSamehadaInstance(t.Name(), common.BufferPoolMaxFrameNumForTest)
+	samehada_instance.GetLogManager().DeactivateLogging()
+	txn = samehada_instance.GetTransactionManager().Begin(nil)
+	txn.SetIsRecoveryPhase(true)
+	log_recovery := log_recovery.NewLogRecovery(
+		samehada_instance.GetDiskManager(),
+		samehada_instance.GetBufferPoolManager(),
+		samehada_instance.GetLogManager())
+	_, isUndoNeeded, _ := log_recovery.Redo(txn)
+	testingpkg.Assert(t, isUndoNeeded, "")
+	log_recovery.Undo(txn)
+
+	test_table = access.NewTableHeap(
+		samehada_instance.GetBufferPoolManager(),
+		samehada_instance.GetLogManager(),
+		samehada_instance.GetLockManager(),
+		txn)
+	for _, rid := range committedRids {
+		tuple_, _ := test_table.GetTuple(rid, txn)
+		testingpkg.Assert(t, tuple_ != nil, "committed tuple should be recovered")
+	}
+	tuple_, _ := test_table.GetTuple(uncommittedRid, txn)
+	testingpkg.Assert(t, tuple_ == nil, "tuple of uncommitted txn should be undone")
+	samehada_instance.GetTransactionManager().Commit(nil, txn)
+
+	common.TempSuppressOnMemStorage = false
+	samehada_instance.Shutdown(samehada.ShutdownPatternRemoveFiles)
+	common.TempSuppressOnMemStorageMutex.Unlock()
+}
+
+func TestGroupCommitWithFlushTh(t *testing.T) {
+	common.TempSuppressOnMemStorageMutex.Lock()
+	common.TempSuppressOnMemStorage = true
+	if !common.EnableOnMemStorage || common.TempSuppressOnMemStorage {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	samehada_instance := samehada.NewSamehadaInstance(t.Name(), common.BufferPoolMaxFrameNumForTest)
+	log_manager := samehada_instance.GetLogManager()
+	log_manager.SetSyncPolicy(recovery.SyncPolicyInterval, 10*time.Millisecond)
+	log_manager.StartFlushTh()
+
+	txn := samehada_instance.GetTransactionManager().Begin(nil)
+	test_table := access.NewTableHeap(samehada_instance.GetBufferPoolManager(), log_manager,
+		samehada_instance.GetLockManager(), txn)
+	samehada_instance.GetTransactionManager().Commit(nil, txn)
+
+	col1 := column.NewColumn("a", types.Varchar, false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), nil)
+	col2 := column.NewColumn("b", types.Integer, false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), nil)
+	schema_ := schema.NewSchema([]*column.Column{col1, col2})
+
+	// commits of these transactions wait for the flush thread
+	const txnNum = 32
+	rids := make([]*page.RID, txnNum)
+	ch := make(chan int, txnNum)
+	for ii := 0; ii < txnNum; ii++ {
+		go func(idx int) {
+			txn_ := samehada_instance.GetTransactionManager().Begin(nil)
+			rids[idx], _ = test_table.InsertTuple(ConstructTuple(schema_), txn_, math.MaxUint32, false)
+			samehada_instance.GetTransactionManager().Commit(nil, txn_)
+			testingpkg.Assert(t, txn_.GetPrevLSN() <= log_manager.GetPersistentLSN(), "commit record should be persisted")
+			ch <- idx
+		}(ii)
+	}
+	for ii := 0; ii < txnNum; ii++ {
+		<-ch
+	}
+
+	fmt.Println("Shutdown System")
+	samehada_instance.CloseFilesForTesting()
+
+	fmt.Println("System restart...")
+	samehada_instance = samehada.NewSamehadaInstance(t.Name(), common.BufferPoolMaxFrameNumForTest)
+	samehada_instance.GetLogManager().DeactivateLogging()
+	txn = samehada_instance.GetTransactionManager().Begin(nil)
+	txn.SetIsRecoveryPhase(true)
+	log_recovery := log_recovery.NewLogRecovery(
+		samehada_instance.GetDiskManager(),
+		samehada_instance.GetBufferPoolManager(),
+		samehada_instance.GetLogManager())
+	log_recovery.Redo(txn)
+	log_recovery.Undo(txn)
+
+	test_table = access.NewTableHeap(
+		samehada_instance.GetBufferPoolManager(),
+		samehada_instance.GetLogManager(),
+		samehada_instance.GetLockManager(),
+		txn)
+	for ii := 0; ii < txnNum; ii++ {
+		tuple_, _ := test_table.GetTuple(rids[ii], txn)
+		testingpkg.Assert(t, tuple_ != nil, "committed tuple should be recovered")
+	}
+	samehada_instance.GetTransactionManager().Commit(nil, txn)
+
+	common.TempSuppressOnMemStorage = false
+	samehada_instance.Shutdown(samehada.ShutdownPatternRemoveFiles)
+	common.TempSuppressOnMemStorageMutex.Unlock()
+}
+
 // use a fixed schema to construct a random tuple
 func ConstructTuple(schema_ *schema.Schema) *tuple.Tuple {
 	var values []types.Value
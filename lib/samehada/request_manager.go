@@ -2,12 +2,14 @@ package samehada
 
 import (
 	"github.com/ryogrid/SamehadaDB/lib/common"
+	"github.com/ryogrid/SamehadaDB/lib/parser"
 	"sync"
 )
 
 type queryRequest struct {
 	reqId    *uint64
 	queryStr *string
+	qi       *parser.QueryInfo // already rewritten query (prepared statement). queryStr is not used when this is set
 	callerCh *chan *reqResult
 }
 
@@ -27,13 +29,24 @@ func NewRequestManager(sdb *SamehadaDB) *RequestManager {
 }
 
 func (reqManager *RequestManager) AppendRequest(queryStr *string) *chan *reqResult {
-	reqManager.queMutex.Lock()
+	qr := new(queryRequest)
+	qr.queryStr = queryStr
+	return reqManager.appendRequest(qr)
+}
 
+// qi should be already rewritten with optimizer.RewriteQueryInfo and must not be modified after this call
+func (reqManager *RequestManager) AppendQueryInfoRequest(qi *parser.QueryInfo) *chan *reqResult {
 	qr := new(queryRequest)
+	qr.qi = qi
+	return reqManager.appendRequest(qr)
+}
+
+func (reqManager *RequestManager) appendRequest(qr *queryRequest) *chan *reqResult {
+	reqManager.queMutex.Lock()
+
 	tmpId := reqManager.nextReqId
 	qr.reqId = &tmpId
 	reqManager.nextReqId++
-	qr.queryStr = queryStr
 
 	retCh := make(chan *reqResult)
 
@@ -74,7 +87,7 @@ func (reqManager *RequestManager) executeQuedTxns() {
 // caller must having lock of queMutex
 func (reqManager *RequestManager) handleAbortedByCCTxn(result *reqResult) {
 	// insert aborted request to head of que
-	reqManager.execQue = append([]*queryRequest{{result.reqId, result.query, result.callerCh}}, reqManager.execQue...)
+	reqManager.execQue = append([]*queryRequest{{result.reqId, result.query, result.qi, result.callerCh}}, reqManager.execQue...)
 	//fmt.Println("add que aborted req")
 }
 
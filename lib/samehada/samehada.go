@@ -34,9 +34,10 @@ type SamehadaDB struct {
 
 type reqResult struct {
 	err      error
-	result   [][]interface{}
+	result   *ResultSet
 	reqId    *uint64
 	query    *string
+	qi       *parser.QueryInfo
 	callerCh *chan *reqResult
 }
 
@@ -45,7 +46,12 @@ func (sdb *SamehadaDB) GetSamehadaInstance() *SamehadaInstance {
 	return sdb.shi_
 }
 
-func reconstructIndexDataOfATbl(t *catalog.TableMetadata, c *catalog.Catalog, dman disk.DiskManager, txn *access.Transaction) {
+func isSkipListIndex(kind index_constants.IndexKind) bool {
+	return kind == index_constants.INDEX_KIND_SKIP_LIST || kind == index_constants.INDEX_KIND_UNIQ_SKIP_LIST
+}
+
+// when onlySkipList is true, only SkipList indexes are reconstructed
+func reconstructIndexDataOfATbl(t *catalog.TableMetadata, c *catalog.Catalog, dman disk.DiskManager, txn *access.Transaction, onlySkipList bool) {
 	executionEngine := &executors.ExecutionEngine{}
 	executorContext := executors.NewExecutorContext(c, t.Table().GetBufferPoolManager(), txn)
 
@@ -56,6 +62,9 @@ func reconstructIndexDataOfATbl(t *catalog.TableMetadata, c *catalog.Catalog, dm
 	for colIdx, index_ := range t.Indexes() {
 		if index_ != nil {
 			column_ := t.Schema().GetColumn(uint32(colIdx))
+			if onlySkipList && !isSkipListIndex(column_.IndexKind()) {
+				continue
+			}
 			switch column_.IndexKind() {
 			case index_constants.INDEX_KIND_HASH:
 				// clear pages for HashTableBlockPage for avoiding conflict with reconstruction
@@ -92,8 +101,11 @@ func reconstructIndexDataOfATbl(t *catalog.TableMetadata, c *catalog.Catalog, dm
 	var allTuples []*tuple.Tuple = nil
 
 	// insert index entries correspond to each tuple and column to each index objects
-	for _, index_ := range t.Indexes() {
+	for colIdx, index_ := range t.Indexes() {
 		if index_ != nil {
+			if onlySkipList && !isSkipListIndex(t.Schema().GetColumn(uint32(colIdx)).IndexKind()) {
+				continue
+			}
 			if allTuples == nil {
 				// get all tuples once
 				outSchema := t.Schema()
@@ -106,24 +118,57 @@ func reconstructIndexDataOfATbl(t *catalog.TableMetadata, c *catalog.Catalog, dm
 			}
 		}
 	}
+
+	// composite indexes are SkipList based. so, they are reconstructed always
+	for _, index_ := range t.CompositeIndexes() {
+		if allTuples == nil {
+			seqPlan := plans.NewSeqScanPlanNode(c, t.Schema(), nil, t.OID())
+			allTuples = executionEngine.Execute(seqPlan, executorContext)
+		}
+		for _, tuple_ := range allTuples {
+			index_.InsertEntry(tuple_, *tuple_.GetRID(), txn)
+		}
+	}
 }
 
 func ReconstructAllIndexData(c *catalog.Catalog, dman disk.DiskManager, txn *access.Transaction) {
 	allTables := c.GetAllTables()
 	for ii := 0; ii < len(allTables); ii++ {
-		reconstructIndexDataOfATbl(allTables[ii], c, dman, txn)
+		reconstructIndexDataOfATbl(allTables[ii], c, dman, txn, false)
+	}
+}
+
+// SkipList index always uses new pages at launch. so, its data is reconstructed
+// even if last shutdown is graceful
+func ReconstructSkipListIndexData(c *catalog.Catalog, dman disk.DiskManager, txn *access.Transaction) {
+	allTables := c.GetAllTables()
+	for ii := 0; ii < len(allTables); ii++ {
+		reconstructIndexDataOfATbl(allTables[ii], c, dman, txn, true)
 	}
 }
 
+// storage is decided with common.EnableOnMemStorage. use NewSamehadaDBWithOptions for specifying
+// storage and other settings at runtime
 func NewSamehadaDB(dbName string, memKBytes int) *SamehadaDB {
+	err, ret := NewSamehadaDBWithOptions(dbName, &Options{BufferPoolKBytes: memKBytes})
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+func newSamehadaDB(dbPath string, opts *Options) *SamehadaDB {
+	isOnMemStorage := opts.isOnMemStorage()
 	isExistingDB := false
 
-	if !common.EnableOnMemStorage || common.TempSuppressOnMemStorage {
-		isExistingDB = samehada_util.FileExists(dbName + ".db")
+	if !isOnMemStorage {
+		isExistingDB = samehada_util.FileExists(dbPath + ".db")
 	}
 
-	bpoolSize := math.Floor(float64(memKBytes*1024) / float64(common.PageSize))
-	shi := NewSamehadaInstance(dbName, int(bpoolSize))
+	bpoolSize := math.Floor(float64(opts.bufferPoolKBytes()*1024) / float64(common.PageSize))
+	shi := newSamehadaInstance(dbPath, int(bpoolSize), isOnMemStorage, opts.logBufferSize())
+	shi.GetCheckpointManager().SetCheckpointInterval(opts.checkpointInterval())
+	shi.GetLogManager().SetSyncPolicy(opts.LogSyncPolicy, opts.LogSyncInterval)
 	txn := shi.GetTransactionManager().Begin(nil)
 
 	shi.GetLogManager().DeactivateLogging()
@@ -156,6 +201,7 @@ func NewSamehadaDB(dbName string, memKBytes int) *SamehadaDB {
 		shi.log_manager.Flush()
 
 		c = catalog.RecoveryCatalogFromCatalogPage(shi.GetBufferPoolManager(), shi.GetLogManager(), shi.GetLockManager(), txn, isGracefulShutdown)
+		bindCheckConstraints(c)
 
 		// if last shutdown is not gracefully done, all index data should be reconstructed
 		if !isGracefulShutdown {
@@ -163,6 +209,8 @@ func NewSamehadaDB(dbName string, memKBytes int) *SamehadaDB {
 			// so when db did not exit graceful, all index data should be reconstructed
 			// (hash index uses already allocated pages but skip list index deserts these...)
 			ReconstructAllIndexData(c, shi.GetDiskManager(), txn)
+		} else {
+			ReconstructSkipListIndexData(c, shi.GetDiskManager(), txn)
 		}
 	} else {
 		c = catalog.BootstrapCatalog(shi.GetBufferPoolManager(), shi.GetLogManager(), shi.GetLockManager(), txn)
@@ -172,6 +220,8 @@ func NewSamehadaDB(dbName string, memKBytes int) *SamehadaDB {
 	shi.transaction_manager.Commit(c, txn)
 
 	shi.GetLogManager().ActivateLogging()
+	// commit waits for the flush thread to write its commit record
+	shi.GetLogManager().StartFlushTh()
 
 	exec_engine := &executors.ExecutionEngine{}
 
@@ -191,71 +241,190 @@ func NewSamehadaDB(dbName string, memKBytes int) *SamehadaDB {
 }
 
 func (sdb *SamehadaDB) ExecuteSQLForTxnTh(ch *chan *reqResult, qr *queryRequest) {
-	err, results := sdb.ExecuteSQLRetValues(*qr.queryStr)
+	var err error
+	var results *ResultSet
+	if qr.qi != nil {
+		err, results = sdb.executeQueryInfoOnce(qr.qi)
+	} else {
+		err, results = sdb.executeSQLOnce(*qr.queryStr)
+	}
 	if err != nil {
-		*ch <- &reqResult{err, nil, qr.reqId, qr.queryStr, qr.callerCh}
+		*ch <- &reqResult{err, nil, qr.reqId, qr.queryStr, qr.qi, qr.callerCh}
 		return
 	}
-	*ch <- &reqResult{nil, samehada_util.ConvValueListToIFs(results), qr.reqId, qr.queryStr, qr.callerCh}
+	*ch <- &reqResult{nil, results, qr.reqId, qr.queryStr, qr.qi, qr.callerCh}
 }
 
 func (sdb *SamehadaDB) ExecuteSQL(sqlStr string) (error, [][]interface{}) {
+	err, results := sdb.executeSQLWithRetry(sqlStr)
+	if err != nil {
+		return err, nil
+	}
+	return nil, samehada_util.ConvValueListToIFs(results)
+}
+
+// query is executed by RequestManager. it retries the query when it is aborted due to lock conflict
+func (sdb *SamehadaDB) executeSQLWithRetry(sqlStr string) (error, [][]*types.Value) {
+	err, rs := sdb.ExecuteSQLRetResultSet(sqlStr)
+	return err, rs.getRows()
+}
+
+// same as ExecuteSQL but column descriptors and affected row count are also returned
+func (sdb *SamehadaDB) ExecuteSQLRetResultSet(sqlStr string) (error, *ResultSet) {
 	ch := sdb.request_manager.AppendRequest(&sqlStr)
 	ret := <-*ch
 	return ret.err, ret.result
 }
 
+// same as ExecuteSQLRetResultSet but already rewritten query is executed
+func (sdb *SamehadaDB) executeQueryInfoWithRetry(qi *parser.QueryInfo) (error, *ResultSet) {
+	ch := sdb.request_manager.AppendQueryInfoRequest(qi)
+	ret := <-*ch
+	return ret.err, ret.result
+}
+
 var PlanCreationErr = errors.New("plan creation error")
 
 // temporal error
 var QueryAbortedErr = errors.New("query aborted")
 
-func (sdb *SamehadaDB) ExecuteSQLRetValues(sqlStr string) (error, [][]*types.Value) {
+// returns true when err is caused by the query itself (constraint violation, etc...) and retry is meaningless
+func isQueryResultErr(err error) bool {
+	return catalog.IsConstraintViolationErr(err) || err == executors.ScalarSubqueryReturnedMultipleRowsErr ||
+		errors.Is(err, catalog.TypeMismatchErr) || errors.Is(err, catalog.ValueTooLongErr)
+}
+
+// BEGIN, COMMIT and ROLLBACK are meaningful only on a transaction handle (see BeginTxn)
+var TxnCtrlStmtWithoutTxnErr = errors.New("BEGIN, COMMIT and ROLLBACK are only allowed on a transaction handle")
+
+func (sdb *SamehadaDB) parseAndRewriteSQL(sqlStr string) (*parser.QueryInfo, error) {
 	qi, err := parser.ProcessSQLStr(&sqlStr)
 	if err != nil {
-		return err, nil
+		return nil, err
 	}
-	qi, err = optimizer.RewriteQueryInfo(sdb.catalog_, qi)
+	if isTxnCtrlQuery(qi) {
+		// rewriting is not needed
+		return qi, nil
+	}
+	return optimizer.RewriteQueryInfo(sdb.catalog_, qi)
+}
+
+func isTxnCtrlQuery(qi *parser.QueryInfo) bool {
+	switch *qi.QueryType_ {
+	case parser.BEGIN, parser.COMMIT, parser.ROLLBACK:
+		return true
+	default:
+		return false
+	}
+}
+
+func isDDLQuery(qi *parser.QueryInfo) bool {
+	switch *qi.QueryType_ {
+	case parser.CREATE_TABLE, parser.CREATE_INDEX, parser.DROP_INDEX, parser.DROP_TABLE, parser.TRUNCATE, parser.ALTER_TABLE:
+		return true
+	default:
+		return false
+	}
+}
+
+// DDL which scans or replaces existing data of a table
+func isBlockingDDLQuery(qi *parser.QueryInfo) bool {
+	switch *qi.QueryType_ {
+	case parser.CREATE_INDEX, parser.DROP_INDEX, parser.DROP_TABLE, parser.TRUNCATE, parser.ALTER_TABLE:
+		return true
+	default:
+		return false
+	}
+}
+
+func (sdb *SamehadaDB) ExecuteSQLRetValues(sqlStr string) (error, [][]*types.Value) {
+	err, rs := sdb.executeSQLOnce(sqlStr)
+	return err, rs.getRows()
+}
+
+// executes sqlStr on a new txn without retry
+func (sdb *SamehadaDB) executeSQLOnce(sqlStr string) (error, *ResultSet) {
+	qi, err := sdb.parseAndRewriteSQL(sqlStr)
 	if err != nil {
 		return err, nil
 	}
-	txn := sdb.shi_.transaction_manager.Begin(nil)
+	return sdb.executeQueryInfoOnce(qi)
+}
+
+// qi should be already rewritten with optimizer.RewriteQueryInfo
+func (sdb *SamehadaDB) executeQueryInfoOnce(qi *parser.QueryInfo) (error, *ResultSet) {
+	if isTxnCtrlQuery(qi) {
+		return TxnCtrlStmtWithoutTxnErr, nil
+	}
+
+	txnMgr := sdb.shi_.GetTransactionManager()
+	txn := txnMgr.Begin(nil)
+	var err error
+	var rs *ResultSet
+	if isBlockingDDLQuery(qi) {
+		// index data is filled and tuples are rewritten with a table scan. modifications by other txns
+		// during the scan are not reflected to them. so, other statements are blocked
+		txnMgr.ReleaseGlobalTxnLatch()
+		txnMgr.BlockAllTransactions()
+		err, rs = sdb.executeQueryOnTxn(qi, txn)
+		txnMgr.ResumeTransactions()
+		txnMgr.AcquireGlobalTxnLatch()
+	} else {
+		err, rs = sdb.executeQueryOnTxn(qi, txn)
+	}
+	if txn.GetState() == access.ABORTED {
+		sdb.shi_.GetTransactionManager().Abort(sdb.catalog_, txn)
+		if isQueryResultErr(err) {
+			// retry is meaningless
+			return err, nil
+		}
+		// temporal impl
+		return QueryAbortedErr, nil
+	}
+	// when planning failed, txn is committed also because nothing is written
+	sdb.shi_.GetTransactionManager().Commit(sdb.catalog_, txn)
+
+	return err, rs
+}
+
+// executes a query on passed txn.
+// commit or abort of txn is caller's responsibility.
+// when txn is set ABORTED state, QueryAbortedErr is returned.
+// but when the txn is aborted due to constraint violation or result of subquery, error which shows it is returned
+func (sdb *SamehadaDB) executeQueryOnTxn(qi *parser.QueryInfo, txn *access.Transaction) (error, *ResultSet) {
 	err, plan := planner.NewSimplePlanner(sdb.catalog_, sdb.shi_.bpm).MakePlan(qi, txn)
 
 	if err == nil && plan == nil {
 		// some problem exists on SQL string
-		sdb.shi_.GetTransactionManager().Commit(sdb.catalog_, txn)
-		if *qi.QueryType_ == parser.CREATE_TABLE {
-			return nil, nil
+		if isDDLQuery(qi) {
+			return nil, newResultSet(nil, nil)
 		} else {
 			return PlanCreationErr, nil
 		}
 	} else if err != nil {
 		// already table exist case
-		sdb.shi_.GetTransactionManager().Commit(sdb.catalog_, txn)
 		return err, nil
 	}
 
 	context := executors.NewExecutorContext(sdb.catalog_, sdb.shi_.GetBufferPoolManager(), txn)
-	result := sdb.exec_engine_.Execute(plan, context)
+	result, err := sdb.exec_engine_.ExecuteRetErr(plan, context)
 
 	if txn.GetState() == access.ABORTED {
-		sdb.shi_.GetTransactionManager().Abort(sdb.catalog_, txn)
-		// temporal impl
+		if isQueryResultErr(err) {
+			return err, nil
+		}
 		return QueryAbortedErr, nil
-	} else {
-		sdb.shi_.GetTransactionManager().Commit(sdb.catalog_, txn)
 	}
 
-	outSchema := plan.OutputSchema()
-	if outSchema == nil { // when DELETE etc...
-		return nil, nil
+	// OutputSchema is nil when DELETE etc... (it is set when RETURNING clause is specified)
+	//fmt.Println(result, plan.OutputSchema())
+	rs := newResultSet(plan.OutputSchema(), result)
+	switch *qi.QueryType_ {
+	case parser.INSERT, parser.UPDATE, parser.DELETE:
+		// these executors return a tuple for each row
+		rs.AffectedRows = int64(len(result))
 	}
-
-	//fmt.Println(result, outSchema)
-	retVals := samehada_util.ConvTupleListToValues(outSchema, result)
-
-	return nil, retVals
+	return nil, rs
 }
 
 // use this when shutdown DB
@@ -277,6 +446,19 @@ func (si *SamehadaDB) finalizeIndexesInternalState() {
 	}
 }
 
+// catalog stores CHECK constraints as SQL text. so, expressions of them are created here
+func bindCheckConstraints(c *catalog.Catalog) {
+	for _, tm := range c.GetAllTables() {
+		for _, check := range tm.CheckConstraints() {
+			expr, err := parser.ParseCheckExpr(tm.Schema(), check.ExprStr())
+			if err != nil {
+				panic("CHECK constraint stored in catalog is broken: " + check.ExprStr())
+			}
+			check.SetExpr(expr)
+		}
+	}
+}
+
 func (sdb *SamehadaDB) Shutdown() {
 	// set a flag which is checked by checkpointing thread
 	sdb.statistics_updator.StopStatsUpdateTh()
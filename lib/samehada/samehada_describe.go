@@ -0,0 +1,167 @@
+package samehada
+
+import (
+	"github.com/ryogrid/SamehadaDB/lib/parser"
+	"github.com/ryogrid/SamehadaDB/lib/planner"
+	"github.com/ryogrid/SamehadaDB/lib/samehada/samehada_util"
+	"github.com/ryogrid/SamehadaDB/lib/types"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// StmtDesc is result of DescribeSQL
+type StmtDesc struct {
+	// types of placeholders ($1, $2, ...). index 0 corresponds to $1.
+	// types.Invalid is set when the type can't be inferred
+	ParamTypes []types.TypeID
+	// columns of rows which the statement returns. empty when the statement is not SELECT and has no RETURNING clause
+	Columns []*ColumnDesc
+}
+
+// string literal which is embedded in place of placeholder for inferring its type
+const placeholderMarkPrefix = "samehada_placeholder_"
+
+// DescribeSQL returns types of placeholders and column descriptors of the result
+// of sqlStr without execution.
+// type of a placeholder is inferred from a column which the value is compared with,
+// assigned to or inserted to.
+func (sdb *SamehadaDB) DescribeSQL(sqlStr string) (error, *StmtDesc) {
+	err, ps := sdb.Prepare(sqlStr)
+	if err != nil {
+		return err, nil
+	}
+	return nil, ps.Describe()
+}
+
+func (sdb *SamehadaDB) describeQuery(sqlStr string, markedQi *parser.QueryInfo, paramNum int) (error, *StmtDesc) {
+	ret := &StmtDesc{make([]types.TypeID, paramNum), make([]*ColumnDesc, 0)}
+	sdb.inferParamTypes(markedQi, ret.ParamTypes)
+	if *markedQi.QueryType_ != parser.SELECT && markedQi.ReturningFields_ == nil {
+		return nil, ret
+	}
+
+	qi := markedQi
+	if paramNum > 0 {
+		// placeholders don't affect to the output schema
+		nulledSQL, _ := samehada_util.ReplacePlaceholders(sqlStr, func(n int) (string, error) {
+			return "NULL", nil
+		})
+		var err error
+		qi, err = sdb.parseAndRewriteSQL(nulledSQL)
+		if err != nil {
+			return err, nil
+		}
+	}
+
+	// planning only reads catalog and statistics, so txn is committed always
+	txn := sdb.shi_.transaction_manager.Begin(nil)
+	err, plan := planner.NewSimplePlanner(sdb.catalog_, sdb.shi_.bpm).MakePlan(qi, txn)
+	sdb.shi_.GetTransactionManager().Commit(sdb.catalog_, txn)
+	if err != nil {
+		return err, nil
+	} else if plan == nil {
+		return PlanCreationErr, nil
+	}
+	ret.Columns = newResultSet(plan.OutputSchema(), nil).Columns
+
+	return nil, ret
+}
+
+// returns placeholder number (1-origin) or 0 when val is not a placeholder mark
+func placeholderNumOf(val interface{}) int {
+	if !samehada_util.IsConstantValue(val) {
+		return 0
+	}
+	v := val.(*types.Value)
+	if v.IsNull() || v.ValueType() != types.Varchar || !strings.HasPrefix(v.ToVarchar(), placeholderMarkPrefix) {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(v.ToVarchar(), placeholderMarkPrefix))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// colName is "table_name.column_name" or column name of defaultTable
+func (sdb *SamehadaDB) getColumnType(colName string, defaultTable *string) types.TypeID {
+	tableName := ""
+	if strings.Contains(colName, ".") {
+		tableName = strings.Split(colName, ".")[0]
+	} else if defaultTable != nil {
+		tableName = *defaultTable
+	}
+
+	tm := sdb.catalog_.GetTableByName(strings.ToLower(tableName))
+	if tm == nil {
+		return types.Invalid
+	}
+	colIdx := tm.Schema().GetColIndex(colName)
+	if colIdx == math.MaxUint32 {
+		return types.Invalid
+	}
+	return tm.Schema().GetColumn(colIdx).GetType()
+}
+
+func (sdb *SamehadaDB) inferParamTypes(qi *parser.QueryInfo, paramTypes []types.TypeID) {
+	setType := func(n int, colName string, defaultTable *string) {
+		if n > 0 && n <= len(paramTypes) && paramTypes[n-1] == types.Invalid {
+			paramTypes[n-1] = sdb.getColumnType(colName, defaultTable)
+		}
+	}
+
+	var defaultTable *string = nil
+	if len(qi.JoinTables_) > 0 {
+		defaultTable = qi.JoinTables_[0]
+	}
+
+	// INSERT
+	targetCols := qi.TargetCols_
+	if len(targetCols) == 0 && len(qi.Values_) > 0 && defaultTable != nil {
+		// all columns are target when column names are omitted
+		if tm := sdb.catalog_.GetTableByName(*defaultTable); tm != nil {
+			for _, col := range tm.Schema().GetColumns() {
+				colName := col.GetColumnName()
+				targetCols = append(targetCols, &colName)
+			}
+		}
+	}
+	for idx, val := range qi.Values_ {
+		if len(targetCols) > 0 {
+			setType(placeholderNumOf(val), *targetCols[idx%len(targetCols)], defaultTable)
+		}
+	}
+	if qi.SelectQuery_ != nil {
+		sdb.inferParamTypes(qi.SelectQuery_, paramTypes)
+	}
+	// UPDATE
+	for _, setExp := range qi.SetExpressions_ {
+		setType(placeholderNumOf(setExp.UpdateValue_), *setExp.ColName_, defaultTable)
+	}
+
+	// WHERE (predicates of ON clause is attached to it on rewriting)
+	var traverse func(exp *parser.BinaryOpExpression)
+	traverse = func(exp *parser.BinaryOpExpression) {
+		if exp == nil || (exp.Left_ == nil && exp.Right_ == nil) {
+			return
+		}
+		switch exp.GetType() {
+		case parser.Compare:
+			if samehada_util.IsColumnName(exp.Left_) {
+				setType(placeholderNumOf(exp.Right_), *exp.Left_.(*string), defaultTable)
+			}
+			if samehada_util.IsColumnName(exp.Right_) {
+				setType(placeholderNumOf(exp.Left_), *exp.Right_.(*string), defaultTable)
+			}
+		case parser.Logical:
+			traverse(exp.Left_.(*parser.BinaryOpExpression))
+			traverse(exp.Right_.(*parser.BinaryOpExpression))
+		}
+	}
+	traverse(qi.WhereExpression_)
+
+	for _, sq := range qi.Subqueries() {
+		sdb.inferParamTypes(sq.Query_, paramTypes)
+	}
+}
@@ -0,0 +1,207 @@
+package samehada_driver
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"github.com/ryogrid/SamehadaDB/lib/samehada"
+)
+
+var UnsupportedTxOptionErr = errors.New("only default isolation level and read-write transaction are supported")
+var LastInsertIdErr = errors.New("LastInsertId is not supported")
+var NamedArgErr = errors.New("named arguments are not supported")
+
+// conn has a samehada.SamehadaSession. so BEGIN, COMMIT and ROLLBACK
+// (and Tx of database/sql) are processed on it
+type conn struct {
+	sdb  *samehada.SamehadaDB
+	sess *samehada.SamehadaSession
+}
+
+func newConn(sdb *samehada.SamehadaDB) *conn {
+	return &conn{sdb, sdb.NewSession()}
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	err, ps := c.sdb.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{c, ps}, nil
+}
+
+// transaction in progress is rolled back
+func (c *conn) Close() error {
+	c.sess.Close()
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if opts.Isolation != driver.IsolationLevel(0) || opts.ReadOnly {
+		return nil, UnsupportedTxOptionErr
+	}
+	if _, err := c.execute(ctx, "BEGIN;", nil); err != nil {
+		return nil, err
+	}
+	return &tx{c}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	rs, err := c.execute(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &result{rs.AffectedRows}, nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rs, err := c.execute(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return newRows(rs), nil
+}
+
+// ResetSession is called before the connection is reused by connection pool
+func (c *conn) ResetSession(ctx context.Context) error {
+	if c.sess.InTxn() {
+		// Tx should be committed or rolled back before the connection is returned to the pool
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+func (c *conn) execute(ctx context.Context, query string, args []driver.NamedValue) (*samehada.ResultSet, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(args) > 0 {
+		err, ps := c.sdb.Prepare(query)
+		if err != nil {
+			return nil, err
+		}
+		return c.executePrepared(ctx, ps, args)
+	}
+
+	err, rs := c.sess.ExecuteSQLRetResultSet(query)
+	return toResultSet(err, rs)
+}
+
+// args are converted to int64, float64, bool, []byte, string or nil by database/sql
+func (c *conn) executePrepared(ctx context.Context, ps *samehada.PreparedStmt, args []driver.NamedValue) (*samehada.ResultSet, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ifArgs := make([]interface{}, len(args))
+	for _, arg := range args {
+		if arg.Name != "" {
+			return nil, NamedArgErr
+		}
+		if arg.Ordinal < 1 || arg.Ordinal > len(args) {
+			return nil, samehada.ParamNumMismatchErr
+		}
+		ifArgs[arg.Ordinal-1] = arg.Value
+	}
+	vals, err := ps.ConvIFsToArgs(ifArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	err, rs := c.sess.ExecutePrepared(ps, vals...)
+	return toResultSet(err, rs)
+}
+
+func toResultSet(err error, rs *samehada.ResultSet) (*samehada.ResultSet, error) {
+	if err != nil {
+		return nil, err
+	}
+	if rs == nil {
+		// COMMIT and ROLLBACK don't return ResultSet
+		rs = &samehada.ResultSet{}
+	}
+	return rs, nil
+}
+
+type tx struct {
+	c *conn
+}
+
+func (t *tx) Commit() error {
+	_, err := t.c.execute(context.Background(), "COMMIT;", nil)
+	return err
+}
+
+func (t *tx) Rollback() error {
+	_, err := t.c.execute(context.Background(), "ROLLBACK;", nil)
+	return err
+}
+
+type stmt struct {
+	c  *conn
+	ps *samehada.PreparedStmt
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return s.ps.ParamNum()
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), valuesToNamedValues(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), valuesToNamedValues(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	rs, err := s.c.executePrepared(ctx, s.ps, args)
+	if err != nil {
+		return nil, err
+	}
+	return &result{rs.AffectedRows}, nil
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	rs, err := s.c.executePrepared(ctx, s.ps, args)
+	if err != nil {
+		return nil, err
+	}
+	return newRows(rs), nil
+}
+
+func valuesToNamedValues(args []driver.Value) []driver.NamedValue {
+	ret := make([]driver.NamedValue, 0, len(args))
+	for idx, arg := range args {
+		ret = append(ret, driver.NamedValue{Ordinal: idx + 1, Value: arg})
+	}
+	return ret
+}
+
+type result struct {
+	affectedRows int64
+}
+
+func (r *result) LastInsertId() (int64, error) {
+	return 0, LastInsertIdErr
+}
+
+func (r *result) RowsAffected() (int64, error) {
+	return r.affectedRows, nil
+}
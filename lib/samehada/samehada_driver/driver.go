@@ -0,0 +1,110 @@
+// Package samehada_driver is a database/sql driver for embedded SamehadaDB.
+//
+//	db, err := sql.Open("samehada", "dbName?mem_kbytes=5000")
+//
+// or when a samehada.SamehadaDB object is already created
+//
+//	db := sql.OpenDB(samehada_driver.NewConnector(sdb))
+//
+// Placeholders in SQL string are $1, $2, ...
+package samehada_driver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"github.com/ryogrid/SamehadaDB/lib/samehada"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const DriverName = "samehada"
+
+// used when mem_kbytes is not specified on DSN
+const defaultMemKBytes = 5000
+
+func init() {
+	sql.Register(DriverName, &Driver{})
+}
+
+type Driver struct{}
+
+// Open returns a new connection. a SamehadaDB object is created for each call,
+// so use sql.Open (OpenConnector is used) instead of calling this directly.
+func (d *Driver) Open(dsn string) (driver.Conn, error) {
+	connector, err := d.OpenConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(context.Background())
+}
+
+// OpenConnector parses DSN ("dbName" or "dbName?mem_kbytes=N").
+// SamehadaDB object is created at first connection and it is shut down
+// when sql.DB is closed.
+func (d *Driver) OpenConnector(dsn string) (driver.Connector, error) {
+	dbName := dsn
+	memKBytes := defaultMemKBytes
+	if idx := strings.Index(dsn, "?"); idx >= 0 {
+		dbName = dsn[:idx]
+		params, err := url.ParseQuery(dsn[idx+1:])
+		if err != nil {
+			return nil, err
+		}
+		if memStr := params.Get("mem_kbytes"); memStr != "" {
+			memKBytes, err = strconv.Atoi(memStr)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &Connector{nil, dbName, memKBytes, true, new(sync.Mutex)}, nil
+}
+
+// Connector implements driver.Connector. all connections share a SamehadaDB object
+type Connector struct {
+	sdb       *samehada.SamehadaDB
+	dbName    string
+	memKBytes int
+	// true when sdb is created by the connector
+	isOwnDB bool
+	mutex   *sync.Mutex
+}
+
+// NewConnector is used with sql.OpenDB for accessing already created SamehadaDB object.
+// sdb is not shut down when sql.DB is closed.
+func NewConnector(sdb *samehada.SamehadaDB) *Connector {
+	return &Connector{sdb, "", 0, false, new(sync.Mutex)}
+}
+
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.sdb == nil {
+		c.sdb = samehada.NewSamehadaDB(c.dbName, c.memKBytes)
+	}
+	return newConn(c.sdb), nil
+}
+
+func (c *Connector) Driver() driver.Driver {
+	return &Driver{}
+}
+
+// Close is called by sql.DB.Close
+func (c *Connector) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.isOwnDB && c.sdb != nil {
+		c.sdb.Shutdown()
+		c.sdb = nil
+	}
+	return nil
+}
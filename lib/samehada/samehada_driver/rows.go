@@ -0,0 +1,107 @@
+package samehada_driver
+
+import (
+	"database/sql/driver"
+	"github.com/ryogrid/SamehadaDB/lib/samehada"
+	"github.com/ryogrid/SamehadaDB/lib/types"
+	"io"
+	"reflect"
+	"time"
+)
+
+// rows returns values of samehada.ResultSet. column names are ones on output schema
+// of the plan ("table_name.column_name")
+type rows struct {
+	rs     *samehada.ResultSet
+	rowIdx int
+}
+
+func newRows(rs *samehada.ResultSet) *rows {
+	return &rows{rs, 0}
+}
+
+func (r *rows) Columns() []string {
+	ret := make([]string, 0, len(r.rs.Columns))
+	for _, col := range r.rs.Columns {
+		ret = append(ret, col.Name)
+	}
+	return ret
+}
+
+func (r *rows) Close() error {
+	r.rowIdx = len(r.rs.Rows)
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.rowIdx >= len(r.rs.Rows) {
+		return io.EOF
+	}
+	for idx, val := range r.rs.Rows[r.rowIdx] {
+		dest[idx] = valueToDriverValue(val)
+	}
+	r.rowIdx++
+	return nil
+}
+
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	return r.rs.Columns[index].TypeName()
+}
+
+func (r *rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	return r.rs.Columns[index].Nullable, true
+}
+
+func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	switch r.rs.Columns[index].Type {
+	case types.Integer:
+		return reflect.TypeOf(int64(0))
+	case types.Float:
+		return reflect.TypeOf(float64(0))
+	case types.Boolean:
+		return reflect.TypeOf(false)
+	case types.Varchar, types.Decimal:
+		return reflect.TypeOf("")
+	case types.BigInt:
+		return reflect.TypeOf(int64(0))
+	case types.Double:
+		return reflect.TypeOf(float64(0))
+	case types.Timestamp:
+		return reflect.TypeOf(time.Time{})
+	case types.Blob:
+		return reflect.TypeOf([]byte{})
+	default:
+		return reflect.TypeOf(new(interface{})).Elem()
+	}
+}
+
+// Integer and Float are converted to int64 and float64 and Decimal is converted to string because
+// these are types which database/sql can handle
+func valueToDriverValue(val *types.Value) driver.Value {
+	if val == nil || val.IsNull() {
+		return nil
+	}
+	switch val.ValueType() {
+	case types.Integer:
+		return int64(val.ToInteger())
+	case types.Float:
+		return float64(val.ToFloat())
+	case types.Boolean:
+		return val.ToBoolean()
+	case types.Varchar:
+		return val.ToVarchar()
+	case types.BigInt:
+		return val.ToBigInt()
+	case types.Double:
+		return val.ToDouble()
+	case types.Decimal:
+		// string for keeping exactness
+		return val.ToString()
+	case types.Timestamp:
+		return val.ToTimestamp()
+	case types.Blob:
+		return val.ToBlob()
+	default:
+		panic("not supported Value object")
+	}
+}
@@ -0,0 +1,93 @@
+package samehada_driver
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/ryogrid/SamehadaDB/lib/common"
+	"github.com/ryogrid/SamehadaDB/lib/samehada"
+	testingpkg "github.com/ryogrid/SamehadaDB/lib/testing/testing_assert"
+	"os"
+	"testing"
+)
+
+func TestDriverWithTx(t *testing.T) {
+	// clear all state of DB
+	if !common.EnableOnMemStorage {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	db, err := sql.Open(DriverName, t.Name()+"?mem_kbytes=200")
+	testingpkg.SimpleAssert(t, err == nil)
+
+	_, err = db.Exec("CREATE TABLE name_age_list(name VARCHAR(256), age INT, height FLOAT);")
+	testingpkg.SimpleAssert(t, err == nil)
+	res, err := db.Exec("INSERT INTO name_age_list(name, age, height) VALUES ($1, $2, $3);", "鈴木", 20, 170.5)
+	testingpkg.SimpleAssert(t, err == nil)
+	affected, _ := res.RowsAffected()
+	testingpkg.SimpleAssert(t, affected == 1)
+
+	// quote in string value is escaped
+	stmt, err := db.Prepare("INSERT INTO name_age_list(name, age, height) VALUES ($1, $2, $3);")
+	testingpkg.SimpleAssert(t, err == nil)
+	_, err = stmt.Exec("O'Brien", 30, 180.0)
+	testingpkg.SimpleAssert(t, err == nil)
+	stmt.Close()
+
+	// rolled back
+	tx, err := db.Begin()
+	testingpkg.SimpleAssert(t, err == nil)
+	_, err = tx.Exec("INSERT INTO name_age_list(name, age, height) VALUES ('佐藤', 40, 160.0);")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, tx.Rollback() == nil)
+
+	// committed
+	tx, err = db.Begin()
+	testingpkg.SimpleAssert(t, err == nil)
+	_, err = tx.Exec("INSERT INTO name_age_list(name, age, height) VALUES ('山田', 50, 150.0);")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, tx.Commit() == nil)
+
+	rows, err := db.Query("SELECT name, age, height FROM name_age_list WHERE age >= $1;", 30)
+	testingpkg.SimpleAssert(t, err == nil)
+	cols, _ := rows.Columns()
+	testingpkg.SimpleAssert(t, len(cols) == 3 && cols[0] == "name_age_list.name" && cols[1] == "name_age_list.age")
+	colTypes, _ := rows.ColumnTypes()
+	testingpkg.SimpleAssert(t, colTypes[0].DatabaseTypeName() == "VARCHAR")
+	testingpkg.SimpleAssert(t, colTypes[1].DatabaseTypeName() == "INT")
+	testingpkg.SimpleAssert(t, colTypes[2].DatabaseTypeName() == "FLOAT")
+	names := make(map[string]int32)
+	for rows.Next() {
+		var name string
+		var age int32
+		var height float64
+		testingpkg.SimpleAssert(t, rows.Scan(&name, &age, &height) == nil)
+		names[name] = age
+	}
+	testingpkg.SimpleAssert(t, rows.Err() == nil)
+	testingpkg.SimpleAssert(t, len(names) == 2)
+	testingpkg.SimpleAssert(t, names["O'Brien"] == 30)
+	testingpkg.SimpleAssert(t, names["山田"] == 50)
+
+	var age int32
+	err = db.QueryRow("SELECT age FROM name_age_list WHERE name = $1;", "鈴木").Scan(&age)
+	testingpkg.SimpleAssert(t, err == nil && age == 20)
+	err = db.QueryRow("SELECT age FROM name_age_list WHERE name = $1;", "佐藤").Scan(&age)
+	testingpkg.SimpleAssert(t, err == sql.ErrNoRows)
+	err = db.QueryRow("SELECT age FROM name_age_list WHERE name = ? AND height > ?;", "O'Brien", 100).Scan(&age)
+	testingpkg.SimpleAssert(t, err == nil && age == 30)
+	_, err = db.Exec("INSERT INTO name_age_list(name, age, height) VALUES ($1, $2, $3);", "高橋", "60", 165.0)
+	testingpkg.SimpleAssert(t, errors.Is(err, samehada.ParamTypeMismatchErr))
+
+	res, err = db.Exec("UPDATE name_age_list SET height = 175.0 WHERE age < $1;", 100)
+	testingpkg.SimpleAssert(t, err == nil)
+	affected, _ = res.RowsAffected()
+	testingpkg.SimpleAssert(t, affected == 3)
+	res, err = db.Exec("DELETE FROM name_age_list WHERE name = $1;", "鈴木")
+	testingpkg.SimpleAssert(t, err == nil)
+	affected, _ = res.RowsAffected()
+	testingpkg.SimpleAssert(t, affected == 1)
+
+	// SamehadaDB object is shut down
+	testingpkg.SimpleAssert(t, db.Close() == nil)
+}
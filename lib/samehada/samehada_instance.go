@@ -35,14 +35,25 @@ func NewSamehadaInstanceForTesting() *SamehadaInstance {
 // and db/log file
 // bpoolSize: usable buffer size in frame(=page) num
 func NewSamehadaInstance(dbName string, bpoolSize int) *SamehadaInstance {
+	return newSamehadaInstance(dbName, bpoolSize, isOnMemStorageDefault(), common.LogBufferSize)
+}
+
+// storage which is used when it is not specified with Options
+func isOnMemStorageDefault() bool {
+	return common.EnableOnMemStorage && !common.TempSuppressOnMemStorage
+}
+
+// dbPath: path of db file without extension
+// logBufferSize: size of log buffer in bytes
+func newSamehadaInstance(dbPath string, bpoolSize int, isOnMemStorage bool, logBufferSize uint32) *SamehadaInstance {
 	var disk_manager disk.DiskManager
-	if !common.EnableOnMemStorage || common.TempSuppressOnMemStorage {
-		disk_manager = disk.NewDiskManagerImpl(dbName + ".db")
+	if isOnMemStorage {
+		disk_manager = disk.NewVirtualDiskManagerImpl(dbPath + ".db")
 	} else {
-		disk_manager = disk.NewVirtualDiskManagerImpl(dbName + ".db")
+		disk_manager = disk.NewDiskManagerImpl(dbPath + ".db")
 	}
 
-	log_manager := recovery.NewLogManager(&disk_manager)
+	log_manager := recovery.NewLogManagerWithBufferSize(&disk_manager, logBufferSize)
 	log_manager.ActivateLogging()
 	bpm := buffer.NewBufferPoolManager(uint32(bpoolSize), disk_manager, log_manager)
 	lock_manager := access.NewLockManager(access.STRICT, access.SS2PL_MODE)
@@ -78,6 +89,7 @@ func (si *SamehadaInstance) GetCheckpointManager() *concurrency.CheckpointManage
 
 // functionality is Flushing dirty pages, shutdown of DiskManager and action around DB/Log files
 func (si *SamehadaInstance) Shutdown(shutdownPat ShutdownPattern) {
+	si.log_manager.StopFlushTh()
 	switch shutdownPat {
 	case ShutdownPatternRemoveFiles:
 		//close
@@ -101,5 +113,6 @@ func (si *SamehadaInstance) Shutdown(shutdownPat ShutdownPattern) {
 
 // for testing. this method does file closing only in contrast to Shutdown method
 func (si *SamehadaInstance) CloseFilesForTesting() {
+	si.log_manager.StopFlushTh()
 	si.disk_manager.ShutDown()
 }
@@ -0,0 +1,159 @@
+package samehada
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ryogrid/SamehadaDB/lib/common"
+	"github.com/ryogrid/SamehadaDB/lib/concurrency"
+	"github.com/ryogrid/SamehadaDB/lib/recovery"
+)
+
+type StorageMode int
+
+const (
+	// decided with common.EnableOnMemStorage (and common.TempSuppressOnMemStorage)
+	StorageModeDefault StorageMode = iota
+	// db and log are stored to files (DiskManagerImpl)
+	StorageModeFile
+	// db and log are stored on memory and they are lost at shutdown (VirtualDiskManagerImpl)
+	StorageModeOnMemory
+)
+
+// used when BufferPoolKBytes is not specified
+const DefaultBufferPoolKBytes = 5000
+
+var InvalidOptionErr = errors.New("invalid option")
+
+// Options is passed to NewSamehadaDBWithOptions. zero value of each field means default
+type Options struct {
+	StorageMode StorageMode
+	// directory which db and log files are placed. current directory is used when it is empty.
+	// it is created when it does not exist
+	DataDir string
+	// size of buffer pool
+	BufferPoolKBytes int
+	// interval of checkpointing (concurrency.DefaultCheckpointInterval is used when it is zero)
+	CheckpointInterval time.Duration
+	// size of log buffer in bytes. it should be larger than or equal to common.MinLogBufferSize
+	LogBufferSize int
+	// when log file is synced (fsync). default is recovery.SyncPolicyAlways
+	LogSyncPolicy recovery.SyncPolicy
+	// interval of sync with recovery.SyncPolicyInterval (recovery.DefaultSyncInterval is used when it is zero)
+	LogSyncInterval time.Duration
+}
+
+// ParseStorageMode converts "file", "memory" or "" (default) to StorageMode
+func ParseStorageMode(str string) (error, StorageMode) {
+	switch str {
+	case "":
+		return nil, StorageModeDefault
+	case "file":
+		return nil, StorageModeFile
+	case "memory":
+		return nil, StorageModeOnMemory
+	default:
+		return fmt.Errorf("%w: unknown storage mode %s", InvalidOptionErr, str), StorageModeDefault
+	}
+}
+
+// ParseLogSyncPolicy converts "always", "never" or duration string (ex: "100ms") to SyncPolicy
+// and interval. duration means recovery.SyncPolicyInterval. "" means default
+func ParseLogSyncPolicy(str string) (error, recovery.SyncPolicy, time.Duration) {
+	switch str {
+	case "", "always":
+		return nil, recovery.SyncPolicyAlways, 0
+	case "never":
+		return nil, recovery.SyncPolicyNever, 0
+	default:
+		interval, err := time.ParseDuration(str)
+		if err != nil || interval <= 0 {
+			return fmt.Errorf("%w: unknown log sync policy %s", InvalidOptionErr, str), recovery.SyncPolicyAlways, 0
+		}
+		return nil, recovery.SyncPolicyInterval, interval
+	}
+}
+
+func (opts *Options) validate() error {
+	if opts.StorageMode < StorageModeDefault || opts.StorageMode > StorageModeOnMemory {
+		return fmt.Errorf("%w: unknown storage mode %d", InvalidOptionErr, opts.StorageMode)
+	}
+	if opts.BufferPoolKBytes < 0 {
+		return fmt.Errorf("%w: buffer pool size should not be negative", InvalidOptionErr)
+	}
+	if opts.BufferPoolKBytes > 0 && opts.BufferPoolKBytes*1024 < common.PageSize {
+		return fmt.Errorf("%w: buffer pool size should be larger than or equal to page size", InvalidOptionErr)
+	}
+	if opts.CheckpointInterval < 0 {
+		return fmt.Errorf("%w: checkpoint interval should not be negative", InvalidOptionErr)
+	}
+	if opts.LogSyncPolicy < recovery.SyncPolicyAlways || opts.LogSyncPolicy > recovery.SyncPolicyNever {
+		return fmt.Errorf("%w: unknown log sync policy %d", InvalidOptionErr, opts.LogSyncPolicy)
+	}
+	if opts.LogSyncInterval < 0 {
+		return fmt.Errorf("%w: log sync interval should not be negative", InvalidOptionErr)
+	}
+	if opts.LogBufferSize < 0 || (opts.LogBufferSize > 0 && opts.LogBufferSize < common.MinLogBufferSize) {
+		return fmt.Errorf("%w: log buffer size should be larger than or equal to %d", InvalidOptionErr, common.MinLogBufferSize)
+	}
+	return nil
+}
+
+func (opts *Options) isOnMemStorage() bool {
+	switch opts.StorageMode {
+	case StorageModeFile:
+		return false
+	case StorageModeOnMemory:
+		return true
+	default:
+		return isOnMemStorageDefault()
+	}
+}
+
+func (opts *Options) bufferPoolKBytes() int {
+	if opts.BufferPoolKBytes == 0 {
+		return DefaultBufferPoolKBytes
+	}
+	return opts.BufferPoolKBytes
+}
+
+func (opts *Options) checkpointInterval() time.Duration {
+	if opts.CheckpointInterval == 0 {
+		return concurrency.DefaultCheckpointInterval
+	}
+	return opts.CheckpointInterval
+}
+
+func (opts *Options) logBufferSize() uint32 {
+	if opts.LogBufferSize == 0 {
+		return common.LogBufferSize
+	}
+	return uint32(opts.LogBufferSize)
+}
+
+// NewSamehadaDBWithOptions creates SamehadaDB object with settings which are specified at runtime.
+// db and log files are "<DataDir>/<dbName>.db" and "<DataDir>/<dbName>.log".
+// opts can be nil (all settings are default)
+func NewSamehadaDBWithOptions(dbName string, opts *Options) (error, *SamehadaDB) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if err := opts.validate(); err != nil {
+		return err, nil
+	}
+
+	dbPath := dbName
+	if opts.DataDir != "" {
+		if !opts.isOnMemStorage() {
+			if err := os.MkdirAll(opts.DataDir, 0755); err != nil {
+				return err, nil
+			}
+		}
+		dbPath = filepath.Join(opts.DataDir, dbName)
+	}
+
+	return nil, newSamehadaDB(dbPath, opts)
+}
@@ -0,0 +1,324 @@
+package samehada
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ryogrid/SamehadaDB/lib/parser"
+	"github.com/ryogrid/SamehadaDB/lib/samehada/samehada_util"
+	"github.com/ryogrid/SamehadaDB/lib/types"
+	"math"
+	"strconv"
+	"time"
+)
+
+var ParamNumMismatchErr = errors.New("number of parameters doesn't match with placeholders")
+var ParamTypeMismatchErr = errors.New("type of parameter doesn't match with the column")
+
+// PreparedStmt is a SQL statement which is parsed once and executed many times
+// with different parameter values.
+// placeholders are ? or $1, $2, ... and they can be used in place of constant values
+// of INSERT VALUES, UPDATE SET and predicates of WHERE and ON clauses.
+// it can be shared among goroutines.
+type PreparedStmt struct {
+	sqlStr string
+	// rewritten query. placeholders are embedded as string literal marks
+	qi   *parser.QueryInfo
+	desc *StmtDesc
+}
+
+// Prepare parses sqlStr and returns a PreparedStmt which can be executed with
+// ExecutePrepared of SamehadaDB, SamehadaTxn and SamehadaSession.
+// note: the statement is not re-parsed when schema of tables is changed after preparation
+func (sdb *SamehadaDB) Prepare(sqlStr string) (error, *PreparedStmt) {
+	paramNum := 0
+	markedSQL, err := samehada_util.ReplacePlaceholders(sqlStr, func(n int) (string, error) {
+		if n > paramNum {
+			paramNum = n
+		}
+		return "'" + placeholderMarkPrefix + strconv.Itoa(n) + "'", nil
+	})
+	if err != nil {
+		return err, nil
+	}
+	qi, err := sdb.parseAndRewriteSQL(markedSQL)
+	if err != nil {
+		return err, nil
+	}
+	err, desc := sdb.describeQuery(sqlStr, qi, paramNum)
+	if err != nil {
+		return err, nil
+	}
+	return nil, &PreparedStmt{sqlStr, qi, desc}
+}
+
+func (ps *PreparedStmt) SQL() string {
+	return ps.sqlStr
+}
+
+// largest number of placeholders. args of ExecutePrepared should have this length
+func (ps *PreparedStmt) ParamNum() int {
+	return len(ps.desc.ParamTypes)
+}
+
+func (ps *PreparedStmt) Describe() *StmtDesc {
+	return ps.desc
+}
+
+// ConvIFToValue converts a Go value to a parameter value for placeholder which has paramType.
+// nil, int, int32, int64, float32, float64, bool, string, []byte and time.Time are supported.
+// paramType can be types.Invalid (type of the placeholder is unknown)
+func ConvIFToValue(arg interface{}, paramType types.TypeID) (*types.Value, error) {
+	switch val := arg.(type) {
+	case nil:
+		return newNullParam(paramType), nil
+	case int:
+		return convIntToValue(int64(val), paramType)
+	case int32:
+		return convIntToValue(int64(val), paramType)
+	case int64:
+		return convIntToValue(val, paramType)
+	case float32:
+		return convFloatToValue(float64(val), paramType)
+	case float64:
+		return convFloatToValue(val, paramType)
+	case bool:
+		if paramType == types.Integer {
+			if val {
+				return samehada_util.GetPonterOfValue(types.NewInteger(1)), nil
+			}
+			return samehada_util.GetPonterOfValue(types.NewInteger(0)), nil
+		}
+		return samehada_util.GetPonterOfValue(types.NewBoolean(val)), nil
+	case string:
+		return convStringToValue(val, paramType)
+	case []byte:
+		if paramType == types.Blob {
+			return samehada_util.GetPonterOfValue(types.NewBlob(val)), nil
+		}
+		return convStringToValue(string(val), paramType)
+	case time.Time:
+		if paramType == types.Timestamp || paramType == types.Invalid {
+			return samehada_util.GetPonterOfValue(types.NewTimestamp(val)), nil
+		}
+		return nil, fmt.Errorf("%w: time is passed for %s column", ParamTypeMismatchErr, typeNameOf(paramType))
+	default:
+		return nil, fmt.Errorf("%w: %T is not supported", ParamTypeMismatchErr, arg)
+	}
+}
+
+// NULL value which has same type as the placeholder
+func newNullParam(paramType types.TypeID) *types.Value {
+	return samehada_util.GetPonterOfValue(types.NewNullOfType(paramType))
+}
+
+func convIntToValue(val int64, paramType types.TypeID) (*types.Value, error) {
+	switch paramType {
+	case types.Float:
+		return samehada_util.GetPonterOfValue(types.NewFloat(float32(val))), nil
+	case types.Double:
+		return samehada_util.GetPonterOfValue(types.NewDouble(float64(val))), nil
+	case types.BigInt:
+		return samehada_util.GetPonterOfValue(types.NewBigInt(val)), nil
+	case types.Decimal:
+		return samehada_util.GetPonterOfValue(types.NewDecimal(types.NewDecimalFromInt64(val))), nil
+	case types.Integer:
+		if val > math.MaxInt32 || val < math.MinInt32 {
+			return nil, fmt.Errorf("%w: %d is out of range of integer", ParamTypeMismatchErr, val)
+		}
+		return samehada_util.GetPonterOfValue(types.NewInteger(int32(val))), nil
+	case types.Invalid:
+		if val > math.MaxInt32 || val < math.MinInt32 {
+			return samehada_util.GetPonterOfValue(types.NewBigInt(val)), nil
+		}
+		return samehada_util.GetPonterOfValue(types.NewInteger(int32(val))), nil
+	}
+	return nil, fmt.Errorf("%w: integer is passed for %s column", ParamTypeMismatchErr, typeNameOf(paramType))
+}
+
+func convFloatToValue(val float64, paramType types.TypeID) (*types.Value, error) {
+	switch paramType {
+	case types.Integer, types.BigInt:
+		// JSON numbers are decoded as float64
+		if val != math.Trunc(val) || val >= math.MaxInt64 || val < math.MinInt64 {
+			return nil, fmt.Errorf("%w: %v is passed for integer column", ParamTypeMismatchErr, val)
+		}
+		return convIntToValue(int64(val), paramType)
+	case types.Float, types.Invalid:
+		return samehada_util.GetPonterOfValue(types.NewFloat(float32(val))), nil
+	case types.Double:
+		return samehada_util.GetPonterOfValue(types.NewDouble(val)), nil
+	case types.Decimal:
+		dval, ok := types.NewDecimalFromFloat64(val, 64)
+		if !ok {
+			return nil, fmt.Errorf("%w: %v is out of range of decimal", ParamTypeMismatchErr, val)
+		}
+		return samehada_util.GetPonterOfValue(types.NewDecimal(dval)), nil
+	}
+	return nil, fmt.Errorf("%w: float is passed for %s column", ParamTypeMismatchErr, typeNameOf(paramType))
+}
+
+func convStringToValue(val string, paramType types.TypeID) (*types.Value, error) {
+	switch paramType {
+	case types.Varchar, types.Invalid:
+		return samehada_util.GetPonterOfValue(types.NewVarchar(val)), nil
+	case types.BigInt:
+		// JSON numbers lose precision of 64-bit integer. so, it can be passed as string
+		if ival, err := strconv.ParseInt(val, 10, 64); err == nil {
+			return samehada_util.GetPonterOfValue(types.NewBigInt(ival)), nil
+		}
+	case types.Decimal:
+		// exact value such as "12.34" can be passed as string
+		if dval, ok := types.NewDecimalFromString(val); ok {
+			return samehada_util.GetPonterOfValue(types.NewDecimal(dval)), nil
+		}
+	case types.Timestamp, types.Blob:
+		if converted, ok := types.NewVarchar(val).CoerceTo(paramType); ok {
+			return converted, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: string is passed for %s column", ParamTypeMismatchErr, typeNameOf(paramType))
+}
+
+func typeNameOf(typeId types.TypeID) string {
+	return (&ColumnDesc{"", typeId, true}).TypeName()
+}
+
+// ConvIFsToArgs converts Go values to parameter values of ps with ConvIFToValue
+func (ps *PreparedStmt) ConvIFsToArgs(args ...interface{}) ([]*types.Value, error) {
+	if len(args) != ps.ParamNum() {
+		return nil, ParamNumMismatchErr
+	}
+	ret := make([]*types.Value, len(args))
+	for idx, arg := range args {
+		val, err := ConvIFToValue(arg, ps.desc.ParamTypes[idx])
+		if err != nil {
+			return nil, fmt.Errorf("parameter $%d: %w", idx+1, err)
+		}
+		ret[idx] = val
+	}
+	return ret, nil
+}
+
+// returns a parameter value which has same type as the placeholder
+func (ps *PreparedStmt) coerceArg(n int, arg *types.Value) (*types.Value, error) {
+	paramType := ps.desc.ParamTypes[n-1]
+	if arg == nil || arg.IsNull() {
+		return newNullParam(paramType), nil
+	}
+	if paramType == types.Invalid {
+		return arg.GetDeepCopy(), nil
+	}
+	// ex: Integer to Float
+	if converted, ok := arg.CoerceTo(paramType); ok {
+		return converted, nil
+	}
+	return nil, fmt.Errorf("parameter $%d: %w: %s is passed for %s column", n, ParamTypeMismatchErr,
+		typeNameOf(arg.ValueType()), typeNameOf(paramType))
+}
+
+// returns copy of the query which placeholders are replaced with args
+func (ps *PreparedStmt) bind(args []*types.Value) (error, *parser.QueryInfo) {
+	if len(args) != ps.ParamNum() {
+		return ParamNumMismatchErr, nil
+	}
+	if len(args) == 0 {
+		// planning and execution don't modify QueryInfo
+		return nil, ps.qi
+	}
+	coerced := make([]*types.Value, len(args))
+	for idx, arg := range args {
+		val, err := ps.coerceArg(idx+1, arg)
+		if err != nil {
+			return err, nil
+		}
+		coerced[idx] = val
+	}
+	bindVal := func(val *types.Value) *types.Value {
+		if n := placeholderNumOf(val); n > 0 {
+			return coerced[n-1]
+		}
+		return val
+	}
+
+	ret := ps.qi.GetDeepCopy()
+	for idx, val := range ret.Values_ {
+		ret.Values_[idx] = bindVal(val)
+	}
+	for _, setExp := range ret.SetExpressions_ {
+		setExp.UpdateValue_ = bindVal(setExp.UpdateValue_)
+	}
+	var traverse func(exp interface{}) interface{}
+	traverse = func(exp interface{}) interface{} {
+		switch casted := exp.(type) {
+		case *types.Value:
+			return bindVal(casted)
+		case *parser.BinaryOpExpression:
+			if casted != nil {
+				casted.Left_ = traverse(casted.Left_)
+				casted.Right_ = traverse(casted.Right_)
+			}
+		case *parser.CalcExpression:
+			if casted != nil {
+				for idx, arg := range casted.Args_ {
+					casted.Args_[idx] = traverse(arg)
+				}
+			}
+		}
+		return exp
+	}
+	// placeholders in subqueries are also bound
+	var bindQuery func(qi *parser.QueryInfo)
+	bindQuery = func(qi *parser.QueryInfo) {
+		traverse(qi.WhereExpression_)
+		traverse(qi.OnExpressions_)
+		for _, sfield := range qi.SelectFields_ {
+			traverse(sfield.Calc_)
+		}
+		for _, sq := range qi.Subqueries() {
+			bindQuery(sq.Query_)
+		}
+		if qi.SelectQuery_ != nil {
+			bindQuery(qi.SelectQuery_)
+		}
+	}
+	bindQuery(ret)
+	return nil, ret
+}
+
+// ExecutePrepared executes ps with args in auto-commit mode.
+// as ExecuteSQL, the statement is retried when it is aborted due to lock conflict.
+// each arg is converted to the type of the placeholder if possible (ex: Integer to Float).
+// nil or NULL value can be passed as NULL
+func (sdb *SamehadaDB) ExecutePrepared(ps *PreparedStmt, args ...*types.Value) (error, *ResultSet) {
+	err, qi := ps.bind(args)
+	if err != nil {
+		return err, nil
+	}
+	if isTxnCtrlQuery(qi) {
+		return TxnCtrlStmtWithoutTxnErr, nil
+	}
+	return sdb.executeQueryInfoWithRetry(qi)
+}
+
+// same as ExecuteSQLRetResultSet but prepared statement is executed
+func (stxn *SamehadaTxn) ExecutePrepared(ps *PreparedStmt, args ...*types.Value) (error, *ResultSet) {
+	err, qi := ps.bind(args)
+	if err != nil {
+		return err, nil
+	}
+	return stxn.executeQuery(qi)
+}
+
+// same as SamehadaDB::Prepare. the statement can be executed on other sessions also
+func (sess *SamehadaSession) Prepare(sqlStr string) (error, *PreparedStmt) {
+	return sess.sdb.Prepare(sqlStr)
+}
+
+// same as ExecuteSQLRetResultSet but prepared statement is executed
+func (sess *SamehadaSession) ExecutePrepared(ps *PreparedStmt, args ...*types.Value) (error, *ResultSet) {
+	err, qi := ps.bind(args)
+	if err != nil {
+		return err, nil
+	}
+	return sess.executeQuery(qi)
+}
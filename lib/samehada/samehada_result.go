@@ -0,0 +1,50 @@
+package samehada
+
+import (
+	"github.com/ryogrid/SamehadaDB/lib/samehada/samehada_util"
+	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
+	"github.com/ryogrid/SamehadaDB/lib/storage/tuple"
+	"github.com/ryogrid/SamehadaDB/lib/types"
+)
+
+// ColumnDesc describes a column of ResultSet
+type ColumnDesc struct {
+	// qualified column name on output schema of the plan (ex: "table_name.column_name")
+	Name string
+	Type types.TypeID
+	// false when the column has NOT NULL constraint (PRIMARY KEY column also has it)
+	Nullable bool
+}
+
+func (cd *ColumnDesc) TypeName() string {
+	return cd.Type.Name()
+}
+
+// ResultSet is result of a statement with descriptors of its columns.
+// Columns is empty when the statement does not return rows (INSERT without RETURNING clause, DDL, etc...)
+type ResultSet struct {
+	Columns []*ColumnDesc
+	Rows    [][]*types.Value
+	// count of inserted, updated or deleted rows. 0 on other statements
+	AffectedRows int64
+}
+
+func newResultSet(outSchema *schema.Schema, result []*tuple.Tuple) *ResultSet {
+	if outSchema == nil {
+		return &ResultSet{make([]*ColumnDesc, 0), nil, 0}
+	}
+
+	cols := make([]*ColumnDesc, 0)
+	for _, col := range outSchema.GetColumns() {
+		cols = append(cols, &ColumnDesc{col.GetColumnName(), col.GetType(), !col.IsNotNull()})
+	}
+	return &ResultSet{cols, samehada_util.ConvTupleListToValues(outSchema, result), 0}
+}
+
+// returns nil when rs is nil
+func (rs *ResultSet) getRows() [][]*types.Value {
+	if rs == nil {
+		return nil
+	}
+	return rs.Rows
+}
@@ -0,0 +1,107 @@
+package samehada
+
+import (
+	"errors"
+	"github.com/ryogrid/SamehadaDB/lib/parser"
+	"github.com/ryogrid/SamehadaDB/lib/samehada/samehada_util"
+	"github.com/ryogrid/SamehadaDB/lib/types"
+	"sync"
+)
+
+var NotInTxnErr = errors.New("there is no transaction in progress")
+var SessionClosedErr = errors.New("session is already closed")
+
+// SamehadaSession binds transaction control statements (BEGIN, COMMIT and ROLLBACK)
+// to a client. Statements are executed in auto-commit mode unless BEGIN is executed,
+// and these after BEGIN are executed on a SamehadaTxn until COMMIT or ROLLBACK.
+// This is used by network frontends which need to keep a transaction across requests.
+type SamehadaSession struct {
+	sdb      *SamehadaDB
+	txn      *SamehadaTxn // nil when no transaction is in progress
+	isClosed bool
+	mutex    *sync.Mutex
+}
+
+func (sdb *SamehadaDB) NewSession() *SamehadaSession {
+	return &SamehadaSession{sdb, nil, false, new(sync.Mutex)}
+}
+
+func (sess *SamehadaSession) ExecuteSQL(sqlStr string) (error, [][]interface{}) {
+	err, results := sess.ExecuteSQLRetValues(sqlStr)
+	if err != nil {
+		return err, nil
+	}
+	return nil, samehada_util.ConvValueListToIFs(results)
+}
+
+func (sess *SamehadaSession) ExecuteSQLRetValues(sqlStr string) (error, [][]*types.Value) {
+	err, rs := sess.ExecuteSQLRetResultSet(sqlStr)
+	return err, rs.getRows()
+}
+
+// same as ExecuteSQLRetValues but column descriptors and affected row count are also returned
+func (sess *SamehadaSession) ExecuteSQLRetResultSet(sqlStr string) (error, *ResultSet) {
+	qi, err := sess.sdb.parseAndRewriteSQL(sqlStr)
+	if err != nil {
+		return err, nil
+	}
+	return sess.executeQuery(qi)
+}
+
+// qi should be already rewritten with optimizer.RewriteQueryInfo
+func (sess *SamehadaSession) executeQuery(qi *parser.QueryInfo) (error, *ResultSet) {
+	sess.mutex.Lock()
+	defer sess.mutex.Unlock()
+
+	if sess.isClosed {
+		return SessionClosedErr, nil
+	}
+
+	switch *qi.QueryType_ {
+	case parser.BEGIN:
+		if sess.txn != nil {
+			return TxnAlreadyBegunErr, nil
+		}
+		sess.txn = sess.sdb.BeginTxn()
+		return nil, newResultSet(nil, nil)
+	case parser.COMMIT, parser.ROLLBACK:
+		if sess.txn == nil {
+			return NotInTxnErr, nil
+		}
+	}
+
+	if sess.txn == nil {
+		// auto-commit mode
+		return sess.sdb.executeQueryInfoWithRetry(qi)
+	}
+
+	err, results := sess.txn.executeQuery(qi)
+	if sess.txn.IsFinished() {
+		// committed, rolled back or aborted due to lock conflict
+		sess.txn = nil
+	}
+	return err, results
+}
+
+// true when BEGIN is executed and the transaction is not finished yet
+func (sess *SamehadaSession) InTxn() bool {
+	sess.mutex.Lock()
+	defer sess.mutex.Unlock()
+
+	return sess.txn != nil
+}
+
+// Close rolls back the transaction in progress if exists
+func (sess *SamehadaSession) Close() {
+	sess.mutex.Lock()
+	defer sess.mutex.Unlock()
+
+	if sess.isClosed {
+		return
+	}
+	if sess.txn != nil {
+		sess.txn.Rollback()
+		sess.txn = nil
+	}
+	sess.isClosed = true
+}
@@ -1,15 +1,23 @@
 package samehada_test
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"github.com/ryogrid/SamehadaDB/lib/catalog"
 	"github.com/ryogrid/SamehadaDB/lib/common"
+	"github.com/ryogrid/SamehadaDB/lib/parser"
 	"github.com/ryogrid/SamehadaDB/lib/samehada"
 	"github.com/ryogrid/SamehadaDB/lib/samehada/samehada_util"
+	"github.com/ryogrid/SamehadaDB/lib/storage/index/index_constants"
 	testingpkg "github.com/ryogrid/SamehadaDB/lib/testing/testing_assert"
+	"github.com/ryogrid/SamehadaDB/lib/types"
+	"math"
 	"math/rand"
 	"os"
 	"runtime"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -553,3 +561,1526 @@ func TestRebootAndReturnIFValuesWithCheckpoint(t *testing.T) {
 	db3.Shutdown()
 	common.TempSuppressOnMemStorageMutex.Unlock()
 }
+
+func TestMultiStatementTxnCommitAndRollback(t *testing.T) {
+	// clear all state of DB
+	if !common.EnableOnMemStorage {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	db := samehada.NewSamehadaDB(t.Name(), 200)
+	db.ExecuteSQL("CREATE TABLE name_age_list(name VARCHAR(256), age INT);")
+	db.ExecuteSQL("INSERT INTO name_age_list(name, age) VALUES ('鈴木', 20);")
+
+	// statements on rolled back txn are undone
+	txn := db.BeginTxn()
+	err, _ := txn.ExecuteSQL("INSERT INTO name_age_list(name, age) VALUES ('青木', 22);")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = txn.ExecuteSQL("INSERT INTO name_age_list(name, age) VALUES ('山田', 25);")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = txn.ExecuteSQL("UPDATE name_age_list SET age = 30 WHERE name = '鈴木';")
+	testingpkg.SimpleAssert(t, err == nil)
+	// written records are visible in the txn
+	_, results := txn.ExecuteSQL("SELECT * FROM name_age_list;")
+	testingpkg.SimpleAssert(t, len(results) == 3)
+	testingpkg.SimpleAssert(t, txn.Rollback() == nil)
+	testingpkg.SimpleAssert(t, txn.IsFinished())
+
+	err, _ = txn.ExecuteSQL("SELECT * FROM name_age_list;")
+	testingpkg.SimpleAssert(t, err == samehada.TxnFinishedErr)
+
+	_, results = db.ExecuteSQL("SELECT * FROM name_age_list;")
+	testingpkg.SimpleAssert(t, len(results) == 1)
+	testingpkg.SimpleAssert(t, results[0][1].(int32) == 20)
+
+	// statements on committed txn are persisted (COMMIT statement is used)
+	txn = db.BeginTxn()
+	err, _ = txn.ExecuteSQL("BEGIN;")
+	testingpkg.SimpleAssert(t, err == samehada.TxnAlreadyBegunErr)
+	err, _ = txn.ExecuteSQL("INSERT INTO name_age_list(name, age) VALUES ('青木', 22);")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = txn.ExecuteSQL("INSERT INTO name_age_list(name, age) VALUES ('山田', 25);")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = txn.ExecuteSQL("UPDATE name_age_list SET age = 30 WHERE name = '鈴木';")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = txn.ExecuteSQL("COMMIT;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, txn.IsFinished())
+
+	// every statement on finished txn fails
+	err, _ = txn.ExecuteSQL("BEGIN;")
+	testingpkg.SimpleAssert(t, err == samehada.TxnFinishedErr)
+	err, _ = txn.ExecuteSQL("COMMIT;")
+	testingpkg.SimpleAssert(t, err == samehada.TxnFinishedErr)
+
+	_, results = db.ExecuteSQL("SELECT * FROM name_age_list WHERE age >= 22;")
+	testingpkg.SimpleAssert(t, len(results) == 3)
+
+	// transaction control statements can't be used without txn handle
+	err, _ = db.ExecuteSQL("BEGIN;")
+	testingpkg.SimpleAssert(t, err == samehada.TxnCtrlStmtWithoutTxnErr)
+
+	// DDL is not allowed on txn handle because it can't be rolled back
+	txn = db.BeginTxn()
+	err, _ = txn.ExecuteSQL("CREATE TABLE tmp_list(id INT);")
+	testingpkg.SimpleAssert(t, err == samehada.DDLOnTxnErr)
+	testingpkg.SimpleAssert(t, txn.Rollback() == nil)
+	testingpkg.SimpleAssert(t, db.GetCatalogForTesting().GetTableByName("tmp_list") == nil)
+
+	db.Shutdown()
+}
+
+func TestMultiStatementTxnConflict(t *testing.T) {
+	// clear all state of DB
+	if !common.EnableOnMemStorage {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	db := samehada.NewSamehadaDB(t.Name(), 200)
+	db.ExecuteSQL("CREATE TABLE k_v_list(k INT, v INT);")
+	db.ExecuteSQL("INSERT INTO k_v_list(k, v) VALUES (1, 10);")
+	db.ExecuteSQL("INSERT INTO k_v_list(k, v) VALUES (2, 20);")
+
+	txn1 := db.BeginTxn()
+	txn2 := db.BeginTxn()
+	err, _ := txn2.ExecuteSQL("INSERT INTO k_v_list(k, v) VALUES (3, 30);")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = txn1.ExecuteSQL("UPDATE k_v_list SET v = 11 WHERE k = 1;")
+	testingpkg.SimpleAssert(t, err == nil)
+
+	// txn2 touches the record locked by txn1
+	err, _ = txn2.ExecuteSQL("UPDATE k_v_list SET v = 12 WHERE k = 1;")
+	testingpkg.SimpleAssert(t, err == samehada.TxnAbortedErr)
+	testingpkg.SimpleAssert(t, txn2.IsFinished())
+	err, _ = txn2.ExecuteSQL("SELECT * FROM k_v_list;")
+	testingpkg.SimpleAssert(t, err == samehada.TxnFinishedErr)
+
+	testingpkg.SimpleAssert(t, txn1.Commit() == nil)
+
+	// insertion by txn2 is undone and update by txn1 is persisted
+	_, results := db.ExecuteSQL("SELECT * FROM k_v_list WHERE k = 3;")
+	testingpkg.SimpleAssert(t, len(results) == 0)
+	_, results = db.ExecuteSQL("SELECT * FROM k_v_list WHERE k = 1;")
+	testingpkg.SimpleAssert(t, len(results) == 1)
+	testingpkg.SimpleAssert(t, results[0][1].(int32) == 11)
+
+	db.Shutdown()
+}
+
+func TestMultiStatementTxnWithCheckpoint(t *testing.T) {
+	// clear all state of DB
+	if !common.EnableOnMemStorage {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	db := samehada.NewSamehadaDB(t.Name(), 200)
+	db.ExecuteSQL("CREATE TABLE k_v_list(k INT, v INT);")
+
+	txn := db.BeginTxn()
+	err, _ := txn.ExecuteSQL("INSERT INTO k_v_list(k, v) VALUES (1, 10);")
+	testingpkg.SimpleAssert(t, err == nil)
+
+	// checkpointing and other statements are not blocked by idle txn handle
+	doneCh := make(chan bool)
+	go func() {
+		db.ForceCheckpointingForTestcase()
+		db.ExecuteSQL("INSERT INTO k_v_list(k, v) VALUES (2, 20);")
+		doneCh <- true
+	}()
+	select {
+	case <-doneCh:
+	case <-time.After(10 * time.Second):
+		t.Fatal("checkpointing is blocked by txn handle")
+	}
+
+	err, _ = txn.ExecuteSQL("INSERT INTO k_v_list(k, v) VALUES (3, 30);")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, txn.Commit() == nil)
+
+	_, results := db.ExecuteSQL("SELECT * FROM k_v_list;")
+	testingpkg.SimpleAssert(t, len(results) == 3)
+
+	db.Shutdown()
+}
+
+func TestSessionBindsTxnCtrlStatements(t *testing.T) {
+	// clear all state of DB
+	if !common.EnableOnMemStorage {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	db := samehada.NewSamehadaDB(t.Name(), 200)
+	sess := db.NewSession()
+
+	// auto-commit mode
+	err, _ := sess.ExecuteSQL("CREATE TABLE k_v_list(k INT, v INT);")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = sess.ExecuteSQL("INSERT INTO k_v_list(k, v) VALUES (1, 10);")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, !sess.InTxn())
+	err, _ = sess.ExecuteSQL("COMMIT;")
+	testingpkg.SimpleAssert(t, err == samehada.NotInTxnErr)
+
+	// rolled back
+	err, _ = sess.ExecuteSQL("BEGIN;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, sess.InTxn())
+	err, _ = sess.ExecuteSQL("BEGIN;")
+	testingpkg.SimpleAssert(t, err == samehada.TxnAlreadyBegunErr)
+	err, _ = sess.ExecuteSQL("INSERT INTO k_v_list(k, v) VALUES (2, 20);")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = sess.ExecuteSQL("ROLLBACK;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, !sess.InTxn())
+	_, results := db.ExecuteSQL("SELECT * FROM k_v_list;")
+	testingpkg.SimpleAssert(t, len(results) == 1)
+
+	// committed
+	err, _ = sess.ExecuteSQL("BEGIN;")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = sess.ExecuteSQL("INSERT INTO k_v_list(k, v) VALUES (2, 20);")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = sess.ExecuteSQL("COMMIT;")
+	testingpkg.SimpleAssert(t, err == nil)
+	_, results = db.ExecuteSQL("SELECT * FROM k_v_list;")
+	testingpkg.SimpleAssert(t, len(results) == 2)
+
+	// closing session rolls back transaction in progress
+	sess.ExecuteSQL("BEGIN;")
+	err, _ = sess.ExecuteSQL("INSERT INTO k_v_list(k, v) VALUES (3, 30);")
+	testingpkg.SimpleAssert(t, err == nil)
+	sess.Close()
+	err, _ = sess.ExecuteSQL("SELECT * FROM k_v_list;")
+	testingpkg.SimpleAssert(t, err == samehada.SessionClosedErr)
+	_, results = db.ExecuteSQL("SELECT * FROM k_v_list;")
+	testingpkg.SimpleAssert(t, len(results) == 2)
+
+	db.Shutdown()
+}
+
+func TestDescribeSQLWithPlaceholders(t *testing.T) {
+	// clear all state of DB
+	if !common.EnableOnMemStorage {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	db := samehada.NewSamehadaDB(t.Name(), 200)
+	db.ExecuteSQL("CREATE TABLE name_age_list(name VARCHAR(256), age INT, height FLOAT);")
+
+	err, desc := db.DescribeSQL("SELECT name, height FROM name_age_list WHERE age > $1 AND name = $2;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, len(desc.ParamTypes) == 2)
+	testingpkg.SimpleAssert(t, desc.ParamTypes[0] == types.Integer)
+	testingpkg.SimpleAssert(t, desc.ParamTypes[1] == types.Varchar)
+	testingpkg.SimpleAssert(t, len(desc.Columns) == 2)
+	testingpkg.SimpleAssert(t, desc.Columns[0].Name == "name_age_list.name")
+	testingpkg.SimpleAssert(t, desc.Columns[0].Type == types.Varchar)
+	testingpkg.SimpleAssert(t, desc.Columns[1].Type == types.Float)
+
+	err, desc = db.DescribeSQL("INSERT INTO name_age_list(name, age, height) VALUES ($1, $2, $3);")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, len(desc.Columns) == 0)
+	testingpkg.SimpleAssert(t, desc.ParamTypes[0] == types.Varchar)
+	testingpkg.SimpleAssert(t, desc.ParamTypes[1] == types.Integer)
+	testingpkg.SimpleAssert(t, desc.ParamTypes[2] == types.Float)
+
+	err, desc = db.DescribeSQL("UPDATE name_age_list SET height = $1 WHERE name = $2;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, desc.ParamTypes[0] == types.Float)
+	testingpkg.SimpleAssert(t, desc.ParamTypes[1] == types.Varchar)
+
+	// describing does not execute the statement
+	_, results := db.ExecuteSQL("SELECT * FROM name_age_list;")
+	testingpkg.SimpleAssert(t, len(results) == 0)
+
+	err, _ = db.DescribeSQL("SELECT name FROM no_such_table WHERE age = $1;")
+	testingpkg.SimpleAssert(t, err != nil)
+
+	db.Shutdown()
+}
+
+func TestResultSetColumnsAndAffectedRows(t *testing.T) {
+	// clear all state of DB
+	if !common.EnableOnMemStorage {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	db := samehada.NewSamehadaDB(t.Name(), 200)
+	db.ExecuteSQL("CREATE TABLE name_age_list(name VARCHAR(256), age INT);")
+	db.ExecuteSQL("CREATE TABLE name_height_list(name VARCHAR(256), height FLOAT);")
+	db.ExecuteSQL("INSERT INTO name_height_list(name, height) VALUES ('鈴木', 170.5);")
+
+	err, rs := db.ExecuteSQLRetResultSet("INSERT INTO name_age_list(name, age) VALUES ('鈴木', 20);")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, rs.AffectedRows == 1)
+	testingpkg.SimpleAssert(t, len(rs.Columns) == 0)
+	db.ExecuteSQL("INSERT INTO name_age_list(name, age) VALUES ('佐藤', 30);")
+	db.ExecuteSQL("INSERT INTO name_age_list(name, age) VALUES ('山田', 40);")
+
+	// columns of joined tables can be distinguished
+	err, rs = db.ExecuteSQLRetResultSet("SELECT * FROM name_age_list JOIN name_height_list ON name_age_list.name = name_height_list.name;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, rs.AffectedRows == 0)
+	testingpkg.SimpleAssert(t, len(rs.Rows) == 1)
+	testingpkg.SimpleAssert(t, len(rs.Columns) == 4)
+	testingpkg.SimpleAssert(t, rs.Columns[0].Name == "name_age_list.name")
+	testingpkg.SimpleAssert(t, rs.Columns[1].Name == "name_age_list.age")
+	testingpkg.SimpleAssert(t, rs.Columns[1].Type == types.Integer)
+	testingpkg.SimpleAssert(t, rs.Columns[1].TypeName() == "INT")
+	testingpkg.SimpleAssert(t, rs.Columns[2].Name == "name_height_list.name")
+	testingpkg.SimpleAssert(t, rs.Columns[3].Type == types.Float)
+	testingpkg.SimpleAssert(t, rs.Columns[3].Nullable)
+
+	err, rs = db.ExecuteSQLRetResultSet("UPDATE name_age_list SET age = 50 WHERE age >= 30;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, rs.AffectedRows == 2)
+
+	err, rs = db.ExecuteSQLRetResultSet("DELETE FROM name_age_list WHERE age = 50;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, rs.AffectedRows == 2)
+
+	err, rs = db.ExecuteSQLRetResultSet("DELETE FROM name_age_list WHERE age = 50;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, rs.AffectedRows == 0)
+
+	db.Shutdown()
+}
+
+func TestPreparedStatement(t *testing.T) {
+	// clear all state of DB
+	if !common.EnableOnMemStorage {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	db := samehada.NewSamehadaDB(t.Name(), 200)
+	db.ExecuteSQL("CREATE TABLE name_age_list(name VARCHAR(256), age INT, height FLOAT);")
+
+	err, insStmt := db.Prepare("INSERT INTO name_age_list(name, age, height) VALUES (?, ?, ?);")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, insStmt.ParamNum() == 3)
+	// integer is converted to float
+	err, rs := db.ExecutePrepared(insStmt, samehada_util.GetPonterOfValue(types.NewVarchar("O'Brien")),
+		samehada_util.GetPonterOfValue(types.NewInteger(20)), samehada_util.GetPonterOfValue(types.NewInteger(180)))
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, rs.AffectedRows == 1)
+	args, err := insStmt.ConvIFsToArgs("鈴木", int64(30), 170.5)
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = db.ExecutePrepared(insStmt, args...)
+	testingpkg.SimpleAssert(t, err == nil)
+	args, _ = insStmt.ConvIFsToArgs("佐藤", 40, nil)
+	err, _ = db.ExecutePrepared(insStmt, args...)
+	testingpkg.SimpleAssert(t, err == nil)
+
+	// wrong number or type of parameters
+	err, _ = db.ExecutePrepared(insStmt, samehada_util.GetPonterOfValue(types.NewVarchar("山田")))
+	testingpkg.SimpleAssert(t, err == samehada.ParamNumMismatchErr)
+	_, err = insStmt.ConvIFsToArgs("山田", "50", 160.0)
+	testingpkg.SimpleAssert(t, errors.Is(err, samehada.ParamTypeMismatchErr))
+
+	// same statement is executed with different parameters
+	err, selStmt := db.Prepare("SELECT name, height FROM name_age_list WHERE age >= $1 AND name != $2;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, len(selStmt.Describe().Columns) == 2)
+	args, _ = selStmt.ConvIFsToArgs(30, "佐藤")
+	err, rs = db.ExecutePrepared(selStmt, args...)
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, len(rs.Rows) == 1)
+	testingpkg.SimpleAssert(t, rs.Rows[0][0].ToVarchar() == "鈴木")
+	args, _ = selStmt.ConvIFsToArgs(0, "鈴木")
+	err, rs = db.ExecutePrepared(selStmt, args...)
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, len(rs.Rows) == 2)
+
+	// placeholder value is not interpreted as SQL
+	args, _ = selStmt.ConvIFsToArgs(0, "x' OR name = 'O''Brien")
+	err, rs = db.ExecutePrepared(selStmt, args...)
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, len(rs.Rows) == 3)
+
+	err, _ = db.Prepare("SELECT name FROM name_age_list WHERE age = ? AND name = $2;")
+	testingpkg.SimpleAssert(t, err == samehada_util.MixedPlaceholderStyleErr)
+
+	// prepared statement on a transaction
+	err, updStmt := db.Prepare("UPDATE name_age_list SET height = $1 WHERE name = $2;")
+	testingpkg.SimpleAssert(t, err == nil)
+	txn := db.BeginTxn()
+	args, _ = updStmt.ConvIFsToArgs(175.5, "佐藤")
+	err, rs = txn.ExecutePrepared(updStmt, args...)
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, rs.AffectedRows == 1)
+	txn.Rollback()
+
+	args, _ = selStmt.ConvIFsToArgs(40, "")
+	err, rs = db.ExecutePrepared(selStmt, args...)
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, len(rs.Rows) == 1)
+	testingpkg.SimpleAssert(t, rs.Rows[0][1].IsNull())
+
+	db.Shutdown()
+}
+
+func TestCreateAndDropIndex(t *testing.T) {
+	common.TempSuppressOnMemStorageMutex.Lock()
+	common.TempSuppressOnMemStorage = true
+
+	// clear all state of DB
+	if !common.EnableOnMemStorage || common.TempSuppressOnMemStorage == true {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	db := samehada.NewSamehadaDB(t.Name(), 10*1024)
+	db.ExecuteSQL("CREATE TABLE name_age_list(name VARCHAR(256), age INT);")
+	db.ExecuteSQL("INSERT INTO name_age_list(name, age) VALUES ('鈴木', 20);")
+	db.ExecuteSQL("INSERT INTO name_age_list(name, age) VALUES ('青木', 22);")
+	db.ExecuteSQL("INSERT INTO name_age_list(name, age) VALUES ('山田', 20);")
+
+	// columns of table created with SQL have skip list index
+	err, _ := db.ExecuteSQL("CREATE INDEX age_idx ON name_age_list(age) USING HASH;")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.ColumnAlreadyIndexedErr))
+	err, _ = db.ExecuteSQL("DROP INDEX age_index ON name_age_list;")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = db.ExecuteSQL("DROP INDEX age_index ON name_age_list;")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.IndexNotFoundErr))
+
+	// index is filled with existing rows
+	err, _ = db.ExecuteSQL("CREATE INDEX age_idx ON name_age_list(age) USING HASH;")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = db.ExecuteSQL("CREATE INDEX age_idx ON name_age_list(name);")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.IndexAlreadyExistsErr))
+	db.ExecuteSQL("INSERT INTO name_age_list(name, age) VALUES ('加藤', 20);")
+	_, results := db.ExecuteSQL("SELECT * FROM name_age_list WHERE age = 20;")
+	testingpkg.SimpleAssert(t, len(results) == 3)
+	// range scan is not done with hash index
+	_, results = db.ExecuteSQL("SELECT * FROM name_age_list WHERE age > 20;")
+	testingpkg.SimpleAssert(t, len(results) == 1)
+
+	// unique index can't be created when there are duplicated values
+	db.ExecuteSQL("DROP INDEX name_index ON name_age_list;")
+	db.ExecuteSQL("INSERT INTO name_age_list(name, age) VALUES ('鈴木', 30);")
+	err, _ = db.ExecuteSQL("CREATE INDEX name_idx ON name_age_list(name) USING UNIQUE SKIPLIST;")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.DuplicateKeyErr))
+	db.ExecuteSQL("DELETE FROM name_age_list WHERE age = 30;")
+	err, _ = db.ExecuteSQL("CREATE INDEX name_idx ON name_age_list(name) USING UNIQUE SKIPLIST;")
+	testingpkg.SimpleAssert(t, err == nil)
+	_, results = db.ExecuteSQL("SELECT * FROM name_age_list WHERE name = '青木';")
+	testingpkg.SimpleAssert(t, len(results) == 1)
+
+	// DDL is not allowed in a transaction
+	txn := db.BeginTxn()
+	err, _ = txn.ExecuteSQL("DROP INDEX name_idx ON name_age_list;")
+	testingpkg.SimpleAssert(t, errors.Is(err, samehada.DDLOnTxnErr))
+	txn.Rollback()
+
+	db.Shutdown()
+
+	// created and dropped indexes are persisted
+	db2 := samehada.NewSamehadaDB(t.Name(), 10*1024)
+	sc := db2.GetCatalogForTesting().GetTableByName("name_age_list").Schema()
+	nameCol := sc.GetColumn(sc.GetColIndex("name"))
+	testingpkg.SimpleAssert(t, nameCol.IndexKind() == index_constants.INDEX_KIND_UNIQ_SKIP_LIST)
+	testingpkg.SimpleAssert(t, nameCol.IndexName() == "name_idx")
+	ageCol := sc.GetColumn(sc.GetColIndex("age"))
+	testingpkg.SimpleAssert(t, ageCol.IndexKind() == index_constants.INDEX_KIND_HASH)
+	testingpkg.SimpleAssert(t, ageCol.IndexName() == "age_idx")
+	_, results = db2.ExecuteSQL("SELECT * FROM name_age_list WHERE age = 20;")
+	testingpkg.SimpleAssert(t, len(results) == 3)
+	_, results = db2.ExecuteSQL("SELECT * FROM name_age_list WHERE name = '青木';")
+	testingpkg.SimpleAssert(t, len(results) == 1)
+	err, _ = db2.ExecuteSQL("DROP INDEX age_idx ON name_age_list;")
+	testingpkg.SimpleAssert(t, err == nil)
+	_, results = db2.ExecuteSQL("SELECT * FROM name_age_list WHERE age = 20;")
+	testingpkg.SimpleAssert(t, len(results) == 3)
+
+	common.TempSuppressOnMemStorage = false
+	db2.Shutdown()
+	common.TempSuppressOnMemStorageMutex.Unlock()
+}
+
+func TestDropTruncateAndAlterTable(t *testing.T) {
+	common.TempSuppressOnMemStorageMutex.Lock()
+	common.TempSuppressOnMemStorage = true
+
+	// clear all state of DB
+	if !common.EnableOnMemStorage || common.TempSuppressOnMemStorage == true {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	db := samehada.NewSamehadaDB(t.Name(), 10*1024)
+	db.ExecuteSQL("CREATE TABLE name_age_list(name VARCHAR(256), age INT);")
+	db.ExecuteSQL("INSERT INTO name_age_list(name, age) VALUES ('鈴木', 20);")
+	db.ExecuteSQL("INSERT INTO name_age_list(name, age) VALUES ('青木', 22);")
+	db.ExecuteSQL("INSERT INTO name_age_list(name, age) VALUES ('山田', 25);")
+	db.ExecuteSQL("CREATE TABLE item_list(name VARCHAR(256), price INT);")
+	db.ExecuteSQL("INSERT INTO item_list(name, price) VALUES ('Laptop PC', 1000);")
+
+	// added column is filled with NULL
+	err, _ := db.ExecuteSQL("ALTER TABLE name_age_list ADD COLUMN height FLOAT;")
+	testingpkg.SimpleAssert(t, err == nil)
+	_, results := db.ExecuteSQL("SELECT * FROM name_age_list;")
+	testingpkg.SimpleAssert(t, len(results) == 3)
+	for _, row := range results {
+		testingpkg.SimpleAssert(t, len(row) == 3)
+		testingpkg.SimpleAssert(t, row[2] == nil)
+	}
+	db.ExecuteSQL("INSERT INTO name_age_list(name, age, height) VALUES ('加藤', 18, 170.5);")
+	_, results = db.ExecuteSQL("SELECT name FROM name_age_list WHERE height = 170.5;")
+	testingpkg.SimpleAssert(t, len(results) == 1 && results[0][0].(string) == "加藤")
+	err, _ = db.ExecuteSQL("ALTER TABLE name_age_list ADD COLUMN age INT;")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.ColumnAlreadyExistsErr))
+
+	// values of remaining columns and indexes are kept
+	err, _ = db.ExecuteSQL("ALTER TABLE name_age_list DROP COLUMN age;")
+	testingpkg.SimpleAssert(t, err == nil)
+	_, results = db.ExecuteSQL("SELECT * FROM name_age_list WHERE name = '青木';")
+	testingpkg.SimpleAssert(t, len(results) == 1 && len(results[0]) == 2)
+	err, _ = db.ExecuteSQL("SELECT age FROM name_age_list;")
+	testingpkg.SimpleAssert(t, err != nil)
+	err, _ = db.ExecuteSQL("ALTER TABLE name_age_list DROP COLUMN age;")
+	testingpkg.SimpleAssert(t, err != nil)
+
+	err, _ = db.ExecuteSQL("TRUNCATE TABLE name_age_list;")
+	testingpkg.SimpleAssert(t, err == nil)
+	_, results = db.ExecuteSQL("SELECT * FROM name_age_list;")
+	testingpkg.SimpleAssert(t, len(results) == 0)
+	db.ExecuteSQL("INSERT INTO name_age_list(name, height) VALUES ('木村', 160.5);")
+	_, results = db.ExecuteSQL("SELECT * FROM name_age_list WHERE name = '木村';")
+	testingpkg.SimpleAssert(t, len(results) == 1)
+
+	// DDL is not allowed in a transaction
+	txn := db.BeginTxn()
+	err, _ = txn.ExecuteSQL("TRUNCATE TABLE item_list;")
+	testingpkg.SimpleAssert(t, errors.Is(err, samehada.DDLOnTxnErr))
+	txn.Rollback()
+
+	err, _ = db.ExecuteSQL("DROP TABLE item_list;")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = db.ExecuteSQL("SELECT * FROM item_list;")
+	testingpkg.SimpleAssert(t, err != nil)
+	err, _ = db.ExecuteSQL("DROP TABLE item_list;")
+	testingpkg.SimpleAssert(t, err != nil)
+	// same name can be used again
+	db.ExecuteSQL("CREATE TABLE item_list(name VARCHAR(256), stock INT);")
+	db.ExecuteSQL("INSERT INTO item_list(name, stock) VALUES ('3D Printer', 3);")
+
+	db.Shutdown()
+
+	// dropped and altered tables are persisted
+	db2 := samehada.NewSamehadaDB(t.Name(), 10*1024)
+	_, results = db2.ExecuteSQL("SELECT * FROM name_age_list;")
+	testingpkg.SimpleAssert(t, len(results) == 1 && len(results[0]) == 2)
+	testingpkg.SimpleAssert(t, results[0][0].(string) == "木村" && results[0][1].(float32) == 160.5)
+	_, results = db2.ExecuteSQL("SELECT * FROM item_list;")
+	testingpkg.SimpleAssert(t, len(results) == 1 && results[0][1].(int32) == 3)
+	// table created after relaunch doesn't conflict with existing ones
+	db2.ExecuteSQL("CREATE TABLE order_list(item VARCHAR(256), num INT);")
+	db2.ExecuteSQL("INSERT INTO order_list(item, num) VALUES ('3D Printer', 1);")
+	_, results = db2.ExecuteSQL("SELECT * FROM item_list;")
+	testingpkg.SimpleAssert(t, len(results) == 1 && results[0][1].(int32) == 3)
+	_, results = db2.ExecuteSQL("SELECT * FROM order_list;")
+	testingpkg.SimpleAssert(t, len(results) == 1)
+
+	common.TempSuppressOnMemStorage = false
+	db2.Shutdown()
+	common.TempSuppressOnMemStorageMutex.Unlock()
+}
+
+func TestCompositeIndex(t *testing.T) {
+	common.TempSuppressOnMemStorageMutex.Lock()
+	common.TempSuppressOnMemStorage = true
+
+	// clear all state of DB
+	if !common.EnableOnMemStorage || common.TempSuppressOnMemStorage == true {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	db := samehada.NewSamehadaDB(t.Name(), 10*1024)
+	db.ExecuteSQL("CREATE TABLE order_list(customer VARCHAR(256), item_id INT, num INT);")
+	// composite index is used instead of indexes of each column
+	db.ExecuteSQL("DROP INDEX customer_index ON order_list;")
+	db.ExecuteSQL("DROP INDEX item_id_index ON order_list;")
+	for ii := 0; ii < 100; ii++ {
+		db.ExecuteSQL(fmt.Sprintf("INSERT INTO order_list(customer, item_id, num) VALUES ('customer%d', %d, %d);", ii%5, ii%20, ii))
+	}
+
+	err, _ := db.ExecuteSQL("CREATE INDEX customer_item_idx ON order_list(customer, item_id) USING HASH;")
+	testingpkg.SimpleAssert(t, err != nil)
+	err, _ = db.ExecuteSQL("CREATE INDEX customer_item_idx ON order_list(customer, item_id);")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = db.ExecuteSQL("CREATE INDEX customer_item_idx ON order_list(num, item_id);")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.IndexAlreadyExistsErr))
+
+	_, results := db.ExecuteSQL("SELECT num FROM order_list WHERE customer = 'customer1' AND item_id = 6;")
+	testingpkg.SimpleAssert(t, len(results) == 5)
+	_, results = db.ExecuteSQL("SELECT num FROM order_list WHERE customer = 'customer1' AND item_id >= 6 AND item_id < 16;")
+	testingpkg.SimpleAssert(t, len(results) == 10)
+	_, results = db.ExecuteSQL("SELECT num FROM order_list WHERE customer = 'customer1';")
+	testingpkg.SimpleAssert(t, len(results) == 20)
+
+	// index entries are updated by UPDATE and DELETE
+	db.ExecuteSQL("UPDATE order_list SET item_id = 100 WHERE num = 6;")
+	db.ExecuteSQL("DELETE FROM order_list WHERE num = 26;")
+	_, results = db.ExecuteSQL("SELECT num FROM order_list WHERE customer = 'customer1' AND item_id = 6;")
+	testingpkg.SimpleAssert(t, len(results) == 3)
+	_, results = db.ExecuteSQL("SELECT num FROM order_list WHERE customer = 'customer1' AND item_id = 100;")
+	testingpkg.SimpleAssert(t, len(results) == 1 && results[0][0].(int32) == 6)
+
+	// index entries are restored at rollback
+	txn := db.BeginTxn()
+	txn.ExecuteSQL("UPDATE order_list SET item_id = 200 WHERE num = 46;")
+	txn.ExecuteSQL("DELETE FROM order_list WHERE num = 66;")
+	txn.ExecuteSQL("INSERT INTO order_list(customer, item_id, num) VALUES ('customer1', 6, 1000);")
+	txn.Rollback()
+	_, results = db.ExecuteSQL("SELECT num FROM order_list WHERE customer = 'customer1' AND item_id = 6;")
+	testingpkg.SimpleAssert(t, len(results) == 3)
+	_, results = db.ExecuteSQL("SELECT num FROM order_list WHERE customer = 'customer1' AND item_id = 200;")
+	testingpkg.SimpleAssert(t, len(results) == 0)
+
+	db.Shutdown()
+
+	// composite index is persisted and its data is reconstructed at launch
+	db2 := samehada.NewSamehadaDB(t.Name(), 10*1024)
+	testingpkg.SimpleAssert(t, db2.GetCatalogForTesting().GetTableByName("order_list").GetCompositeIndex("customer_item_idx") != nil)
+	_, results = db2.ExecuteSQL("SELECT num FROM order_list WHERE customer = 'customer1' AND item_id = 6;")
+	testingpkg.SimpleAssert(t, len(results) == 3)
+
+	// composite index is kept at adding column and dropped with its key column
+	err, _ = db2.ExecuteSQL("ALTER TABLE order_list ADD COLUMN price INT;")
+	testingpkg.SimpleAssert(t, err == nil)
+	_, results = db2.ExecuteSQL("SELECT num FROM order_list WHERE customer = 'customer1' AND item_id = 6;")
+	testingpkg.SimpleAssert(t, len(results) == 3)
+	testingpkg.SimpleAssert(t, db2.GetCatalogForTesting().GetTableByName("order_list").GetCompositeIndex("customer_item_idx") != nil)
+	err, _ = db2.ExecuteSQL("ALTER TABLE order_list DROP COLUMN item_id;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, db2.GetCatalogForTesting().GetTableByName("order_list").GetCompositeIndex("customer_item_idx") == nil)
+
+	db2.ExecuteSQL("CREATE INDEX customer_num_idx ON order_list(customer, num);")
+	_, results = db2.ExecuteSQL("SELECT num FROM order_list WHERE customer = 'customer1' AND num < 50;")
+	testingpkg.SimpleAssert(t, len(results) == 9)
+	err, _ = db2.ExecuteSQL("DROP INDEX customer_num_idx ON order_list;")
+	testingpkg.SimpleAssert(t, err == nil)
+	_, results = db2.ExecuteSQL("SELECT num FROM order_list WHERE customer = 'customer1' AND num < 50;")
+	testingpkg.SimpleAssert(t, len(results) == 9)
+
+	common.TempSuppressOnMemStorage = false
+	db2.Shutdown()
+	common.TempSuppressOnMemStorageMutex.Unlock()
+}
+
+func TestConstraints(t *testing.T) {
+	common.TempSuppressOnMemStorageMutex.Lock()
+	common.TempSuppressOnMemStorage = true
+
+	// clear all state of DB
+	if !common.EnableOnMemStorage || common.TempSuppressOnMemStorage == true {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	db := samehada.NewSamehadaDB(t.Name(), 10*1024)
+	err, _ := db.ExecuteSQL("CREATE TABLE account(id INT PRIMARY KEY, mail VARCHAR(256) UNIQUE, name VARCHAR(256) NOT NULL, age INT CHECK (age >= 0 AND age < 200));")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = db.ExecuteSQL("CREATE TABLE order_list(customer VARCHAR(256), item_id INT, num INT, PRIMARY KEY (customer, item_id));")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = db.ExecuteSQL("CREATE TABLE broken(a INT, CHECK (b > 0));")
+	testingpkg.SimpleAssert(t, err != nil)
+
+	err, _ = db.ExecuteSQL("INSERT INTO account(id, mail, name, age) VALUES (1, 'a@example.com', 'alice', 20);")
+	testingpkg.SimpleAssert(t, err == nil)
+
+	// PRIMARY KEY
+	err, _ = db.ExecuteSQL("INSERT INTO account(id, mail, name, age) VALUES (1, 'b@example.com', 'bob', 30);")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.DuplicateKeyErr))
+	err, _ = db.ExecuteSQL("INSERT INTO account(mail, name, age) VALUES ('b@example.com', 'bob', 30);")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.NotNullViolationErr))
+
+	// UNIQUE allows multiple NULLs
+	err, _ = db.ExecuteSQL("INSERT INTO account(id, mail, name, age) VALUES (2, 'a@example.com', 'bob', 30);")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.DuplicateKeyErr))
+	err, _ = db.ExecuteSQL("INSERT INTO account(id, name, age) VALUES (2, 'bob', 30);")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = db.ExecuteSQL("INSERT INTO account(id, mail, name, age) VALUES (3, NULL, 'carol', 40);")
+	testingpkg.SimpleAssert(t, err == nil)
+
+	// NOT NULL
+	err, _ = db.ExecuteSQL("INSERT INTO account(id, mail, age) VALUES (4, 'd@example.com', 50);")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.NotNullViolationErr))
+
+	// CHECK. NULL satisfies the constraint
+	err, _ = db.ExecuteSQL("INSERT INTO account(id, mail, name, age) VALUES (4, 'd@example.com', 'dave', 250);")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.CheckViolationErr))
+	err, _ = db.ExecuteSQL("INSERT INTO account(id, mail, name) VALUES (4, 'd@example.com', 'dave');")
+	testingpkg.SimpleAssert(t, err == nil)
+
+	// composite PRIMARY KEY
+	err, _ = db.ExecuteSQL("INSERT INTO order_list(customer, item_id, num) VALUES ('alice', 1, 10);")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = db.ExecuteSQL("INSERT INTO order_list(customer, item_id, num) VALUES ('alice', 2, 10);")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = db.ExecuteSQL("INSERT INTO order_list(customer, item_id, num) VALUES ('alice', 1, 20);")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.DuplicateKeyErr))
+	err, _ = db.ExecuteSQL("INSERT INTO order_list(item_id, num) VALUES (1, 20);")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.NotNullViolationErr))
+
+	// UPDATE is also validated and updating row to its own key is allowed
+	err, _ = db.ExecuteSQL("UPDATE account SET id = 1 WHERE name = 'bob';")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.DuplicateKeyErr))
+	err, _ = db.ExecuteSQL("UPDATE account SET age = 300 WHERE id = 2;")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.CheckViolationErr))
+	err, _ = db.ExecuteSQL("UPDATE account SET id = 2, age = 31 WHERE id = 2;")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = db.ExecuteSQL("UPDATE order_list SET item_id = 2 WHERE item_id = 1;")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.DuplicateKeyErr))
+
+	// violation in txn aborts the txn
+	txn := db.BeginTxn()
+	err, _ = txn.ExecuteSQL("INSERT INTO account(id, mail, name, age) VALUES (5, 'e@example.com', 'eve', 50);")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = txn.ExecuteSQL("INSERT INTO account(id, mail, name, age) VALUES (5, 'f@example.com', 'frank', 60);")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.DuplicateKeyErr))
+	_, results := db.ExecuteSQL("SELECT id FROM account WHERE id = 5;")
+	testingpkg.SimpleAssert(t, len(results) == 0)
+
+	// deleted key can be reused
+	db.ExecuteSQL("DELETE FROM account WHERE id = 4;")
+	err, _ = db.ExecuteSQL("INSERT INTO account(id, mail, name, age) VALUES (4, 'd@example.com', 'dave', 60);")
+	testingpkg.SimpleAssert(t, err == nil)
+
+	db.Shutdown()
+
+	// constraints are persisted
+	db2 := samehada.NewSamehadaDB(t.Name(), 10*1024)
+	_, results = db2.ExecuteSQL("SELECT id FROM account;")
+	testingpkg.SimpleAssert(t, len(results) == 4)
+	err, rs := db2.ExecuteSQLRetResultSet("SELECT id, mail, name FROM account;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, !rs.Columns[0].Nullable && rs.Columns[1].Nullable && !rs.Columns[2].Nullable)
+	err, _ = db2.ExecuteSQL("INSERT INTO account(id, mail, name, age) VALUES (1, 'g@example.com', 'grace', 20);")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.DuplicateKeyErr))
+	err, _ = db2.ExecuteSQL("INSERT INTO account(id, mail, age) VALUES (6, 'g@example.com', 20);")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.NotNullViolationErr))
+	err, _ = db2.ExecuteSQL("INSERT INTO account(id, mail, name, age) VALUES (6, 'g@example.com', 'grace', 200);")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.CheckViolationErr))
+	err, _ = db2.ExecuteSQL("INSERT INTO order_list(customer, item_id, num) VALUES ('alice', 2, 20);")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.DuplicateKeyErr))
+
+	// CHECK constraint follows the column position at ALTER TABLE
+	err, _ = db2.ExecuteSQL("ALTER TABLE account DROP COLUMN mail;")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = db2.ExecuteSQL("INSERT INTO account(id, name, age) VALUES (6, 'grace', 200);")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.CheckViolationErr))
+	err, _ = db2.ExecuteSQL("ALTER TABLE account ADD COLUMN score INT NOT NULL;")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.NotNullViolationErr))
+
+	common.TempSuppressOnMemStorage = false
+	db2.Shutdown()
+	common.TempSuppressOnMemStorageMutex.Unlock()
+}
+
+func TestGroupByAndAggregation(t *testing.T) {
+	common.TempSuppressOnMemStorageMutex.Lock()
+	common.TempSuppressOnMemStorage = true
+
+	// clear all state of DB
+	if !common.EnableOnMemStorage || common.TempSuppressOnMemStorage == true {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	db := samehada.NewSamehadaDB(t.Name(), 10*1024)
+	db.ExecuteSQL("CREATE TABLE employee(name VARCHAR(256), dept INT, salary INT);")
+	db.ExecuteSQL("CREATE TABLE dept_info(id INT, dname VARCHAR(256));")
+	db.ExecuteSQL("CREATE TABLE empty_tbl(a INT);")
+	db.ExecuteSQL("INSERT INTO employee(name, dept, salary) VALUES ('a', 1, 100);")
+	db.ExecuteSQL("INSERT INTO employee(name, dept, salary) VALUES ('b', 1, 200);")
+	db.ExecuteSQL("INSERT INTO employee(name, dept, salary) VALUES ('c', 2, 300);")
+	db.ExecuteSQL("INSERT INTO employee(name, dept, salary) VALUES ('d', 2, 300);")
+	db.ExecuteSQL("INSERT INTO employee(name, dept, salary) VALUES ('e', 3, 500);")
+	db.ExecuteSQL("INSERT INTO employee(name, dept) VALUES ('f', 3);")
+	db.ExecuteSQL("INSERT INTO dept_info(id, dname) VALUES (1, 'sales');")
+	db.ExecuteSQL("INSERT INTO dept_info(id, dname) VALUES (2, 'sales');")
+	db.ExecuteSQL("INSERT INTO dept_info(id, dname) VALUES (3, 'dev');")
+
+	// order of groups is not defined
+	rowsByFirstCol := func(results [][]interface{}) map[interface{}][]interface{} {
+		ret := make(map[interface{}][]interface{})
+		for _, row := range results {
+			ret[row[0]] = row
+		}
+		return ret
+	}
+
+	// NULL is ignored except COUNT(*)
+	err, results := db.ExecuteSQL("SELECT dept, COUNT(*), COUNT(salary), SUM(salary), AVG(salary), COUNT(DISTINCT salary) FROM employee GROUP BY dept;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, len(results) == 3)
+	rows := rowsByFirstCol(results)
+	testingpkg.SimpleAssert(t, rows[int32(1)][1].(int32) == 2 && rows[int32(1)][2].(int32) == 2 && rows[int32(1)][3].(int32) == 300)
+	testingpkg.SimpleAssert(t, rows[int32(1)][4].(float32) == 150 && rows[int32(1)][5].(int32) == 2)
+	testingpkg.SimpleAssert(t, rows[int32(2)][3].(int32) == 600 && rows[int32(2)][4].(float32) == 300 && rows[int32(2)][5].(int32) == 1)
+	testingpkg.SimpleAssert(t, rows[int32(3)][1].(int32) == 2 && rows[int32(3)][2].(int32) == 1 && rows[int32(3)][4].(float32) == 500)
+
+	err, rs := db.ExecuteSQLRetResultSet("SELECT dept, COUNT(*), AVG(salary) FROM employee GROUP BY dept;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, rs.Columns[1].Name == "count(*)" && rs.Columns[2].Name == "avg(employee.salary)")
+
+	// HAVING
+	_, results = db.ExecuteSQL("SELECT dept, SUM(salary) FROM employee GROUP BY dept HAVING SUM(salary) > 400;")
+	testingpkg.SimpleAssert(t, len(results) == 2)
+	rows = rowsByFirstCol(results)
+	testingpkg.SimpleAssert(t, rows[int32(2)] != nil && rows[int32(3)] != nil)
+	_, results = db.ExecuteSQL("SELECT dept FROM employee GROUP BY dept HAVING AVG(salary) >= 300 AND dept < 3;")
+	testingpkg.SimpleAssert(t, len(results) == 1 && results[0][0].(int32) == 2)
+	_, results = db.ExecuteSQL("SELECT COUNT(*) FROM employee GROUP BY dept HAVING MAX(name) = 'f';")
+	testingpkg.SimpleAssert(t, len(results) == 1 && results[0][0].(int32) == 2)
+
+	// without GROUP BY
+	_, results = db.ExecuteSQL("SELECT MIN(name), MAX(salary), COUNT(*) FROM employee WHERE dept = 1;")
+	testingpkg.SimpleAssert(t, len(results) == 1)
+	testingpkg.SimpleAssert(t, results[0][0].(string) == "a" && results[0][1].(int32) == 200 && results[0][2].(int32) == 2)
+	_, results = db.ExecuteSQL("SELECT COUNT(*) FROM employee WHERE dept = 1 OR dept = 3;")
+	testingpkg.SimpleAssert(t, len(results) == 1 && results[0][0].(int32) == 4)
+
+	// aggregation on empty input returns a row
+	_, results = db.ExecuteSQL("SELECT COUNT(*), SUM(a) FROM empty_tbl;")
+	testingpkg.SimpleAssert(t, len(results) == 1 && results[0][0].(int32) == 0 && results[0][1] == nil)
+	_, results = db.ExecuteSQL("SELECT a, COUNT(*) FROM empty_tbl GROUP BY a;")
+	testingpkg.SimpleAssert(t, len(results) == 0)
+
+	// aggregation over join
+	_, results = db.ExecuteSQL("SELECT dept_info.dname, COUNT(*), SUM(employee.salary) FROM employee, dept_info WHERE employee.dept = dept_info.id GROUP BY dept_info.dname;")
+	testingpkg.SimpleAssert(t, len(results) == 2)
+	rows = rowsByFirstCol(results)
+	testingpkg.SimpleAssert(t, rows["sales"][1].(int32) == 4 && rows["sales"][2].(int32) == 900)
+	testingpkg.SimpleAssert(t, rows["dev"][1].(int32) == 2 && rows["dev"][2].(int32) == 500)
+
+	// column which is not grouped
+	err, _ = db.ExecuteSQL("SELECT name, COUNT(*) FROM employee GROUP BY dept;")
+	testingpkg.SimpleAssert(t, err != nil)
+	// SUM of VARCHAR
+	err, _ = db.ExecuteSQL("SELECT SUM(name) FROM employee;")
+	testingpkg.SimpleAssert(t, err != nil)
+
+	common.TempSuppressOnMemStorage = false
+	db.Shutdown()
+	common.TempSuppressOnMemStorageMutex.Unlock()
+}
+
+func TestOrderByAndLimit(t *testing.T) {
+	common.TempSuppressOnMemStorageMutex.Lock()
+	common.TempSuppressOnMemStorage = true
+
+	// clear all state of DB
+	if !common.EnableOnMemStorage || common.TempSuppressOnMemStorage == true {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	db := samehada.NewSamehadaDB(t.Name(), 10*1024)
+	db.ExecuteSQL("CREATE TABLE item(id INT, name VARCHAR(256), price INT);")
+	db.ExecuteSQL("INSERT INTO item(id, name, price) VALUES (1, 'apple', 300);")
+	db.ExecuteSQL("INSERT INTO item(id, name, price) VALUES (2, 'banana', 100);")
+	db.ExecuteSQL("INSERT INTO item(id, name) VALUES (3, 'cherry');")
+	db.ExecuteSQL("INSERT INTO item(id, name, price) VALUES (4, 'durian', 300);")
+	db.ExecuteSQL("INSERT INTO item(id, name, price) VALUES (5, 'elderberry', 200);")
+
+	ids := func(results [][]interface{}) []int32 {
+		ret := make([]int32, 0)
+		for _, row := range results {
+			ret = append(ret, row[0].(int32))
+		}
+		return ret
+	}
+	equals := func(a []int32, b []int32) bool {
+		return fmt.Sprint(a) == fmt.Sprint(b)
+	}
+
+	// NULL is regarded as larger than any value by default
+	err, results := db.ExecuteSQL("SELECT id FROM item ORDER BY price, id;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, equals(ids(results), []int32{2, 5, 1, 4, 3}))
+	_, results = db.ExecuteSQL("SELECT id FROM item ORDER BY price DESC, id DESC;")
+	testingpkg.SimpleAssert(t, equals(ids(results), []int32{3, 4, 1, 5, 2}))
+	_, results = db.ExecuteSQL("SELECT id FROM item ORDER BY price NULLS FIRST, id;")
+	testingpkg.SimpleAssert(t, equals(ids(results), []int32{3, 2, 5, 1, 4}))
+	_, results = db.ExecuteSQL("SELECT id, name FROM item ORDER BY price DESC NULLS LAST, name;")
+	testingpkg.SimpleAssert(t, equals(ids(results), []int32{1, 4, 5, 2, 3}))
+
+	// LIMIT and OFFSET
+	_, results = db.ExecuteSQL("SELECT id FROM item ORDER BY id LIMIT 2;")
+	testingpkg.SimpleAssert(t, equals(ids(results), []int32{1, 2}))
+	_, results = db.ExecuteSQL("SELECT id FROM item ORDER BY id LIMIT 2 OFFSET 3;")
+	testingpkg.SimpleAssert(t, equals(ids(results), []int32{4, 5}))
+	_, results = db.ExecuteSQL("SELECT id FROM item ORDER BY id LIMIT 3, 10;")
+	testingpkg.SimpleAssert(t, equals(ids(results), []int32{4, 5}))
+	_, results = db.ExecuteSQL("SELECT id FROM item WHERE price >= 200 LIMIT 0;")
+	testingpkg.SimpleAssert(t, len(results) == 0)
+
+	// predicate including OR
+	_, results = db.ExecuteSQL("SELECT id FROM item WHERE id = 1 OR id = 2 OR id = 5 ORDER BY price DESC LIMIT 2;")
+	testingpkg.SimpleAssert(t, equals(ids(results), []int32{1, 5}))
+
+	// with aggregation
+	_, results = db.ExecuteSQL("SELECT price, COUNT(*) FROM item GROUP BY price ORDER BY COUNT(*) DESC, price LIMIT 2;")
+	testingpkg.SimpleAssert(t, len(results) == 2 && results[0][0].(int32) == 300 && results[0][1].(int32) == 2)
+	testingpkg.SimpleAssert(t, results[1][0].(int32) == 100)
+	err, _ = db.ExecuteSQL("SELECT price, COUNT(*) FROM item GROUP BY price ORDER BY id;")
+	testingpkg.SimpleAssert(t, err != nil)
+
+	common.TempSuppressOnMemStorage = false
+	db.Shutdown()
+	common.TempSuppressOnMemStorageMutex.Unlock()
+}
+
+func TestORAndParentheses(t *testing.T) {
+	common.TempSuppressOnMemStorageMutex.Lock()
+	common.TempSuppressOnMemStorage = true
+
+	// clear all state of DB
+	if !common.EnableOnMemStorage || common.TempSuppressOnMemStorage == true {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	db := samehada.NewSamehadaDB(t.Name(), 10*1024)
+	db.ExecuteSQL("CREATE TABLE item(id INT, name VARCHAR(256), price INT);")
+	db.ExecuteSQL("CREATE INDEX id_idx ON item(id);")
+	db.ExecuteSQL("CREATE INDEX price_idx ON item(price);")
+	db.ExecuteSQL("CREATE TABLE stock(item_id INT, amount INT);")
+	for ii := 1; ii <= 20; ii++ {
+		db.ExecuteSQL(fmt.Sprintf("INSERT INTO item(id, name, price) VALUES (%d, 'item-%d', %d);", ii, ii, (ii%5)*100))
+		db.ExecuteSQL(fmt.Sprintf("INSERT INTO stock(item_id, amount) VALUES (%d, %d);", ii, ii%3))
+	}
+
+	err, results := db.ExecuteSQL("SELECT id FROM item WHERE id = 3 OR price = 200;")
+	testingpkg.SimpleAssert(t, err == nil)
+	// 2, 3, 7, 12, 17
+	testingpkg.SimpleAssert(t, len(results) == 5)
+	_, results = db.ExecuteSQL("SELECT id FROM item WHERE (id < 5 OR id > 18) AND (price = 100 OR price = 400);")
+	// 1, 4, 19
+	testingpkg.SimpleAssert(t, len(results) == 3)
+	_, results = db.ExecuteSQL("SELECT id FROM item WHERE ((id = 1 AND price = 100) OR (id = 2 AND price = 100)) OR name = 'item-20';")
+	testingpkg.SimpleAssert(t, len(results) == 2)
+	_, results = db.ExecuteSQL("SELECT item.id, amount FROM item, stock WHERE id = item_id AND (price = 0 OR amount = 0);")
+	// price = 0: 5, 10, 15, 20. amount = 0: 3, 6, 9, 12, 15, 18
+	testingpkg.SimpleAssert(t, len(results) == 9)
+
+	err, _ = db.ExecuteSQL("UPDATE item SET price = 1000 WHERE id = 1 OR id = 20;")
+	testingpkg.SimpleAssert(t, err == nil)
+	_, results = db.ExecuteSQL("SELECT id FROM item WHERE price = 1000;")
+	testingpkg.SimpleAssert(t, len(results) == 2)
+	err, _ = db.ExecuteSQL("DELETE FROM item WHERE price = 1000 OR (id >= 10 AND id < 12);")
+	testingpkg.SimpleAssert(t, err == nil)
+	_, results = db.ExecuteSQL("SELECT id FROM item;")
+	testingpkg.SimpleAssert(t, len(results) == 16)
+
+	common.TempSuppressOnMemStorage = false
+	db.Shutdown()
+	common.TempSuppressOnMemStorageMutex.Unlock()
+}
+
+func TestOuterJoin(t *testing.T) {
+	common.TempSuppressOnMemStorageMutex.Lock()
+	common.TempSuppressOnMemStorage = true
+
+	// clear all state of DB
+	if !common.EnableOnMemStorage || common.TempSuppressOnMemStorage == true {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	db := samehada.NewSamehadaDB(t.Name(), 10*1024)
+	db.ExecuteSQL("CREATE TABLE customer(id INT, name VARCHAR(256));")
+	db.ExecuteSQL("CREATE TABLE orders(order_id INT, customer_id INT, amount INT);")
+	db.ExecuteSQL("CREATE INDEX customer_id_idx ON orders(customer_id);")
+	db.ExecuteSQL("CREATE TABLE payment(order_id INT, paid INT);")
+	// customers 1..5. customer 4 and 5 have no order
+	for ii := 1; ii <= 5; ii++ {
+		db.ExecuteSQL(fmt.Sprintf("INSERT INTO customer(id, name) VALUES (%d, 'customer-%d');", ii, ii))
+	}
+	// orders 1..6. order 6 has customer which doesn't exist
+	db.ExecuteSQL("INSERT INTO orders(order_id, customer_id, amount) VALUES (1, 1, 100);")
+	db.ExecuteSQL("INSERT INTO orders(order_id, customer_id, amount) VALUES (2, 1, 200);")
+	db.ExecuteSQL("INSERT INTO orders(order_id, customer_id, amount) VALUES (3, 2, 300);")
+	db.ExecuteSQL("INSERT INTO orders(order_id, customer_id, amount) VALUES (4, 3, 400);")
+	db.ExecuteSQL("INSERT INTO orders(order_id, customer_id, amount) VALUES (5, 3, 500);")
+	db.ExecuteSQL("INSERT INTO orders(order_id, customer_id, amount) VALUES (6, 9, 600);")
+	// only order 1 and 4 are paid
+	db.ExecuteSQL("INSERT INTO payment(order_id, paid) VALUES (1, 100);")
+	db.ExecuteSQL("INSERT INTO payment(order_id, paid) VALUES (4, 400);")
+
+	err, results := db.ExecuteSQL("SELECT customer.id, orders.order_id FROM customer JOIN orders ON customer.id = orders.customer_id;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, len(results) == 5)
+
+	_, results = db.ExecuteSQL("SELECT customer.id, orders.order_id FROM customer LEFT JOIN orders ON customer.id = orders.customer_id ORDER BY customer.id, orders.order_id;")
+	testingpkg.SimpleAssert(t, len(results) == 7)
+	testingpkg.SimpleAssert(t, results[5][0].(int32) == 4 && results[5][1] == nil)
+	testingpkg.SimpleAssert(t, results[6][0].(int32) == 5 && results[6][1] == nil)
+
+	// condition of ON clause doesn't filter records of preserved side
+	_, results = db.ExecuteSQL("SELECT customer.id, orders.amount FROM customer LEFT OUTER JOIN orders ON customer.id = orders.customer_id AND orders.amount > 250 ORDER BY customer.id;")
+	// 1: NULL, 2: 300, 3: 400, 3: 500, 4: NULL, 5: NULL
+	testingpkg.SimpleAssert(t, len(results) == 6)
+	testingpkg.SimpleAssert(t, results[0][0].(int32) == 1 && results[0][1] == nil)
+
+	// condition of WHERE clause is evaluated after outer join
+	_, results = db.ExecuteSQL("SELECT customer.id FROM customer LEFT JOIN orders ON customer.id = orders.customer_id WHERE orders.order_id IS NULL ORDER BY customer.id;")
+	testingpkg.SimpleAssert(t, len(results) == 2)
+	testingpkg.SimpleAssert(t, results[0][0].(int32) == 4 && results[1][0].(int32) == 5)
+	_, results = db.ExecuteSQL("SELECT customer.id FROM customer LEFT JOIN orders ON customer.id = orders.customer_id WHERE orders.amount >= 300;")
+	testingpkg.SimpleAssert(t, len(results) == 3)
+
+	_, results = db.ExecuteSQL("SELECT customer.id, orders.order_id FROM customer RIGHT JOIN orders ON customer.id = orders.customer_id WHERE customer.id IS NULL;")
+	testingpkg.SimpleAssert(t, len(results) == 1)
+	testingpkg.SimpleAssert(t, results[0][0] == nil && results[0][1].(int32) == 6)
+
+	_, results = db.ExecuteSQL("SELECT * FROM customer FULL OUTER JOIN orders ON customer.id = orders.customer_id;")
+	// 5 matched, customer 4 and 5, order 6
+	testingpkg.SimpleAssert(t, len(results) == 8)
+	testingpkg.SimpleAssert(t, len(results[0]) == 5)
+	_, results = db.ExecuteSQL("SELECT customer.id, orders.order_id FROM customer FULL JOIN orders ON customer.id = orders.customer_id WHERE customer.id IS NULL OR orders.order_id IS NULL;")
+	testingpkg.SimpleAssert(t, len(results) == 3)
+
+	// chain of outer joins. payment is padded with NULL when orders is
+	_, results = db.ExecuteSQL("SELECT customer.id, orders.order_id, payment.paid FROM customer LEFT JOIN orders ON customer.id = orders.customer_id LEFT JOIN payment ON orders.order_id = payment.order_id;")
+	testingpkg.SimpleAssert(t, len(results) == 7)
+	_, results = db.ExecuteSQL("SELECT customer.id, payment.paid FROM customer LEFT JOIN orders ON customer.id = orders.customer_id LEFT JOIN payment ON orders.order_id = payment.order_id WHERE payment.paid IS NOT NULL;")
+	testingpkg.SimpleAssert(t, len(results) == 2)
+	// inner join in null side of outer join is evaluated before the outer join
+	_, results = db.ExecuteSQL("SELECT customer.id, payment.paid FROM customer LEFT JOIN (orders JOIN payment ON orders.order_id = payment.order_id) ON customer.id = orders.customer_id ORDER BY customer.id;")
+	// 1: 100, 2: NULL, 3: 400, 4: NULL, 5: NULL
+	testingpkg.SimpleAssert(t, len(results) == 5)
+	testingpkg.SimpleAssert(t, results[1][0].(int32) == 2 && results[1][1] == nil)
+	testingpkg.SimpleAssert(t, results[2][0].(int32) == 3 && results[2][1].(int32) == 400)
+
+	common.TempSuppressOnMemStorage = false
+	db.Shutdown()
+	common.TempSuppressOnMemStorageMutex.Unlock()
+}
+
+func TestSubquery(t *testing.T) {
+	common.TempSuppressOnMemStorageMutex.Lock()
+	common.TempSuppressOnMemStorage = true
+
+	// clear all state of DB
+	if !common.EnableOnMemStorage || common.TempSuppressOnMemStorage == true {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	db := samehada.NewSamehadaDB(t.Name(), 10*1024)
+	db.ExecuteSQL("CREATE TABLE customer(id INT, name VARCHAR(256));")
+	db.ExecuteSQL("CREATE TABLE orders(order_id INT, customer_id INT, amount INT);")
+	db.ExecuteSQL("CREATE INDEX customer_id_idx ON orders(customer_id);")
+	// customers 1..5. customer 4 and 5 have no order
+	for ii := 1; ii <= 5; ii++ {
+		db.ExecuteSQL(fmt.Sprintf("INSERT INTO customer(id, name) VALUES (%d, 'customer-%d');", ii, ii))
+	}
+	db.ExecuteSQL("INSERT INTO orders(order_id, customer_id, amount) VALUES (1, 1, 100);")
+	db.ExecuteSQL("INSERT INTO orders(order_id, customer_id, amount) VALUES (2, 1, 200);")
+	db.ExecuteSQL("INSERT INTO orders(order_id, customer_id, amount) VALUES (3, 2, 300);")
+	db.ExecuteSQL("INSERT INTO orders(order_id, customer_id, amount) VALUES (4, 3, 400);")
+	db.ExecuteSQL("INSERT INTO orders(order_id, customer_id, amount) VALUES (5, 3, 500);")
+
+	err, results := db.ExecuteSQL("SELECT customer.id FROM customer WHERE customer.id IN (SELECT orders.customer_id FROM orders WHERE orders.amount >= 300) ORDER BY customer.id;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, len(results) == 2)
+	testingpkg.SimpleAssert(t, results[0][0].(int32) == 2 && results[1][0].(int32) == 3)
+
+	// IN list is rewritten to OR
+	_, results = db.ExecuteSQL("SELECT id FROM customer WHERE id IN (1, 3, 9);")
+	testingpkg.SimpleAssert(t, len(results) == 2)
+	_, results = db.ExecuteSQL("SELECT id FROM customer WHERE id NOT IN (SELECT customer_id FROM orders);")
+	testingpkg.SimpleAssert(t, len(results) == 2)
+
+	// correlated subqueries
+	_, results = db.ExecuteSQL("SELECT customer.id FROM customer WHERE EXISTS (SELECT * FROM orders WHERE orders.customer_id = customer.id AND orders.amount < 300);")
+	testingpkg.SimpleAssert(t, len(results) == 1)
+	testingpkg.SimpleAssert(t, results[0][0].(int32) == 1)
+	_, results = db.ExecuteSQL("SELECT customer.id FROM customer WHERE NOT EXISTS (SELECT * FROM orders WHERE orders.customer_id = customer.id) ORDER BY customer.id;")
+	testingpkg.SimpleAssert(t, len(results) == 2)
+	testingpkg.SimpleAssert(t, results[0][0].(int32) == 4 && results[1][0].(int32) == 5)
+
+	// scalar subqueries
+	_, results = db.ExecuteSQL("SELECT order_id FROM orders WHERE amount > (SELECT MAX(amount) FROM orders WHERE customer_id = 1) ORDER BY order_id;")
+	testingpkg.SimpleAssert(t, len(results) == 3)
+	testingpkg.SimpleAssert(t, results[0][0].(int32) == 3 && results[2][0].(int32) == 5)
+	_, results = db.ExecuteSQL("SELECT customer.id, (SELECT MAX(orders.amount) FROM orders WHERE orders.customer_id = customer.id) FROM customer ORDER BY customer.id;")
+	// 1: 200, 2: 300, 3: 500, 4: NULL, 5: NULL
+	testingpkg.SimpleAssert(t, len(results) == 5)
+	testingpkg.SimpleAssert(t, results[0][1].(int32) == 200 && results[2][1].(int32) == 500)
+	testingpkg.SimpleAssert(t, results[3][1] == nil)
+	_, results = db.ExecuteSQL("SELECT customer.id FROM customer WHERE (SELECT SUM(orders.amount) FROM orders WHERE orders.customer_id = customer.id) > 250 ORDER BY customer.id;")
+	testingpkg.SimpleAssert(t, len(results) == 3)
+
+	// derived table
+	_, results = db.ExecuteSQL("SELECT t.customer_id, t.total FROM (SELECT customer_id, SUM(amount) AS total FROM orders GROUP BY customer_id) AS t WHERE t.total > 300 ORDER BY t.customer_id;")
+	// 1: 300, 2: 300, 3: 900
+	testingpkg.SimpleAssert(t, len(results) == 1)
+	testingpkg.SimpleAssert(t, results[0][0].(int32) == 3 && results[0][1].(int32) == 900)
+	_, results = db.ExecuteSQL("SELECT customer.name, t.total FROM customer JOIN (SELECT customer_id, SUM(amount) AS total FROM orders GROUP BY customer_id) AS t ON customer.id = t.customer_id ORDER BY customer.id;")
+	testingpkg.SimpleAssert(t, len(results) == 3)
+	testingpkg.SimpleAssert(t, results[0][0].(string) == "customer-1" && results[0][1].(int32) == 300)
+
+	// scalar subquery which returns multiple rows is error
+	err, _ = db.ExecuteSQL("SELECT id FROM customer WHERE id = (SELECT customer_id FROM orders);")
+	testingpkg.SimpleAssert(t, err != nil)
+
+	// parameters in subquery
+	err, selStmt := db.Prepare("SELECT customer.id FROM customer WHERE customer.id IN (SELECT orders.customer_id FROM orders WHERE orders.amount >= ?);")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, selStmt.ParamNum() == 1)
+	err, rs := db.ExecutePrepared(selStmt, samehada_util.GetPonterOfValue(types.NewInteger(400)))
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, len(rs.Rows) == 1)
+
+	// NOT IN is not true for any record when result of subquery has NULL
+	err, insStmt := db.Prepare("INSERT INTO orders(order_id, customer_id, amount) VALUES (?, ?, ?);")
+	testingpkg.SimpleAssert(t, err == nil)
+	args, _ := insStmt.ConvIFsToArgs(6, nil, 600)
+	err, _ = db.ExecutePrepared(insStmt, args...)
+	testingpkg.SimpleAssert(t, err == nil)
+	_, results = db.ExecuteSQL("SELECT id FROM customer WHERE id NOT IN (SELECT customer_id FROM orders);")
+	testingpkg.SimpleAssert(t, len(results) == 0)
+	_, results = db.ExecuteSQL("SELECT id FROM customer WHERE id IN (SELECT customer_id FROM orders);")
+	testingpkg.SimpleAssert(t, len(results) == 3)
+
+	common.TempSuppressOnMemStorage = false
+	db.Shutdown()
+	common.TempSuppressOnMemStorageMutex.Unlock()
+}
+
+func TestExpressions(t *testing.T) {
+	common.TempSuppressOnMemStorageMutex.Lock()
+	common.TempSuppressOnMemStorage = true
+
+	// clear all state of DB
+	if !common.EnableOnMemStorage || common.TempSuppressOnMemStorage == true {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	db := samehada.NewSamehadaDB(t.Name(), 10*1024)
+	db.ExecuteSQL("CREATE TABLE item(id INT, name VARCHAR(256), price INT, cost INT, rate FLOAT);")
+	db.ExecuteSQL("INSERT INTO item(id, name, price, cost, rate) VALUES (1, 'Apple', 100, 80, 0.5);")
+	db.ExecuteSQL("INSERT INTO item(id, name, price, cost, rate) VALUES (2, 'Banana', 50, 60, 1.5);")
+	db.ExecuteSQL("INSERT INTO item(id, name, price, cost, rate) VALUES (3, 'Cherry', 300, 100, 2.5);")
+	db.ExecuteSQL("INSERT INTO item(id, name, cost, rate) VALUES (4, 'durian', 10, -1.25);")
+
+	// arithmetic and alias
+	err, results := db.ExecuteSQL("SELECT id, price - cost AS profit, price * 2 + 1, price / 3, price % 7, price * rate FROM item WHERE id <= 3 ORDER BY id;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, len(results) == 3)
+	testingpkg.SimpleAssert(t, results[0][1].(int32) == 20 && results[1][1].(int32) == -10)
+	testingpkg.SimpleAssert(t, results[0][2].(int32) == 201 && results[0][3].(int32) == 33 && results[0][4].(int32) == 2)
+	testingpkg.SimpleAssert(t, results[2][5].(float32) == 750)
+
+	// ORDER BY alias of calculated value
+	_, results = db.ExecuteSQL("SELECT id, price - cost AS profit FROM item WHERE id <= 3 ORDER BY profit DESC;")
+	testingpkg.SimpleAssert(t, len(results) == 3)
+	testingpkg.SimpleAssert(t, results[0][0].(int32) == 3 && results[2][0].(int32) == 2)
+
+	// NULL is propagated and division by zero is NULL
+	_, results = db.ExecuteSQL("SELECT price + 1, price / 0, price % 0 FROM item WHERE id = 4;")
+	testingpkg.SimpleAssert(t, len(results) == 1)
+	testingpkg.SimpleAssert(t, results[0][0] == nil && results[0][1] == nil && results[0][2] == nil)
+
+	// comparison between columns and with calculated value
+	_, results = db.ExecuteSQL("SELECT id FROM item WHERE price > cost ORDER BY id;")
+	testingpkg.SimpleAssert(t, len(results) == 2)
+	testingpkg.SimpleAssert(t, results[0][0].(int32) == 1 && results[1][0].(int32) == 3)
+	_, results = db.ExecuteSQL("SELECT id FROM item WHERE price - cost >= 20 AND cost * 2 < 300;")
+	testingpkg.SimpleAssert(t, len(results) == 2)
+	_, results = db.ExecuteSQL("SELECT id FROM item WHERE rate > -1.5 AND rate < 0;")
+	testingpkg.SimpleAssert(t, len(results) == 1)
+	testingpkg.SimpleAssert(t, results[0][0].(int32) == 4)
+
+	// LIKE, BETWEEN and IN list
+	_, results = db.ExecuteSQL("SELECT id FROM item WHERE name LIKE '%an%' ORDER BY id;")
+	testingpkg.SimpleAssert(t, len(results) == 2)
+	testingpkg.SimpleAssert(t, results[0][0].(int32) == 2 && results[1][0].(int32) == 4)
+	_, results = db.ExecuteSQL("SELECT id FROM item WHERE name NOT LIKE '_a%';")
+	testingpkg.SimpleAssert(t, len(results) == 3)
+	_, results = db.ExecuteSQL("SELECT id FROM item WHERE price BETWEEN 50 AND 100 ORDER BY id;")
+	testingpkg.SimpleAssert(t, len(results) == 2)
+	testingpkg.SimpleAssert(t, results[0][0].(int32) == 1 && results[1][0].(int32) == 2)
+	_, results = db.ExecuteSQL("SELECT id FROM item WHERE price NOT BETWEEN 50 AND 100;")
+	testingpkg.SimpleAssert(t, len(results) == 1)
+	_, results = db.ExecuteSQL("SELECT id FROM item WHERE price + 0 IN (100, 300);")
+	testingpkg.SimpleAssert(t, len(results) == 2)
+
+	// CASE, COALESCE and CAST
+	_, results = db.ExecuteSQL("SELECT CASE WHEN price >= 100 THEN 'high' WHEN price IS NULL THEN 'unknown' ELSE 'low' END FROM item ORDER BY id;")
+	testingpkg.SimpleAssert(t, len(results) == 4)
+	testingpkg.SimpleAssert(t, results[0][0].(string) == "high" && results[1][0].(string) == "low" && results[3][0].(string) == "unknown")
+	_, results = db.ExecuteSQL("SELECT CASE id WHEN 1 THEN 'one' WHEN 2 THEN 'two' END FROM item ORDER BY id;")
+	testingpkg.SimpleAssert(t, results[1][0].(string) == "two" && results[2][0] == nil)
+	_, results = db.ExecuteSQL("SELECT COALESCE(price, cost, 0), IFNULL(price, -1) FROM item WHERE id = 4;")
+	testingpkg.SimpleAssert(t, results[0][0].(int32) == 10 && results[0][1].(int32) == -1)
+	_, results = db.ExecuteSQL("SELECT CAST(rate AS SIGNED), CAST(price AS CHAR), CAST('12' AS SIGNED) + 1 FROM item WHERE id = 3;")
+	testingpkg.SimpleAssert(t, results[0][0].(int64) == 2 && results[0][1].(string) == "300" && results[0][2].(int64) == 13)
+
+	// scalar functions
+	_, results = db.ExecuteSQL("SELECT LOWER(name), UPPER(name), LENGTH(name), SUBSTR(name, 2, 3), ABS(rate), ROUND(rate), ROUND(price, -2) FROM item ORDER BY id;")
+	testingpkg.SimpleAssert(t, len(results) == 4)
+	testingpkg.SimpleAssert(t, results[0][0].(string) == "apple" && results[0][1].(string) == "APPLE")
+	testingpkg.SimpleAssert(t, results[1][2].(int32) == 6 && results[1][3].(string) == "ana")
+	testingpkg.SimpleAssert(t, results[3][4].(float32) == 1.25 && results[2][5].(float32) == 3)
+	testingpkg.SimpleAssert(t, results[1][6].(int32) == 100 && results[3][6] == nil)
+	_, results = db.ExecuteSQL("SELECT id FROM item WHERE UPPER(name) = 'DURIAN';")
+	testingpkg.SimpleAssert(t, len(results) == 1)
+
+	// parameters in expressions
+	err, selStmt := db.Prepare("SELECT id, price * ? FROM item WHERE price - cost > ? ORDER BY id;")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, rs := db.ExecutePrepared(selStmt, samehada_util.GetPonterOfValue(types.NewInteger(3)), samehada_util.GetPonterOfValue(types.NewInteger(0)))
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, len(rs.Rows) == 2)
+	testingpkg.SimpleAssert(t, rs.Rows[1][1].ToInteger() == 900)
+
+	// unsupported function and calculation with aggregation are error
+	err, _ = db.ExecuteSQL("SELECT CONCAT(name, 'x') FROM item;")
+	testingpkg.SimpleAssert(t, err != nil)
+	err, _ = db.ExecuteSQL("SELECT SUM(price) + 1 FROM item;")
+	testingpkg.SimpleAssert(t, err != nil)
+
+	common.TempSuppressOnMemStorage = false
+	db.Shutdown()
+	common.TempSuppressOnMemStorageMutex.Unlock()
+}
+
+func TestAdditionalTypes(t *testing.T) {
+	common.TempSuppressOnMemStorageMutex.Lock()
+	common.TempSuppressOnMemStorage = true
+
+	// clear all state of DB
+	if !common.EnableOnMemStorage || common.TempSuppressOnMemStorage == true {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	db := samehada.NewSamehadaDB(t.Name(), 10*1024)
+	err, _ := db.ExecuteSQL("CREATE TABLE account(id BIGINT, balance DECIMAL(15,2), score DOUBLE, created TIMESTAMP, birthday DATE, icon BLOB);")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = db.ExecuteSQL("INSERT INTO account(id, balance, score, created, birthday, icon) VALUES (3000000000, 100.25, 0.125, '2024-01-02 03:04:05', '1990-05-06', X'0102ff');")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = db.ExecuteSQL("INSERT INTO account(id, balance, score, created, birthday, icon) VALUES (-5000000000, 1.50, 1e300, '2023-12-31 23:59:59.5', '2001-01-01', X'00');")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = db.ExecuteSQL("INSERT INTO account(id, balance, score, created) VALUES (7, 0.1, -2.5, '2024-06-01');")
+	testingpkg.SimpleAssert(t, err == nil)
+
+	// wrong literal for the type is error
+	err, _ = db.ExecuteSQL("INSERT INTO account(id, created) VALUES (8, 'not a timestamp');")
+	testingpkg.SimpleAssert(t, err != nil)
+
+	// values are returned with Go types which correspond to column types
+	err, results := db.ExecuteSQL("SELECT id, balance, score, created, birthday, icon FROM account ORDER BY id;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, len(results) == 3)
+	testingpkg.SimpleAssert(t, results[0][0].(int64) == -5000000000 && results[2][0].(int64) == 3000000000)
+	testingpkg.SimpleAssert(t, results[2][1].(string) == "100.25" && results[0][1].(string) == "1.50")
+	testingpkg.SimpleAssert(t, results[2][2].(float64) == 0.125 && results[0][2].(float64) == 1e300)
+	testingpkg.SimpleAssert(t, results[2][3].(time.Time).Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+	testingpkg.SimpleAssert(t, results[2][4].(time.Time).Equal(time.Date(1990, 5, 6, 0, 0, 0, 0, time.UTC)))
+	testingpkg.SimpleAssert(t, bytes.Equal(results[2][5].([]byte), []byte{0x01, 0x02, 0xff}))
+	testingpkg.SimpleAssert(t, results[1][4] == nil && results[1][5] == nil)
+
+	// comparison with literals of other types
+	_, results = db.ExecuteSQL("SELECT id FROM account WHERE id > 2147483647;")
+	testingpkg.SimpleAssert(t, len(results) == 1)
+	_, results = db.ExecuteSQL("SELECT id FROM account WHERE balance = 1.5;")
+	testingpkg.SimpleAssert(t, len(results) == 1 && results[0][0].(int64) == -5000000000)
+	_, results = db.ExecuteSQL("SELECT id FROM account WHERE created >= '2024-01-01' ORDER BY created;")
+	testingpkg.SimpleAssert(t, len(results) == 2)
+	testingpkg.SimpleAssert(t, results[0][0].(int64) == 3000000000 && results[1][0].(int64) == 7)
+	_, results = db.ExecuteSQL("SELECT id FROM account WHERE icon = X'00';")
+	testingpkg.SimpleAssert(t, len(results) == 1)
+
+	// decimal calculation is exact
+	_, results = db.ExecuteSQL("SELECT balance * 3, balance + 0.2 FROM account WHERE id = 7;")
+	testingpkg.SimpleAssert(t, len(results) == 1)
+	testingpkg.SimpleAssert(t, results[0][0].(string) == "0.3" && results[0][1].(string) == "0.3")
+	_, results = db.ExecuteSQL("SELECT SUM(balance) FROM account;")
+	testingpkg.SimpleAssert(t, results[0][0].(string) == "101.85")
+
+	// skip list index is created on each column by default. replace some of them with other kinds
+	db.ExecuteSQL("DROP INDEX balance_index ON account;")
+	db.ExecuteSQL("DROP INDEX created_index ON account;")
+	err, _ = db.ExecuteSQL("CREATE INDEX balance_idx ON account(balance) USING HASH;")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = db.ExecuteSQL("CREATE INDEX created_idx ON account(created) USING BTREE;")
+	testingpkg.SimpleAssert(t, err == nil)
+	_, results = db.ExecuteSQL("SELECT id FROM account WHERE id = -5000000000;")
+	testingpkg.SimpleAssert(t, len(results) == 1)
+	_, results = db.ExecuteSQL("SELECT id FROM account WHERE balance = 100.250;")
+	testingpkg.SimpleAssert(t, len(results) == 1 && results[0][0].(int64) == 3000000000)
+	_, results = db.ExecuteSQL("SELECT id FROM account WHERE created < '2024-01-02 03:04:05' ORDER BY id;")
+	testingpkg.SimpleAssert(t, len(results) == 1 && results[0][0].(int64) == -5000000000)
+
+	common.TempSuppressOnMemStorage = false
+	db.Shutdown()
+	common.TempSuppressOnMemStorageMutex.Unlock()
+}
+
+func TestLargeValues(t *testing.T) {
+	common.TempSuppressOnMemStorageMutex.Lock()
+	common.TempSuppressOnMemStorage = true
+
+	// clear all state of DB
+	if !common.EnableOnMemStorage || common.TempSuppressOnMemStorage == true {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	// values are larger than a page. so they are stored in overflow pages
+	body1 := strings.Repeat("{\"key\": \"value\"}", 1000)
+	body2 := strings.Repeat("description ", 2000)
+
+	db := samehada.NewSamehadaDB(t.Name(), 10*1024)
+	err, _ := db.ExecuteSQL("CREATE TABLE doc(id INT, body TEXT);")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = db.ExecuteSQL("INSERT INTO doc(id, body) VALUES (1, '" + body1 + "');")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = db.ExecuteSQL("INSERT INTO doc(id, body) VALUES (2, 'short');")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = db.ExecuteSQL("INSERT INTO doc(id, body) VALUES (3, '" + body2 + "');")
+	testingpkg.SimpleAssert(t, err == nil)
+
+	_, results := db.ExecuteSQL("SELECT id, body FROM doc WHERE id = 1;")
+	testingpkg.SimpleAssert(t, len(results) == 1 && results[0][1].(string) == body1)
+	_, results = db.ExecuteSQL("SELECT id FROM doc WHERE body = '" + body2 + "';")
+	testingpkg.SimpleAssert(t, len(results) == 1 && results[0][0].(int32) == 3)
+
+	// short value to large one and large one to short one
+	err, _ = db.ExecuteSQL("UPDATE doc SET body = '" + body2 + "' WHERE id = 2;")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = db.ExecuteSQL("UPDATE doc SET body = 'short' WHERE id = 3;")
+	testingpkg.SimpleAssert(t, err == nil)
+	// other column of large value
+	err, _ = db.ExecuteSQL("UPDATE doc SET id = 10 WHERE id = 1;")
+	testingpkg.SimpleAssert(t, err == nil)
+	_, results = db.ExecuteSQL("SELECT id, body FROM doc ORDER BY id;")
+	testingpkg.SimpleAssert(t, len(results) == 3)
+	testingpkg.SimpleAssert(t, results[0][1].(string) == body2 && results[1][1].(string) == "short")
+	testingpkg.SimpleAssert(t, results[2][0].(int32) == 10 && results[2][1].(string) == body1)
+
+	err, _ = db.ExecuteSQL("DELETE FROM doc WHERE id = 2;")
+	testingpkg.SimpleAssert(t, err == nil)
+
+	// value which can't be stored
+	err, _ = db.ExecuteSQL("INSERT INTO doc(id, body) VALUES (4, '" + strings.Repeat("a", math.MaxUint16+1) + "');")
+	testingpkg.SimpleAssert(t, err != nil)
+	// value which can't be a key of skip list index
+	db.ExecuteSQL("CREATE TABLE tag(name VARCHAR(2000));")
+	err, _ = db.ExecuteSQL("INSERT INTO tag(name) VALUES ('" + strings.Repeat("a", 2000) + "');")
+	testingpkg.SimpleAssert(t, err != nil)
+
+	// close db without flushing pages
+	db.ShutdownForTescase()
+
+	// relaunch
+	// load of db file and redo/undo process runs
+	db2 := samehada.NewSamehadaDB(t.Name(), 10*1024)
+	_, results = db2.ExecuteSQL("SELECT id, body FROM doc ORDER BY id;")
+	testingpkg.SimpleAssert(t, len(results) == 2)
+	testingpkg.SimpleAssert(t, results[0][0].(int32) == 3 && results[0][1].(string) == "short")
+	testingpkg.SimpleAssert(t, results[1][0].(int32) == 10 && results[1][1].(string) == body1)
+
+	common.TempSuppressOnMemStorage = false
+	db2.Shutdown()
+	common.TempSuppressOnMemStorageMutex.Unlock()
+}
+
+func TestDeclaredLengthAndTypeCheck(t *testing.T) {
+	common.TempSuppressOnMemStorageMutex.Lock()
+	common.TempSuppressOnMemStorage = true
+
+	// clear all state of DB
+	if !common.EnableOnMemStorage || common.TempSuppressOnMemStorage == true {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	db := samehada.NewSamehadaDB(t.Name(), 10*1024)
+	err, _ := db.ExecuteSQL("CREATE TABLE item(code VARCHAR(5), price FLOAT, qty INT);")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = db.ExecuteSQL("CREATE TABLE too_long(code VARCHAR(65536));")
+	testingpkg.SimpleAssert(t, err != nil)
+
+	// length is counted in characters. integer literal is converted for FLOAT column
+	err, _ = db.ExecuteSQL("INSERT INTO item(code, price, qty) VALUES ('あいうえお', 3, 1);")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = db.ExecuteSQL("INSERT INTO item(code, price, qty) VALUES ('abcdef', 1.5, 2);")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.ValueTooLongErr))
+	err, _ = db.ExecuteSQL("INSERT INTO item(code, price, qty) VALUES ('abc', 1.5, 'many');")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.TypeMismatchErr))
+	err, _ = db.ExecuteSQL("INSERT INTO item(code, price, qty) VALUES ('abc', 1.5, 2.5);")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.TypeMismatchErr))
+
+	err, _ = db.ExecuteSQL("UPDATE item SET code = 'abcdef' WHERE qty = 1;")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.ValueTooLongErr))
+	err, _ = db.ExecuteSQL("UPDATE item SET qty = 'x' WHERE qty = 1;")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.TypeMismatchErr))
+	err, _ = db.ExecuteSQL("UPDATE item SET price = 10 WHERE qty = 1;")
+	testingpkg.SimpleAssert(t, err == nil)
+
+	_, results := db.ExecuteSQL("SELECT code, price, qty FROM item;")
+	testingpkg.SimpleAssert(t, len(results) == 1)
+	testingpkg.SimpleAssert(t, results[0][0].(string) == "あいうえお" && results[0][1].(float32) == 10)
+
+	db.ShutdownForTescase()
+
+	// declared length is loaded from catalog
+	db2 := samehada.NewSamehadaDB(t.Name(), 10*1024)
+	err, _ = db2.ExecuteSQL("INSERT INTO item(code, price, qty) VALUES ('abcdef', 1.5, 2);")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.ValueTooLongErr))
+	err, _ = db2.ExecuteSQL("INSERT INTO item(code, price, qty) VALUES ('abcde', 1.5, 2);")
+	testingpkg.SimpleAssert(t, err == nil)
+
+	common.TempSuppressOnMemStorage = false
+	db2.Shutdown()
+	common.TempSuppressOnMemStorageMutex.Unlock()
+}
+
+func TestInsertSelectAndReturning(t *testing.T) {
+	common.TempSuppressOnMemStorageMutex.Lock()
+	common.TempSuppressOnMemStorage = true
+
+	// clear all state of DB
+	if !common.EnableOnMemStorage || common.TempSuppressOnMemStorage == true {
+		os.Remove(t.Name() + ".db")
+		os.Remove(t.Name() + ".log")
+	}
+
+	db := samehada.NewSamehadaDB(t.Name(), 10*1024)
+	db.ExecuteSQL("CREATE TABLE item(id INT, name VARCHAR(10), price FLOAT);")
+	db.ExecuteSQL("CREATE TABLE archive(id BIGINT, name VARCHAR(3), price FLOAT);")
+
+	// multi-row VALUES
+	err, rs := db.ExecuteSQLRetResultSet("INSERT INTO item(id, name, price) VALUES (1, 'apple', 1.5), (2, 'egg', 3), (3, 'banana', NULL);")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, rs.AffectedRows == 3)
+	testingpkg.SimpleAssert(t, len(rs.Rows) == 0)
+	// column names can be omitted
+	err, _ = db.ExecuteSQL("INSERT INTO item VALUES (4, 'milk', 2), (5, 'tea', 4);")
+	testingpkg.SimpleAssert(t, err == nil)
+	err, _ = db.ExecuteSQL("INSERT INTO item(id, name) VALUES (6, 'x'), (7);")
+	testingpkg.SimpleAssert(t, err != nil)
+
+	// INSERT ... SELECT. values are converted for columns of the target table
+	err, rs = db.ExecuteSQLRetResultSet("INSERT INTO archive(id, name) SELECT id, name FROM item WHERE price > 2;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, rs.AffectedRows == 2)
+	_, results := db.ExecuteSQL("SELECT id, name, price FROM archive ORDER BY id;")
+	testingpkg.SimpleAssert(t, len(results) == 2)
+	testingpkg.SimpleAssert(t, results[0][0].(int64) == 2 && results[0][1].(string) == "egg" && results[0][2] == nil)
+	testingpkg.SimpleAssert(t, results[1][0].(int64) == 5 && results[1][1].(string) == "tea")
+	err, _ = db.ExecuteSQL("INSERT INTO archive SELECT id, name FROM item;")
+	testingpkg.SimpleAssert(t, err != nil)
+	// value of name is too long for archive. nothing is inserted
+	err, _ = db.ExecuteSQL("INSERT INTO archive SELECT * FROM item WHERE id < 3;")
+	testingpkg.SimpleAssert(t, errors.Is(err, catalog.ValueTooLongErr))
+	// rows inserted by the statement itself are not read
+	err, rs = db.ExecuteSQLRetResultSet("INSERT INTO item SELECT id + 10, name, price FROM item;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, rs.AffectedRows == 5)
+	_, results = db.ExecuteSQL("SELECT COUNT(*) FROM archive;")
+	testingpkg.SimpleAssert(t, results[0][0].(int32) == 2)
+
+	// RETURNING
+	err, rs = db.ExecuteSQLRetResultSet("INSERT INTO item(id, name) VALUES (21, 'cake'), (22, 'pie') RETURNING id, item.name;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, rs.AffectedRows == 2)
+	testingpkg.SimpleAssert(t, len(rs.Columns) == 2 && rs.Columns[0].Name == "item.id" && rs.Columns[1].Name == "item.name")
+	testingpkg.SimpleAssert(t, len(rs.Rows) == 2)
+	testingpkg.SimpleAssert(t, rs.Rows[0][0].ToInteger() == 21 && rs.Rows[1][1].ToVarchar() == "pie")
+
+	err, rs = db.ExecuteSQLRetResultSet("UPDATE item SET price = 9 WHERE id = 21 RETURNING *;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, len(rs.Columns) == 3)
+	testingpkg.SimpleAssert(t, len(rs.Rows) == 1)
+	testingpkg.SimpleAssert(t, rs.Rows[0][1].ToVarchar() == "cake" && rs.Rows[0][2].ToFloat() == 9)
+
+	err, rs = db.ExecuteSQLRetResultSet("DELETE FROM item WHERE id > 20 RETURNING name;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, rs.AffectedRows == 2)
+	names := []string{rs.Rows[0][0].ToVarchar(), rs.Rows[1][0].ToVarchar()}
+	testingpkg.SimpleAssert(t, samehada_util.IsContainList[string](names, "cake") && samehada_util.IsContainList[string](names, "pie"))
+
+	err, _ = db.ExecuteSQL("DELETE FROM item WHERE id = 1 RETURNING no_such_col;")
+	testingpkg.SimpleAssert(t, err != nil)
+	err, _ = db.ExecuteSQL("DELETE FROM item WHERE id = 1 RETURNING price * 2;")
+	testingpkg.SimpleAssert(t, errors.Is(err, parser.UnsupportedReturningErr))
+	_, results = db.ExecuteSQL("SELECT COUNT(*) FROM item;")
+	testingpkg.SimpleAssert(t, results[0][0].(int32) == 10)
+
+	// placeholders of rows and RETURNING clause of prepared statement
+	err, ps := db.Prepare("INSERT INTO item VALUES (?, ?, 1), (?, 'z', ?) RETURNING id;")
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, ps.Describe().ParamTypes[2] == types.Integer && ps.Describe().ParamTypes[3] == types.Float)
+	testingpkg.SimpleAssert(t, len(ps.Describe().Columns) == 1)
+	err, rs = db.ExecutePrepared(ps, samehada_util.GetPonterOfValue(types.NewInteger(31)), samehada_util.GetPonterOfValue(types.NewVarchar("y")),
+		samehada_util.GetPonterOfValue(types.NewInteger(32)), samehada_util.GetPonterOfValue(types.NewInteger(5)))
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, len(rs.Rows) == 2 && rs.Rows[1][0].ToInteger() == 32)
+
+	common.TempSuppressOnMemStorage = false
+	db.Shutdown()
+	common.TempSuppressOnMemStorageMutex.Unlock()
+}
+
+func TestNewSamehadaDBWithOptions(t *testing.T) {
+	dataDir := t.Name() + "_data"
+	os.RemoveAll(dataDir)
+	defer os.RemoveAll(dataDir)
+
+	opts := &samehada.Options{
+		StorageMode:        samehada.StorageModeFile,
+		DataDir:            dataDir,
+		BufferPoolKBytes:   1024,
+		CheckpointInterval: time.Second,
+		LogBufferSize:      4 * common.PageSize,
+	}
+	err, db := samehada.NewSamehadaDBWithOptions("optdb", opts)
+	testingpkg.SimpleAssert(t, err == nil)
+	testingpkg.SimpleAssert(t, samehada_util.FileExists(dataDir+"/optdb.db"))
+	db.ExecuteSQL("CREATE TABLE item(id INT, name TEXT);")
+	// records which are larger than a page are logged with small log buffer
+	for ii := 0; ii < 20; ii++ {
+		err, _ = db.ExecuteSQL(fmt.Sprintf("INSERT INTO item(id, name) VALUES (%d, '%s');", ii, strings.Repeat("a", 10000)))
+		testingpkg.SimpleAssert(t, err == nil)
+	}
+	db.Shutdown()
+
+	// data is loaded from files of DataDir
+	err, db = samehada.NewSamehadaDBWithOptions("optdb", opts)
+	testingpkg.SimpleAssert(t, err == nil)
+	_, results := db.ExecuteSQL("SELECT COUNT(*) FROM item;")
+	testingpkg.SimpleAssert(t, results[0][0].(int32) == 20)
+	db.Shutdown()
+
+	// on memory storage doesn't create files
+	err, db = samehada.NewSamehadaDBWithOptions("memdb", &samehada.Options{StorageMode: samehada.StorageModeOnMemory, DataDir: dataDir})
+	testingpkg.SimpleAssert(t, err == nil)
+	db.ExecuteSQL("CREATE TABLE item(id INT);")
+	db.ExecuteSQL("INSERT INTO item(id) VALUES (1);")
+	_, results = db.ExecuteSQL("SELECT id FROM item;")
+	testingpkg.SimpleAssert(t, len(results) == 1)
+	db.Shutdown()
+	testingpkg.SimpleAssert(t, !samehada_util.FileExists(dataDir+"/memdb.db"))
+
+	err, _ = samehada.NewSamehadaDBWithOptions("optdb", &samehada.Options{LogBufferSize: 100})
+	testingpkg.SimpleAssert(t, errors.Is(err, samehada.InvalidOptionErr))
+	err, _ = samehada.ParseStorageMode("disk")
+	testingpkg.SimpleAssert(t, errors.Is(err, samehada.InvalidOptionErr))
+}
@@ -0,0 +1,163 @@
+package samehada
+
+import (
+	"errors"
+	"github.com/ryogrid/SamehadaDB/lib/parser"
+	"github.com/ryogrid/SamehadaDB/lib/samehada/samehada_util"
+	"github.com/ryogrid/SamehadaDB/lib/storage/access"
+	"github.com/ryogrid/SamehadaDB/lib/types"
+	"sync"
+)
+
+var TxnAlreadyBegunErr = errors.New("transaction is already begun")
+var TxnFinishedErr = errors.New("transaction is already committed or rolled back")
+
+// catalog changes are not undone at rollback, so DDL is allowed only on auto-commit execution
+var DDLOnTxnErr = errors.New("DDL can't be executed on a transaction handle")
+
+// returned when a statement on the txn conflicted with other txn.
+// in contrast to QueryAbortedErr, retry is not possible because
+// all statements executed on the txn are rolled back
+var TxnAbortedErr = errors.New("transaction aborted")
+
+// SamehadaTxn is a handle of a transaction which spans multiple SQL statements.
+// Results of statements executed through the handle are not visible to others
+// until Commit is called, and all of them are undone when Rollback is called.
+// Limitation: DDL can't be executed on the handle (DDLOnTxnErr is returned)
+type SamehadaTxn struct {
+	sdb        *SamehadaDB
+	txn        *access.Transaction
+	isFinished bool
+	// statements on a txn are executed serially
+	mutex *sync.Mutex
+}
+
+// BeginTxn starts a transaction and returns its handle.
+// Statements executed through the handle bypass RequestManager,
+// so retry of the statement aborted due to lock conflict is not done.
+// The handle holds the global transaction latch only while a statement, Commit or Rollback
+// is processed, so idle handle does not block checkpointing and other transactions.
+func (sdb *SamehadaDB) BeginTxn() *SamehadaTxn {
+	txnMgr := sdb.shi_.GetTransactionManager()
+	txn := txnMgr.Begin(nil)
+	txnMgr.ReleaseGlobalTxnLatch()
+	return &SamehadaTxn{sdb, txn, false, new(sync.Mutex)}
+}
+
+func (stxn *SamehadaTxn) ExecuteSQL(sqlStr string) (error, [][]interface{}) {
+	err, results := stxn.ExecuteSQLRetValues(sqlStr)
+	if err != nil {
+		return err, nil
+	}
+	return nil, samehada_util.ConvValueListToIFs(results)
+}
+
+// COMMIT and ROLLBACK statements passed to this method finish the txn.
+// when a statement is aborted due to lock conflict, the txn is rolled back and TxnAbortedErr is returned.
+// when a statement violates constraints of a table, the txn is also rolled back and the violation error
+// (catalog.DuplicateKeyErr, etc...) is returned.
+func (stxn *SamehadaTxn) ExecuteSQLRetValues(sqlStr string) (error, [][]*types.Value) {
+	err, rs := stxn.ExecuteSQLRetResultSet(sqlStr)
+	return err, rs.getRows()
+}
+
+// same as ExecuteSQLRetValues but column descriptors and affected row count are also returned
+func (stxn *SamehadaTxn) ExecuteSQLRetResultSet(sqlStr string) (error, *ResultSet) {
+	stxn.mutex.Lock()
+	defer stxn.mutex.Unlock()
+
+	if stxn.isFinished {
+		return TxnFinishedErr, nil
+	}
+
+	qi, err := stxn.sdb.parseAndRewriteSQL(sqlStr)
+	if err != nil {
+		return err, nil
+	}
+	return stxn.executeQueryLocked(qi)
+}
+
+// qi should be already rewritten with optimizer.RewriteQueryInfo
+func (stxn *SamehadaTxn) executeQuery(qi *parser.QueryInfo) (error, *ResultSet) {
+	stxn.mutex.Lock()
+	defer stxn.mutex.Unlock()
+
+	if stxn.isFinished {
+		return TxnFinishedErr, nil
+	}
+	return stxn.executeQueryLocked(qi)
+}
+
+// caller must having lock of mutex and check isFinished
+func (stxn *SamehadaTxn) executeQueryLocked(qi *parser.QueryInfo) (error, *ResultSet) {
+	switch *qi.QueryType_ {
+	case parser.BEGIN:
+		return TxnAlreadyBegunErr, nil
+	case parser.COMMIT:
+		return stxn.finish(true), nil
+	case parser.ROLLBACK:
+		return stxn.finish(false), nil
+	}
+	if isDDLQuery(qi) {
+		return DDLOnTxnErr, nil
+	}
+
+	txnMgr := stxn.sdb.shi_.GetTransactionManager()
+	txnMgr.AcquireGlobalTxnLatch()
+	err, rs := stxn.sdb.executeQueryOnTxn(qi, stxn.txn)
+	if stxn.txn.GetState() == access.ABORTED {
+		// Abort releases the global transaction latch
+		txnMgr.Abort(stxn.sdb.catalog_, stxn.txn)
+		stxn.isFinished = true
+		if isQueryResultErr(err) {
+			// the txn is also rolled back
+			return err, nil
+		}
+		return TxnAbortedErr, nil
+	}
+	txnMgr.ReleaseGlobalTxnLatch()
+
+	return err, rs
+}
+
+func (stxn *SamehadaTxn) Commit() error {
+	stxn.mutex.Lock()
+	defer stxn.mutex.Unlock()
+
+	if stxn.isFinished {
+		return TxnFinishedErr
+	}
+	return stxn.finish(true)
+}
+
+func (stxn *SamehadaTxn) Rollback() error {
+	stxn.mutex.Lock()
+	defer stxn.mutex.Unlock()
+
+	if stxn.isFinished {
+		return TxnFinishedErr
+	}
+	return stxn.finish(false)
+}
+
+// caller must having lock of mutex and check isFinished
+func (stxn *SamehadaTxn) finish(isCommit bool) error {
+	txnMgr := stxn.sdb.shi_.GetTransactionManager()
+	// Commit and Abort release the global transaction latch
+	txnMgr.AcquireGlobalTxnLatch()
+	if isCommit {
+		txnMgr.Commit(stxn.sdb.catalog_, stxn.txn)
+	} else {
+		txnMgr.Abort(stxn.sdb.catalog_, stxn.txn)
+	}
+	stxn.isFinished = true
+	return nil
+}
+
+// true when the txn is committed or rolled back (includes abort due to lock conflict)
+func (stxn *SamehadaTxn) IsFinished() bool {
+	stxn.mutex.Lock()
+	defer stxn.mutex.Unlock()
+
+	return stxn.isFinished
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/deckarep/golang-set/v2"
 	"github.com/ryogrid/SamehadaDB/lib/common"
@@ -12,11 +13,13 @@ import (
 	"github.com/ryogrid/SamehadaDB/lib/storage/tuple"
 	"github.com/ryogrid/SamehadaDB/lib/types"
 	"math"
+	"math/big"
 	"math/rand"
 	"os"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unsafe"
 )
 
@@ -281,6 +284,30 @@ func encodeToDicOrderComparableBytes(orgVal interface{}, valType types.TypeID) [
 		convedArr := buf.Bytes()
 		convedArr[0] ^= SIGN_MASK_SMALL
 		return convedArr
+	case types.Double:
+		f := orgVal.(float64)
+		u := math.Float64bits(f)
+		if f >= 0 {
+			u |= uint64(SIGN_MASK_BIG) << 32
+		} else {
+			u = ^u
+		}
+		return binary.BigEndian.AppendUint64(nil, u)
+	case types.BigInt, types.Timestamp:
+		// Timestamp is passed as microseconds (int64)
+		convedArr := binary.BigEndian.AppendUint64(nil, uint64(orgVal.(int64)))
+		convedArr[0] ^= SIGN_MASK_SMALL
+		return convedArr
+	case types.Decimal:
+		// value is converted to 16 bytes two's complement integer at max scale for
+		// making values which have different scale comparable
+		unscaled := orgVal.(types.DecimalNum).UnscaledAt(types.MaxDecimalScale)
+		if unscaled.Sign() < 0 {
+			unscaled.Add(unscaled, new(big.Int).Lsh(big.NewInt(1), 128))
+		}
+		convedArr := unscaled.FillBytes(make([]byte, 16))
+		convedArr[0] ^= SIGN_MASK_SMALL
+		return convedArr
 	default:
 		panic("not supported type")
 	}
@@ -308,6 +335,29 @@ func decodeFromDicOrderComparableBytes(convedArr []byte, valType types.TypeID) i
 		var u uint32
 		binary.Read(buf, binary.BigEndian, &u)
 		return int32(u)
+	case types.Double:
+		u := binary.BigEndian.Uint64(convedArr)
+		if u&(uint64(SIGN_MASK_BIG)<<32) > 0 {
+			u &= ^(uint64(SIGN_MASK_BIG) << 32)
+		} else {
+			u = ^u
+		}
+		return math.Float64frombits(u)
+	case types.BigInt, types.Timestamp:
+		convedArr_ := make([]byte, 8)
+		copy(convedArr_, convedArr)
+		convedArr_[0] ^= SIGN_MASK_SMALL
+		return int64(binary.BigEndian.Uint64(convedArr_))
+	case types.Decimal:
+		convedArr_ := make([]byte, 16)
+		copy(convedArr_, convedArr)
+		convedArr_[0] ^= SIGN_MASK_SMALL
+		unscaled := new(big.Int).SetBytes(convedArr_)
+		if convedArr_[0]&SIGN_MASK_SMALL > 0 {
+			unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), 128))
+		}
+		ret, _ := types.NewDecimalFromUnscaled(unscaled, types.MaxDecimalScale)
+		return ret
 	default:
 		panic("not suppoted type")
 	}
@@ -347,11 +397,87 @@ func EncodeValueAndRIDToDicOrderComparableVarchar(orgVal *types.Value, rid *page
 		arrToFill = append(arrToFill, types.UInt64(PackRIDtoUint64(rid)).Serialize()...)
 		//fmt.Println(arrToFill)
 		return types.NewValueFromBytes(arrToFill, types.Varchar)
+	case types.BigInt, types.Double, types.Decimal, types.Timestamp:
+		convedBytes := encodeToDicOrderComparableBytes(dicOrderComparableOrgValOf(orgVal), orgVal.ValueType())
+		totalSizeBytes := types.UInt16(len(convedBytes) + 8).Serialize()
+		// {false, firstByte of str len, secondeByte}
+		arrToFill = append(arrToFill, []byte{0, totalSizeBytes[1], totalSizeBytes[0]}...)
+		arrToFill = append(arrToFill, convedBytes...)
+		arrToFill = append(arrToFill, types.UInt64(PackRIDtoUint64(rid)).Serialize()...)
+		return types.NewValueFromBytes(arrToFill, types.Varchar)
+	case types.Blob:
+		// Blob can contain 0. so, encoding of Varchar can't be used
+		convedBytes := encodeBytesToDicOrderComparableBytes(orgVal.ToBlob())
+		totalSizeBytes := types.UInt16(len(convedBytes) + 8).Serialize()
+		// {false, firstByte of str len, secondeByte}
+		arrToFill = append(arrToFill, []byte{0, totalSizeBytes[1], totalSizeBytes[0]}...)
+		arrToFill = append(arrToFill, convedBytes...)
+		arrToFill = append(arrToFill, types.UInt64(PackRIDtoUint64(rid)).Serialize()...)
+		return types.NewValueFromBytes(arrToFill, types.Varchar)
 	default:
 		panic("not supported type")
 	}
 }
 
+// returns original value of orgVal which is passed to encodeToDicOrderComparableBytes
+func dicOrderComparableOrgValOf(orgVal *types.Value) interface{} {
+	switch orgVal.ValueType() {
+	case types.Integer:
+		return orgVal.ToInteger()
+	case types.Float:
+		return orgVal.ToFloat()
+	case types.BigInt:
+		return orgVal.ToBigInt()
+	case types.Double:
+		return orgVal.ToDouble()
+	case types.Decimal:
+		return orgVal.ToDecimal()
+	case types.Timestamp:
+		return orgVal.ToTimestamp().UnixMicro()
+	default:
+		panic("not supported type")
+	}
+}
+
+// returned value of decodeFromDicOrderComparableBytes is converted to Value
+func dicOrderComparableValueOf(decoded interface{}, valType types.TypeID) *types.Value {
+	if valType == types.Timestamp {
+		return GetPonterOfValue(types.NewTimestamp(time.UnixMicro(decoded.(int64))))
+	}
+	return GetPonterOfValue(types.NewValue(decoded))
+}
+
+// 0 in buf is escaped to {0, 255} and {0, 1} is appended as terminator.
+// so, dictionary order of encoded bytes is same as buf even if it is followed by other bytes
+func encodeBytesToDicOrderComparableBytes(buf []byte) []byte {
+	ret := make([]byte, 0, len(buf)+2)
+	for _, b := range buf {
+		if b == 0 {
+			ret = append(ret, 0, 255)
+		} else {
+			ret = append(ret, b)
+		}
+	}
+	return append(ret, 0, 1)
+}
+
+// inverse of encodeBytesToDicOrderComparableBytes. following bytes of the terminator are ignored
+func decodeBytesFromDicOrderComparableBytes(convedArr []byte) []byte {
+	ret := make([]byte, 0, len(convedArr))
+	for ii := 0; ii < len(convedArr); ii++ {
+		if convedArr[ii] != 0 {
+			ret = append(ret, convedArr[ii])
+			continue
+		}
+		if ii+1 >= len(convedArr) || convedArr[ii+1] == 1 {
+			break
+		}
+		ret = append(ret, 0)
+		ii++
+	}
+	return ret
+}
+
 func ExtractOrgKeyFromDicOrderComparableEncodedVarchar(encodedVal *types.Value, valType types.TypeID) *types.Value {
 	switch valType {
 	case types.Integer:
@@ -366,6 +492,12 @@ func ExtractOrgKeyFromDicOrderComparableEncodedVarchar(encodedVal *types.Value,
 		encodedStr := encodedVal.ToString()
 		orgStr := encodedStr[:len(encodedStr)-(4+8)]
 		return GetPonterOfValue(types.NewVarchar(orgStr))
+	case types.BigInt, types.Double, types.Decimal, types.Timestamp:
+		encodedStrBytes := encodedVal.Serialize()
+		return dicOrderComparableValueOf(decodeFromDicOrderComparableBytes(encodedStrBytes[3:len(encodedStrBytes)-8], valType), valType)
+	case types.Blob:
+		encodedStr := encodedVal.ToString()
+		return GetPonterOfValue(types.NewBlob(decodeBytesFromDicOrderComparableBytes([]byte(encodedStr[:len(encodedStr)-8]))))
 	default:
 		panic("not supported type")
 	}
@@ -382,11 +514,85 @@ func ExtractOrgKeyFromDicOrderComparableEncodedBytes(buf []byte, valType types.T
 	case types.Varchar:
 		orgStr := string(buf)
 		return GetPonterOfValue(types.NewVarchar(orgStr))
+	case types.BigInt, types.Double, types.Decimal, types.Timestamp:
+		return dicOrderComparableValueOf(decodeFromDicOrderComparableBytes(buf[3:len(buf)-8], valType), valType)
+	case types.Blob:
+		return GetPonterOfValue(types.NewBlob(decodeBytesFromDicOrderComparableBytes(buf)))
 	default:
 		panic("not supported type")
 	}
 }
 
+// encodes values to byte array whose dictionary order is same as order of the values.
+// values are compared from first element and each encoded element is self-delimiting.
+// so, encoded prefix of values is usable as a bound of range scan.
+//
+// format of each element: {0} (NULL) or {1, encoded value}
+// Varchar value is terminated with {0, 1} and 0 in the value is escaped to {0, 255}
+func EncodeValuesToDicOrderComparableBytes(vals []*types.Value) []byte {
+	ret := make([]byte, 0)
+	for _, val := range vals {
+		if val.IsNull() {
+			// NULL is placed before all values
+			ret = append(ret, 0)
+			continue
+		}
+		ret = append(ret, 1)
+		switch val.ValueType() {
+		case types.Integer:
+			ret = append(ret, encodeToDicOrderComparableBytes(val.ToInteger(), types.Integer)...)
+		case types.Float:
+			ret = append(ret, encodeToDicOrderComparableBytes(val.ToFloat(), types.Float)...)
+		case types.Varchar:
+			for _, b := range []byte(val.ToVarchar()) {
+				if b == 0 {
+					ret = append(ret, 0, 255)
+				} else {
+					ret = append(ret, b)
+				}
+			}
+			ret = append(ret, 0, 1)
+		case types.Boolean:
+			ret = append(ret, byte(boolToUint8(val.ToBoolean())))
+		case types.BigInt, types.Double, types.Decimal, types.Timestamp:
+			ret = append(ret, encodeToDicOrderComparableBytes(dicOrderComparableOrgValOf(val), val.ValueType())...)
+		case types.Blob:
+			ret = append(ret, encodeBytesToDicOrderComparableBytes(val.ToBlob())...)
+		default:
+			panic("not supported type")
+		}
+	}
+	return ret
+}
+
+func boolToUint8(val bool) uint8 {
+	if val {
+		return 1
+	}
+	return 0
+}
+
+// key of composite index. RID is concatenated for supporting key duplication like EncodeValueAndRIDToDicOrderComparableVarchar
+func EncodeValuesAndRIDToDicOrderComparableVarchar(vals []*types.Value, rid *page.RID) *types.Value {
+	buf := EncodeValuesToDicOrderComparableBytes(vals)
+	buf = append(buf, PackRIDto8bytes(rid)...)
+	return GetPonterOfValue(types.NewVarchar(string(buf)))
+}
+
+// returns smallest key of composite index whose values begin with vals
+func GetLowerBoundOfDicOrderComparableValues(vals []*types.Value) *types.Value {
+	return GetPonterOfValue(types.NewVarchar(string(EncodeValuesToDicOrderComparableBytes(vals))))
+}
+
+// returns value which is bigger than all keys of composite index whose values begin with vals
+func GetUpperBoundOfDicOrderComparableValues(vals []*types.Value) *types.Value {
+	buf := EncodeValuesToDicOrderComparableBytes(vals)
+	// following byte of encoded values is head of next element ({0} or {1}) or RID (8 bytes).
+	// so, 9 bytes of 255 is bigger than all of them
+	buf = append(buf, bytes.Repeat([]byte{255}, 9)...)
+	return GetPonterOfValue(types.NewVarchar(string(buf)))
+}
+
 func SHAssert(cond bool, msg string) {
 	if !cond {
 		panic(msg)
@@ -455,6 +661,93 @@ func IsConstantValue(v interface{}) bool {
 	}
 }
 
+// returns string literal which can be embedded in SQL string.
+// backslash is an escape character on SQL parser of SamehadaDB
+func QuoteStringLiteral(str string) string {
+	str = strings.ReplaceAll(str, "\\", "\\\\")
+	str = strings.ReplaceAll(str, "'", "''")
+	return "'" + str + "'"
+}
+
+// returns numeric literal which is parsed as Float (not Integer) value
+func FloatLiteral(f float64, bitSize int) string {
+	ret := strconv.FormatFloat(f, 'f', -1, bitSize)
+	if !strings.Contains(ret, ".") {
+		ret += ".0"
+	}
+	return ret
+}
+
+var MixedPlaceholderStyleErr = errors.New("placeholders ? and $n can't be used together")
+
+// ReplacePlaceholders replaces placeholders ($1, $2, ... or ?) in sqlStr with return values of
+// conv(n) (n is 1-origin). n of ? is its position in sqlStr.
+// placeholders in string literals and quoted identifiers are ignored
+func ReplacePlaceholders(sqlStr string, conv func(n int) (string, error)) (string, error) {
+	var sb strings.Builder
+	var quote byte = 0
+	questionNum := 0
+	hasDollar := false
+	for idx := 0; idx < len(sqlStr); idx++ {
+		c := sqlStr[idx]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '\'' && idx+1 < len(sqlStr) {
+				// escaped character
+				sb.WriteByte(c)
+				idx++
+				c = sqlStr[idx]
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '$' && idx+1 < len(sqlStr) && sqlStr[idx+1] >= '0' && sqlStr[idx+1] <= '9':
+			end := idx + 1
+			for end < len(sqlStr) && sqlStr[end] >= '0' && sqlStr[end] <= '9' {
+				end++
+			}
+			if questionNum > 0 {
+				return "", MixedPlaceholderStyleErr
+			}
+			hasDollar = true
+			n, _ := strconv.Atoi(sqlStr[idx+1 : end])
+			replaced, err := conv(n)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(replaced)
+			idx = end - 1
+			continue
+		case c == '?':
+			if hasDollar {
+				return "", MixedPlaceholderStyleErr
+			}
+			questionNum++
+			replaced, err := conv(questionNum)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(replaced)
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String(), nil
+}
+
+// returns the largest placeholder number in sqlStr
+func CountPlaceholders(sqlStr string) int {
+	ret := 0
+	ReplacePlaceholders(sqlStr, func(n int) (string, error) {
+		if n > ret {
+			ret = n
+		}
+		return "", nil
+	})
+	return ret
+}
+
 // make deep copied object and set its address to dst pointer type arg
 // ex:
 // DeepCopy(&dst, &src)
@@ -502,6 +795,19 @@ func ConvValueListToIFs(vals [][]*types.Value) [][]interface{} {
 					ifsList = append(ifsList, val.ToFloat())
 				case types.Varchar:
 					ifsList = append(ifsList, val.ToString())
+				case types.Boolean:
+					ifsList = append(ifsList, val.ToBoolean())
+				case types.BigInt:
+					ifsList = append(ifsList, val.ToBigInt())
+				case types.Double:
+					ifsList = append(ifsList, val.ToDouble())
+				case types.Decimal:
+					// string for keeping exactness
+					ifsList = append(ifsList, val.ToString())
+				case types.Timestamp:
+					ifsList = append(ifsList, val.ToTimestamp())
+				case types.Blob:
+					ifsList = append(ifsList, val.ToBlob())
 				default:
 					panic("not supported Value object")
 				}
@@ -5,6 +5,7 @@ import (
 	testing2 "github.com/ryogrid/SamehadaDB/lib/testing/testing_assert"
 	"github.com/ryogrid/SamehadaDB/lib/types"
 	"math"
+	"strconv"
 	"testing"
 )
 
@@ -102,3 +103,99 @@ func TestEncDecOrgKeyAndRIDConcated(t *testing.T) {
 	charVal2 := testEncDecOrgKeyAndRIDConcated(t, GetPonterOfValue(types.NewVarchar("abcde")), types.Varchar, page.RID{-1, 128})
 	testing2.SimpleAssert(t, charVal2.CompareGreaterThan(*charVal1))
 }
+
+func TestEncDecOrgKeyAndRIDConcatedOfAdditionalTypes(t *testing.T) {
+	// each list is in ascending order
+	decimalOf := func(str string) types.Value {
+		d, _ := types.NewDecimalFromString(str)
+		return types.NewDecimal(d)
+	}
+	timestampOf := func(str string) types.Value {
+		ts, _ := types.NewTimestampFromString(str)
+		return ts
+	}
+	valLists := [][]types.Value{
+		{types.NewBigInt(math.MinInt64 + 1), types.NewBigInt(-5000000000), types.NewBigInt(-1), types.NewBigInt(0), types.NewBigInt(5000000000), types.NewBigInt(math.MaxInt64 - 1)},
+		{types.NewDouble(-1.0e300), types.NewDouble(-2.5), types.NewDouble(0), types.NewDouble(1.0e-300), types.NewDouble(2.5), types.NewDouble(1.0e300)},
+		{decimalOf("-92233720368547758.07"), decimalOf("-1.5"), decimalOf("-0.000001"), decimalOf("0"), decimalOf("0.1"), decimalOf("1.49"), decimalOf("1.5"), decimalOf("12"), decimalOf("9223372036854775806")},
+		{timestampOf("1960-01-01"), timestampOf("1970-01-01 00:00:00.000001"), timestampOf("2024-02-29 12:34:56"), timestampOf("2024-02-29 12:34:56.5")},
+		{types.NewBlob([]byte{}), types.NewBlob([]byte{0}), types.NewBlob([]byte{0, 0}), types.NewBlob([]byte{0, 1}), types.NewBlob([]byte{1}), types.NewBlob([]byte{255, 0})},
+	}
+	for _, vals := range valLists {
+		for idx := range vals {
+			encoded := testEncDecOrgKeyAndRIDConcated(t, &vals[idx], vals[idx].ValueType(), page.RID{-1, 128})
+			if idx == 0 {
+				continue
+			}
+			prevEncoded := EncodeValueAndRIDToDicOrderComparableVarchar(&vals[idx-1], &page.RID{math.MaxInt32, math.MaxUint32})
+			testing2.SimpleAssert(t, prevEncoded.CompareLessThan(*encoded))
+			testing2.SimpleAssert(t, GetLowerBoundOfDicOrderComparableValues([]*types.Value{&vals[idx-1]}).CompareLessThan(*GetLowerBoundOfDicOrderComparableValues([]*types.Value{&vals[idx]})))
+		}
+	}
+
+	// values which have different scale are same key
+	testing2.SimpleAssert(t, EncodeValueAndRIDToDicOrderComparableVarchar(GetPonterOfValue(decimalOf("1.5")), &page.RID{1, 1}).CompareEquals(
+		*EncodeValueAndRIDToDicOrderComparableVarchar(GetPonterOfValue(decimalOf("1.500")), &page.RID{1, 1})))
+}
+
+func TestEncodeValuesToComparableVarchar(t *testing.T) {
+	encode := func(strVal string, intVal int32, rid page.RID) *types.Value {
+		return EncodeValuesAndRIDToDicOrderComparableVarchar([]*types.Value{GetPonterOfValue(types.NewVarchar(strVal)), GetPonterOfValue(types.NewInteger(intVal))}, &rid)
+	}
+	key1 := encode("abc", 10, page.RID{3, 1})
+	key2 := encode("abc", 11, page.RID{1, 1})
+	key3 := encode("abcd", -5, page.RID{1, 1})
+	key4 := encode("ab\x00c", 100, page.RID{1, 1})
+	key5 := encode("abc", -5, page.RID{2, 1})
+
+	// first value is compared first
+	testing2.SimpleAssert(t, key1.CompareLessThan(*key2))
+	testing2.SimpleAssert(t, key2.CompareLessThan(*key3))
+	testing2.SimpleAssert(t, key4.CompareLessThan(*key1))
+	testing2.SimpleAssert(t, key5.CompareLessThan(*key1))
+
+	// NULL is placed before all values
+	nullKey := EncodeValuesAndRIDToDicOrderComparableVarchar([]*types.Value{GetPonterOfValue(types.NewNullOfType(types.Varchar)), GetPonterOfValue(types.NewInteger(100))}, &page.RID{1, 1})
+	testing2.SimpleAssert(t, nullKey.CompareLessThan(*key4))
+
+	// bounds of prefix
+	prefix := []*types.Value{GetPonterOfValue(types.NewVarchar("abc"))}
+	lower := GetLowerBoundOfDicOrderComparableValues(prefix)
+	upper := GetUpperBoundOfDicOrderComparableValues(prefix)
+	for _, key := range []*types.Value{key1, key2, key5} {
+		testing2.SimpleAssert(t, lower.CompareLessThanOrEqual(*key) && key.CompareLessThan(*upper))
+	}
+	for _, key := range []*types.Value{key3, key4, nullKey} {
+		testing2.SimpleAssert(t, key.CompareLessThan(*lower) || upper.CompareLessThan(*key))
+	}
+	fullKey := []*types.Value{GetPonterOfValue(types.NewVarchar("abc")), GetPonterOfValue(types.NewInteger(10))}
+	testing2.SimpleAssert(t, key1.CompareLessThan(*GetUpperBoundOfDicOrderComparableValues(fullKey)))
+	testing2.SimpleAssert(t, GetUpperBoundOfDicOrderComparableValues(fullKey).CompareLessThan(*key2))
+}
+
+func TestReplacePlaceholders(t *testing.T) {
+	conv := func(n int) (string, error) {
+		return "p" + strconv.Itoa(n), nil
+	}
+
+	replaced, err := ReplacePlaceholders("SELECT a FROM t WHERE a = $1 AND b = $12;", conv)
+	testing2.SimpleAssert(t, err == nil)
+	testing2.SimpleAssert(t, replaced == "SELECT a FROM t WHERE a = p1 AND b = p12;")
+
+	// placeholders in string literal and quoted identifier are not replaced
+	replaced, _ = ReplacePlaceholders("SELECT `$1` FROM t WHERE a = '$1' AND b = 'x\\'$2' AND c = $3", conv)
+	testing2.SimpleAssert(t, replaced == "SELECT `$1` FROM t WHERE a = '$1' AND b = 'x\\'$2' AND c = p3")
+
+	replaced, err = ReplacePlaceholders("UPDATE t SET a = ? WHERE b = '?' AND c = ?", conv)
+	testing2.SimpleAssert(t, err == nil)
+	testing2.SimpleAssert(t, replaced == "UPDATE t SET a = p1 WHERE b = '?' AND c = p2")
+	_, err = ReplacePlaceholders("SELECT a FROM t WHERE a = ? AND b = $2", conv)
+	testing2.SimpleAssert(t, err == MixedPlaceholderStyleErr)
+
+	testing2.SimpleAssert(t, CountPlaceholders("INSERT INTO t(a, b) VALUES ($2, $1);") == 2)
+	testing2.SimpleAssert(t, CountPlaceholders("SELECT a FROM t WHERE b = '$1';") == 0)
+
+	testing2.SimpleAssert(t, QuoteStringLiteral("O'Brien\\") == "'O''Brien\\\\'")
+	testing2.SimpleAssert(t, FloatLiteral(180, 64) == "180.0")
+	testing2.SimpleAssert(t, FloatLiteral(1.5, 32) == "1.5")
+}
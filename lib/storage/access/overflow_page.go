@@ -0,0 +1,91 @@
+package access
+
+import (
+	"encoding/binary"
+	"unsafe"
+
+	"github.com/ryogrid/SamehadaDB/lib/common"
+	"github.com/ryogrid/SamehadaDB/lib/storage/page"
+	"github.com/ryogrid/SamehadaDB/lib/storage/tuple"
+	"github.com/ryogrid/SamehadaDB/lib/types"
+)
+
+const sizeOverflowPageHeader = uint32(16)
+const offsetOverflowNextPageId = uint32(8)
+const offsetOverflowDataSize = uint32(12)
+
+// OverflowPageCapacity is max size of data which is stored in an overflow page
+const OverflowPageCapacity = common.PageSize - sizeOverflowPageHeader
+
+// tuple which is larger than this is stored in overflow pages (same as TOAST_TUPLE_THRESHOLD of PostgreSQL)
+const overflowThreshold = (common.PageSize - sizeTablePageHeader) / 4
+
+// first 4 bytes of tuple which points overflow pages.
+// normal tuple never starts with it because first 4 bytes of normal tuple is NULL flag (0 or 1) of inlined
+// column or offset of variable length value which is smaller than tuple size
+const overflowTupleMarker = uint32(0xFFFFFFFF)
+
+// tuple which is stored in table page instead of large tuple
+const sizeOverflowTuple = uint32(12)
+
+// Overflow page stores a part of large tuple. pages which store the tuple are linked with NextPageId
+//
+//	Overflow page format (size in bytes):
+//	-------------------------------------------------------------
+//	| PageId (4)| LSN (4)| NextPageId (4)| DataSize (4)| DATA |
+//	-------------------------------------------------------------
+//
+//	Format of tuple which is stored in table page instead of large tuple (size in bytes):
+//	-------------------------------------------------------------------------------
+//	| 0xFFFFFFFF (4) | size of original tuple (4) | PageId of first overflow page (4) |
+//	-------------------------------------------------------------------------------
+type OverflowPage struct {
+	page.Page
+}
+
+// CastPageAsOverflowPage casts the abstract Page struct into OverflowPage
+func CastPageAsOverflowPage(page *page.Page) *OverflowPage {
+	if page == nil {
+		return nil
+	}
+
+	return (*OverflowPage)(unsafe.Pointer(page))
+}
+
+// Init writes header and data to the page. data should be smaller than or equal to OverflowPageCapacity
+func (op *OverflowPage) Init(pageId types.PageID, nextPageId types.PageID, data []byte) {
+	op.Copy(0, pageId.Serialize())
+	op.Copy(offsetOverflowNextPageId, nextPageId.Serialize())
+	op.Copy(offsetOverflowDataSize, types.UInt32(len(data)).Serialize())
+	op.Copy(sizeOverflowPageHeader, data)
+}
+
+func (op *OverflowPage) GetNextPageId() types.PageID {
+	return types.NewPageIDFromBytes(op.Data()[offsetOverflowNextPageId:])
+}
+
+// GetOverflowData returns copy of data stored in the page
+func (op *OverflowPage) GetOverflowData() []byte {
+	dataSize := uint32(types.NewUInt32FromBytes(op.Data()[offsetOverflowDataSize:]))
+	ret := make([]byte, dataSize)
+	copy(ret, op.Data()[sizeOverflowPageHeader:sizeOverflowPageHeader+dataSize])
+	return ret
+}
+
+func newOverflowTuple(tupleSize uint32, firstPageId types.PageID) *tuple.Tuple {
+	data := make([]byte, sizeOverflowTuple)
+	binary.LittleEndian.PutUint32(data[0:], overflowTupleMarker)
+	binary.LittleEndian.PutUint32(data[4:], tupleSize)
+	binary.LittleEndian.PutUint32(data[8:], uint32(firstPageId))
+	return tuple.NewTuple(nil, sizeOverflowTuple, data)
+}
+
+// IsOverflowTuple returns true when tuple_ points overflow pages which store the original tuple
+func IsOverflowTuple(tuple_ *tuple.Tuple) bool {
+	return tuple_ != nil && tuple_.Size() == sizeOverflowTuple && binary.LittleEndian.Uint32(tuple_.Data()) == overflowTupleMarker
+}
+
+// returns size of original tuple and PageId of first overflow page
+func parseOverflowTuple(tuple_ *tuple.Tuple) (uint32, types.PageID) {
+	return binary.LittleEndian.Uint32(tuple_.Data()[4:]), types.PageID(binary.LittleEndian.Uint32(tuple_.Data()[8:]))
+}
@@ -73,6 +73,12 @@ func (t *TableHeap) InsertTuple(tuple_ *tuple.Tuple, txn *Transaction, oid uint3
 		}
 	}
 
+	// large tuple is stored in overflow pages and table page stores the tuple which points them
+	storedTuple := tuple_
+	if tuple_.Size() > overflowThreshold {
+		storedTuple = t.writeOverflowPages(tuple_, txn)
+	}
+
 	// seek from last (almost case)
 	currentPage := CastPageAsTablePage(t.bpm.FetchPage(t.lastPageId))
 	//currentPage := CastPageAsTablePage(t.bpm.FetchPage(t.firstPageId))
@@ -83,7 +89,7 @@ func (t *TableHeap) InsertTuple(tuple_ *tuple.Tuple, txn *Transaction, oid uint3
 	// INVARIANT: currentPage is WLatched if you leave the loop normally.
 
 	for {
-		rid, err = currentPage.InsertTuple(tuple_, t.log_manager, t.lock_manager, txn)
+		rid, err = currentPage.InsertTuple(storedTuple, t.log_manager, t.lock_manager, txn)
 		if err == nil || err == ErrEmptyTuple {
 			break
 		}
@@ -129,6 +135,7 @@ func (t *TableHeap) InsertTuple(tuple_ *tuple.Tuple, txn *Transaction, oid uint3
 	}
 	currentPage.RemoveWLatchRecord(int32(txn.txn_id))
 	currentPage.WUnlatch()
+	tuple_.SetRID(rid)
 	if !isForUpdate {
 		// Update the transaction's write set.
 		txn.AddIntoWriteSet(NewWriteRecord(rid, nil, INSERT, tuple_, nil, t, oid))
@@ -152,26 +159,72 @@ func (t *TableHeap) UpdateTuple(tuple_ *tuple.Tuple, update_col_idxs []int, sche
 			}()
 		}
 	}
-	// Find the page which contains the tuple1.
-	page_ := CastPageAsTablePage(t.bpm.FetchPage(rid.GetPageId()))
-	// If the page could not be found, then abort the transaction.
-	if page_ == nil {
-		txn.SetState(ABORTED)
-		return false, nil, ErrGeneral, nil, nil
-	}
 	// Update the tuple1; but first save the old value for rollbacks.
 	old_tuple := new(tuple.Tuple)
 	old_tuple.SetRID(new(page.RID))
 
-	page_.WLatch()
-	page_.AddWLatchRecord(int32(txn.txn_id))
-	is_updated, err, need_follow_tuple := page_.UpdateTuple(tuple_, update_col_idxs, schema_, old_tuple, &rid, txn, t.lock_manager, t.log_manager, false)
-	t.bpm.UnpinPage(page_.GetPageId(), is_updated)
-	if common.EnableDebug && common.ActiveLogKindSetting&common.PIN_COUNT_ASSERT > 0 {
-		common.SH_Assert(page_.PinCount() == 0, "PinCount is not zero when finish TablePage::UpdateTuple!!!")
+	var is_updated = false
+	var need_follow_tuple *tuple.Tuple = nil
+	var err error = nil
+	storedOldTuple := t.peekStoredTuple(&rid)
+	if IsOverflowTuple(storedOldTuple) || (update_col_idxs == nil && tuple_.Size() > overflowThreshold) {
+		// tuple which uses overflow pages is updated with delete and insert
+		// because old overflow pages should be kept until commit for rollback
+		if !txn.IsRecoveryPhase() {
+			// Acquire an exclusive lock, upgrading from shared if necessary.
+			if txn.IsSharedLocked(&rid) {
+				if !t.lock_manager.LockUpgrade(txn, &rid) {
+					txn.SetState(ABORTED)
+					return false, nil, ErrGeneral, nil, nil
+				}
+			} else if !txn.IsExclusiveLocked(&rid) && !t.lock_manager.LockExclusive(txn, &rid) {
+				txn.SetState(ABORTED)
+				return false, nil, ErrGeneral, nil, nil
+			}
+			// stored tuple may be changed before the lock is acquired
+			storedOldTuple = t.peekStoredTuple(&rid)
+		}
+		if storedOldTuple == nil {
+			txn.SetState(ABORTED)
+			return false, nil, ErrGeneral, nil, nil
+		}
+		old_tuple = t.readOverflowPagesIfNeeded(storedOldTuple)
+		old_tuple.SetRID(&rid)
+		need_follow_tuple = tuple_
+		if update_col_idxs != nil && schema_ != nil {
+			// update specifed columns only case
+			update_tuple_values := make([]types.Value, 0)
+			matched_cnt := int(0)
+			for idx := range schema_.GetColumns() {
+				if matched_cnt < len(update_col_idxs) && idx == update_col_idxs[matched_cnt] {
+					update_tuple_values = append(update_tuple_values, tuple_.GetValue(schema_, uint32(idx)))
+					matched_cnt++
+				} else {
+					update_tuple_values = append(update_tuple_values, old_tuple.GetValue(schema_, uint32(idx)))
+				}
+			}
+			need_follow_tuple = tuple.NewTupleFromSchema(update_tuple_values, schema_)
+		}
+		err = ErrNotEnoughSpace
+	} else {
+		// Find the page which contains the tuple1.
+		page_ := CastPageAsTablePage(t.bpm.FetchPage(rid.GetPageId()))
+		// If the page could not be found, then abort the transaction.
+		if page_ == nil {
+			txn.SetState(ABORTED)
+			return false, nil, ErrGeneral, nil, nil
+		}
+
+		page_.WLatch()
+		page_.AddWLatchRecord(int32(txn.txn_id))
+		is_updated, err, need_follow_tuple = page_.UpdateTuple(tuple_, update_col_idxs, schema_, old_tuple, &rid, txn, t.lock_manager, t.log_manager, false)
+		t.bpm.UnpinPage(page_.GetPageId(), is_updated)
+		if common.EnableDebug && common.ActiveLogKindSetting&common.PIN_COUNT_ASSERT > 0 {
+			common.SH_Assert(page_.PinCount() == 0, "PinCount is not zero when finish TablePage::UpdateTuple!!!")
+		}
+		page_.RemoveWLatchRecord(int32(txn.txn_id))
+		page_.WUnlatch()
 	}
-	page_.RemoveWLatchRecord(int32(txn.txn_id))
-	page_.WUnlatch()
 
 	var new_rid *page.RID
 	var isUpdateWithDelInsert = false
@@ -256,6 +309,11 @@ func (t *TableHeap) MarkDelete(rid *page.RID, oid uint32, txn *Transaction, isFo
 	}
 	page_.RemoveWLatchRecord(int32(txn.txn_id))
 	page_.WUnlatch()
+	if is_marked && IsOverflowTuple(markedTuple) {
+		// overflow pages are needed until commit for rollback
+		t.DeallocatePagesAtCommit(t.getOverflowPageIds(markedTuple), txn)
+		markedTuple = t.readOverflowPagesIfNeeded(markedTuple)
+	}
 	if is_marked && !isForUpdate {
 		// Update the transaction's write set.
 		txn.AddIntoWriteSet(NewWriteRecord(rid, nil, DELETE, markedTuple, nil, t, oid))
@@ -283,6 +341,7 @@ func (t *TableHeap) ApplyDelete(rid *page.RID, txn *Transaction) {
 	// Delete the tuple1 from the page.
 	page_.WLatch()
 	page_.AddWLatchRecord(int32(txn.txn_id))
+	overflowPageIds := t.getOverflowPageIdsAt(page_, rid)
 	page_.ApplyDelete(rid, txn, t.log_manager)
 
 	// reset seek start point of Insert to first page
@@ -294,6 +353,7 @@ func (t *TableHeap) ApplyDelete(rid *page.RID, txn *Transaction) {
 	}
 	page_.RemoveWLatchRecord(int32(txn.txn_id))
 	page_.WUnlatch()
+	t.deallocatePages(overflowPageIds)
 }
 
 func (t *TableHeap) RollbackDelete(rid *page.RID, txn *Transaction) {
@@ -336,6 +396,15 @@ func (t *TableHeap) GetTuple(rid *page.RID, txn *Transaction) (*tuple.Tuple, err
 			}()
 		}
 	}
+	ret, err := t.getStoredTuple(rid, txn)
+	if err != nil {
+		return ret, err
+	}
+	return t.readOverflowPagesIfNeeded(ret), nil
+}
+
+// returns tuple which is stored in table page. it may point overflow pages
+func (t *TableHeap) getStoredTuple(rid *page.RID, txn *Transaction) (*tuple.Tuple, error) {
 	if !txn.IsRecoveryPhase() {
 		if !txn.IsSharedLocked(rid) && !txn.IsExclusiveLocked(rid) && !t.lock_manager.LockShared(txn, rid) {
 			txn.SetState(ABORTED)
@@ -392,6 +461,158 @@ func (t *TableHeap) Iterator(txn *Transaction) *TableHeapIterator {
 	return NewTableHeapIterator(t, t.lock_manager, txn)
 }
 
+// GetPageIds returns ids of all pages which compose the table heap
+func (t *TableHeap) GetPageIds() []types.PageID {
+	ret := make([]types.PageID, 0)
+	pageId := t.firstPageId
+	for pageId.IsValid() {
+		ret = append(ret, pageId)
+		page_ := CastPageAsTablePage(t.bpm.FetchPage(pageId))
+		page_.RLatch()
+		nextPageId := page_.GetNextPageId()
+		page_.RUnlatch()
+		t.bpm.UnpinPage(pageId, false)
+		pageId = nextPageId
+	}
+	return ret
+}
+
+// DeallocatePagesAtCommit registers pages which are deallocated when txn is committed.
+// pages are not needed to be ones of this table heap (ex: pages of index of the table)
+// because only buffer pool manager of this is used.
+// when txn is aborted, the pages are kept as is
+func (t *TableHeap) DeallocatePagesAtCommit(pageIds []types.PageID, txn *Transaction) {
+	for _, pageId := range pageIds {
+		txn.AddIntoWriteSet(NewWriteRecord(&page.RID{PageId: pageId}, nil, DEALLOCATE, nil, nil, t, 0))
+	}
+}
+
+// writes data of tuple_ to overflow pages and returns tuple which points them.
+// the pages are written from the tail of data because each page has PageId of next page
+func (t *TableHeap) writeOverflowPages(tuple_ *tuple.Tuple, txn *Transaction) *tuple.Tuple {
+	data := tuple_.Data()[:tuple_.Size()]
+	pageCnt := (len(data) + int(OverflowPageCapacity) - 1) / int(OverflowPageCapacity)
+	nextPageId := types.InvalidPageID
+	for ii := pageCnt - 1; ii >= 0; ii-- {
+		chunk := data[ii*int(OverflowPageCapacity) : min((ii+1)*int(OverflowPageCapacity), len(data))]
+		p := t.bpm.NewPage()
+		overflowPage := CastPageAsOverflowPage(p)
+		overflowPage.WLatch()
+		overflowPage.AddWLatchRecord(int32(txn.txn_id))
+		overflowPage.Init(p.GetPageId(), nextPageId, chunk)
+		if t.log_manager.IsEnabledLogging() {
+			log_record := recovery.NewLogRecordNewOverflowPage(txn.GetTransactionId(), txn.GetPrevLSN(), p.GetPageId(), nextPageId, chunk)
+			lsn := t.log_manager.AppendLogRecord(log_record)
+			overflowPage.SetLSN(lsn)
+			txn.SetPrevLSN(lsn)
+		}
+		// flush page for recovery process works...
+		t.bpm.FlushPage(p.GetPageId())
+		t.bpm.UnpinPage(p.GetPageId(), true)
+		overflowPage.RemoveWLatchRecord(int32(txn.txn_id))
+		overflowPage.WUnlatch()
+		nextPageId = p.GetPageId()
+	}
+	return newOverflowTuple(tuple_.Size(), nextPageId)
+}
+
+// returns original tuple when tuple_ points overflow pages. otherwise, tuple_ is returned as is
+func (t *TableHeap) readOverflowPagesIfNeeded(tuple_ *tuple.Tuple) *tuple.Tuple {
+	if !IsOverflowTuple(tuple_) {
+		return tuple_
+	}
+	tupleSize, pageId := parseOverflowTuple(tuple_)
+	data := make([]byte, 0, tupleSize)
+	for pageId.IsValid() {
+		overflowPage := CastPageAsOverflowPage(t.bpm.FetchPage(pageId))
+		overflowPage.RLatch()
+		data = append(data, overflowPage.GetOverflowData()...)
+		nextPageId := overflowPage.GetNextPageId()
+		overflowPage.RUnlatch()
+		t.bpm.UnpinPage(pageId, false)
+		pageId = nextPageId
+	}
+	return tuple.NewTuple(tuple_.GetRID(), tupleSize, data)
+}
+
+// returns ids of overflow pages which tuple_ points
+func (t *TableHeap) getOverflowPageIds(tuple_ *tuple.Tuple) []types.PageID {
+	ret := make([]types.PageID, 0)
+	_, pageId := parseOverflowTuple(tuple_)
+	for pageId.IsValid() {
+		ret = append(ret, pageId)
+		overflowPage := CastPageAsOverflowPage(t.bpm.FetchPage(pageId))
+		overflowPage.RLatch()
+		nextPageId := overflowPage.GetNextPageId()
+		overflowPage.RUnlatch()
+		t.bpm.UnpinPage(pageId, false)
+		pageId = nextPageId
+	}
+	return ret
+}
+
+// returns copy of tuple which is stored in table page without locking. deleted mark of the tuple is ignored.
+// nil is returned when the slot is empty
+func (t *TableHeap) peekStoredTuple(rid *page.RID) *tuple.Tuple {
+	page_ := CastPageAsTablePage(t.bpm.FetchPage(rid.GetPageId()))
+	if page_ == nil {
+		return nil
+	}
+	page_.RLatch()
+	ret := peekStoredTupleOfPage(page_, rid)
+	page_.RUnlatch()
+	t.bpm.UnpinPage(page_.GetPageId(), false)
+	return ret
+}
+
+// same as peekStoredTuple but page_ should be latched by caller
+func peekStoredTupleOfPage(page_ *TablePage, rid *page.RID) *tuple.Tuple {
+	slotNum := rid.GetSlotNum()
+	if slotNum >= page_.GetTupleCount() {
+		return nil
+	}
+	tupleSize := UnsetDeletedFlag(page_.GetTupleSize(slotNum))
+	if tupleSize == 0 {
+		return nil
+	}
+	tupleOffset := page_.GetTupleOffsetAtSlot(slotNum)
+	data := make([]byte, tupleSize)
+	copy(data, page_.Data()[tupleOffset:tupleOffset+tupleSize])
+	return tuple.NewTuple(rid, tupleSize, data)
+}
+
+// returns ids of overflow pages which tuple at rid points. page_ should be latched by caller
+func (t *TableHeap) getOverflowPageIdsAt(page_ *TablePage, rid *page.RID) []types.PageID {
+	storedTuple := peekStoredTupleOfPage(page_, rid)
+	if !IsOverflowTuple(storedTuple) {
+		return nil
+	}
+	return t.getOverflowPageIds(storedTuple)
+}
+
+// deallocates pages immediately. this is used for overflow pages which are not referred by any txn
+func (t *TableHeap) deallocatePages(pageIds []types.PageID) {
+	for _, pageId := range pageIds {
+		t.deallocatePage(pageId)
+	}
+}
+
+// called by TransactionManager::Commit after commit log record is flushed.
+// pins of the page are released all because in-memory object which is not used anymore
+// can keep the page pinned (ex: header page of skip list)
+func (t *TableHeap) deallocatePage(pageId types.PageID) {
+	pg := t.bpm.FetchPage(pageId)
+	if pg == nil {
+		// already deallocated
+		return
+	}
+	pg.SetIsDeallocated(true)
+	for pg.PinCount() > 0 {
+		t.bpm.UnpinPage(pageId, false)
+	}
+	t.bpm.DeallocatePage(pageId, false)
+}
+
 func (t *TableHeap) GetBufferPoolManager() *buffer.BufferPoolManager {
 	return t.bpm
 }
@@ -86,5 +86,8 @@ start:
 	}
 
 	finalizeCurrentPage()
+	if it.tuple != nil && err == nil {
+		it.tuple = it.tableHeap.readOverflowPagesIfNeeded(it.tuple)
+	}
 	return it.tuple
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/ryogrid/SamehadaDB/lib/storage/index/index_constants"
 	testingpkg "github.com/ryogrid/SamehadaDB/lib/testing/testing_assert"
 	"math"
+	"strings"
 	"testing"
 
 	"github.com/ryogrid/SamehadaDB/lib/recovery"
@@ -163,3 +164,74 @@ func TestTableHeapFourCol(t *testing.T) {
 
 	txn_mgr.Commit(nil, txn)
 }
+
+func TestTableHeapLargeTuple(t *testing.T) {
+	dm := disk.NewDiskManagerTest()
+	defer dm.ShutDown()
+	log_manager := recovery.NewLogManager(&dm)
+	bpm := buffer.NewBufferPoolManager(10, dm, log_manager)
+	lock_manager := NewLockManager(STRICT, SS2PL_MODE)
+	txn_mgr := NewTransactionManager(lock_manager, log_manager)
+	txn := txn_mgr.Begin(nil)
+
+	th := NewTableHeap(bpm, log_manager, lock_manager, txn)
+
+	columnA := column.NewColumn("a", types.Integer, false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), nil)
+	columnB := column.NewColumn("b", types.Varchar, false, index_constants.INDEX_KIND_INVALID, types.PageID(-1), nil)
+	schema_ := schema.NewSchema([]*column.Column{columnA, columnB})
+
+	// second tuple is larger than a page. so it is stored in overflow pages
+	strs := []string{"small1", strings.Repeat("large", 4000), "small2"}
+	rids := make([]*page.RID, 0)
+	for i, str := range strs {
+		row := []types.Value{types.NewInteger(int32(i)), types.NewVarchar(str)}
+		rid, err := th.InsertTuple(tuple.NewTupleFromSchema(row, schema_), txn, math.MaxUint32, false)
+		testingpkg.Ok(t, err)
+		rids = append(rids, rid)
+	}
+	txn_mgr.Commit(nil, txn)
+
+	txn = txn_mgr.Begin(nil)
+	for i, str := range strs {
+		tuple_, err := th.GetTuple(rids[i], txn)
+		testingpkg.Ok(t, err)
+		testingpkg.Equals(t, int32(i), tuple_.GetValue(schema_, 0).ToInteger())
+		testingpkg.Equals(t, str, tuple_.GetValue(schema_, 1).ToVarchar())
+	}
+
+	it := th.Iterator(txn)
+	tuple_cnt := 0
+	for tuple_ := it.Current(); !it.End(); tuple_ = it.Next() {
+		testingpkg.Equals(t, strs[tuple_cnt], tuple_.GetValue(schema_, 1).ToVarchar())
+		tuple_cnt++
+	}
+	testingpkg.Equals(t, 3, tuple_cnt)
+	txn_mgr.Commit(nil, txn)
+
+	// update to larger value is rolled back
+	txn = txn_mgr.Begin(nil)
+	newRow := []types.Value{types.NewInteger(1), types.NewVarchar(strings.Repeat("LARGE", 8000))}
+	isUpdated, _, err, _, _ := th.UpdateTuple(tuple.NewTupleFromSchema(newRow, schema_), nil, nil, math.MaxUint32, *rids[1], txn, false)
+	testingpkg.Ok(t, err)
+	testingpkg.Assert(t, isUpdated, "update of large tuple failed.")
+	txn_mgr.Abort(nil, txn)
+
+	txn = txn_mgr.Begin(nil)
+	tuple_, err := th.GetTuple(rids[1], txn)
+	testingpkg.Ok(t, err)
+	testingpkg.Equals(t, strs[1], tuple_.GetValue(schema_, 1).ToVarchar())
+
+	// delete of large tuple
+	testingpkg.Assert(t, th.MarkDelete(rids[1], math.MaxUint32, txn, false), "delete of large tuple failed.")
+	txn_mgr.Commit(nil, txn)
+
+	txn = txn_mgr.Begin(nil)
+	it = th.Iterator(txn)
+	tuple_cnt = 0
+	for tuple_ := it.Current(); !it.End(); tuple_ = it.Next() {
+		testingpkg.Assert(t, tuple_.GetValue(schema_, 1).ToVarchar() != strs[1], "deleted tuple is returned.")
+		tuple_cnt++
+	}
+	testingpkg.Equals(t, 2, tuple_cnt)
+	txn_mgr.Commit(nil, txn)
+}
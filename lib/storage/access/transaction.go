@@ -39,6 +39,8 @@ const (
 	DELETE
 	UPDATE
 	RESERVE_SPACE
+	// deallocation of a page which is done after commit. nothing is done at abort
+	DEALLOCATE
 )
 
 /**
@@ -22,12 +22,12 @@ type TransactionManager struct {
 	/** The global transaction latch is used for checkpointing. */
 	global_txn_latch common.ReaderWriterLatch
 	mutex            *sync.Mutex
+	// transactions which are not committed or aborted yet
+	txn_map map[types.TxnID]*Transaction
 }
 
-var txn_map = make(map[types.TxnID]*Transaction)
-
 func NewTransactionManager(lock_manager *LockManager, log_manager *recovery.LogManager) *TransactionManager {
-	return &TransactionManager{0, lock_manager, log_manager, common.NewRWLatch(), new(sync.Mutex)}
+	return &TransactionManager{0, lock_manager, log_manager, common.NewRWLatch(), new(sync.Mutex), make(map[types.TxnID]*Transaction)}
 }
 
 func (transaction_manager *TransactionManager) Begin(txn *Transaction) *Transaction {
@@ -50,7 +50,7 @@ func (transaction_manager *TransactionManager) Begin(txn *Transaction) *Transact
 	}
 
 	transaction_manager.mutex.Lock()
-	txn_map[txn_ret.GetTransactionId()] = txn_ret
+	transaction_manager.txn_map[txn_ret.GetTransactionId()] = txn_ret
 	transaction_manager.mutex.Unlock()
 	return txn_ret
 }
@@ -73,11 +73,15 @@ func (transaction_manager *TransactionManager) Commit(catalog_ catalog_interface
 		}
 		common.ShPrintf(common.RDB_OP_FUNC_CALL, "TransactionManager::Commit txn.txn_id:%v dbgInfo:%s write_set:%s\n", txn.txn_id, txn.dbgInfo, writeSetStr)
 	}
+	// pages are deallocated after the commit is persisted
+	deallocItems := make([]*WriteRecord, 0)
 	for len(write_set) != 0 {
 		item := write_set[len(write_set)-1]
 		table := item.table
 		rid := item.rid1
-		if item.wtype == DELETE {
+		if item.wtype == DEALLOCATE {
+			deallocItems = append(deallocItems, item)
+		} else if item.wtype == DELETE {
 			if common.EnableDebug && common.ActiveLogKindSetting&common.COMMIT_ABORT_HANDLE_INFO > 0 {
 				fmt.Printf("TransactionManager::Commit handle DELETE write log. txn.txn_id:%v dbgInfo:%s rid1:%v\n", txn.txn_id, txn.dbgInfo, rid)
 			}
@@ -115,13 +119,19 @@ func (transaction_manager *TransactionManager) Commit(catalog_ catalog_interface
 		lsn := transaction_manager.log_manager.AppendLogRecord(log_record)
 		txn.SetPrevLSN(lsn)
 		if !isReadOnlyTxn {
-			transaction_manager.log_manager.Flush()
+			// commit records of concurrent transactions are written together by flush thread of LogManager
+			transaction_manager.log_manager.WaitForPersistence(lsn)
 		}
 	}
 
+	for _, item := range deallocItems {
+		item.table.deallocatePage(item.rid1.GetPageId())
+	}
+
 	// Release all the locks.
 	transaction_manager.mutex.Lock()
 	transaction_manager.releaseLocks(txn)
+	delete(transaction_manager.txn_map, txn.GetTransactionId())
 	transaction_manager.mutex.Unlock()
 	// Release the global transaction latch.
 	transaction_manager.global_txn_latch.RUnlock()
@@ -186,9 +196,12 @@ func (transaction_manager *TransactionManager) Abort(catalog_ catalog_interface.
 			pageID := rid.GetPageId()
 			tpage := CastPageAsTablePage(table.bpm.FetchPage(pageID))
 			tpage.WLatch()
+			overflowPageIds := table.getOverflowPageIdsAt(tpage, item.rid1)
 			tpage.ApplyDelete(item.rid1, txn, transaction_manager.log_manager)
 			table.bpm.UnpinPage(pageID, true)
 			tpage.WUnlatch()
+			// overflow pages of inserted tuple are not referred by any txn
+			table.deallocatePages(overflowPageIds)
 
 			// rollback index data
 			if catalog_ != nil {
@@ -212,9 +225,11 @@ func (transaction_manager *TransactionManager) Abort(catalog_ catalog_interface.
 				pageID := item.rid2.GetPageId()
 				tpage := CastPageAsTablePage(table.bpm.FetchPage(pageID))
 				tpage.WLatch()
+				overflowPageIds := table.getOverflowPageIdsAt(tpage, item.rid2)
 				tpage.ApplyDelete(item.rid2, txn, transaction_manager.log_manager)
 				table.bpm.UnpinPage(pageID, true)
 				tpage.WUnlatch()
+				table.deallocatePages(overflowPageIds)
 
 				// rollback deleted record data
 				table.RollbackDelete(item.rid1, txn)
@@ -241,10 +256,16 @@ func (transaction_manager *TransactionManager) Abort(catalog_ catalog_interface.
 				//fmt.Printf("TransactionManager::Abort  rollback of Update! txn.txn_id:%d, tuple_.Size():%d err:%v indexes:%v\n", txn.txn_id, tuple_.Size(), err, indexes)
 				for _, index_ := range indexes {
 					if index_ != nil {
-						colIdx := index_.GetKeyAttrs()[0]
-						bfRlbkKeyVal := catalog_.GetColValFromTupleForRollback(item.tuple2, colIdx, item.oid)
-						rlbkKeyVal := catalog_.GetColValFromTupleForRollback(item.tuple1, colIdx, item.oid)
-						if !bfRlbkKeyVal.CompareEquals(*rlbkKeyVal) || *item.rid1 != *item.rid2 {
+						isKeyChanged := false
+						// composite index has multiple key columns
+						for _, colIdx := range index_.GetKeyAttrs() {
+							bfRlbkKeyVal := catalog_.GetColValFromTupleForRollback(item.tuple2, colIdx, item.oid)
+							rlbkKeyVal := catalog_.GetColValFromTupleForRollback(item.tuple1, colIdx, item.oid)
+							if !bfRlbkKeyVal.CompareEquals(*rlbkKeyVal) {
+								isKeyChanged = true
+							}
+						}
+						if isKeyChanged || *item.rid1 != *item.rid2 {
 							index_.UpdateEntry(item.tuple2, *item.rid2, item.tuple1, *item.rid1, txn)
 						}
 					}
@@ -264,11 +285,25 @@ func (transaction_manager *TransactionManager) Abort(catalog_ catalog_interface.
 	// Release all the locks.
 	transaction_manager.mutex.Lock()
 	transaction_manager.releaseLocks(txn)
+	delete(transaction_manager.txn_map, txn.GetTransactionId())
 	transaction_manager.mutex.Unlock()
 	// Release the global transaction latch.
 	transaction_manager.global_txn_latch.RUnlock()
 }
 
+// a txn which spans multiple statements releases the global transaction latch
+// between statements for not blocking checkpointing while it is idle.
+// the latch must be reacquired with AcquireGlobalTxnLatch before next statement, Commit or Abort.
+// this is safe because checkpointing does not discard log records and uncommitted
+// changes persisted at checkpointing are undone by log recovery
+func (transaction_manager *TransactionManager) ReleaseGlobalTxnLatch() {
+	transaction_manager.global_txn_latch.RUnlock()
+}
+
+func (transaction_manager *TransactionManager) AcquireGlobalTxnLatch() {
+	transaction_manager.global_txn_latch.RLock()
+}
+
 func (transaction_manager *TransactionManager) BlockAllTransactions() {
 	transaction_manager.global_txn_latch.WLock()
 }
@@ -277,6 +312,17 @@ func (transaction_manager *TransactionManager) ResumeTransactions() {
 	transaction_manager.global_txn_latch.WUnlock()
 }
 
+// GetActiveTxnTable returns LSN of last log record of each transaction which is running
+func (transaction_manager *TransactionManager) GetActiveTxnTable() []recovery.ActiveTxnEntry {
+	transaction_manager.mutex.Lock()
+	defer transaction_manager.mutex.Unlock()
+	ret := make([]recovery.ActiveTxnEntry, 0, len(transaction_manager.txn_map))
+	for txnId, txn := range transaction_manager.txn_map {
+		ret = append(ret, recovery.ActiveTxnEntry{TxnId: txnId, LastLSN: txn.GetPrevLSN()})
+	}
+	return ret
+}
+
 func (transaction_manager *TransactionManager) releaseLocks(txn *Transaction) {
 	var lock_set = make([]page.RID, 0)
 	lock_set = append(lock_set, txn.GetExclusiveLockSet()...)
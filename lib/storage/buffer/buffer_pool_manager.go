@@ -64,6 +64,9 @@ func (b *BufferPoolManager) FetchPage(pageID types.PageID) *page.Page {
 				fmt.Sprintf("BPM::FetchPage pin count must be zero here when single thread execution!!!. pageId:%d PinCount:%d", pg.GetPageId(), pg.PinCount()))
 		}
 		pg.IncPinCount()
+		if pg.PinCount() == 1 && !pg.IsDirty() {
+			pg.SetRecLSN(b.log_manager.GetNextLSN())
+		}
 		(*b.replacer).Pin(frameID)
 		b.mutex.Unlock()
 		if common.EnableDebug {
@@ -128,6 +131,8 @@ func (b *BufferPoolManager) FetchPage(pageID types.PageID) *page.Page {
 	var pageData *[common.PageSize]byte
 	pageData = (*[common.PageSize]byte)(data)
 	pg := page.New(pageID, false, pageData)
+	// modifications by pinning threads are logged after this LSN
+	pg.SetRecLSN(b.log_manager.GetNextLSN())
 
 	if common.EnableDebug && common.ActiveLogKindSetting&common.PIN_COUNT_ASSERT > 0 {
 		common.SH_Assert(pg.PinCount() == 1,
@@ -285,6 +290,7 @@ func (b *BufferPoolManager) NewPage() *page.Page {
 	var pageData *[common.PageSize]byte
 	pageData = (*[common.PageSize]byte)(GetBuffer())
 	pg := page.NewEmpty(pageID, pageData)
+	pg.SetRecLSN(b.log_manager.GetNextLSN())
 
 	b.pageTable[pageID] = *frameID
 	b.pages[*frameID] = pg
@@ -357,6 +363,67 @@ func (b *BufferPoolManager) FlushAllDirtyPages() bool {
 	return true
 }
 
+// GetDirtyPageTable returns pages which may have modifications not written to disk and their recLSN.
+// pinned pages are included even if they are not marked as dirty yet because dirty flag is set at unpin
+func (b *BufferPoolManager) GetDirtyPageTable() map[types.PageID]types.LSN {
+	ret := make(map[types.PageID]types.LSN)
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for pageID, frameID := range b.pageTable {
+		if frameID == DEALLOCATED_FRAME {
+			continue
+		}
+		pg := b.pages[frameID]
+		if pg.IsDeallocated() {
+			continue
+		}
+		if pg.IsDirty() || pg.PinCount() > 0 {
+			ret[pageID] = pg.GetRecLSN()
+		}
+	}
+	return ret
+}
+
+// FlushDirtyPage writes the page to disk if it is dirty. transactions are not blocked
+// except ones which try to write the page while it is copied.
+// log records are flushed before the write when page LSN is not persisted (WAL)
+func (b *BufferPoolManager) FlushDirtyPage(pageID types.PageID) bool {
+	b.mutex.Lock()
+	frameID, ok := b.pageTable[pageID]
+	if !ok || frameID == DEALLOCATED_FRAME {
+		b.mutex.Unlock()
+		return true
+	}
+	pg := b.pages[frameID]
+	if pg.IsDeallocated() || !pg.IsDirty() {
+		b.mutex.Unlock()
+		return true
+	}
+	// pin for avoiding cache out while writing
+	pg.IncPinCount()
+	(*b.replacer).Pin(frameID)
+	b.mutex.Unlock()
+
+	data := make([]byte, common.PageSize)
+	pg.RLatch()
+	if pg.GetLSN() > b.log_manager.GetPersistentLSN() {
+		b.log_manager.Flush()
+	}
+	pg.SetIsDirty(false)
+	copy(data, pg.Data()[:])
+	pg.RUnlatch()
+
+	err := b.diskManager.WritePage(pageID, data)
+
+	b.mutex.Lock()
+	pg.DecPinCount()
+	if curFrameID, ok := b.pageTable[pageID]; ok && curFrameID == frameID && pg.PinCount() <= 0 {
+		(*b.replacer).Unpin(frameID)
+	}
+	b.mutex.Unlock()
+	return err == nil
+}
+
 func (b *BufferPoolManager) getFrameID() (*FrameID, bool) {
 	if len(b.freeList) > 0 {
 		frameID, newFreeList := b.freeList[0], b.freeList[1:]
@@ -22,6 +22,7 @@ type DiskManager interface {
 	RemoveDBFile()
 	RemoveLogFile()
 	WriteLog([]byte) error
+	SyncLog() error
 	ReadLog([]byte, int32, *uint32) bool
 	GetLogFileSize() int64
 	GCLogFile() error
@@ -248,7 +248,7 @@ func (d *DiskManagerImpl) GCLogFile() error {
 
 /**
  * Write the contents of the log into disk file
- * Only return when sync is done, and only perform sequence write
+ * Only perform sequence write. sync is done with SyncLog
  */
 func (d *DiskManagerImpl) WriteLog(log_data []byte) error {
 	d.logFileMutex.Lock()
@@ -291,14 +291,19 @@ func (d *DiskManagerImpl) WriteLog(log_data []byte) error {
 		return err
 	}
 
-	// needs to flush to keep disk file in sync
-
-	d.log.Sync()
 	d.flush_log = false
 
 	return nil
 }
 
+// SyncLog makes written log data persistent (fsync)
+func (d *DiskManagerImpl) SyncLog() error {
+	d.logFileMutex.Lock()
+	defer d.logFileMutex.Unlock()
+
+	return d.log.Sync()
+}
+
 /**
 * Read the contents of the log into the given memory area
 * Always read from the beginning and perform sequence read
@@ -169,7 +169,7 @@ func (d *VirtualDiskManagerImpl) GCLogFile() error {
 
 /**
  * Write the contents of the log into disk file
- * Only return when sync is done, and only perform sequence write
+ * Only perform sequence write. sync is done with SyncLog
  */
 func (d *VirtualDiskManagerImpl) WriteLog(log_data []byte) error {
 	d.logFileMutex.Lock()
@@ -187,6 +187,11 @@ func (d *VirtualDiskManagerImpl) WriteLog(log_data []byte) error {
 	return nil
 }
 
+// do nothing because log data is on memory
+func (d *VirtualDiskManagerImpl) SyncLog() error {
+	return nil
+}
+
 /**
 * Read the contents of the log into the given memory area
 * Always read from the beginning and perform sequence read
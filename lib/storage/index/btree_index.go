@@ -47,7 +47,7 @@ func (btreeItr *BtreeIndexIterator) Next() (done bool, err error, key *types.Val
 	// and when Varchar, bytes to make comapreable is inserted
 	var newKeyBytes []byte
 	switch btreeItr.valType {
-	case types.Integer, types.Float:
+	case types.Integer, types.Float, types.BigInt, types.Double, types.Decimal, types.Timestamp:
 		keyLen := uint16(len(keyBytes) - 8) // 8 is length of packedRID
 		keyLenBuf := make([]byte, 2)
 		binary.LittleEndian.PutUint16(keyLenBuf, keyLen)
@@ -60,6 +60,10 @@ func (btreeItr *BtreeIndexIterator) Next() (done bool, err error, key *types.Val
 		// 4 is {0, 0, 0, 0}
 		// 8 is length of packedRID
 		newKeyBytes = keyBytes[:len(keyBytes)-4-8]
+	case types.Blob:
+		keyBytes = samehada_util.EliminateZeroValues(keyBytes)
+		// 8 is length of packedRID
+		newKeyBytes = keyBytes[:len(keyBytes)-8]
 	default:
 		panic("not supported type")
 	}
@@ -117,7 +121,7 @@ func (btidx *BTreeIndex) insertEntryInner(key *tuple.Tuple, rid page.RID, txn in
 	sixBytesVal := [6]byte{ridBytes[0], ridBytes[1], ridBytes[2], ridBytes[3], ridBytes[6], ridBytes[7]}
 
 	keyBytes := convedKeyVal.SerializeOnlyVal()
-	if orgKeyVal.ValueType() == types.Varchar {
+	if orgKeyVal.ValueType().IsVariableLength() {
 		// fill 0 to make keyBytes length to MaxKeyLen for avoiding bug of BLTree
 		keyBytes = samehada_util.FillZeroValues(keyBytes, MaxKeyLen)
 	}
@@ -140,7 +144,7 @@ func (btidx *BTreeIndex) deleteEntryInner(key *tuple.Tuple, rid page.RID, txn in
 		defer btidx.rwMtx.RUnlock()
 	}
 	keyBytes := convedKeyVal.SerializeOnlyVal()
-	if orgKeyVal.ValueType() == types.Varchar {
+	if orgKeyVal.ValueType().IsVariableLength() {
 		// fill 0 to make keyBytes length to MaxKeyLen for avoiding bug of BLTree
 		keyBytes = samehada_util.FillZeroValues(keyBytes, MaxKeyLen)
 	}
@@ -0,0 +1,153 @@
+package index
+
+import (
+	"github.com/ryogrid/SamehadaDB/lib/container/skip_list"
+	"github.com/ryogrid/SamehadaDB/lib/recovery"
+	"github.com/ryogrid/SamehadaDB/lib/samehada/samehada_util"
+	"github.com/ryogrid/SamehadaDB/lib/storage/buffer"
+	"github.com/ryogrid/SamehadaDB/lib/storage/page"
+	"github.com/ryogrid/SamehadaDB/lib/storage/table/schema"
+	"github.com/ryogrid/SamehadaDB/lib/storage/tuple"
+	"github.com/ryogrid/SamehadaDB/lib/types"
+	"sync"
+)
+
+// CompositeSkipListIndex is an index whose key consists of values of multiple columns (key attrs of metadata).
+// the values are encoded to Varchar which keeps order of them and RID is concatenated for supporting
+// key duplication like SkipListIndex. so, range scan on prefix of the key columns is available
+type CompositeSkipListIndex struct {
+	container skip_list.SkipList
+	metadata  *IndexMetadata
+	// PRIMARY KEY or UNIQUE constraint on multiple columns. entries are not checked by the index itself
+	isUnique bool
+	// UpdateEntry only get Write lock
+	updateMtx sync.RWMutex
+}
+
+func NewCompositeSkipListIndex(metadata *IndexMetadata, buffer_pool_manager *buffer.BufferPoolManager, log_manager *recovery.LogManager, isUnique bool) *CompositeSkipListIndex {
+	ret := new(CompositeSkipListIndex)
+	ret.metadata = metadata
+	ret.isUnique = isUnique
+	ret.container = *skip_list.NewSkipList(buffer_pool_manager, types.Varchar, log_manager)
+	ret.updateMtx = sync.RWMutex{}
+	return ret
+}
+
+// returns values of key columns in order of key attrs
+func (csidx *CompositeSkipListIndex) GetKeyValues(key *tuple.Tuple) []*types.Value {
+	tupleSchema_ := csidx.GetTupleSchema()
+	ret := make([]*types.Value, 0)
+	for _, colIdx := range csidx.GetKeyAttrs() {
+		ret = append(ret, samehada_util.GetPonterOfValue(key.GetValue(tupleSchema_, colIdx)))
+	}
+	return ret
+}
+
+func (csidx *CompositeSkipListIndex) insertEntryInner(key *tuple.Tuple, rid page.RID, txn interface{}, isNoLock bool) {
+	convedKeyVal := samehada_util.EncodeValuesAndRIDToDicOrderComparableVarchar(csidx.GetKeyValues(key), &rid)
+
+	if isNoLock == false {
+		csidx.updateMtx.RLock()
+		defer csidx.updateMtx.RUnlock()
+	}
+	csidx.container.Insert(convedKeyVal, samehada_util.PackRIDtoUint64(&rid))
+}
+
+func (csidx *CompositeSkipListIndex) InsertEntry(key *tuple.Tuple, rid page.RID, txn interface{}) {
+	csidx.insertEntryInner(key, rid, txn, false)
+}
+
+func (csidx *CompositeSkipListIndex) deleteEntryInner(key *tuple.Tuple, rid page.RID, txn interface{}, isNoLock bool) {
+	convedKeyVal := samehada_util.EncodeValuesAndRIDToDicOrderComparableVarchar(csidx.GetKeyValues(key), &rid)
+
+	if isNoLock == false {
+		csidx.updateMtx.RLock()
+		defer csidx.updateMtx.RUnlock()
+	}
+	csidx.container.Remove(convedKeyVal, 0)
+}
+
+func (csidx *CompositeSkipListIndex) DeleteEntry(key *tuple.Tuple, rid page.RID, txn interface{}) {
+	csidx.deleteEntryInner(key, rid, txn, false)
+}
+
+func (csidx *CompositeSkipListIndex) ScanKey(key *tuple.Tuple, txn interface{}) []page.RID {
+	rangeItr := csidx.GetPrefixRangeScanIterator(csidx.GetKeyValues(key), nil, nil, txn)
+
+	retArr := make([]page.RID, 0)
+	for done, _, _, rid := rangeItr.Next(); !done; done, _, _, rid = rangeItr.Next() {
+		retArr = append(retArr, *rid)
+	}
+	return retArr
+}
+
+func (csidx *CompositeSkipListIndex) UpdateEntry(oldKey *tuple.Tuple, oldRID page.RID, newKey *tuple.Tuple, newRID page.RID, txn interface{}) {
+	csidx.updateMtx.Lock()
+	defer csidx.updateMtx.Unlock()
+	csidx.deleteEntryInner(oldKey, oldRID, txn, true)
+	csidx.insertEntryInner(newKey, newRID, txn, true)
+}
+
+// get iterator which iterates entry in key sorted order.
+// values of all key columns on start_key and end_key are used. nil is also ok as SkipListIndex
+func (csidx *CompositeSkipListIndex) GetRangeScanIterator(start_key *tuple.Tuple, end_key *tuple.Tuple, transaction interface{}) IndexRangeScanIterator {
+	var smallestKeyVal *types.Value = nil
+	if start_key != nil {
+		smallestKeyVal = samehada_util.GetLowerBoundOfDicOrderComparableValues(csidx.GetKeyValues(start_key))
+	}
+	var biggestKeyVal *types.Value = nil
+	if end_key != nil {
+		biggestKeyVal = samehada_util.GetUpperBoundOfDicOrderComparableValues(csidx.GetKeyValues(end_key))
+	}
+
+	csidx.updateMtx.RLock()
+	defer csidx.updateMtx.RUnlock()
+	return csidx.container.Iterator(smallestKeyVal, biggestKeyVal)
+}
+
+// GetPrefixRangeScanIterator returns iterator which iterates entries whose values of first len(prefix) key columns
+// are equal to prefix and value of next key column is in [start, end]. nil start or end means the range is not bounded.
+// Attention: returned itr's containing keys are encoded values as GetRangeScanIterator of SkipListIndex
+func (csidx *CompositeSkipListIndex) GetPrefixRangeScanIterator(prefix []*types.Value, start *types.Value, end *types.Value, transaction interface{}) IndexRangeScanIterator {
+	var smallestKeyVal *types.Value = nil
+	if start != nil {
+		smallestKeyVal = samehada_util.GetLowerBoundOfDicOrderComparableValues(append(append([]*types.Value{}, prefix...), start))
+	} else if len(prefix) > 0 {
+		smallestKeyVal = samehada_util.GetLowerBoundOfDicOrderComparableValues(prefix)
+	}
+	var biggestKeyVal *types.Value = nil
+	if end != nil {
+		biggestKeyVal = samehada_util.GetUpperBoundOfDicOrderComparableValues(append(append([]*types.Value{}, prefix...), end))
+	} else if len(prefix) > 0 {
+		biggestKeyVal = samehada_util.GetUpperBoundOfDicOrderComparableValues(prefix)
+	}
+
+	csidx.updateMtx.RLock()
+	defer csidx.updateMtx.RUnlock()
+	return csidx.container.Iterator(smallestKeyVal, biggestKeyVal)
+}
+
+func (csidx *CompositeSkipListIndex) IsUnique() bool { return csidx.isUnique }
+
+// Return the metadata object associated with the index
+func (csidx *CompositeSkipListIndex) GetMetadata() *IndexMetadata { return csidx.metadata }
+
+func (csidx *CompositeSkipListIndex) GetIndexColumnCount() uint32 {
+	return csidx.metadata.GetIndexColumnCount()
+}
+
+func (csidx *CompositeSkipListIndex) GetName() *string { return csidx.metadata.GetName() }
+
+func (csidx *CompositeSkipListIndex) GetTupleSchema() *schema.Schema {
+	return csidx.metadata.GetTupleSchema()
+}
+
+func (csidx *CompositeSkipListIndex) GetKeyAttrs() []uint32 { return csidx.metadata.GetKeyAttrs() }
+
+func (csidx *CompositeSkipListIndex) GetHeaderPageId() types.PageID {
+	return csidx.container.GetHeaderPageId()
+}
+
+func (csidx *CompositeSkipListIndex) GetPageIds() []types.PageID {
+	return csidx.container.GetPageIds()
+}
@@ -9,3 +9,7 @@ const (
 	INDEX_KIND_HASH
 	INDEX_KIND_BTREE // B-link tree
 )
+
+// max size of a value which is used as key of skip list index.
+// an entry of skip list block page should be small enough for the page can be split
+const MaxSkipListKeySize = 1024
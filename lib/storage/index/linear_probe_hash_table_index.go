@@ -77,3 +77,7 @@ func (htidx *LinearProbeHashTableIndex) GetRangeScanIterator(startkey *tuple.Tup
 func (htidx *LinearProbeHashTableIndex) GetHeaderPageId() types.PageID {
 	return htidx.container.GetHeaderPageId()
 }
+
+func (htidx *LinearProbeHashTableIndex) GetPageIds() []types.PageID {
+	return htidx.container.GetPageIds()
+}
@@ -145,3 +145,7 @@ func (slidx *SkipListIndex) GetKeyAttrs() []uint32 { return slidx.metadata.GetKe
 func (slidx *SkipListIndex) GetHeaderPageId() types.PageID {
 	return slidx.container.GetHeaderPageId()
 }
+
+func (slidx *SkipListIndex) GetPageIds() []types.PageID {
+	return slidx.container.GetPageIds()
+}
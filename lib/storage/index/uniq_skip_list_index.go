@@ -13,6 +13,8 @@ import (
 	"sync"
 )
 
+// UniqSkipListIndex is an index which can hold one entry for a key.
+// NULL key is not stored because multiple NULL values are allowed on UNIQUE column
 type UniqSkipListIndex struct {
 	container skip_list.SkipList
 	metadata  *IndexMetadata
@@ -34,6 +36,9 @@ func NewUniqSkipListIndex(metadata *IndexMetadata, buffer_pool_manager *buffer.B
 func (slidx *UniqSkipListIndex) insertEntryInner(key *tuple.Tuple, rid page.RID, txn interface{}, isNoLock bool) {
 	tupleSchema_ := slidx.GetTupleSchema()
 	keyVal := key.GetValue(tupleSchema_, slidx.col_idx)
+	if keyVal.IsNull() {
+		return
+	}
 
 	if isNoLock == false {
 		slidx.updateMtx.RLock()
@@ -49,6 +54,9 @@ func (slidx *UniqSkipListIndex) InsertEntry(key *tuple.Tuple, rid page.RID, tran
 func (slidx *UniqSkipListIndex) deleteEntryInner(key *tuple.Tuple, rid page.RID, txn interface{}, isNoLock bool) {
 	tupleSchema_ := slidx.GetTupleSchema()
 	keyVal := key.GetValue(tupleSchema_, slidx.col_idx)
+	if keyVal.IsNull() {
+		return
+	}
 
 	if isNoLock == false {
 		slidx.updateMtx.RLock()
@@ -69,6 +77,9 @@ func (slidx *UniqSkipListIndex) ScanKey(key *tuple.Tuple, transaction interface{
 	keyVal := key.GetValue(tupleSchema_, slidx.col_idx)
 
 	ret_arr := make([]page.RID, 0)
+	if keyVal.IsNull() {
+		return ret_arr
+	}
 	slidx.updateMtx.RLock()
 	packed_value := slidx.container.GetValue(&keyVal)
 	slidx.updateMtx.RUnlock()
@@ -124,3 +135,7 @@ func (slidx *UniqSkipListIndex) GetKeyAttrs() []uint32 { return slidx.metadata.G
 func (slidx *UniqSkipListIndex) GetHeaderPageId() types.PageID {
 	return slidx.container.GetHeaderPageId()
 }
+
+func (slidx *UniqSkipListIndex) GetPageIds() []types.PageID {
+	return slidx.container.GetPageIds()
+}
@@ -41,6 +41,9 @@ type Page struct {
 	WLatchMap      map[int32]bool
 	RLatchMap      map[int32]bool
 	RLatchMapMutex *sync.Mutex
+	// LSN which is smaller than or equal to LSN of first log record which modified the page after last flush.
+	// it is set by BufferPoolManager and used for dirty page table of checkpoint
+	recLSN types.LSN
 }
 
 // IncPinCount increments pin count
@@ -100,12 +103,20 @@ func (p *Page) Copy(offset uint32, data []byte) {
 
 // New creates a new page
 func New(id types.PageID, isDirty bool, data *[common.PageSize]byte) *Page {
-	return &Page{id, int32(1), isDirty, false, data, common.NewRWLatch(), make(map[int32]bool, 0), make(map[int32]bool, 0), new(sync.Mutex)}
+	return &Page{id, int32(1), isDirty, false, data, common.NewRWLatch(), make(map[int32]bool, 0), make(map[int32]bool, 0), new(sync.Mutex), common.InvalidLSN}
 }
 
 // New creates a new empty page
 func NewEmpty(id types.PageID, buf *[common.PageSize]byte) *Page {
-	return &Page{id, int32(1), false, false, buf, common.NewRWLatch(), make(map[int32]bool, 0), make(map[int32]bool, 0), new(sync.Mutex)}
+	return &Page{id, int32(1), false, false, buf, common.NewRWLatch(), make(map[int32]bool, 0), make(map[int32]bool, 0), new(sync.Mutex), common.InvalidLSN}
+}
+
+func (p *Page) GetRecLSN() types.LSN {
+	return p.recLSN
+}
+
+func (p *Page) SetRecLSN(lsn types.LSN) {
+	p.recLSN = lsn
 }
 
 /** @return the page LSN. */
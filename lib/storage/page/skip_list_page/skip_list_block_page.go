@@ -285,7 +285,7 @@ func (node *SkipListBlockPage) Insert(key *types.Value, value uint64, bpm *buffe
 
 			common.ShPrintf(common.DEBUG_INFO, "SkipListBlockPage::Insert: node split occured!\n")
 
-			if key.ValueType() == types.Varchar {
+			if key.ValueType().IsVariableLength() {
 				// entries located post half data space are moved to new node
 				splitIdx = node.getSplitIdxForNotFixed()
 			} else {
@@ -315,7 +315,7 @@ func (node *SkipListBlockPage) Insert(key *types.Value, value uint64, bpm *buffe
 				newSmallerIdx := foundIdx - splitIdx - 1
 
 				insEntry := &index_common.IndexEntry{*key, value}
-				if key.ValueType() == types.Varchar && (newNode.GetSpecifiedSLPNeedSpace(insEntry) > newNode.getFreeSpaceRemaining()) {
+				if key.ValueType().IsVariableLength() && (newNode.GetSpecifiedSLPNeedSpace(insEntry) > newNode.getFreeSpaceRemaining()) {
 					panic("not enough space for insert (new node)")
 				}
 				newNode.InsertInner(int(newSmallerIdx), insEntry)
@@ -334,7 +334,7 @@ func (node *SkipListBlockPage) Insert(key *types.Value, value uint64, bpm *buffe
 				// new entry is inserted next of the entry
 
 				insEntry := &index_common.IndexEntry{*key, value}
-				if key.ValueType() == types.Varchar && (node.GetSpecifiedSLPNeedSpace(insEntry) > node.getFreeSpaceRemaining()) {
+				if key.ValueType().IsVariableLength() && (node.GetSpecifiedSLPNeedSpace(insEntry) > node.getFreeSpaceRemaining()) {
 					panic("not enough space for insert (parent node)")
 				}
 				node.InsertInner(int(foundIdx), insEntry)
@@ -53,6 +53,10 @@ func NewSkipListStartBlockPage(bpm *buffer.BufferPoolManager, keyType types.Type
 		startNode = NewSkipListBlockPage(bpm, MAX_FOWARD_LIST_LEN, index_common.IndexEntry{v, 0})
 	case types.Boolean:
 		startNode = NewSkipListBlockPage(bpm, MAX_FOWARD_LIST_LEN, index_common.IndexEntry{types.NewBoolean(false), 0})
+	case types.BigInt, types.Double, types.Decimal, types.Timestamp, types.Blob:
+		v := types.NewZeroValueOf(keyType)
+		v.SetInfMin()
+		startNode = NewSkipListBlockPage(bpm, MAX_FOWARD_LIST_LEN, index_common.IndexEntry{v, 0})
 	}
 
 	var sentinelNode *SkipListBlockPage = nil
@@ -73,6 +77,10 @@ func NewSkipListStartBlockPage(bpm *buffer.BufferPoolManager, keyType types.Type
 		pl := index_common.IndexEntry{types.NewBoolean(false), 0}
 		pl.Key = *pl.Key.SetInfMax()
 		sentinelNode = NewSkipListBlockPage(bpm, MAX_FOWARD_LIST_LEN, pl)
+	case types.BigInt, types.Double, types.Decimal, types.Timestamp, types.Blob:
+		pl := index_common.IndexEntry{types.NewZeroValueOf(keyType), 0}
+		pl.Key = *pl.Key.SetInfMax()
+		sentinelNode = NewSkipListBlockPage(bpm, MAX_FOWARD_LIST_LEN, pl)
 	}
 
 	startNode.SetLevel(1)
@@ -20,7 +20,9 @@ type Column struct {
 	hasIndex          bool   // whether the column has index data
 	indexKind         index_constants.IndexKind
 	indexHeaderPageId types.PageID
-	isLeft            bool // when temporal schema, this is used for join
+	indexName         string // empty when the column has no index
+	isNotNull         bool   // NOT NULL constraint. PRIMARY KEY column also has it
+	isLeft            bool   // when temporal schema, this is used for join
 	// should be pointer of subtype of expression.Expression
 	// this member is used and needed at temporarily created table (schema) on query execution
 	expr_ interface{}
@@ -32,15 +34,15 @@ type Column struct {
 func NewColumn(name string, columnType types.TypeID, hasIndex bool, indexKind index_constants.IndexKind, indexHeaderPageID types.PageID, expr interface{}) *Column {
 	// note: alphabets on column name is stored in lowercase
 
-	if columnType != types.Varchar {
-		return &Column{strings.ToLower(name), columnType, columnType.Size(), 0, 0, hasIndex, indexKind, indexHeaderPageID, true, expr}
+	if !columnType.IsVariableLength() {
+		return &Column{strings.ToLower(name), columnType, columnType.Size(), 0, 0, hasIndex, indexKind, indexHeaderPageID, "", false, true, expr}
 	}
 
-	return &Column{strings.ToLower(name), types.Varchar, 4, 255, 0, hasIndex, indexKind, indexHeaderPageID, true, expr}
+	return &Column{strings.ToLower(name), columnType, 4, types.MaxVariableLengthSize, 0, hasIndex, indexKind, indexHeaderPageID, "", false, true, expr}
 }
 
 func (c *Column) IsInlined() bool {
-	return c.columnType != types.Varchar
+	return !c.columnType.IsVariableLength()
 }
 
 func (c *Column) GetType() types.TypeID {
@@ -103,6 +105,22 @@ func (c *Column) SetIndexHeaderPageId(pageId types.PageID) {
 	c.indexHeaderPageId = pageId
 }
 
+func (c *Column) IndexName() string {
+	return c.indexName
+}
+
+func (c *Column) SetIndexName(indexName string) {
+	c.indexName = indexName
+}
+
+func (c *Column) IsNotNull() bool {
+	return c.isNotNull
+}
+
+func (c *Column) SetIsNotNull(isNotNull bool) {
+	c.isNotNull = isNotNull
+}
+
 func (c *Column) IsLeft() bool {
 	return c.isLeft
 }
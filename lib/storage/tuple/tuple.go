@@ -86,6 +86,12 @@ func GenTupleForIndexSearch(schema_ *schema.Schema, colIndex uint32, keyVal *typ
 			} else {
 				values = append(values, types.NewVarchar(""))
 			}
+		default:
+			if idx == int(colIndex) {
+				values = append(values, *keyVal)
+			} else {
+				values = append(values, types.NewZeroValueOf(columnObj.GetType()))
+			}
 		}
 	}
 	return NewTupleFromSchema(values, schema_)
@@ -159,6 +165,15 @@ func (t *Tuple) GetValueInBytes(schema *schema.Schema, colIndex uint32) []byte {
 		retArr = append(retArr, retBuf.Bytes()...)
 		retArr = append(retArr, t.data[offset+(1+2):offset+(uint32(*length)+(1+2))]...)
 		return retArr
+	case types.BigInt, types.Double, types.Timestamp, types.Blob:
+		return t.GetValue(schema, colIndex).Serialize()
+	case types.Decimal:
+		// equal values which have different scale (ex: 1.5 and 1.50) must be same bytes
+		val := t.GetValue(schema, colIndex)
+		if val.IsNull() {
+			return val.Serialize()
+		}
+		return types.NewDecimal(val.ToDecimal().Normalize()).Serialize()
 	default:
 		panic("illegal type column found in schema")
 	}
@@ -3,6 +3,8 @@
 
 package types
 
+import "math"
+
 type TypeID int
 
 // Every possible SQL type GetPageId
@@ -18,6 +20,9 @@ const (
 	Varchar
 	Timestamp
 	Null
+	// types below are added after Null for keeping ID of types which have been stored in catalog
+	Double
+	Blob
 )
 
 func (t TypeID) Size() uint32 {
@@ -28,6 +33,98 @@ func (t TypeID) Size() uint32 {
 		return 1 + 4
 	case Boolean:
 		return 1 + 1
+	case BigInt, Double, Timestamp:
+		return 1 + 8
+	case Decimal:
+		// unscaled value and scale
+		return 1 + 8 + 1
 	}
 	return 0
 }
+
+// max size of Varchar and Blob value. the length is serialized as uint16
+const MaxVariableLengthSize = math.MaxUint16
+
+// returns SQL type name. empty string is returned for types which can't be a column type
+func (t TypeID) Name() string {
+	switch t {
+	case Boolean:
+		return "BOOLEAN"
+	case Integer:
+		return "INT"
+	case Float:
+		return "FLOAT"
+	case Varchar:
+		return "VARCHAR"
+	case BigInt:
+		return "BIGINT"
+	case Double:
+		return "DOUBLE"
+	case Decimal:
+		return "DECIMAL"
+	case Timestamp:
+		return "TIMESTAMP"
+	case Blob:
+		return "BLOB"
+	default:
+		return ""
+	}
+}
+
+// Varchar and Blob are stored with length and they are not inlined in tuple
+func (t TypeID) IsVariableLength() bool {
+	return t == Varchar || t == Blob
+}
+
+func (t TypeID) IsNumeric() bool {
+	return numericRank(t) >= 0
+}
+
+// types which are compared or calculated together are converted to the type which has larger rank
+func numericRank(t TypeID) int {
+	switch t {
+	case Integer:
+		return 0
+	case BigInt:
+		return 1
+	case Decimal:
+		return 2
+	case Float:
+		return 3
+	case Double:
+		return 4
+	default:
+		return -1
+	}
+}
+
+// CommonTypeOf returns type which values of l and r should be converted to when they are compared
+// (ex: Integer and Decimal => Decimal, Timestamp and Varchar => Timestamp).
+// l is returned when they can't be converted to common type
+func CommonTypeOf(l TypeID, r TypeID) TypeID {
+	switch {
+	case l.IsNumeric() && r.IsNumeric():
+		if numericRank(l) >= numericRank(r) {
+			return l
+		}
+		return r
+	case l == Varchar && (r == Timestamp || r == Blob):
+		return r
+	default:
+		return l
+	}
+}
+
+// IsComparable returns true when values of l and r can be compared after conversion to CommonTypeOf(l, r)
+func IsComparable(l TypeID, r TypeID) bool {
+	switch {
+	case l == r:
+		return true
+	case l.IsNumeric() && r.IsNumeric():
+		return true
+	case l == Varchar && (r == Timestamp || r == Blob), r == Varchar && (l == Timestamp || l == Blob):
+		return true
+	default:
+		return false
+	}
+}
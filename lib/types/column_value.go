@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // A value is an class that represents a view over SQL data stored in
@@ -21,43 +23,100 @@ type Value struct {
 	boolean   *bool
 	varchar   *string
 	float     *float32
+	bigint    *int64
+	double    *float64
+	decimal   *DecimalNum
+	// microseconds since Unix epoch (UTC)
+	timestamp *int64
+	blob      *[]byte
 }
 
+// layout of string representation of Timestamp. fraction of second is omitted when it is zero
+const TimestampLayout = "2006-01-02 15:04:05.999999"
+
+// layouts which are accepted as Timestamp literal (DATE is converted to Timestamp of 00:00:00)
+var timestampParseLayouts = []string{"2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05.999999999", time.RFC3339Nano, "2006-01-02"}
+
 func NewInteger(value int32) Value {
 	tmpBool := false
-	return Value{Integer, &tmpBool, &value, nil, nil, nil}
+	return Value{valueType: Integer, isNull: &tmpBool, integer: &value}
 }
 
 func NewFloat(value float32) Value {
 	tmpBool := false
-	return Value{Float, &tmpBool, nil, nil, nil, &value}
+	return Value{valueType: Float, isNull: &tmpBool, float: &value}
 }
 
 func NewBoolean(value bool) Value {
 	tmpBool := false
-	return Value{Boolean, &tmpBool, nil, &value, nil, nil}
+	return Value{valueType: Boolean, isNull: &tmpBool, boolean: &value}
 }
 
 func NewVarchar(value string) Value {
 	tmpBool := false
-	return Value{Varchar, &tmpBool, nil, nil, &value, nil}
+	return Value{valueType: Varchar, isNull: &tmpBool, varchar: &value}
 }
 
-func NewValue(value interface{}) Value {
+func NewBigInt(value int64) Value {
+	tmpBool := false
+	return Value{valueType: BigInt, isNull: &tmpBool, bigint: &value}
+}
+
+func NewDouble(value float64) Value {
+	tmpBool := false
+	return Value{valueType: Double, isNull: &tmpBool, double: &value}
+}
+
+func NewDecimal(value DecimalNum) Value {
+	tmpBool := false
+	return Value{valueType: Decimal, isNull: &tmpBool, decimal: &value}
+}
+
+// value is stored with microsecond precision
+func NewTimestamp(value time.Time) Value {
+	tmpBool := false
+	micros := value.UnixMicro()
+	return Value{valueType: Timestamp, isNull: &tmpBool, timestamp: &micros}
+}
+
+// NewTimestampFromString parses str such as "2024-01-02 03:04:05" and "2024-01-02" as UTC time.
+// false is returned when str is not a timestamp
+func NewTimestampFromString(str string) (Value, bool) {
+	for _, layout := range timestampParseLayouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(str)); err == nil {
+			return NewTimestamp(t), true
+		}
+	}
+	return Value{}, false
+}
+
+func NewBlob(value []byte) Value {
 	tmpBool := false
-	switch value.(type) {
+	copied := make([]byte, len(value))
+	copy(copied, value)
+	return Value{valueType: Blob, isNull: &tmpBool, blob: &copied}
+}
+
+func NewValue(value interface{}) Value {
+	switch casted := value.(type) {
 	case int32:
-		val := value.(int32)
-		return Value{Integer, &tmpBool, &val, nil, nil, nil}
+		return NewInteger(casted)
 	case float32:
-		val := value.(float32)
-		return Value{Float, &tmpBool, nil, nil, nil, &val}
+		return NewFloat(casted)
 	case bool:
-		val := value.(bool)
-		return Value{Boolean, &tmpBool, nil, &val, nil, nil}
+		return NewBoolean(casted)
 	case string:
-		val := value.(string)
-		return Value{Varchar, &tmpBool, nil, nil, &val, nil}
+		return NewVarchar(casted)
+	case int64:
+		return NewBigInt(casted)
+	case float64:
+		return NewDouble(casted)
+	case DecimalNum:
+		return NewDecimal(casted)
+	case time.Time:
+		return NewTimestamp(casted)
+	case []byte:
+		return NewBlob(casted)
 	default:
 		panic("not supported type passed")
 	}
@@ -67,7 +126,56 @@ func NewValue(value interface{}) Value {
 func NewNull() Value {
 	tmpTrue := true
 	tmpVal := int32(0)
-	return Value{Integer, &tmpTrue, &tmpVal, nil, nil, nil}
+	return Value{valueType: Integer, isNull: &tmpTrue, integer: &tmpVal}
+}
+
+// returns not NULL value which has valueType and zero value (ex: 0, "", false and Unix epoch)
+func NewZeroValueOf(valueType TypeID) Value {
+	switch valueType {
+	case Integer:
+		return NewInteger(0)
+	case Float:
+		return NewFloat(0)
+	case Varchar:
+		return NewVarchar("")
+	case Boolean:
+		return NewBoolean(false)
+	case BigInt:
+		return NewBigInt(0)
+	case Double:
+		return NewDouble(0)
+	case Decimal:
+		return NewDecimal(DecimalNum{})
+	case Timestamp:
+		return NewTimestamp(time.UnixMicro(0))
+	case Blob:
+		return NewBlob([]byte{})
+	default:
+		panic("not supported type")
+	}
+}
+
+// returns NULL value which has valueType (ex: for filling a newly added column)
+func NewNullOfType(valueType TypeID) Value {
+	switch valueType {
+	case Float:
+		return *NewFloat(0).SetNull()
+	case Varchar:
+		return *NewVarchar("").SetNull()
+	case Boolean:
+		return *NewBoolean(false).SetNull()
+	case BigInt:
+		return *NewBigInt(0).SetNull()
+	case Double:
+		return *NewDouble(0).SetNull()
+	case Decimal:
+		return *NewDecimal(DecimalNum{}).SetNull()
+	case Timestamp:
+		return *NewTimestamp(time.UnixMicro(0)).SetNull()
+	case Blob:
+		return *NewBlob([]byte{}).SetNull()
+	}
+	return *NewInteger(0).SetNull()
 }
 
 // NewValueFromBytes is used for deserialization
@@ -117,6 +225,49 @@ func NewValueFromBytes(data []byte, valueType TypeID) (ret *Value) {
 			vBoolean.SetNull()
 		}
 		ret = &vBoolean
+	case BigInt, Double, Timestamp:
+		buf := bytes.NewBuffer(data)
+		isNull := new(bool)
+		binary.Read(buf, binary.LittleEndian, isNull)
+		v := new(int64)
+		binary.Read(buf, binary.LittleEndian, v)
+		var val Value
+		switch valueType {
+		case BigInt:
+			val = NewBigInt(*v)
+		case Double:
+			val = NewDouble(math.Float64frombits(uint64(*v)))
+		default:
+			val = NewTimestamp(time.UnixMicro(*v))
+		}
+		if *isNull {
+			val.SetNull()
+		}
+		ret = &val
+	case Decimal:
+		buf := bytes.NewBuffer(data)
+		isNull := new(bool)
+		binary.Read(buf, binary.LittleEndian, isNull)
+		v := new(int64)
+		binary.Read(buf, binary.LittleEndian, v)
+		scale := new(uint8)
+		binary.Read(buf, binary.LittleEndian, scale)
+		vDecimal := NewDecimal(DecimalNum{*v, *scale})
+		if *isNull {
+			vDecimal.SetNull()
+		}
+		ret = &vDecimal
+	case Blob:
+		buf := bytes.NewBuffer(data)
+		isNull := new(bool)
+		binary.Read(buf, binary.LittleEndian, isNull)
+		length := new(uint16)
+		binary.Read(buf, binary.LittleEndian, length)
+		blob := NewBlob(data[1+2 : (*length + (1 + 2))])
+		if *isNull {
+			blob.SetNull()
+		}
+		ret = &blob
 	default:
 		fmt.Printf("%v is illegal\n", valueType)
 		panic("")
@@ -143,6 +294,8 @@ func (v Value) CompareEquals(right Value) bool {
 		return *v.varchar == *right.varchar
 	case Boolean:
 		return *v.boolean == *right.boolean
+	case BigInt, Double, Decimal, Timestamp, Blob:
+		return v.compareSameType(right) == 0
 	}
 	return false
 }
@@ -168,6 +321,8 @@ func (v Value) CompareNotEquals(right Value) bool {
 		return *v.varchar != *right.varchar
 	case Boolean:
 		return *v.boolean != *right.boolean
+	case BigInt, Double, Decimal, Timestamp, Blob:
+		return v.compareSameType(right) != 0
 	}
 	return false
 }
@@ -200,6 +355,8 @@ func (v Value) CompareGreaterThan(right Value) bool {
 		return *v.varchar > *right.varchar
 	case Boolean:
 		return *v.boolean == true && *right.boolean == false
+	case BigInt, Double, Decimal, Timestamp, Blob:
+		return v.compareSameType(right) > 0
 	}
 	return false
 }
@@ -234,6 +391,8 @@ func (v Value) CompareGreaterThanOrEqual(right Value) bool {
 		return *v.varchar >= *right.varchar
 	case Boolean:
 		return *v.boolean == *right.boolean || (*v.boolean == true && *right.boolean == false)
+	case BigInt, Double, Decimal, Timestamp, Blob:
+		return v.compareSameType(right) >= 0
 	}
 	return false
 }
@@ -266,6 +425,8 @@ func (v Value) CompareLessThan(right Value) bool {
 		return *v.varchar < *right.varchar
 	case Boolean:
 		return *v.boolean == false && *right.boolean == true
+	case BigInt, Double, Decimal, Timestamp, Blob:
+		return v.compareSameType(right) < 0
 	}
 	return false
 }
@@ -300,11 +461,43 @@ func (v Value) CompareLessThanOrEqual(right Value) bool {
 		return *v.varchar <= *right.varchar
 	case Boolean:
 		return *v.boolean == *right.boolean || (*v.boolean == false && *right.boolean == true)
+	case BigInt, Double, Decimal, Timestamp, Blob:
+		return v.compareSameType(right) <= 0
 	default:
 		panic("illegal valueType is passed!")
 	}
 }
 
+// returns -1, 0 or 1 as result of comparison of v and right which are not NULL and have same type
+// (BigInt, Double, Decimal, Timestamp and Blob)
+func (v Value) compareSameType(right Value) int {
+	switch v.valueType {
+	case BigInt:
+		return compareOrdered(*v.bigint, *right.bigint)
+	case Double:
+		return compareOrdered(*v.double, *right.double)
+	case Decimal:
+		return v.decimal.Cmp(*right.decimal)
+	case Timestamp:
+		return compareOrdered(*v.timestamp, *right.timestamp)
+	case Blob:
+		return bytes.Compare(*v.blob, *right.blob)
+	default:
+		panic("illegal valueType is passed!")
+	}
+}
+
+func compareOrdered[T int64 | float64](l T, r T) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	default:
+		return 0
+	}
+}
+
 func (v Value) Serialize() []byte {
 	switch v.valueType {
 	case Integer:
@@ -328,6 +521,17 @@ func (v Value) Serialize() []byte {
 		binary.Write(buf, binary.LittleEndian, *v.isNull)
 		binary.Write(buf, binary.LittleEndian, v.ToBoolean())
 		return buf.Bytes()
+	case BigInt, Double, Decimal, Timestamp:
+		buf := new(bytes.Buffer)
+		binary.Write(buf, binary.LittleEndian, *v.isNull)
+		buf.Write(v.SerializeOnlyVal())
+		return buf.Bytes()
+	case Blob:
+		buf := new(bytes.Buffer)
+		binary.Write(buf, binary.LittleEndian, *v.isNull)
+		binary.Write(buf, binary.LittleEndian, uint16(len(*v.blob)))
+		isNullAndLength := buf.Bytes()
+		return append(isNullAndLength, *v.blob...)
 	}
 	return []byte{}
 }
@@ -349,6 +553,16 @@ func (v Value) SerializeOnlyVal() []byte {
 		buf := new(bytes.Buffer)
 		binary.Write(buf, binary.LittleEndian, v.ToBoolean())
 		return buf.Bytes()
+	case BigInt:
+		return binary.LittleEndian.AppendUint64(nil, uint64(*v.bigint))
+	case Double:
+		return binary.LittleEndian.AppendUint64(nil, math.Float64bits(*v.double))
+	case Decimal:
+		return append(binary.LittleEndian.AppendUint64(nil, uint64(v.decimal.unscaled)), v.decimal.scale)
+	case Timestamp:
+		return binary.LittleEndian.AppendUint64(nil, uint64(*v.timestamp))
+	case Blob:
+		return *v.blob
 	}
 	return []byte{}
 }
@@ -365,6 +579,10 @@ func (v Value) Size() uint32 {
 		return uint32(len(*v.varchar)) + 1 + 2 // varchar occupies the size of the string + 2 bytes for length storage
 	case Boolean:
 		return v.valueType.Size()
+	case BigInt, Double, Decimal, Timestamp:
+		return v.valueType.Size()
+	case Blob:
+		return uint32(len(*v.blob)) + 1 + 2
 	}
 	panic("not implemented")
 }
@@ -384,6 +602,16 @@ func (v Value) ToString() string {
 		} else {
 			return "false"
 		}
+	case BigInt:
+		return strconv.FormatInt(*v.bigint, 10)
+	case Double:
+		return strconv.FormatFloat(*v.double, 'f', -1, 64)
+	case Decimal:
+		return v.decimal.String()
+	case Timestamp:
+		return v.ToTimestamp().Format(TimestampLayout)
+	case Blob:
+		return string(*v.blob)
 	}
 	panic("not implemented")
 }
@@ -405,10 +633,52 @@ func (v Value) ToInteger() int32 {
 
 // if you use this to get column value
 // NULL value check is needed in general
+// other numeric types are converted to float32
 func (v Value) ToFloat() float32 {
+	switch v.valueType {
+	case Integer:
+		return float32(*v.integer)
+	case BigInt:
+		return float32(*v.bigint)
+	case Double:
+		return float32(*v.double)
+	case Decimal:
+		return float32(v.decimal.Float64())
+	}
 	return *v.float
 }
 
+// if you use this to get column value
+// NULL value check is needed in general
+func (v Value) ToBigInt() int64 {
+	return *v.bigint
+}
+
+// if you use this to get column value
+// NULL value check is needed in general
+func (v Value) ToDouble() float64 {
+	return *v.double
+}
+
+// if you use this to get column value
+// NULL value check is needed in general
+func (v Value) ToDecimal() DecimalNum {
+	return *v.decimal
+}
+
+// returned time is UTC
+// if you use this to get column value
+// NULL value check is needed in general
+func (v Value) ToTimestamp() time.Time {
+	return time.UnixMicro(*v.timestamp).UTC()
+}
+
+// if you use this to get column value
+// NULL value check is needed in general
+func (v Value) ToBlob() []byte {
+	return *v.blob
+}
+
 // if you use this to get column value
 // NULL value check is needed in general
 func (v Value) ToVarchar() string {
@@ -425,6 +695,18 @@ func (v Value) ToIFValue() interface{} {
 		return *v.varchar
 	case Float:
 		return *v.float
+	case BigInt:
+		return *v.bigint
+	case Double:
+		return *v.double
+	case Decimal:
+		// equal values which have different scale should be same key of map
+		return v.decimal.Normalize()
+	case Timestamp:
+		return v.ToTimestamp()
+	case Blob:
+		// []byte can't be used as key of map
+		return string(*v.blob)
 	default:
 		panic("not supported type!")
 	}
@@ -452,6 +734,21 @@ func (v Value) SetNull() *Value {
 	case Boolean:
 		*v.boolean = false
 		return &v
+	case BigInt:
+		*v.bigint = 0
+		return &v
+	case Double:
+		*v.double = 0
+		return &v
+	case Decimal:
+		*v.decimal = DecimalNum{}
+		return &v
+	case Timestamp:
+		*v.timestamp = 0
+		return &v
+	case Blob:
+		*v.blob = []byte{}
+		return &v
 	}
 	panic("not implemented")
 }
@@ -474,6 +771,21 @@ func (v Value) SetInfMax() *Value {
 	case Boolean:
 		*v.boolean = true
 		return &v
+	case BigInt:
+		*v.bigint = math.MaxInt64
+		return &v
+	case Double:
+		*v.double = math.MaxFloat64
+		return &v
+	case Decimal:
+		*v.decimal = DecimalNum{math.MaxInt64, 0}
+		return &v
+	case Timestamp:
+		*v.timestamp = math.MaxInt64
+		return &v
+	case Blob:
+		*v.blob = []byte("SamehadaDBInfMaxValue")
+		return &v
 	}
 	panic("not implemented")
 }
@@ -492,6 +804,21 @@ func (v Value) SetInfMin() *Value {
 	case Boolean:
 		*v.boolean = false
 		return &v
+	case BigInt:
+		*v.bigint = math.MinInt64
+		return &v
+	case Double:
+		*v.double = -1.0 * math.MaxFloat64
+		return &v
+	case Decimal:
+		*v.decimal = DecimalNum{math.MinInt64, 0}
+		return &v
+	case Timestamp:
+		*v.timestamp = math.MinInt64
+		return &v
+	case Blob:
+		*v.blob = []byte("SamehadaDBInfMinValue")
+		return &v
 	}
 	panic("not implemented")
 }
@@ -506,6 +833,16 @@ func (v Value) IsInfMax() bool {
 		return *v.varchar == "SamehadaDBInfMaxValue"
 	case Boolean:
 		return *v.boolean == true
+	case BigInt:
+		return *v.bigint == math.MaxInt64
+	case Double:
+		return *v.double == math.MaxFloat64
+	case Decimal:
+		return *v.decimal == DecimalNum{math.MaxInt64, 0}
+	case Timestamp:
+		return *v.timestamp == math.MaxInt64
+	case Blob:
+		return string(*v.blob) == "SamehadaDBInfMaxValue"
 	}
 	panic("not implemented")
 }
@@ -520,6 +857,16 @@ func (v Value) IsInfMin() bool {
 		return *v.varchar == "SamehadaDBInfMinValue"
 	case Boolean:
 		return *v.boolean == false
+	case BigInt:
+		return *v.bigint == math.MinInt64
+	case Double:
+		return *v.double == -1.0*math.MaxFloat64
+	case Decimal:
+		return *v.decimal == DecimalNum{math.MinInt64, 0}
+	case Timestamp:
+		return *v.timestamp == math.MinInt64
+	case Blob:
+		return string(*v.blob) == "SamehadaDBInfMinValue"
 	default:
 		panic("not implemented")
 	}
@@ -538,8 +885,16 @@ func (v Value) Add(other *Value) *Value {
 	case Float:
 		ret := NewFloat(*v.float + *other.float)
 		return &ret
+	case BigInt:
+		ret := NewBigInt(*v.bigint + *other.bigint)
+		return &ret
+	case Double:
+		ret := NewDouble(*v.double + *other.double)
+		return &ret
+	case Decimal:
+		return decimalValueOf(v.decimal.Add(*other.decimal))
 	default:
-		panic("Add is implemented to Integer and Float only.")
+		panic("Add is implemented to numeric types only.")
 	}
 }
 
@@ -555,8 +910,269 @@ func (v Value) Sub(other *Value) *Value {
 	case Float:
 		ret := NewFloat(*v.float - *other.float)
 		return &ret
+	case BigInt:
+		ret := NewBigInt(*v.bigint - *other.bigint)
+		return &ret
+	case Double:
+		ret := NewDouble(*v.double - *other.double)
+		return &ret
+	case Decimal:
+		return decimalValueOf(v.decimal.Sub(*other.decimal))
+	default:
+		panic("Sub is implemented to numeric types only.")
+	}
+}
+
+func (v Value) Mul(other *Value) *Value {
+	if other.IsNull() {
+		return &v
+	}
+
+	switch v.valueType {
+	case Integer:
+		ret := NewInteger(*v.integer * *other.integer)
+		return &ret
+	case Float:
+		ret := NewFloat(*v.float * *other.float)
+		return &ret
+	case BigInt:
+		ret := NewBigInt(*v.bigint * *other.bigint)
+		return &ret
+	case Double:
+		ret := NewDouble(*v.double * *other.double)
+		return &ret
+	case Decimal:
+		return decimalValueOf(v.decimal.Mul(*other.decimal))
+	default:
+		panic("Mul is implemented to numeric types only.")
+	}
+}
+
+// note: caller should check other is not zero
+func (v Value) Div(other *Value) *Value {
+	if other.IsNull() {
+		return &v
+	}
+
+	switch v.valueType {
+	case Integer:
+		ret := NewInteger(*v.integer / *other.integer)
+		return &ret
+	case Float:
+		ret := NewFloat(*v.float / *other.float)
+		return &ret
+	case BigInt:
+		ret := NewBigInt(*v.bigint / *other.bigint)
+		return &ret
+	case Double:
+		ret := NewDouble(*v.double / *other.double)
+		return &ret
+	case Decimal:
+		return decimalValueOf(v.decimal.Div(*other.decimal))
 	default:
-		panic("Sub is implemented to Integer and Float only.")
+		panic("Div is implemented to numeric types only.")
+	}
+}
+
+// note: caller should check other is not zero
+func (v Value) Mod(other *Value) *Value {
+	if other.IsNull() {
+		return &v
+	}
+
+	switch v.valueType {
+	case Integer:
+		ret := NewInteger(*v.integer % *other.integer)
+		return &ret
+	case Float:
+		ret := NewFloat(float32(math.Mod(float64(*v.float), float64(*other.float))))
+		return &ret
+	case BigInt:
+		ret := NewBigInt(*v.bigint % *other.bigint)
+		return &ret
+	case Double:
+		ret := NewDouble(math.Mod(*v.double, *other.double))
+		return &ret
+	case Decimal:
+		return decimalValueOf(v.decimal.Mod(*other.decimal))
+	default:
+		panic("Mod is implemented to numeric types only.")
+	}
+}
+
+// returns NULL when result of calculation of Decimal overflows
+func decimalValueOf(d DecimalNum, ok bool) *Value {
+	if !ok {
+		return NewDecimal(DecimalNum{}).SetNull()
+	}
+	ret := NewDecimal(d)
+	return &ret
+}
+
+// CastAs returns a value converted to typeId.
+// NULL is converted to NULL of typeId. second return value is false
+// when the value can't be converted (ex: 'abc' to Integer)
+func (v Value) CastAs(typeId TypeID) (*Value, bool) {
+	if v.IsNull() {
+		return NewNullOfType(typeId).GetDeepCopy(), true
+	}
+	if v.valueType == typeId {
+		return v.GetDeepCopy(), true
+	}
+
+	var ret Value
+	switch typeId {
+	case Integer, BigInt:
+		var i int64
+		switch v.valueType {
+		case Integer:
+			i = int64(*v.integer)
+		case BigInt:
+			i = *v.bigint
+		case Float, Double:
+			f := math.Trunc(v.toFloat64())
+			if f >= math.MaxInt64 || f < math.MinInt64 {
+				return nil, false
+			}
+			i = int64(f)
+		case Decimal:
+			i = v.decimal.Truncate()
+		case Varchar:
+			parsed, err := strconv.ParseInt(strings.TrimSpace(*v.varchar), 10, 64)
+			if err != nil {
+				return nil, false
+			}
+			i = parsed
+		case Boolean:
+			if *v.boolean {
+				i = 1
+			}
+		default:
+			return nil, false
+		}
+		if typeId == BigInt {
+			ret = NewBigInt(i)
+		} else if i > math.MaxInt32 || i < math.MinInt32 {
+			return nil, false
+		} else {
+			ret = NewInteger(int32(i))
+		}
+	case Float, Double:
+		var f float64
+		switch v.valueType {
+		case Integer, BigInt, Float, Double, Decimal:
+			f = v.toFloat64()
+		case Varchar:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(*v.varchar), 64)
+			if err != nil {
+				return nil, false
+			}
+			f = parsed
+		default:
+			return nil, false
+		}
+		if typeId == Double {
+			ret = NewDouble(f)
+		} else {
+			ret = NewFloat(float32(f))
+		}
+	case Decimal:
+		var d DecimalNum
+		ok := true
+		switch v.valueType {
+		case Integer:
+			d = NewDecimalFromInt64(int64(*v.integer))
+		case BigInt:
+			d = NewDecimalFromInt64(*v.bigint)
+		case Float:
+			d, ok = NewDecimalFromFloat64(float64(*v.float), 32)
+		case Double:
+			d, ok = NewDecimalFromFloat64(*v.double, 64)
+		case Varchar:
+			d, ok = NewDecimalFromString(*v.varchar)
+		default:
+			return nil, false
+		}
+		if !ok {
+			return nil, false
+		}
+		ret = NewDecimal(d)
+	case Varchar:
+		ret = NewVarchar(v.ToString())
+	case Boolean:
+		switch v.valueType {
+		case Integer:
+			ret = NewBoolean(*v.integer != 0)
+		case BigInt:
+			ret = NewBoolean(*v.bigint != 0)
+		default:
+			return nil, false
+		}
+	case Timestamp:
+		if v.valueType != Varchar {
+			return nil, false
+		}
+		parsed, ok := NewTimestampFromString(*v.varchar)
+		if !ok {
+			return nil, false
+		}
+		ret = parsed
+	case Blob:
+		if v.valueType != Varchar {
+			return nil, false
+		}
+		ret = NewBlob([]byte(*v.varchar))
+	default:
+		return nil, false
+	}
+	return &ret, true
+}
+
+// CoerceTo returns a value converted to typeId implicitly (ex: for storing to column of typeId).
+// unlike CastAs, numeric value is not converted to Integer, BigInt and Decimal when the value
+// is changed (ex: 1.5 to Integer) and string is converted only to Timestamp and Blob.
+// second return value is false when the value can't be converted
+func (v Value) CoerceTo(typeId TypeID) (*Value, bool) {
+	if v.IsNull() || v.valueType == typeId {
+		return v.CastAs(typeId)
+	}
+	switch {
+	case v.valueType.IsNumeric() && typeId.IsNumeric():
+		ret, ok := v.CastAs(typeId)
+		if !ok {
+			return nil, false
+		}
+		if typeId == Float || typeId == Double {
+			// approximate value
+			return ret, true
+		}
+		// check that the value is not changed
+		if back, ok := ret.CastAs(v.valueType); !ok || !back.CompareEquals(v) {
+			return nil, false
+		}
+		return ret, true
+	case v.valueType == Varchar && (typeId == Timestamp || typeId == Blob):
+		return v.CastAs(typeId)
+	default:
+		return nil, false
+	}
+}
+
+// note: v should be numeric value
+func (v Value) toFloat64() float64 {
+	switch v.valueType {
+	case Integer:
+		return float64(*v.integer)
+	case BigInt:
+		return float64(*v.bigint)
+	case Float:
+		return float64(*v.float)
+	case Double:
+		return *v.double
+	case Decimal:
+		return v.decimal.Float64()
+	default:
+		panic("not numeric value is passed!")
 	}
 }
 
@@ -591,7 +1207,10 @@ func (v Value) Max(other *Value) *Value {
 			return &ret
 		}
 	default:
-		panic("max is implemented to Integer, Float and Varchar only.")
+		if v.CompareGreaterThanOrEqual(*other) {
+			return v.GetDeepCopy()
+		}
+		return other.GetDeepCopy()
 	}
 }
 
@@ -626,7 +1245,10 @@ func (v Value) Min(other *Value) *Value {
 			return &ret
 		}
 	default:
-		panic("max is implemented to Integer, Float and Varchar only.")
+		if v.CompareLessThanOrEqual(*other) {
+			return v.GetDeepCopy()
+		}
+		return other.GetDeepCopy()
 	}
 }
 
@@ -645,12 +1267,31 @@ func (v Value) Swap(other *Value) {
 		*v.varchar, *other.varchar = *other.varchar, *v.varchar
 	case Boolean:
 		*v.boolean, *other.boolean = *other.boolean, *v.boolean
+	case BigInt:
+		*v.bigint, *other.bigint = *other.bigint, *v.bigint
+	case Double:
+		*v.double, *other.double = *other.double, *v.double
+	case Decimal:
+		*v.decimal, *other.decimal = *other.decimal, *v.decimal
+	case Timestamp:
+		*v.timestamp, *other.timestamp = *other.timestamp, *v.timestamp
+	case Blob:
+		*v.blob, *other.blob = *other.blob, *v.blob
 	default:
 		panic("unkown or not supported type")
 	}
 }
 
 func (v Value) GetDeepCopy() *Value {
+	// length of Varchar and Blob is serialized as uint16. so they are copied directly
+	// for keeping too long value which should be checked and rejected later
+	if !v.IsNull() && v.valueType == Varchar {
+		ret := NewVarchar(*v.varchar)
+		return &ret
+	} else if !v.IsNull() && v.valueType == Blob {
+		ret := NewBlob(*v.blob)
+		return &ret
+	}
 	switch v.valueType {
 	case Integer:
 		return NewValueFromBytes(v.Serialize(), Integer)
@@ -660,6 +1301,8 @@ func (v Value) GetDeepCopy() *Value {
 		return NewValueFromBytes(v.Serialize(), Varchar)
 	case Boolean:
 		return NewValueFromBytes(v.Serialize(), Boolean)
+	case BigInt, Double, Decimal, Timestamp, Blob:
+		return NewValueFromBytes(v.Serialize(), v.valueType)
 	default:
 		panic("unkown or not supported type")
 	}
@@ -0,0 +1,205 @@
+package types
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const MaxDecimalScale = 18
+
+// scale of values which are generated with division (same as div_precision_increment of MySQL)
+const decimalDivScaleIncrement = 4
+
+// DecimalNum is exact fixed-point number whose value is unscaled * 10^(-scale) (ex: {1234, 2} is 12.34).
+// scale of value is kept on calculation. when result can't be represented with int64,
+// it is rounded to smaller scale
+type DecimalNum struct {
+	unscaled int64
+	scale    uint8
+}
+
+func NewDecimalFromInt64(val int64) DecimalNum {
+	return DecimalNum{val, 0}
+}
+
+// NewDecimalFromString parses str such as "-12.34". false is returned when str is not a number
+// or it can't be represented
+func NewDecimalFromString(str string) (DecimalNum, bool) {
+	str = strings.TrimSpace(str)
+	if strings.ContainsAny(str, "eE") {
+		// exponent notation
+		f, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return DecimalNum{}, false
+		}
+		return NewDecimalFromFloat64(f, 64)
+	}
+	intPart, fracPart, _ := strings.Cut(str, ".")
+	digits := intPart + fracPart
+	if len(fracPart) > MaxDecimalScale || digits == "" || digits == "-" || digits == "+" {
+		return DecimalNum{}, false
+	}
+	unscaled, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return DecimalNum{}, false
+	}
+	return decimalOfBigInt(unscaled, len(fracPart))
+}
+
+// NewDecimalFromFloat64 converts val to DecimalNum with the shortest representation of val as float of bitSize
+// (ex: float32(0.1) => 0.1)
+func NewDecimalFromFloat64(val float64, bitSize int) (DecimalNum, bool) {
+	if math.IsNaN(val) || math.IsInf(val, 0) {
+		return DecimalNum{}, false
+	}
+	str := strconv.FormatFloat(val, 'f', -1, bitSize)
+	intPart, fracPart, _ := strings.Cut(str, ".")
+	if len(fracPart) > MaxDecimalScale {
+		fracPart = fracPart[:MaxDecimalScale]
+	}
+	unscaled, ok := new(big.Int).SetString(intPart+fracPart, 10)
+	if !ok {
+		return DecimalNum{}, false
+	}
+	return decimalOfBigInt(unscaled, len(fracPart))
+}
+
+// NewDecimalFromUnscaled returns normalized DecimalNum of unscaled * 10^(-scale) (ex: {12340, 3} => 12.34).
+// false is returned when it can't be represented
+func NewDecimalFromUnscaled(unscaled *big.Int, scale int) (DecimalNum, bool) {
+	ret, ok := decimalOfBigInt(unscaled, scale)
+	return ret.Normalize(), ok
+}
+
+// returns DecimalNum of unscaled * 10^(-scale). when unscaled can't be represented with int64,
+// trailing zeros are removed and then it is rounded to smaller scale
+func decimalOfBigInt(unscaled *big.Int, scale int) (DecimalNum, bool) {
+	ten := big.NewInt(10)
+	val := new(big.Int).Set(unscaled)
+	for scale > MaxDecimalScale || (!val.IsInt64() && scale > 0) {
+		val = roundHalfAwayFromZero(val, ten)
+		scale--
+	}
+	if !val.IsInt64() {
+		return DecimalNum{}, false
+	}
+	return DecimalNum{val.Int64(), uint8(scale)}, true
+}
+
+// returns val / divisor which is rounded half away from zero
+func roundHalfAwayFromZero(val *big.Int, divisor *big.Int) *big.Int {
+	quo, rem := new(big.Int).QuoRem(val, divisor, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(divisor) >= 0 {
+		if val.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	return quo
+}
+
+func pow10(exp int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)
+}
+
+// UnscaledAt returns unscaled value of d when its scale is *scale* (ex: 12.34 at scale 3 => 12340).
+// scale should be larger than or equal to scale of d
+func (d DecimalNum) UnscaledAt(scale int) *big.Int {
+	return new(big.Int).Mul(big.NewInt(d.unscaled), pow10(scale-int(d.scale)))
+}
+
+func (d DecimalNum) Scale() int {
+	return int(d.scale)
+}
+
+func (d DecimalNum) Cmp(other DecimalNum) int {
+	scale := max(d.Scale(), other.Scale())
+	return d.UnscaledAt(scale).Cmp(other.UnscaledAt(scale))
+}
+
+func (d DecimalNum) IsZero() bool {
+	return d.unscaled == 0
+}
+
+func (d DecimalNum) Add(other DecimalNum) (DecimalNum, bool) {
+	scale := max(d.Scale(), other.Scale())
+	return decimalOfBigInt(new(big.Int).Add(d.UnscaledAt(scale), other.UnscaledAt(scale)), scale)
+}
+
+func (d DecimalNum) Sub(other DecimalNum) (DecimalNum, bool) {
+	scale := max(d.Scale(), other.Scale())
+	return decimalOfBigInt(new(big.Int).Sub(d.UnscaledAt(scale), other.UnscaledAt(scale)), scale)
+}
+
+func (d DecimalNum) Mul(other DecimalNum) (DecimalNum, bool) {
+	return decimalOfBigInt(new(big.Int).Mul(big.NewInt(d.unscaled), big.NewInt(other.unscaled)), d.Scale()+other.Scale())
+}
+
+// note: caller should check other is not zero
+func (d DecimalNum) Div(other DecimalNum) (DecimalNum, bool) {
+	scale := min(max(d.Scale(), other.Scale())+decimalDivScaleIncrement, MaxDecimalScale)
+	// d / other = (d.unscaled * 10^(scale - d.scale + other.scale)) / other.unscaled * 10^(-scale)
+	numerator := new(big.Int).Mul(big.NewInt(d.unscaled), pow10(scale-d.Scale()+other.Scale()))
+	return decimalOfBigInt(roundHalfAwayFromZero(numerator, big.NewInt(other.unscaled)), scale)
+}
+
+// note: caller should check other is not zero
+func (d DecimalNum) Mod(other DecimalNum) (DecimalNum, bool) {
+	scale := max(d.Scale(), other.Scale())
+	return decimalOfBigInt(new(big.Int).Rem(d.UnscaledAt(scale), other.UnscaledAt(scale)), scale)
+}
+
+// Normalize returns d whose trailing zeros after decimal point are removed (ex: 1.50 => 1.5).
+// equal values become same representation
+func (d DecimalNum) Normalize() DecimalNum {
+	for d.scale > 0 && d.unscaled%10 == 0 {
+		d.unscaled /= 10
+		d.scale--
+	}
+	return d
+}
+
+func (d DecimalNum) Neg() DecimalNum {
+	return DecimalNum{-d.unscaled, d.scale}
+}
+
+// Round rounds d half away from zero to *digits* digits after decimal point.
+// negative digits rounds integral part (ex: Round(125, -1) => 130)
+func (d DecimalNum) Round(digits int) (DecimalNum, bool) {
+	if digits >= d.Scale() {
+		return d, true
+	}
+	rounded := roundHalfAwayFromZero(big.NewInt(d.unscaled), pow10(d.Scale()-digits))
+	if digits < 0 {
+		return decimalOfBigInt(rounded.Mul(rounded, pow10(-digits)), 0)
+	}
+	return decimalOfBigInt(rounded, digits)
+}
+
+// Truncate returns integral part of d
+func (d DecimalNum) Truncate() int64 {
+	return d.unscaled / pow10(d.Scale()).Int64()
+}
+
+func (d DecimalNum) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+func (d DecimalNum) String() string {
+	str := strconv.FormatInt(d.unscaled, 10)
+	if d.scale == 0 {
+		return str
+	}
+	sign := ""
+	if d.unscaled < 0 {
+		sign, str = "-", str[1:]
+	}
+	if len(str) <= d.Scale() {
+		str = strings.Repeat("0", d.Scale()-len(str)+1) + str
+	}
+	return sign + str[:len(str)-d.Scale()] + "." + str[len(str)-d.Scale():]
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"time"
+
+	"github.com/ryogrid/SamehadaDB/lib/samehada"
+)
+
+// Config is settings of the server. it is loaded from JSON file which is specified with -config
+// and each value can be overwritten with command line flags.
+//
+//	{
+//	  "DBName": "default",
+//	  "Storage": "file",
+//	  "DataDir": "./data",
+//	  "BufferPoolKBytes": 5000,
+//	  "CheckpointInterval": "30s",
+//	  "LogBufferSize": 0,
+//	  "LogSync": "always",
+//	  "ListenAddr": "0.0.0.0:19999",
+//	  "PgWireListenAddr": "0.0.0.0:5432"
+//	}
+type Config struct {
+	DBName string
+	// "file" or "memory"
+	Storage          string
+	DataDir          string
+	BufferPoolKBytes int
+	// duration string (ex: "30s", "5m"). empty means default
+	CheckpointInterval string
+	// size of log buffer in bytes. 0 means default
+	LogBufferSize int
+	// "always", "never" or duration string (ex: "100ms") which means fsync interval
+	LogSync string
+	// listen address of REST API
+	ListenAddr string
+	// listen address for PostgreSQL frontend/backend protocol (psql, pgx, etc...)
+	PgWireListenAddr string
+}
+
+func newDefaultConfig() *Config {
+	return &Config{
+		DBName:           "default",
+		Storage:          "file",
+		DataDir:          "",
+		BufferPoolKBytes: samehada.DefaultBufferPoolKBytes,
+		ListenAddr:       "0.0.0.0:19999",
+		PgWireListenAddr: "0.0.0.0:5432",
+	}
+}
+
+// values of config file are overwritten with flags which are specified explicitly
+func loadConfig(args []string) (error, *Config) {
+	conf := newDefaultConfig()
+
+	fs := flag.NewFlagSet("samehada-server", flag.ContinueOnError)
+	confPath := fs.String("config", "", "path of JSON config file")
+	dbName := fs.String("db-name", conf.DBName, "name of database (db and log file names)")
+	storage := fs.String("storage", conf.Storage, "storage mode (\"file\" or \"memory\")")
+	dataDir := fs.String("data-dir", conf.DataDir, "directory which db and log files are placed")
+	bufferPoolKBytes := fs.Int("buffer-pool-kb", conf.BufferPoolKBytes, "size of buffer pool in KB")
+	checkpointInterval := fs.String("checkpoint-interval", conf.CheckpointInterval, "interval of checkpointing (ex: \"30s\")")
+	logBufferSize := fs.Int("log-buffer-size", conf.LogBufferSize, "size of log buffer in bytes")
+	logSync := fs.String("log-sync", conf.LogSync, "sync policy of log file (\"always\", \"never\" or interval such as \"100ms\")")
+	listenAddr := fs.String("listen", conf.ListenAddr, "listen address of REST API")
+	pgWireListenAddr := fs.String("pgwire-listen", conf.PgWireListenAddr, "listen address of PostgreSQL protocol")
+	if err := fs.Parse(args); err != nil {
+		return err, nil
+	}
+
+	if *confPath != "" {
+		b, err := os.ReadFile(*confPath)
+		if err != nil {
+			return err, nil
+		}
+		if err = json.Unmarshal(b, conf); err != nil {
+			return err, nil
+		}
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "db-name":
+			conf.DBName = *dbName
+		case "storage":
+			conf.Storage = *storage
+		case "data-dir":
+			conf.DataDir = *dataDir
+		case "buffer-pool-kb":
+			conf.BufferPoolKBytes = *bufferPoolKBytes
+		case "checkpoint-interval":
+			conf.CheckpointInterval = *checkpointInterval
+		case "log-buffer-size":
+			conf.LogBufferSize = *logBufferSize
+		case "log-sync":
+			conf.LogSync = *logSync
+		case "listen":
+			conf.ListenAddr = *listenAddr
+		case "pgwire-listen":
+			conf.PgWireListenAddr = *pgWireListenAddr
+		}
+	})
+	return nil, conf
+}
+
+func (conf *Config) toOptions() (error, *samehada.Options) {
+	err, storageMode := samehada.ParseStorageMode(conf.Storage)
+	if err != nil {
+		return err, nil
+	}
+	err, logSyncPolicy, logSyncInterval := samehada.ParseLogSyncPolicy(conf.LogSync)
+	if err != nil {
+		return err, nil
+	}
+	var checkpointInterval time.Duration
+	if conf.CheckpointInterval != "" {
+		checkpointInterval, err = time.ParseDuration(conf.CheckpointInterval)
+		if err != nil {
+			return err, nil
+		}
+	}
+	return nil, &samehada.Options{
+		StorageMode:        storageMode,
+		DataDir:            conf.DataDir,
+		BufferPoolKBytes:   conf.BufferPoolKBytes,
+		CheckpointInterval: checkpointInterval,
+		LogBufferSize:      conf.LogBufferSize,
+		LogSyncPolicy:      logSyncPolicy,
+		LogSyncInterval:    logSyncInterval,
+	}
+}
@@ -4,29 +4,155 @@ import (
 	"fmt"
 	"github.com/ant0ine/go-json-rest/rest"
 	"github.com/ryogrid/SamehadaDB/lib/samehada"
+	"github.com/ryogrid/SamehadaDB/lib/samehada/samehada_util"
+	"github.com/ryogrid/SamehadaDB/server/pgwire"
+	"github.com/ryogrid/SamehadaDB/server/session"
 	"github.com/ryogrid/SamehadaDB/server/signal_handle"
 	"github.com/vmihailenco/msgpack/v5"
 	"log"
 	"net/http"
 	"os"
+	"time"
 )
 
 type QueryInput struct {
 	Query string
+	// values for placeholders ($1, $2, ... or ?) of Query. can be omitted
+	Args []interface{}
 }
 
 type Row struct {
 	C []interface{}
 }
 
+type Column struct {
+	// qualified name (ex: "table_name.column_name")
+	Name     string
+	Type     string
+	Nullable bool
+}
+
 type QueryOutput struct {
 	Result []Row
-	Error  string
+	// empty when the query does not return rows
+	Columns []Column
+	// count of inserted, updated or deleted rows
+	AffectedRows int64
+	Error        string
+}
+
+type OpenSessionInput struct {
+	// 0 means default value (sessionDefaultIdleTimeout)
+	IdleTimeoutSec int
+}
+
+type OpenSessionOutput struct {
+	SessionId string
+	Error     string
+}
+
+type SessionQueryInput struct {
+	SessionId string
+	Query     string
+	Args      []interface{}
+}
+
+type PrepareInput struct {
+	SessionId string
+	Query     string
+}
+
+type PrepareOutput struct {
+	StmtId string
+	// type names of placeholders. empty string when the type can't be inferred
+	ParamTypes []string
+	// empty when the statement does not return rows
+	Columns []Column
+	Error   string
+}
+
+type ExecuteInput struct {
+	SessionId string
+	StmtId    string
+	Args      []interface{}
+}
+
+type DeallocateInput struct {
+	SessionId string
+	StmtId    string
+}
+
+type DeallocateOutput struct {
+	Error string
+}
+
+type CloseSessionInput struct {
+	SessionId string
 }
 
-var db = samehada.NewSamehadaDB("default", 5000) //5MB
+type CloseSessionOutput struct {
+	Error string
+}
+
+const sessionDefaultIdleTimeout = 5 * time.Minute
+const sessionReapInterval = 10 * time.Second
+
+// these are initialized in main with settings of config file and flags
+var config *Config
+var db *samehada.SamehadaDB
+var sessionManager *session.SessionManager
+var pgServer *pgwire.Server
 var IsStopped = false
 
+func newColumns(colDescs []*samehada.ColumnDesc) []Column {
+	cols := make([]Column, 0)
+	for _, col := range colDescs {
+		cols = append(cols, Column{col.Name, col.TypeName(), col.Nullable})
+	}
+	return cols
+}
+
+func newQueryOutput(rs *samehada.ResultSet) *QueryOutput {
+	rows := make([]Row, 0)
+	cols := make([]Column, 0)
+	var affectedRows int64 = 0
+	if rs != nil {
+		for _, row := range samehada_util.ConvValueListToIFs(rs.Rows) {
+			rows = append(rows, Row{row})
+		}
+		cols = newColumns(rs.Columns)
+		affectedRows = rs.AffectedRows
+	}
+	return &QueryOutput{rows, cols, affectedRows, "SUCCESS"}
+}
+
+// when args is passed, they are bound to placeholders of sqlStr
+func executeSQL(sqlStr string, args []interface{}) (error, *samehada.ResultSet) {
+	if len(args) == 0 {
+		return db.ExecuteSQLRetResultSet(sqlStr)
+	}
+
+	err, ps := db.Prepare(sqlStr)
+	if err != nil {
+		return err, nil
+	}
+	vals, err := ps.ConvIFsToArgs(args...)
+	if err != nil {
+		return err, nil
+	}
+	return db.ExecutePrepared(ps, vals...)
+}
+
+func writeMsgPack(w rest.ResponseWriter, v interface{}) {
+	b, err := msgpack.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+
+	w.Header().Set("Content-Type", "application/octent-stream")
+	w.(http.ResponseWriter).Write(b)
+}
+
 func postQuery(w rest.ResponseWriter, req *rest.Request) {
 	if signal_handle.IsStopped {
 		rest.Error(w, "Server is stopped", http.StatusGone)
@@ -47,20 +173,13 @@ func postQuery(w rest.ResponseWriter, req *rest.Request) {
 		return
 	}
 
-	err2, results := db.ExecuteSQL(input.Query)
+	err2, rs := executeSQL(input.Query, input.Args)
 	if err2 != nil {
 		rest.Error(w, err2.Error(), http.StatusBadRequest)
 		return
 	}
 
-	rows := make([]Row, 0)
-	for _, row := range results {
-		rows = append(rows, Row{row})
-	}
-
-	w.WriteJson(&QueryOutput{
-		rows, "SUCCESS",
-	})
+	w.WriteJson(newQueryOutput(rs))
 }
 
 func postQueryMsgPack(w rest.ResponseWriter, req *rest.Request) {
@@ -83,32 +202,227 @@ func postQueryMsgPack(w rest.ResponseWriter, req *rest.Request) {
 		return
 	}
 
-	err2, results := db.ExecuteSQL(input.Query)
+	err2, rs := executeSQL(input.Query, input.Args)
 	if err2 != nil {
 		http.Error(w.(http.ResponseWriter), err2.Error(), http.StatusBadRequest)
 		return
 	}
 
-	rows := make([]Row, 0)
-	for _, row := range results {
-		rows = append(rows, Row{row})
+	writeMsgPack(w, newQueryOutput(rs))
+}
+
+func postOpenSession(w rest.ResponseWriter, req *rest.Request) {
+	if signal_handle.IsStopped {
+		rest.Error(w, "Server is stopped", http.StatusGone)
+		return
 	}
 
-	ret := QueryOutput{rows, "SUCCESS"}
-	b, err := msgpack.Marshal(&ret)
+	input := OpenSessionInput{}
+	err := req.DecodeJsonPayload(&input)
 	if err != nil {
-		panic(err)
+		fmt.Println(err)
+		rest.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	//var decoded []Row
-	//err = msgpack.Unmarshal(b, &decoded)
-	//if err != nil {
-	//	panic(err)
-	//}
-	//fmt.Println(decoded)
+	s := sessionManager.Open(time.Duration(input.IdleTimeoutSec) * time.Second)
+	w.WriteJson(&OpenSessionOutput{s.Id, "SUCCESS"})
+}
 
-	w.Header().Set("Content-Type", "application/octent-stream")
-	w.(http.ResponseWriter).Write(b)
+func postCloseSession(w rest.ResponseWriter, req *rest.Request) {
+	if signal_handle.IsStopped {
+		rest.Error(w, "Server is stopped", http.StatusGone)
+		return
+	}
+
+	input := CloseSessionInput{}
+	err := req.DecodeJsonPayload(&input)
+	if err != nil {
+		fmt.Println(err)
+		rest.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// transaction in progress is rolled back
+	if !sessionManager.Close(input.SessionId) {
+		rest.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	w.WriteJson(&CloseSessionOutput{"SUCCESS"})
+}
+
+func executeOnSession(req *rest.Request) (int, error, *samehada.ResultSet) {
+	input := SessionQueryInput{}
+	err := req.DecodeJsonPayload(&input)
+	if err != nil {
+		fmt.Println(err)
+		return http.StatusBadRequest, err, nil
+	}
+
+	if input.Query == "" {
+		return http.StatusBadRequest, fmt.Errorf("Query is required"), nil
+	}
+
+	s := sessionManager.Get(input.SessionId)
+	if s == nil {
+		return http.StatusNotFound, fmt.Errorf("Session not found (it may be closed due to idle timeout)"), nil
+	}
+	var rs *samehada.ResultSet
+	if len(input.Args) == 0 {
+		err, rs = s.ExecuteSQLRetResultSet(input.Query)
+	} else {
+		err, rs = s.ExecuteSQLWithArgs(input.Query, input.Args)
+	}
+	sessionManager.Touch(s)
+	if err != nil {
+		return http.StatusBadRequest, err, nil
+	}
+	return http.StatusOK, nil, rs
+}
+
+func postSessionQuery(w rest.ResponseWriter, req *rest.Request) {
+	if signal_handle.IsStopped {
+		rest.Error(w, "Server is stopped", http.StatusGone)
+		return
+	}
+
+	status, err, rs := executeOnSession(req)
+	if err != nil {
+		rest.Error(w, err.Error(), status)
+		return
+	}
+
+	w.WriteJson(newQueryOutput(rs))
+}
+
+func postSessionQueryMsgPack(w rest.ResponseWriter, req *rest.Request) {
+	if signal_handle.IsStopped {
+		http.Error(w.(http.ResponseWriter), "Server is stopped", http.StatusGone)
+		return
+	}
+
+	status, err, rs := executeOnSession(req)
+	if err != nil {
+		http.Error(w.(http.ResponseWriter), err.Error(), status)
+		return
+	}
+
+	writeMsgPack(w, newQueryOutput(rs))
+}
+
+func postPrepare(w rest.ResponseWriter, req *rest.Request) {
+	if signal_handle.IsStopped {
+		rest.Error(w, "Server is stopped", http.StatusGone)
+		return
+	}
+
+	input := PrepareInput{}
+	err := req.DecodeJsonPayload(&input)
+	if err != nil {
+		fmt.Println(err)
+		rest.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if input.Query == "" {
+		rest.Error(w, "Query is required", 400)
+		return
+	}
+
+	s := sessionManager.Get(input.SessionId)
+	if s == nil {
+		rest.Error(w, "Session not found (it may be closed due to idle timeout)", http.StatusNotFound)
+		return
+	}
+	err2, stmtId, ps := s.Prepare(input.Query)
+	if err2 != nil {
+		rest.Error(w, err2.Error(), http.StatusBadRequest)
+		return
+	}
+
+	paramTypes := make([]string, 0)
+	for _, typeId := range ps.Describe().ParamTypes {
+		paramTypes = append(paramTypes, (&samehada.ColumnDesc{Type: typeId}).TypeName())
+	}
+	w.WriteJson(&PrepareOutput{stmtId, paramTypes, newColumns(ps.Describe().Columns), "SUCCESS"})
+}
+
+func executePreparedOnSession(req *rest.Request) (int, error, *samehada.ResultSet) {
+	input := ExecuteInput{}
+	err := req.DecodeJsonPayload(&input)
+	if err != nil {
+		fmt.Println(err)
+		return http.StatusBadRequest, err, nil
+	}
+
+	s := sessionManager.Get(input.SessionId)
+	if s == nil {
+		return http.StatusNotFound, fmt.Errorf("Session not found (it may be closed due to idle timeout)"), nil
+	}
+	err, rs := s.ExecutePrepared(input.StmtId, input.Args)
+	sessionManager.Touch(s)
+	if err == session.UnknownStmtErr {
+		return http.StatusNotFound, err, nil
+	} else if err != nil {
+		return http.StatusBadRequest, err, nil
+	}
+	return http.StatusOK, nil, rs
+}
+
+func postExecute(w rest.ResponseWriter, req *rest.Request) {
+	if signal_handle.IsStopped {
+		rest.Error(w, "Server is stopped", http.StatusGone)
+		return
+	}
+
+	status, err, rs := executePreparedOnSession(req)
+	if err != nil {
+		rest.Error(w, err.Error(), status)
+		return
+	}
+
+	w.WriteJson(newQueryOutput(rs))
+}
+
+func postExecuteMsgPack(w rest.ResponseWriter, req *rest.Request) {
+	if signal_handle.IsStopped {
+		http.Error(w.(http.ResponseWriter), "Server is stopped", http.StatusGone)
+		return
+	}
+
+	status, err, rs := executePreparedOnSession(req)
+	if err != nil {
+		http.Error(w.(http.ResponseWriter), err.Error(), status)
+		return
+	}
+
+	writeMsgPack(w, newQueryOutput(rs))
+}
+
+func postDeallocate(w rest.ResponseWriter, req *rest.Request) {
+	if signal_handle.IsStopped {
+		rest.Error(w, "Server is stopped", http.StatusGone)
+		return
+	}
+
+	input := DeallocateInput{}
+	err := req.DecodeJsonPayload(&input)
+	if err != nil {
+		fmt.Println(err)
+		rest.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s := sessionManager.Get(input.SessionId)
+	if s == nil {
+		rest.Error(w, "Session not found (it may be closed due to idle timeout)", http.StatusNotFound)
+		return
+	}
+	if !s.Deallocate(input.StmtId) {
+		rest.Error(w, session.UnknownStmtErr.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteJson(&DeallocateOutput{"SUCCESS"})
 }
 
 func launchDBAndListen() {
@@ -133,6 +447,14 @@ func launchDBAndListen() {
 	router, err := rest.MakeRouter(
 		&rest.Route{"POST", "/Query", postQuery},
 		&rest.Route{"POST", "/QueryMsgPack", postQueryMsgPack},
+		&rest.Route{"POST", "/Session/Open", postOpenSession},
+		&rest.Route{"POST", "/Session/Query", postSessionQuery},
+		&rest.Route{"POST", "/Session/QueryMsgPack", postSessionQueryMsgPack},
+		&rest.Route{"POST", "/Session/Close", postCloseSession},
+		&rest.Route{"POST", "/Session/Prepare", postPrepare},
+		&rest.Route{"POST", "/Session/Execute", postExecute},
+		&rest.Route{"POST", "/Session/ExecuteMsgPack", postExecuteMsgPack},
+		&rest.Route{"POST", "/Session/Deallocate", postDeallocate},
 	)
 	if err != nil {
 		log.Fatal(err)
@@ -141,19 +463,47 @@ func launchDBAndListen() {
 
 	log.Printf("Server started")
 	log.Fatal(http.ListenAndServe(
-		"0.0.0.0:19999",
+		config.ListenAddr,
 		api.MakeHandler(),
 	))
 }
 
+func launchPgWireListener() {
+	log.Printf("PostgreSQL protocol listener started")
+	err := pgServer.ListenAndServe(config.PgWireListenAddr)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
 func main() {
+	err, conf := loadConfig(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	err, opts := conf.toOptions()
+	if err != nil {
+		log.Fatal(err)
+	}
+	config = conf
+	err, db = samehada.NewSamehadaDBWithOptions(config.DBName, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sessionManager = session.NewSessionManager(db, sessionDefaultIdleTimeout)
+	pgServer = pgwire.NewServer(db)
+
 	exitNotifyCh := make(chan bool, 1)
 
 	// start signal handler thread
-	go signal_handle.SignalHandlerTh(db, &exitNotifyCh)
+	go signal_handle.SignalHandlerTh(db, sessionManager, pgServer, &exitNotifyCh)
+
+	// sessions which are idle longer than own timeout are closed by this thread
+	sessionManager.StartReaperTh(sessionReapInterval)
 
 	// start server
 	go launchDBAndListen()
+	go launchPgWireListener()
 
 	// wait shutdown operation finished notification
 	<-exitNotifyCh
@@ -0,0 +1,615 @@
+package pgwire
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/ryogrid/SamehadaDB/lib/catalog"
+	"github.com/ryogrid/SamehadaDB/lib/parser"
+	"github.com/ryogrid/SamehadaDB/lib/samehada"
+	"github.com/ryogrid/SamehadaDB/lib/types"
+	"io"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// payload of a message is limited for protecting server from broken clients
+const maxMessageLen = 64 * 1024 * 1024
+
+var UnsupportedProtocolErr = errors.New("unsupported frontend protocol")
+var UnknownStmtErr = errors.New("prepared statement does not exist")
+var UnknownPortalErr = errors.New("portal does not exist")
+
+type preparedStmt struct {
+	query string
+	// nil when query is empty
+	ps *samehada.PreparedStmt
+	// OIDs specified by frontend or inferred on preparation
+	paramOIDs []uint32
+	// columns of rows which the statement returns
+	columns []*samehada.ColumnDesc
+}
+
+type portal struct {
+	stmt          *preparedStmt
+	args          []*types.Value
+	resultFormats []int16
+	// set at first Execute message. rows are sent from rowIdx
+	rs     *samehada.ResultSet
+	rowIdx int
+}
+
+type conn struct {
+	srv       *Server
+	netConn   net.Conn
+	reader    *bufio.Reader
+	writer    *bufio.Writer
+	sess      *samehada.SamehadaSession
+	procId    int32
+	secretKey int32
+	stmts     map[string]*preparedStmt
+	portals   map[string]*portal
+	// after error on extended query, messages are discarded until Sync
+	isIgnoreTillSync bool
+}
+
+func newConn(srv *Server, netConn net.Conn, procId int32) *conn {
+	return &conn{srv, netConn, bufio.NewReader(netConn), bufio.NewWriter(netConn), nil, procId, rand.Int31(),
+		make(map[string]*preparedStmt), make(map[string]*portal), false}
+}
+
+func (c *conn) serve() error {
+	defer c.netConn.Close()
+
+	isContinue, err := c.handleStartup()
+	if err != nil || !isContinue {
+		return err
+	}
+
+	c.sess = c.srv.db.NewSession()
+	// transaction in progress is rolled back
+	defer c.sess.Close()
+
+	for {
+		msgType, payload, err := c.readMessage()
+		if err != nil {
+			if err == io.EOF || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		if c.isIgnoreTillSync && msgType != msgSync && msgType != msgTerminate {
+			continue
+		}
+
+		switch msgType {
+		case msgQuery:
+			err = c.handleSimpleQuery(payload)
+		case msgParse:
+			err = c.handleParse(payload)
+		case msgBind:
+			err = c.handleBind(payload)
+		case msgDescribe:
+			err = c.handleDescribe(payload)
+		case msgExecute:
+			err = c.handleExecute(payload)
+		case msgClose:
+			err = c.handleClose(payload)
+		case msgSync:
+			c.isIgnoreTillSync = false
+			err = c.sendReadyForQuery()
+		case msgFlush:
+			err = c.writer.Flush()
+		case msgTerminate:
+			return nil
+		default:
+			err = c.sendErrorOnExtendedQuery(fmt.Errorf("unsupported message type: %c", msgType))
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// returns false when the connection should be closed without error
+func (c *conn) handleStartup() (bool, error) {
+	for {
+		var lenBuf [4]byte
+		if _, err := io.ReadFull(c.reader, lenBuf[:]); err != nil {
+			return false, err
+		}
+		msgLen := int(binary.BigEndian.Uint32(lenBuf[:])) - 4
+		if msgLen < 4 || msgLen > maxMessageLen {
+			return false, MalformedMessageErr
+		}
+		payload := make([]byte, msgLen)
+		if _, err := io.ReadFull(c.reader, payload); err != nil {
+			return false, err
+		}
+
+		buf := readBuf(payload)
+		code, _ := buf.int32()
+		switch uint32(code) {
+		case sslRequestCode, gssEncRequest:
+			// not supported. frontend continues on plain connection
+			if _, err := c.netConn.Write([]byte{'N'}); err != nil {
+				return false, err
+			}
+			continue
+		case cancelRequest:
+			return false, nil
+		case protocolVersion3:
+			// parameters (user, database, etc...) are ignored
+		default:
+			c.sendError(UnsupportedProtocolErr)
+			c.writer.Flush()
+			return false, UnsupportedProtocolErr
+		}
+		break
+	}
+
+	// AuthenticationOk
+	msg := newWriteBuf(msgAuthentication)
+	msg.int32(0)
+	c.writer.Write(msg.finish())
+
+	for _, param := range [][2]string{
+		{"server_version", "14.0 (SamehadaDB)"},
+		{"server_encoding", "UTF8"},
+		{"client_encoding", "UTF8"},
+		{"DateStyle", "ISO, MDY"},
+		{"integer_datetimes", "on"},
+		{"standard_conforming_strings", "on"},
+	} {
+		msg = newWriteBuf(msgParameterStatus)
+		msg.string(param[0])
+		msg.string(param[1])
+		c.writer.Write(msg.finish())
+	}
+
+	msg = newWriteBuf(msgBackendKeyData)
+	msg.int32(c.procId)
+	msg.int32(c.secretKey)
+	c.writer.Write(msg.finish())
+
+	return true, c.sendReadyForQuery()
+}
+
+func (c *conn) readMessage() (byte, readBuf, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return 0, nil, err
+	}
+	msgLen := int(binary.BigEndian.Uint32(header[1:])) - 4
+	if msgLen < 0 || msgLen > maxMessageLen {
+		return 0, nil, MalformedMessageErr
+	}
+	payload := make([]byte, msgLen)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return 0, nil, err
+	}
+	return header[0], payload, nil
+}
+
+func (c *conn) sendReadyForQuery() error {
+	msg := newWriteBuf(msgReadyForQuery)
+	if c.sess != nil && c.sess.InTxn() {
+		msg.byte_('T')
+	} else {
+		msg.byte_('I')
+	}
+	c.writer.Write(msg.finish())
+	return c.writer.Flush()
+}
+
+func errToSQLState(err error) string {
+	switch err {
+	case samehada.QueryAbortedErr, samehada.TxnAbortedErr:
+		return "40001" // serialization_failure
+	case samehada.TxnAlreadyBegunErr:
+		return "25001" // active_sql_transaction
+	case samehada.NotInTxnErr:
+		return "25P01" // no_active_sql_transaction
+	case samehada.DDLOnTxnErr, UnsupportedParamFormatErr:
+		return "0A000" // feature_not_supported
+	case catalog.DuplicateKeyErr:
+		return "23505" // unique_violation
+	case catalog.NotNullViolationErr:
+		return "23502" // not_null_violation
+	case catalog.CheckViolationErr:
+		return "23514" // check_violation
+	case MalformedMessageErr, UnsupportedProtocolErr:
+		return "08P01" // protocol_violation
+	case UnknownStmtErr:
+		return "26000" // invalid_sql_statement_name
+	case UnknownPortalErr:
+		return "34000" // invalid_cursor_name
+	default:
+		return "XX000" // internal_error
+	}
+}
+
+func (c *conn) sendError(err error) {
+	msg := newWriteBuf(msgErrorResponse)
+	msg.byte_('S')
+	msg.string("ERROR")
+	msg.byte_('V')
+	msg.string("ERROR")
+	msg.byte_('C')
+	msg.string(errToSQLState(err))
+	msg.byte_('M')
+	msg.string(err.Error())
+	msg.byte_(0)
+	c.writer.Write(msg.finish())
+}
+
+func (c *conn) sendErrorOnExtendedQuery(err error) error {
+	c.sendError(err)
+	c.isIgnoreTillSync = true
+	return nil
+}
+
+func (c *conn) sendRowDescription(cols []*samehada.ColumnDesc, formats []int16) {
+	if len(cols) == 0 {
+		c.writer.Write(newWriteBuf(msgNoData).finish())
+		return
+	}
+
+	msg := newWriteBuf(msgRowDescription)
+	msg.int16(int16(len(cols)))
+	for idx, col := range cols {
+		oid := typeIdToOID(col.Type)
+		msg.string(col.Name)
+		msg.int32(0) // table OID
+		msg.int16(0) // attribute number
+		msg.int32(int32(oid))
+		msg.int16(oidToTypeLen(oid))
+		msg.int32(-1) // type modifier
+		msg.int16(resultFormat(formats, idx))
+	}
+	c.writer.Write(msg.finish())
+}
+
+func resultFormat(formats []int16, colIdx int) int16 {
+	switch len(formats) {
+	case 0:
+		return formatText
+	case 1:
+		return formats[0]
+	default:
+		if colIdx < len(formats) {
+			return formats[colIdx]
+		}
+		return formatText
+	}
+}
+
+// sends rows from rs.Rows[fromIdx] and returns index of next row to be sent.
+// maxRows <= 0 means no limit
+func (c *conn) sendDataRows(rs *samehada.ResultSet, fromIdx int, maxRows int, formats []int16) int {
+	idx := fromIdx
+	for ; idx < len(rs.Rows); idx++ {
+		if maxRows > 0 && idx-fromIdx >= maxRows {
+			break
+		}
+		row := rs.Rows[idx]
+		msg := newWriteBuf(msgDataRow)
+		msg.int16(int16(len(row)))
+		for colIdx, val := range row {
+			data := encodeValue(val, resultFormat(formats, colIdx))
+			if data == nil {
+				msg.int32(-1)
+			} else {
+				msg.int32(int32(len(data)))
+				msg.bytes(data)
+			}
+		}
+		c.writer.Write(msg.finish())
+	}
+	return idx
+}
+
+// tag of CommandComplete message is decided with first keyword of query
+func commandTag(query string, rs *samehada.ResultSet) string {
+	fields := strings.Fields(strings.TrimLeft(query, " \t\r\n("))
+	if len(fields) == 0 {
+		return ""
+	}
+	var rowNum int64 = 0
+	if rs != nil {
+		rowNum = rs.AffectedRows
+	}
+	keyword := strings.ToUpper(strings.TrimRight(fields[0], ";"))
+	switch keyword {
+	case "SELECT":
+		if rs != nil {
+			rowNum = int64(len(rs.Rows))
+		}
+		return "SELECT " + strconv.FormatInt(rowNum, 10)
+	case "INSERT":
+		return "INSERT 0 " + strconv.FormatInt(rowNum, 10)
+	case "UPDATE", "DELETE":
+		return keyword + " " + strconv.FormatInt(rowNum, 10)
+	case "CREATE":
+		if len(fields) > 1 {
+			return keyword + " " + strings.ToUpper(strings.TrimRight(fields[1], ";"))
+		}
+		return keyword
+	case "START":
+		return "BEGIN"
+	case "END":
+		return "COMMIT"
+	default:
+		return keyword
+	}
+}
+
+func isEmptyQuery(query string) bool {
+	return strings.Trim(query, " \t\r\n;") == ""
+}
+
+func (c *conn) handleSimpleQuery(payload readBuf) error {
+	query, err := payload.string()
+	if err != nil {
+		return err
+	}
+
+	if isEmptyQuery(query) {
+		c.writer.Write(newWriteBuf(msgEmptyQueryResponse).finish())
+		return c.sendReadyForQuery()
+	}
+
+	err, rs := c.sess.ExecuteSQLRetResultSet(query)
+	if err == parser.EmptyQueryErr {
+		c.writer.Write(newWriteBuf(msgEmptyQueryResponse).finish())
+		return c.sendReadyForQuery()
+	} else if err != nil {
+		c.sendError(err)
+		return c.sendReadyForQuery()
+	}
+
+	if rs != nil && len(rs.Columns) > 0 {
+		c.sendRowDescription(rs.Columns, nil)
+		c.sendDataRows(rs, 0, 0, nil)
+	}
+	msg := newWriteBuf(msgCommandComplete)
+	msg.string(commandTag(query, rs))
+	c.writer.Write(msg.finish())
+	return c.sendReadyForQuery()
+}
+
+func (c *conn) handleParse(payload readBuf) error {
+	name, err := payload.string()
+	if err != nil {
+		return err
+	}
+	query, err := payload.string()
+	if err != nil {
+		return err
+	}
+	paramNum, err := payload.int16()
+	if err != nil {
+		return err
+	}
+
+	specifiedOIDs := make([]uint32, paramNum)
+	for ii := 0; ii < int(paramNum); ii++ {
+		oid, err := payload.int32()
+		if err != nil {
+			return err
+		}
+		specifiedOIDs[ii] = uint32(oid)
+	}
+
+	stmt := &preparedStmt{query, nil, make([]uint32, 0), make([]*samehada.ColumnDesc, 0)}
+	if !isEmptyQuery(query) {
+		err, ps := c.srv.db.Prepare(query)
+		desc := &samehada.StmtDesc{}
+		if err == nil {
+			stmt.ps = ps
+			desc = ps.Describe()
+		} else if err != parser.EmptyQueryErr {
+			return c.sendErrorOnExtendedQuery(err)
+		}
+		for idx, typeId := range desc.ParamTypes {
+			oid := typeIdToOID(typeId)
+			if idx < len(specifiedOIDs) && specifiedOIDs[idx] != oidUnspecified {
+				oid = specifiedOIDs[idx]
+			} else if typeId == types.Invalid {
+				oid = oidUnknown
+			}
+			stmt.paramOIDs = append(stmt.paramOIDs, oid)
+		}
+		stmt.columns = desc.Columns
+	}
+
+	c.stmts[name] = stmt
+	c.writer.Write(newWriteBuf(msgParseComplete).finish())
+	return nil
+}
+
+func readFormatCodes(payload *readBuf) ([]int16, error) {
+	num, err := payload.int16()
+	if err != nil {
+		return nil, err
+	}
+	formats := make([]int16, num)
+	for ii := 0; ii < int(num); ii++ {
+		if formats[ii], err = payload.int16(); err != nil {
+			return nil, err
+		}
+	}
+	return formats, nil
+}
+
+func (c *conn) handleBind(payload readBuf) error {
+	portalName, err := payload.string()
+	if err != nil {
+		return err
+	}
+	stmtName, err := payload.string()
+	if err != nil {
+		return err
+	}
+	paramFormats, err := readFormatCodes(&payload)
+	if err != nil {
+		return err
+	}
+
+	paramNum, err := payload.int16()
+	if err != nil {
+		return err
+	}
+	params := make([][]byte, paramNum)
+	for ii := 0; ii < int(paramNum); ii++ {
+		dataLen, err := payload.int32()
+		if err != nil {
+			return err
+		}
+		if dataLen < 0 {
+			// NULL
+			continue
+		}
+		if params[ii], err = payload.bytes(int(dataLen)); err != nil {
+			return err
+		}
+	}
+	resultFormats, err := readFormatCodes(&payload)
+	if err != nil {
+		return err
+	}
+
+	stmt, ok := c.stmts[stmtName]
+	if !ok {
+		return c.sendErrorOnExtendedQuery(UnknownStmtErr)
+	}
+	if len(params) != len(stmt.paramOIDs) {
+		return c.sendErrorOnExtendedQuery(fmt.Errorf("bind message supplies %d parameters, but prepared statement requires %d", len(params), len(stmt.paramOIDs)))
+	}
+
+	args := make([]*types.Value, len(params))
+	for idx, param := range params {
+		ifVal, err := paramToIF(param, stmt.paramOIDs[idx], resultFormat(paramFormats, idx))
+		if err != nil {
+			return c.sendErrorOnExtendedQuery(err)
+		}
+		if args[idx], err = samehada.ConvIFToValue(ifVal, stmt.ps.Describe().ParamTypes[idx]); err != nil {
+			return c.sendErrorOnExtendedQuery(fmt.Errorf("parameter $%d: %w", idx+1, err))
+		}
+	}
+
+	c.portals[portalName] = &portal{stmt, args, resultFormats, nil, 0}
+	c.writer.Write(newWriteBuf(msgBindComplete).finish())
+	return nil
+}
+
+func (c *conn) handleDescribe(payload readBuf) error {
+	kind, err := payload.byte_()
+	if err != nil {
+		return err
+	}
+	name, err := payload.string()
+	if err != nil {
+		return err
+	}
+
+	switch kind {
+	case 'S':
+		stmt, ok := c.stmts[name]
+		if !ok {
+			return c.sendErrorOnExtendedQuery(UnknownStmtErr)
+		}
+
+		msg := newWriteBuf(msgParameterDescription)
+		msg.int16(int16(len(stmt.paramOIDs)))
+		for _, oid := range stmt.paramOIDs {
+			if oid == oidUnknown {
+				// type can't be inferred. frontend should send the value as text
+				oid = oidText
+			}
+			msg.int32(int32(oid))
+		}
+		c.writer.Write(msg.finish())
+		// result formats are not decided yet
+		c.sendRowDescription(stmt.columns, nil)
+	case 'P':
+		p, ok := c.portals[name]
+		if !ok {
+			return c.sendErrorOnExtendedQuery(UnknownPortalErr)
+		}
+		c.sendRowDescription(p.stmt.columns, p.resultFormats)
+	default:
+		return MalformedMessageErr
+	}
+	return nil
+}
+
+func (c *conn) handleExecute(payload readBuf) error {
+	name, err := payload.string()
+	if err != nil {
+		return err
+	}
+	maxRows, err := payload.int32()
+	if err != nil {
+		return err
+	}
+
+	p, ok := c.portals[name]
+	if !ok {
+		return c.sendErrorOnExtendedQuery(UnknownPortalErr)
+	}
+
+	if p.stmt.ps == nil {
+		c.writer.Write(newWriteBuf(msgEmptyQueryResponse).finish())
+		return nil
+	}
+
+	if p.rs == nil {
+		err, rs := c.sess.ExecutePrepared(p.stmt.ps, p.args...)
+		if err != nil {
+			return c.sendErrorOnExtendedQuery(err)
+		}
+		if rs == nil {
+			// COMMIT and ROLLBACK don't return ResultSet
+			rs = &samehada.ResultSet{}
+		}
+		p.rs = rs
+	}
+
+	p.rowIdx = c.sendDataRows(p.rs, p.rowIdx, int(maxRows), p.resultFormats)
+	if p.rowIdx < len(p.rs.Rows) {
+		c.writer.Write(newWriteBuf(msgPortalSuspended).finish())
+		return nil
+	}
+
+	msg := newWriteBuf(msgCommandComplete)
+	msg.string(commandTag(p.stmt.query, p.rs))
+	c.writer.Write(msg.finish())
+	return nil
+}
+
+func (c *conn) handleClose(payload readBuf) error {
+	kind, err := payload.byte_()
+	if err != nil {
+		return err
+	}
+	name, err := payload.string()
+	if err != nil {
+		return err
+	}
+
+	switch kind {
+	case 'S':
+		delete(c.stmts, name)
+	case 'P':
+		delete(c.portals, name)
+	default:
+		return MalformedMessageErr
+	}
+	c.writer.Write(newWriteBuf(msgCloseComplete).finish())
+	return nil
+}
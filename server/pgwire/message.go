@@ -0,0 +1,142 @@
+package pgwire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// message types sent by frontend
+const (
+	msgQuery     byte = 'Q'
+	msgParse     byte = 'P'
+	msgBind      byte = 'B'
+	msgDescribe  byte = 'D'
+	msgExecute   byte = 'E'
+	msgSync      byte = 'S'
+	msgClose     byte = 'C'
+	msgFlush     byte = 'H'
+	msgTerminate byte = 'X'
+)
+
+// message types sent by backend
+const (
+	msgAuthentication       byte = 'R'
+	msgParameterStatus      byte = 'S'
+	msgBackendKeyData       byte = 'K'
+	msgReadyForQuery        byte = 'Z'
+	msgRowDescription       byte = 'T'
+	msgDataRow              byte = 'D'
+	msgCommandComplete      byte = 'C'
+	msgEmptyQueryResponse   byte = 'I'
+	msgErrorResponse        byte = 'E'
+	msgParseComplete        byte = '1'
+	msgBindComplete         byte = '2'
+	msgCloseComplete        byte = '3'
+	msgNoData               byte = 'n'
+	msgParameterDescription byte = 't'
+	msgPortalSuspended      byte = 's'
+)
+
+// codes at head of startup packet
+const (
+	protocolVersion3 uint32 = 196608 // 3.0
+	sslRequestCode   uint32 = 80877103
+	gssEncRequest    uint32 = 80877104
+	cancelRequest    uint32 = 80877102
+)
+
+// format codes of parameters and result columns
+const (
+	formatText   int16 = 0
+	formatBinary int16 = 1
+)
+
+var MalformedMessageErr = errors.New("malformed message")
+
+// readBuf is payload of a received message. each method consumes the head of it
+type readBuf []byte
+
+func (b *readBuf) byte_() (byte, error) {
+	if len(*b) < 1 {
+		return 0, MalformedMessageErr
+	}
+	ret := (*b)[0]
+	*b = (*b)[1:]
+	return ret, nil
+}
+
+func (b *readBuf) int16() (int16, error) {
+	if len(*b) < 2 {
+		return 0, MalformedMessageErr
+	}
+	ret := int16(binary.BigEndian.Uint16(*b))
+	*b = (*b)[2:]
+	return ret, nil
+}
+
+func (b *readBuf) int32() (int32, error) {
+	if len(*b) < 4 {
+		return 0, MalformedMessageErr
+	}
+	ret := int32(binary.BigEndian.Uint32(*b))
+	*b = (*b)[4:]
+	return ret, nil
+}
+
+// null terminated string
+func (b *readBuf) string() (string, error) {
+	idx := bytes.IndexByte(*b, 0)
+	if idx < 0 {
+		return "", MalformedMessageErr
+	}
+	ret := string((*b)[:idx])
+	*b = (*b)[idx+1:]
+	return ret, nil
+}
+
+func (b *readBuf) bytes(n int) ([]byte, error) {
+	if n < 0 || len(*b) < n {
+		return nil, MalformedMessageErr
+	}
+	ret := (*b)[:n]
+	*b = (*b)[n:]
+	return ret, nil
+}
+
+// writeBuf builds a message to be sent. length field is filled at finish
+type writeBuf struct {
+	buf []byte
+}
+
+func newWriteBuf(msgType byte) *writeBuf {
+	return &writeBuf{[]byte{msgType, 0, 0, 0, 0}}
+}
+
+func (b *writeBuf) byte_(v byte) {
+	b.buf = append(b.buf, v)
+}
+
+func (b *writeBuf) int16(v int16) {
+	b.buf = binary.BigEndian.AppendUint16(b.buf, uint16(v))
+}
+
+func (b *writeBuf) int32(v int32) {
+	b.buf = binary.BigEndian.AppendUint32(b.buf, uint32(v))
+}
+
+// null terminated string
+func (b *writeBuf) string(v string) {
+	b.buf = append(b.buf, v...)
+	b.buf = append(b.buf, 0)
+}
+
+func (b *writeBuf) bytes(v []byte) {
+	b.buf = append(b.buf, v...)
+}
+
+func (b *writeBuf) finish() []byte {
+	// length includes itself but not message type
+	binary.BigEndian.PutUint32(b.buf[1:5], uint32(len(b.buf)-1))
+	return b.buf
+}
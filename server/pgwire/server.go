@@ -0,0 +1,110 @@
+package pgwire
+
+import (
+	"github.com/ryogrid/SamehadaDB/lib/samehada"
+	"log"
+	"net"
+	"sync"
+)
+
+// Server accepts connections of PostgreSQL frontend/backend protocol (v3) and
+// executes received statements on SamehadaDB. each connection has own samehada.SamehadaSession,
+// so BEGIN, COMMIT and ROLLBACK can be used.
+// Limitations:
+//   - authentication is not done (any user name and database name is accepted)
+//   - SSL and cancel request are not supported
+//   - COPY and multiple statements in a Query message are not supported
+type Server struct {
+	db          *samehada.SamehadaDB
+	listener    net.Listener
+	conns       map[*conn]bool
+	nextProcId  int32
+	isStopped   bool
+	mutex       *sync.Mutex
+	connsWaiter *sync.WaitGroup
+}
+
+func NewServer(db *samehada.SamehadaDB) *Server {
+	return &Server{db, nil, make(map[*conn]bool), 1, false, new(sync.Mutex), new(sync.WaitGroup)}
+}
+
+// ListenAndServe blocks until Close is called
+func (s *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve blocks until Close is called
+func (s *Server) Serve(listener net.Listener) error {
+	s.mutex.Lock()
+	s.listener = listener
+	s.mutex.Unlock()
+
+	for {
+		netConn, err := listener.Accept()
+		if err != nil {
+			s.mutex.Lock()
+			isStopped := s.isStopped
+			s.mutex.Unlock()
+			if isStopped {
+				return nil
+			}
+			return err
+		}
+
+		s.mutex.Lock()
+		if s.isStopped {
+			s.mutex.Unlock()
+			netConn.Close()
+			return nil
+		}
+		c := newConn(s, netConn, s.nextProcId)
+		s.nextProcId++
+		s.conns[c] = true
+		s.connsWaiter.Add(1)
+		s.mutex.Unlock()
+
+		go s.serveConn(c)
+	}
+}
+
+func (s *Server) serveConn(c *conn) {
+	defer s.connsWaiter.Done()
+	defer func() {
+		// same as REST API, panic on a statement does not stop the server
+		if r := recover(); r != nil {
+			log.Println("pgwire: recovered from panic:", r)
+			s.mutex.Lock()
+			delete(s.conns, c)
+			s.mutex.Unlock()
+		}
+	}()
+
+	err := c.serve()
+	if err != nil {
+		log.Println("pgwire:", err)
+	}
+
+	s.mutex.Lock()
+	delete(s.conns, c)
+	s.mutex.Unlock()
+}
+
+// Close stops accepting and closes all connections.
+// transactions in progress on the connections are rolled back
+func (s *Server) Close() {
+	s.mutex.Lock()
+	s.isStopped = true
+	if s.listener != nil {
+		s.listener.Close()
+	}
+	for c := range s.conns {
+		c.netConn.Close()
+	}
+	s.mutex.Unlock()
+
+	s.connsWaiter.Wait()
+}
@@ -0,0 +1,234 @@
+package pgwire
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/ryogrid/SamehadaDB/lib/types"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// type OIDs of PostgreSQL (pg_type.oid)
+const (
+	oidUnspecified uint32 = 0
+	oidBool        uint32 = 16
+	oidBytea       uint32 = 17
+	oidInt8        uint32 = 20
+	oidInt2        uint32 = 21
+	oidInt4        uint32 = 23
+	oidText        uint32 = 25
+	oidFloat4      uint32 = 700
+	oidFloat8      uint32 = 701
+	oidUnknown     uint32 = 705
+	oidVarchar     uint32 = 1043
+	oidTimestamp   uint32 = 1114
+	oidNumeric     uint32 = 1700
+)
+
+var UnsupportedParamFormatErr = errors.New("binary format is not supported for the parameter type")
+
+// binary format of timestamp is microseconds since 2000-01-01 00:00:00 (UTC)
+var pgEpoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func typeIdToOID(typeId types.TypeID) uint32 {
+	switch typeId {
+	case types.Boolean:
+		return oidBool
+	case types.Tinyint, types.Smallint:
+		return oidInt2
+	case types.Integer:
+		return oidInt4
+	case types.BigInt:
+		return oidInt8
+	case types.Decimal:
+		return oidNumeric
+	case types.Float:
+		return oidFloat4
+	case types.Double:
+		return oidFloat8
+	case types.Varchar:
+		return oidVarchar
+	case types.Timestamp:
+		return oidTimestamp
+	case types.Blob:
+		return oidBytea
+	default:
+		// NULL literal etc...
+		return oidText
+	}
+}
+
+// value of typlen field of RowDescription. -1 means variable length
+func oidToTypeLen(oid uint32) int16 {
+	switch oid {
+	case oidBool:
+		return 1
+	case oidInt2:
+		return 2
+	case oidInt4, oidFloat4:
+		return 4
+	case oidInt8, oidFloat8, oidTimestamp:
+		return 8
+	default:
+		return -1
+	}
+}
+
+// returns nil when val is NULL
+func encodeValue(val *types.Value, format int16) []byte {
+	if val == nil || val.IsNull() {
+		return nil
+	}
+
+	if format == formatBinary {
+		switch val.ValueType() {
+		case types.Integer:
+			return binary.BigEndian.AppendUint32(nil, uint32(val.ToInteger()))
+		case types.Float:
+			return binary.BigEndian.AppendUint32(nil, math.Float32bits(val.ToFloat()))
+		case types.BigInt:
+			return binary.BigEndian.AppendUint64(nil, uint64(val.ToBigInt()))
+		case types.Double:
+			return binary.BigEndian.AppendUint64(nil, math.Float64bits(val.ToDouble()))
+		case types.Timestamp:
+			return binary.BigEndian.AppendUint64(nil, uint64(val.ToTimestamp().Sub(pgEpoch).Microseconds()))
+		case types.Blob:
+			return val.ToBlob()
+		case types.Boolean:
+			if val.ToBoolean() {
+				return []byte{1}
+			}
+			return []byte{0}
+		}
+		// binary format of text types is same as text format
+	}
+
+	if val.ValueType() == types.Boolean {
+		if val.ToBoolean() {
+			return []byte("t")
+		}
+		return []byte("f")
+	}
+	if val.ValueType() == types.Blob {
+		// hex format of bytea
+		return []byte("\\x" + hex.EncodeToString(val.ToBlob()))
+	}
+	return []byte(val.ToString())
+}
+
+// converts a bound parameter to nil, bool, int64, float64, string, []byte or time.Time.
+// the result is converted to types.Value with samehada.ConvIFToValue.
+// data is nil when the parameter is NULL
+func paramToIF(data []byte, oid uint32, format int16) (interface{}, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	if format == formatBinary {
+		switch oid {
+		case oidBool:
+			if len(data) != 1 {
+				return nil, MalformedMessageErr
+			}
+			return data[0] != 0, nil
+		case oidInt2:
+			if len(data) != 2 {
+				return nil, MalformedMessageErr
+			}
+			return int64(int16(binary.BigEndian.Uint16(data))), nil
+		case oidInt4:
+			if len(data) != 4 {
+				return nil, MalformedMessageErr
+			}
+			return int64(int32(binary.BigEndian.Uint32(data))), nil
+		case oidInt8:
+			if len(data) != 8 {
+				return nil, MalformedMessageErr
+			}
+			return int64(binary.BigEndian.Uint64(data)), nil
+		case oidFloat4:
+			if len(data) != 4 {
+				return nil, MalformedMessageErr
+			}
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(data))), nil
+		case oidFloat8:
+			if len(data) != 8 {
+				return nil, MalformedMessageErr
+			}
+			return math.Float64frombits(binary.BigEndian.Uint64(data)), nil
+		case oidTimestamp:
+			if len(data) != 8 {
+				return nil, MalformedMessageErr
+			}
+			return pgEpoch.Add(time.Duration(int64(binary.BigEndian.Uint64(data))) * time.Microsecond), nil
+		case oidBytea:
+			return data, nil
+		case oidText, oidVarchar, oidUnknown, oidUnspecified:
+			return string(data), nil
+		default:
+			return nil, UnsupportedParamFormatErr
+		}
+	}
+
+	str := string(data)
+	switch oid {
+	case oidBool:
+		switch strings.ToLower(str) {
+		case "t", "true", "1", "on", "yes":
+			return true, nil
+		case "f", "false", "0", "off", "no":
+			return false, nil
+		}
+		return nil, fmt.Errorf("invalid input syntax for type boolean: %s", str)
+	case oidInt2, oidInt4, oidInt8:
+		i, err := strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input syntax for type integer: %s", str)
+		}
+		return i, nil
+	case oidFloat4, oidFloat8, oidNumeric:
+		f, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input syntax for type numeric: %s", str)
+		}
+		return f, nil
+	case oidBytea:
+		if !strings.HasPrefix(str, "\\x") {
+			return data, nil
+		}
+		decoded, err := hex.DecodeString(str[2:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid input syntax for type bytea: %s", str)
+		}
+		return decoded, nil
+	case oidUnspecified, oidUnknown:
+		// type of the parameter can't be known, so number like string is treated as number
+		if isNumberLiteral(str) {
+			if i, err := strconv.ParseInt(str, 10, 64); err == nil {
+				return i, nil
+			}
+			f, _ := strconv.ParseFloat(str, 64)
+			return f, nil
+		}
+		return str, nil
+	default:
+		return str, nil
+	}
+}
+
+func isNumberLiteral(str string) bool {
+	if str == "" {
+		return false
+	}
+	for idx, c := range str {
+		if (c < '0' || c > '9') && c != '.' && !(idx == 0 && c == '-') {
+			return false
+		}
+	}
+	_, err := strconv.ParseFloat(str, 64)
+	return err == nil
+}
@@ -0,0 +1,219 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"github.com/ryogrid/SamehadaDB/lib/samehada"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var UnknownStmtErr = errors.New("prepared statement not found")
+var TooManyStmtsErr = errors.New("too many prepared statements on the session")
+
+// prepared statements which can be kept on a session
+const maxStmtNumPerSession = 1024
+
+// Settings is per session configuration which is specified at opening
+type Settings struct {
+	// session is closed when no request arrives in this duration
+	IdleTimeout time.Duration
+}
+
+// Session binds a samehada.SamehadaSession (and its open transaction) to a client
+type Session struct {
+	Id         string
+	Settings   Settings
+	sess       *samehada.SamehadaSession
+	lastAccess time.Time
+	// prepared statements are released when the session is closed
+	stmts      map[string]*samehada.PreparedStmt
+	nextStmtId uint64
+	stmtMutex  *sync.Mutex
+}
+
+func (s *Session) ExecuteSQL(sqlStr string) (error, [][]interface{}) {
+	return s.sess.ExecuteSQL(sqlStr)
+}
+
+func (s *Session) ExecuteSQLRetResultSet(sqlStr string) (error, *samehada.ResultSet) {
+	return s.sess.ExecuteSQLRetResultSet(sqlStr)
+}
+
+// executes sqlStr with args bound to its placeholders without keeping the prepared statement
+func (s *Session) ExecuteSQLWithArgs(sqlStr string, args []interface{}) (error, *samehada.ResultSet) {
+	err, ps := s.sess.Prepare(sqlStr)
+	if err != nil {
+		return err, nil
+	}
+	vals, err := ps.ConvIFsToArgs(args...)
+	if err != nil {
+		return err, nil
+	}
+	return s.sess.ExecutePrepared(ps, vals...)
+}
+
+// Prepare returns id of the prepared statement which can be passed to ExecutePrepared
+func (s *Session) Prepare(sqlStr string) (error, string, *samehada.PreparedStmt) {
+	err, ps := s.sess.Prepare(sqlStr)
+	if err != nil {
+		return err, "", nil
+	}
+
+	s.stmtMutex.Lock()
+	defer s.stmtMutex.Unlock()
+
+	if len(s.stmts) >= maxStmtNumPerSession {
+		return TooManyStmtsErr, "", nil
+	}
+	id := strconv.FormatUint(s.nextStmtId, 10)
+	s.nextStmtId++
+	s.stmts[id] = ps
+	return nil, id, ps
+}
+
+// args are converted with samehada.ConvIFToValue
+func (s *Session) ExecutePrepared(stmtId string, args []interface{}) (error, *samehada.ResultSet) {
+	s.stmtMutex.Lock()
+	ps, ok := s.stmts[stmtId]
+	s.stmtMutex.Unlock()
+	if !ok {
+		return UnknownStmtErr, nil
+	}
+
+	vals, err := ps.ConvIFsToArgs(args...)
+	if err != nil {
+		return err, nil
+	}
+	return s.sess.ExecutePrepared(ps, vals...)
+}
+
+func (s *Session) Deallocate(stmtId string) bool {
+	s.stmtMutex.Lock()
+	defer s.stmtMutex.Unlock()
+
+	_, ok := s.stmts[stmtId]
+	delete(s.stmts, stmtId)
+	return ok
+}
+
+func (s *Session) InTxn() bool {
+	return s.sess.InTxn()
+}
+
+type SessionManager struct {
+	db                 *samehada.SamehadaDB
+	defaultIdleTimeout time.Duration
+	sessions           map[string]*Session
+	mutex              *sync.Mutex
+	isReaperActive     bool
+}
+
+func NewSessionManager(db *samehada.SamehadaDB, defaultIdleTimeout time.Duration) *SessionManager {
+	return &SessionManager{db, defaultIdleTimeout, make(map[string]*Session), new(sync.Mutex), true}
+}
+
+func genSessionId() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// idleTimeout <= 0 means default value is used
+func (sm *SessionManager) Open(idleTimeout time.Duration) *Session {
+	if idleTimeout <= 0 {
+		idleTimeout = sm.defaultIdleTimeout
+	}
+	s := &Session{genSessionId(), Settings{idleTimeout}, sm.db.NewSession(), time.Now(),
+		make(map[string]*samehada.PreparedStmt), 0, new(sync.Mutex)}
+
+	sm.mutex.Lock()
+	sm.sessions[s.Id] = s
+	sm.mutex.Unlock()
+	return s
+}
+
+// returns nil when session is not found (or already closed due to timeout)
+// last access time of found session is updated
+func (sm *SessionManager) Get(id string) *Session {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	s, ok := sm.sessions[id]
+	if !ok {
+		return nil
+	}
+	s.lastAccess = time.Now()
+	return s
+}
+
+// used for excluding time of query execution from idle time
+func (sm *SessionManager) Touch(s *Session) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	s.lastAccess = time.Now()
+}
+
+// transaction in progress on the session is rolled back
+func (sm *SessionManager) Close(id string) bool {
+	sm.mutex.Lock()
+	s, ok := sm.sessions[id]
+	delete(sm.sessions, id)
+	sm.mutex.Unlock()
+
+	if !ok {
+		return false
+	}
+	s.sess.Close()
+	return true
+}
+
+func (sm *SessionManager) CloseAll() {
+	sm.mutex.Lock()
+	closeTargets := make([]*Session, 0)
+	for _, s := range sm.sessions {
+		closeTargets = append(closeTargets, s)
+	}
+	sm.sessions = make(map[string]*Session)
+	sm.mutex.Unlock()
+
+	for _, s := range closeTargets {
+		s.sess.Close()
+	}
+}
+
+func (sm *SessionManager) closeIdleSessions() {
+	now := time.Now()
+	sm.mutex.Lock()
+	closeTargets := make([]*Session, 0)
+	for id, s := range sm.sessions {
+		if now.Sub(s.lastAccess) > s.Settings.IdleTimeout {
+			closeTargets = append(closeTargets, s)
+			delete(sm.sessions, id)
+		}
+	}
+	sm.mutex.Unlock()
+
+	// note: Close waits for finish of query which is being executed on the session
+	for _, s := range closeTargets {
+		s.sess.Close()
+	}
+}
+
+func (sm *SessionManager) StartReaperTh(interval time.Duration) {
+	go func() {
+		for sm.isReaperActive {
+			time.Sleep(interval)
+			sm.closeIdleSessions()
+		}
+	}()
+}
+
+func (sm *SessionManager) StopReaperTh() {
+	sm.isReaperActive = false
+}
@@ -2,6 +2,8 @@ package signal_handle
 
 import (
 	"github.com/ryogrid/SamehadaDB/lib/samehada"
+	"github.com/ryogrid/SamehadaDB/server/pgwire"
+	"github.com/ryogrid/SamehadaDB/server/session"
 	"os"
 	"os/signal"
 	"syscall"
@@ -9,7 +11,7 @@ import (
 
 var IsStopped = false
 
-func SignalHandlerTh(db *samehada.SamehadaDB, exitNotifyCh *chan bool) {
+func SignalHandlerTh(db *samehada.SamehadaDB, sessionManager *session.SessionManager, pgServer *pgwire.Server, exitNotifyCh *chan bool) {
 	sigChan := make(chan os.Signal, 1)
 	// receive SIGINT only
 	signal.Ignore()
@@ -23,6 +25,11 @@ func SignalHandlerTh(db *samehada.SamehadaDB, exitNotifyCh *chan bool) {
 	// stop handle request
 	IsStopped = true
 
+	// roll back transactions in progress on sessions and connections
+	pgServer.Close()
+	sessionManager.StopReaperTh()
+	sessionManager.CloseAll()
+
 	// shutdown SamehadaDB object
 	db.Shutdown()
 